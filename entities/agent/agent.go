@@ -13,26 +13,38 @@ import (
 // It returns an Agent with a randomly generated UUID for the ID
 // and the current time for the creation time.
 func New(tr ticket.RepositoryAgentAccess) (Agent, error) {
+	return newAgent(tr, entities.SystemClock(), entities.RandomIDSource(), nil)
+}
+
+func newAgent(tr ticket.RepositoryAgentAccess, clock entities.Clock, ids entities.IDSource, attachments ticket.UploadedAttachments) (Agent, error) {
 	if tr == nil {
 		return nil, ErrTicketRepositoryNotImplemented
 	}
 	return basicAgent{
-		id:               uuid.New(),
-		creationTime:     time.Now(),
+		id:               ids.NewID(),
+		creationTime:     clock.Now(),
 		ticketRepository: tr,
+		clock:            clock,
+		attachments:      attachments,
 	}, nil
 }
 
 // InstanceAgent (deprecated, use the Factory methods instead) is a function that creates an instance of the Agent
 // interface, for an existing Agent.
 func InstanceAgent(uuid1 uuid.UUID, creationTime time.Time, tr ticket.RepositoryAgentAccess) (Agent, error) {
+	return instanceAgent(uuid1, creationTime, tr, entities.SystemClock(), nil)
+}
+
+func instanceAgent(id uuid.UUID, creationTime time.Time, tr ticket.RepositoryAgentAccess, clock entities.Clock, attachments ticket.UploadedAttachments) (Agent, error) {
 	if tr == nil {
 		return nil, ErrTicketRepositoryNotImplemented
 	}
 	return basicAgent{
-		id:               uuid1,
+		id:               id,
 		creationTime:     creationTime,
 		ticketRepository: tr,
+		clock:            clock,
+		attachments:      attachments,
 	}, nil
 }
 
@@ -45,16 +57,23 @@ type Agent interface {
 	// If the provided UUID is nil, it returns an error.
 	// If the repository returns an error, it returns an error.
 	GetTicket(uuid.UUID) (ticket.Ticket, error)
-	AnswerTicket(uuid.UUID, string) error
+	// AnswerTicket adds a response to the ticket, with the attachments the agent uploaded for it. The agents can only
+	// attach files if their factory was created with NewTicketAgentFactoryWithAttachments.
+	AnswerTicket(ticket uuid.UUID, answer string, attachmentIds ...uuid.UUID) error
+	// FollowTicket makes the agent a follower of the ticket, to get its updates.
+	FollowTicket(ticket uuid.UUID) error
+	UnfollowTicket(ticket uuid.UUID) error
 }
 
 type basicAgent struct {
 	id               uuid.UUID
 	creationTime     time.Time
 	ticketRepository ticket.RepositoryAgentAccess
+	clock            entities.Clock
+	attachments      ticket.UploadedAttachments
 }
 
-func (b basicAgent) AnswerTicket(ticketID uuid.UUID, s string) error {
+func (b basicAgent) AnswerTicket(ticketID uuid.UUID, s string, attachmentIds ...uuid.UUID) error {
 	commentError := validateTicketComment(ticketID, s)
 	if commentError != nil {
 		return fmt.Errorf("error while validating comment: %w", commentError)
@@ -63,8 +82,55 @@ func (b basicAgent) AnswerTicket(ticketID uuid.UUID, s string) error {
 	if err != nil {
 		return fmt.Errorf("error while getting ticket: %w", err)
 	}
-	tck.AddResponse(ticket.NewResponse(b.ID(), s))
+	attachments, err := b.claimAttachments(ticketID, attachmentIds)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrAttachingFiles, err)
+	}
+	tck.AddResponse(ticket.MakeResponse(b.ID(), s, b.clock.Now(), attachments...))
 	err = b.ticketRepository.UpdateTicket(tck)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUpdatingTicket, err)
+	}
+	return nil
+}
+
+// claimAttachments checks that the agent uploaded the attachments for the ticket, like the clients' comments do.
+func (b basicAgent) claimAttachments(ticketID uuid.UUID, ids []uuid.UUID) ([]ticket.Attachment, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if b.attachments == nil {
+		return nil, ErrAttachmentsUnavailable
+	}
+	attachments := make([]ticket.Attachment, 0, len(ids))
+	for _, id := range ids {
+		attachment, err := b.attachments.ClaimAttachment(b.id, ticketID, id)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, nil
+}
+
+func (b basicAgent) FollowTicket(ticketID uuid.UUID) error {
+	return b.updateFollowers(ticketID, func(tck ticket.Ticket) { tck.Follow(b.id) })
+}
+
+func (b basicAgent) UnfollowTicket(ticketID uuid.UUID) error {
+	return b.updateFollowers(ticketID, func(tck ticket.Ticket) { tck.Unfollow(b.id) })
+}
+
+func (b basicAgent) updateFollowers(ticketID uuid.UUID, change func(ticket.Ticket)) error {
+	tck, err := b.GetTicket(ticketID)
+	if err != nil {
+		return fmt.Errorf("error while getting ticket: %w", err)
+	}
+	change(tck)
+	err = b.ticketRepository.UpdateTicket(tck)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUpdatingTicket, err)
+	}
 	return nil
 }
 
@@ -100,3 +166,5 @@ func (b basicAgent) ID() uuid.UUID {
 var ErrTicketRepositoryNotImplemented = errors.New("ticket repository not implemented")
 var ErrNilTicketID = errors.New("nil ticket ID")
 var ErrRetrievingTicket = errors.New("error while retrieving ticket")
+var ErrAttachingFiles = errors.New("error while attaching files")
+var ErrAttachmentsUnavailable = errors.New("the agent cannot attach files")
@@ -6,6 +6,7 @@ import (
 	"github.com/google/uuid"
 	"math/rand"
 	"testing"
+	"ticketTao/entities"
 	"ticketTao/entities/ticket"
 	"time"
 )
@@ -188,14 +189,79 @@ func TestBasicAgent_AnswerTicket(t *testing.T) {
 			t.Errorf("Error should be %v", ErrRetrievingTicket)
 		}
 	})
+	t.Run("Should return an error if the ticket cannot be updated", func(t *testing.T) {
+		t.Parallel()
+		errorAgent, _ := New(stubTicketRepository{updateError: errors.New("disk full")})
+		err := errorAgent.AnswerTicket(uuid.New(), "Test Comment")
+		if !errors.Is(err, ErrUpdatingTicket) {
+			t.Errorf("Error should be %v, got %v", ErrUpdatingTicket, err)
+		}
+	})
+	t.Run("Should not attach files without the uploaded attachments", func(t *testing.T) {
+		t.Parallel()
+		err := agent.AnswerTicket(uuid.New(), "Test Comment", uuid.New())
+		if !errors.Is(err, ErrAttachmentsUnavailable) {
+			t.Errorf("Error should be %v, got %v", ErrAttachmentsUnavailable, err)
+		}
+	})
 }
 
+func TestBasicAgent_AnswerTicketWithAttachments(t *testing.T) {
+	t.Parallel()
+	agentID, ticketID := uuid.New(), uuid.New()
+	uploads := fakeUploadedAttachments{
+		uuid.New(): {uploader: agentID, ticket: ticketID},
+		uuid.New(): {uploader: agentID, ticket: uuid.New()},
+		uuid.New(): {uploader: uuid.New(), ticket: ticketID},
+	}
+	factory, err := NewTicketAgentFactoryWithAttachments(&fakeTicketRepository{}, entities.SystemClock(), entities.RandomIDSource(), uploads)
+	if err != nil {
+		t.Fatalf("Error should be nil, got %v", err)
+	}
+	agent, _ := factory.InstantiateAgent(agentID, time.Now())
+	for id, upload := range uploads {
+		err := agent.AnswerTicket(ticketID, "See attached", id)
+		if owned := upload.uploader == agentID && upload.ticket == ticketID; owned != (err == nil) {
+			t.Errorf("Expected only the upload of the agent for the ticket to be attached, got %v for %+v", err, upload)
+		}
+		if err != nil && !errors.Is(err, ErrAttachingFiles) {
+			t.Errorf("Error should be %v, got %v", ErrAttachingFiles, err)
+		}
+	}
+	tck, _ := agent.GetTicket(ticketID)
+	if responses := tck.Responses(); len(responses) != 1 || len(responses[0].Attachments()) != 1 {
+		t.Errorf("Expected one answer with the claimed attachment, got %v", responses)
+	}
+	if _, err := NewTicketAgentFactoryWithAttachments(&fakeTicketRepository{}, entities.SystemClock(), entities.RandomIDSource(), nil); !errors.Is(err, ErrNilAttachments) {
+		t.Errorf("Error should be %v, got %v", ErrNilAttachments, err)
+	}
+}
+
+type fakeUpload struct {
+	uploader uuid.UUID
+	ticket   uuid.UUID
+}
+
+// fakeUploadedAttachments lets the uploaders claim their uploads for the ticket they were uploaded for.
+type fakeUploadedAttachments map[uuid.UUID]fakeUpload
+
+func (f fakeUploadedAttachments) ClaimAttachment(user, ticketID, attachmentID uuid.UUID) (ticket.Attachment, error) {
+	upload, ok := f[attachmentID]
+	if !ok || upload.uploader != user || upload.ticket != ticketID {
+		return ticket.Attachment{}, errNotUploaded
+	}
+	return ticket.Attachment{ID: attachmentID, Name: "file.pdf"}, nil
+}
+
+var errNotUploaded = errors.New("attachment was not uploaded")
+
 type stubTicketRepository struct {
 	forcedError error
+	updateError error
 }
 
 func (s stubTicketRepository) UpdateTicket(tck ticket.Ticket) error {
-	return nil
+	return s.updateError
 }
 
 func (s stubTicketRepository) GetTicket(id uuid.UUID) (ticket.Ticket, error) {
@@ -241,3 +307,26 @@ func (f *fakeTicketRepository) GetTicket(id uuid.UUID) (ticket.Ticket, error) {
 	f.tickets[id] = newTicket
 	return newTicket, nil
 }
+
+func TestBasicAgent_FollowTicket(t *testing.T) {
+	t.Parallel()
+	t.Run("An agent can follow and unfollow a ticket", func(t *testing.T) {
+		t.Parallel()
+		repo := &fakeTicketRepository{}
+		agent, _ := New(repo)
+		ticketID := uuid.New()
+
+		if err := agent.FollowTicket(ticketID); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if !repo.tickets[ticketID].IsFollowedBy(agent.ID()) {
+			t.Error("Expected the agent to follow the ticket")
+		}
+		if err := agent.UnfollowTicket(ticketID); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if repo.tickets[ticketID].IsFollowedBy(agent.ID()) {
+			t.Error("Expected the agent to stop following the ticket")
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"ticketTao/entities/category"
+	"ticketTao/entities/ticket"
+)
+
+// TicketClassifierAgent is an Agent that can tag and categorize tickets.
+type TicketClassifierAgent interface {
+	Agent
+	AddTag(ticket uuid.UUID, tag string) error
+	RemoveTag(ticket uuid.UUID, tag string) error
+	// Categorize sets the category of the ticket, it must exist in the taxonomy. The empty category removes it.
+	Categorize(ticket uuid.UUID, category string) error
+}
+
+func newTicketClassifierAgent(agent Agent, repo ticket.RepositoryAgentAccess, taxonomy category.Taxonomy) TicketClassifierAgent {
+	return ticketClassifierAgent{agent, repo, taxonomy}
+}
+
+type ticketClassifierAgent struct {
+	Agent
+	repo     ticket.RepositoryAgentAccess
+	taxonomy category.Taxonomy
+}
+
+func (t ticketClassifierAgent) AddTag(id uuid.UUID, tag string) error {
+	if ticket.NormalizeTag(tag) == "" {
+		return ErrEmptyTag
+	}
+	return t.update(id, func(tck ticket.Ticket) {
+		tck.AddTag(tag)
+	})
+}
+
+func (t ticketClassifierAgent) RemoveTag(id uuid.UUID, tag string) error {
+	return t.update(id, func(tck ticket.Ticket) {
+		tck.RemoveTag(tag)
+	})
+}
+
+func (t ticketClassifierAgent) Categorize(id uuid.UUID, path string) error {
+	if path != "" && !t.taxonomy.Contains(path) {
+		return fmt.Errorf("%w: %s", category.ErrUnknownCategory, path)
+	}
+	return t.update(id, func(tck ticket.Ticket) {
+		tck.Categorize(path)
+	})
+}
+
+func (t ticketClassifierAgent) update(id uuid.UUID, change func(ticket.Ticket)) error {
+	tck, err := t.GetTicket(id)
+	if err != nil {
+		return fmt.Errorf("%w: %w", TicketRetrievalError, err)
+	}
+	change(tck)
+	err = t.repo.UpdateTicket(tck)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUpdatingTicket, err)
+	}
+	return nil
+}
+
+var ErrEmptyTag = errors.New("tag cannot be empty")
+var ErrNilTaxonomy = errors.New("category taxonomy cannot be nil")
+var ErrUpdatingTicket = errors.New("error while updating ticket")
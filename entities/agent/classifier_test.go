@@ -0,0 +1,54 @@
+package agent
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"slices"
+	"testing"
+	"ticketTao/entities/category"
+	"time"
+)
+
+func TestTicketClassifierAgent(t *testing.T) {
+	t.Parallel()
+	taxonomy, _ := category.NewTaxonomy("billing", "billing/refunds")
+	factory, err := NewTicketAgentFactory(&fakeTicketRepository{})
+	if err != nil {
+		t.Fatalf("Error should be nil, got %v", err)
+	}
+	if _, err := factory.InstantiateTicketClassifierAgent(uuid.New(), time.Now(), nil); !errors.Is(err, ErrNilTaxonomy) {
+		t.Fatalf("Error should be ErrNilTaxonomy, got %v", err)
+	}
+	agent, err := factory.InstantiateTicketClassifierAgent(uuid.New(), time.Now(), taxonomy)
+	if err != nil {
+		t.Fatalf("Error should be nil, got %v", err)
+	}
+	ticketID := uuid.New()
+
+	t.Run("It should add and remove tags", func(t *testing.T) {
+		if err := agent.AddTag(ticketID, "VIP"); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		_ = agent.AddTag(ticketID, "Late delivery")
+		_ = agent.RemoveTag(ticketID, "vip")
+		tck, _ := agent.GetTicket(ticketID)
+		if !slices.Equal(tck.Tags(), []string{"late-delivery"}) {
+			t.Errorf("Expected the tags to be updated, got %v", tck.Tags())
+		}
+		if err := agent.AddTag(ticketID, " "); !errors.Is(err, ErrEmptyTag) {
+			t.Errorf("Error should be ErrEmptyTag, got %v", err)
+		}
+	})
+	t.Run("It should only use the categories of the taxonomy", func(t *testing.T) {
+		if err := agent.Categorize(ticketID, "billing/refunds"); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if err := agent.Categorize(ticketID, "inventory"); !errors.Is(err, category.ErrUnknownCategory) {
+			t.Errorf("Error should be ErrUnknownCategory, got %v", err)
+		}
+		tck, _ := agent.GetTicket(ticketID)
+		if tck.Category() != "billing/refunds" {
+			t.Errorf("Expected the category to be billing/refunds, got %q", tck.Category())
+		}
+	})
+}
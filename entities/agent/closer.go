@@ -1,6 +1,8 @@
 package agent
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
@@ -9,19 +11,29 @@ import (
 
 type TicketCloserAgent interface {
 	Agent
+	// CloseTicket closes the ticket as Resolved. When the agent has a SurveyInviter, a satisfaction survey is requested
+	// and its one-time token is sent to the client.
 	CloseTicket(ticket uuid.UUID) error
 }
 
-func newTicketCloserAgent(agent Agent, repo ticket.RepositoryAgentAccess) TicketCloserAgent {
+// SurveyInviter sends the satisfaction survey token of a closed ticket to its client, e.g. by email.
+type SurveyInviter interface {
+	InviteToSurvey(ticket uuid.UUID, token string) error
+}
+
+func newTicketCloserAgent(agent Agent, repo ticket.RepositoryAgentAccess, inviter SurveyInviter) TicketCloserAgent {
 	return ticketCloserAgent{
 		agent,
 		repo,
+		inviter,
 	}
 }
 
 type ticketCloserAgent struct {
 	Agent
 	repo ticket.RepositoryAgentAccess
+	// inviter is nil when no survey is requested.
+	inviter SurveyInviter
 }
 
 func (t ticketCloserAgent) CloseTicket(id uuid.UUID) error {
@@ -30,8 +42,33 @@ func (t ticketCloserAgent) CloseTicket(id uuid.UUID) error {
 		return fmt.Errorf("%w: %w", TicketRetrievalError, err)
 	}
 	tck.Close()
+	var token string
+	if t.inviter != nil {
+		if token, err = newSurveyToken(); err != nil {
+			return err
+		}
+		tck.RequestSatisfaction(t.ID(), token)
+	}
 	err = t.repo.UpdateTicket(tck)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUpdatingTicket, err)
+	}
+	if t.inviter != nil {
+		if err := t.inviter.InviteToSurvey(id, token); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvitingToSurvey, err)
+		}
+	}
 	return nil
 }
 
+func newSurveyToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvitingToSurvey, err)
+	}
+	return hex.EncodeToString(token), nil
+}
+
 var TicketRetrievalError = errors.New("error while retrieving ticket")
+var ErrNilSurveyInviter = errors.New("survey inviter cannot be nil")
+var ErrInvitingToSurvey = errors.New("error while inviting the client to the satisfaction survey")
@@ -1,8 +1,11 @@
 package agent
 
 import (
+	"errors"
 	"github.com/google/uuid"
 	"testing"
+	"ticketTao/entities/ticket"
+	"time"
 )
 
 func TestTicketCloserAgent_CloseTicket(t *testing.T) {
@@ -26,3 +29,57 @@ func TestTicketCloserAgent_CloseTicket(t *testing.T) {
 		t.Parallel()
 	})
 }
+
+func TestTicketCloserAgent_CloseTicketWithSurvey(t *testing.T) {
+	t.Parallel()
+	t.Run("It should need a survey inviter", func(t *testing.T) {
+		t.Parallel()
+		factory, _ := NewTicketAgentFactory(&fakeTicketRepository{})
+		_, err := factory.InstantiateTicketCloserAgentWithSurvey(uuid.New(), time.Now(), nil)
+		assertErrors(t, err, ErrInstantiatingAgent, ErrNilSurveyInviter)
+	})
+	t.Run("It should request a survey and send its token to the client", func(t *testing.T) {
+		t.Parallel()
+		repo := &fakeTicketRepository{}
+		inviter := &spySurveyInviter{}
+		factory, _ := NewTicketAgentFactory(repo)
+		agent, _ := factory.InstantiateTicketCloserAgentWithSurvey(uuid.New(), time.Now(), inviter)
+		ticketID := uuid.New()
+
+		if err := agent.CloseTicket(ticketID); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+
+		closed := repo.tickets[ticketID]
+		if closed.Status() != ticket.Closed {
+			t.Errorf("Expected the ticket to be closed, got %s", closed.Status())
+		}
+		if len(inviter.tokens) != 1 || inviter.tickets[0] != ticketID {
+			t.Fatalf("Expected one invitation for the ticket, got %v", inviter.tickets)
+		}
+		if err := closed.RateSatisfaction(inviter.tokens[0], 5, ""); err != nil {
+			t.Errorf("The sent token should answer the survey, got %v", err)
+		}
+		if closed.Satisfaction().Agent != agent.ID() {
+			t.Error("Expected the rating to be attributed to the agent")
+		}
+	})
+	t.Run("It should report a failed invitation", func(t *testing.T) {
+		t.Parallel()
+		factory, _ := NewTicketAgentFactory(&fakeTicketRepository{})
+		agent, _ := factory.InstantiateTicketCloserAgentWithSurvey(uuid.New(), time.Now(), &spySurveyInviter{err: errors.New("smtp is down")})
+		assertErrors(t, agent.CloseTicket(uuid.New()), ErrInvitingToSurvey)
+	})
+}
+
+type spySurveyInviter struct {
+	tickets []uuid.UUID
+	tokens  []string
+	err     error
+}
+
+func (s *spySurveyInviter) InviteToSurvey(ticket uuid.UUID, token string) error {
+	s.tickets = append(s.tickets, ticket)
+	s.tokens = append(s.tokens, token)
+	return s.err
+}
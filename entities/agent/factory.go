@@ -4,17 +4,47 @@ import (
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
+	"ticketTao/entities"
+	"ticketTao/entities/category"
+	"ticketTao/entities/macro"
 	"ticketTao/entities/ticket"
 	"time"
 )
 
 func NewTicketAgentFactory(repository ticket.RepositoryAgentAccess) (Factory, error) {
+	return NewTicketAgentFactoryWithSources(repository, entities.SystemClock(), entities.RandomIDSource())
+}
+
+// NewTicketAgentFactoryWithSources creates a Factory whose agents read the time from the clock and take their IDs
+// from the ID source.
+func NewTicketAgentFactoryWithSources(repository ticket.RepositoryAgentAccess, clock entities.Clock, ids entities.IDSource) (Factory, error) {
+	return newTicketAgentFactory(repository, clock, ids, nil)
+}
+
+// NewTicketAgentFactoryWithAttachments works like NewTicketAgentFactoryWithSources, and its agents can attach the
+// files they uploaded to their answers.
+func NewTicketAgentFactoryWithAttachments(repository ticket.RepositoryAgentAccess, clock entities.Clock, ids entities.IDSource, attachments ticket.UploadedAttachments) (Factory, error) {
+	if attachments == nil {
+		return nil, ErrNilAttachments
+	}
+	return newTicketAgentFactory(repository, clock, ids, attachments)
+}
+
+func newTicketAgentFactory(repository ticket.RepositoryAgentAccess, clock entities.Clock, ids entities.IDSource, attachments ticket.UploadedAttachments) (Factory, error) {
 	if repository == nil {
 		return nil, ErrNilRepository
-
+	}
+	if clock == nil {
+		return nil, entities.ErrNilClock
+	}
+	if ids == nil {
+		return nil, entities.ErrNilIDSource
 	}
 	return basicTicketAgentFactory{
 		ticketRepository: repository,
+		clock:            clock,
+		ids:              ids,
+		attachments:      attachments,
 	}, nil
 }
 
@@ -25,10 +55,28 @@ type Factory interface {
 	InstantiateAgent(agent uuid.UUID, createdAt time.Time) (Agent, error)
 	// InstantiateTicketCloserAgent decorates an Agent with the ability to close tickets.
 	InstantiateTicketCloserAgent(agent uuid.UUID, createdAt time.Time) (TicketCloserAgent, error)
+	// InstantiateTicketCloserAgentWithSurvey works like InstantiateTicketCloserAgent, but the agent requests a
+	// satisfaction survey when it closes a ticket, and sends its token through the inviter.
+	InstantiateTicketCloserAgentWithSurvey(agent uuid.UUID, createdAt time.Time, inviter SurveyInviter) (TicketCloserAgent, error)
+	// InstantiateTicketClassifierAgent decorates an Agent with the ability to tag and categorize tickets, using the
+	// categories of the taxonomy.
+	InstantiateTicketClassifierAgent(agent uuid.UUID, createdAt time.Time, taxonomy category.Taxonomy) (TicketClassifierAgent, error)
+	// InstantiateTicketMacroAgent decorates an Agent with the ability to use the canned responses and the macros of
+	// the library, within the granted permissions.
+	InstantiateTicketMacroAgent(agent uuid.UUID, createdAt time.Time, library macro.Library, clients ClientDirectory, permissions ...macro.Permission) (TicketMacroAgent, error)
+	// InstantiateTicketMergerAgent decorates an Agent with the ability to merge duplicated tickets, the owners are used
+	// to check that the merged tickets belong to the same client.
+	InstantiateTicketMergerAgent(agent uuid.UUID, createdAt time.Time, owners TicketOwnerFinder) (TicketMergerAgent, error)
+	// InstantiateTicketSplitterAgent decorates an Agent with the ability to split tickets, the new tickets are created
+	// for the owner of the original ticket through the client writer.
+	InstantiateTicketSplitterAgent(agent uuid.UUID, createdAt time.Time, owners TicketOwnerFinder, clients ticket.RepositoryClientWriter) (TicketSplitterAgent, error)
 }
 
 type basicTicketAgentFactory struct {
 	ticketRepository ticket.RepositoryAgentAccess
+	clock            entities.Clock
+	ids              entities.IDSource
+	attachments      ticket.UploadedAttachments
 }
 
 func (b basicTicketAgentFactory) InstantiateTicketCloserAgent(agent uuid.UUID, createdAt time.Time) (TicketCloserAgent, error) {
@@ -36,11 +84,76 @@ func (b basicTicketAgentFactory) InstantiateTicketCloserAgent(agent uuid.UUID, c
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", ErrInstantiatingAgent, err)
 	}
-	return newTicketCloserAgent(a, b.ticketRepository), nil
+	return newTicketCloserAgent(a, b.ticketRepository, nil), nil
+}
+
+func (b basicTicketAgentFactory) InstantiateTicketCloserAgentWithSurvey(agent uuid.UUID, createdAt time.Time, inviter SurveyInviter) (TicketCloserAgent, error) {
+	if inviter == nil {
+		return nil, fmt.Errorf("%w: %w", ErrInstantiatingAgent, ErrNilSurveyInviter)
+	}
+	a, err := b.InstantiateAgent(agent, createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInstantiatingAgent, err)
+	}
+	return newTicketCloserAgent(a, b.ticketRepository, inviter), nil
+}
+
+func (b basicTicketAgentFactory) InstantiateTicketClassifierAgent(agent uuid.UUID, createdAt time.Time, taxonomy category.Taxonomy) (TicketClassifierAgent, error) {
+	if taxonomy == nil {
+		return nil, fmt.Errorf("%w: %w", ErrInstantiatingAgent, ErrNilTaxonomy)
+	}
+	a, err := b.InstantiateAgent(agent, createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInstantiatingAgent, err)
+	}
+	return newTicketClassifierAgent(a, b.ticketRepository, taxonomy), nil
+}
+
+func (b basicTicketAgentFactory) InstantiateTicketMacroAgent(agent uuid.UUID, createdAt time.Time, library macro.Library, clients ClientDirectory, permissions ...macro.Permission) (TicketMacroAgent, error) {
+	if library == nil {
+		return nil, fmt.Errorf("%w: %w", ErrInstantiatingAgent, ErrNilLibrary)
+	}
+	if clients == nil {
+		return nil, fmt.Errorf("%w: %w", ErrInstantiatingAgent, ErrNilClientDirectory)
+	}
+	a, err := b.InstantiateAgent(agent, createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInstantiatingAgent, err)
+	}
+	return newTicketMacroAgent(a, b.ticketRepository, b.clock, library, clients, permissions), nil
+}
+
+func (b basicTicketAgentFactory) InstantiateTicketMergerAgent(agent uuid.UUID, createdAt time.Time, owners TicketOwnerFinder) (TicketMergerAgent, error) {
+	if owners == nil {
+		return nil, fmt.Errorf("%w: %w", ErrInstantiatingAgent, ErrNilOwnerFinder)
+	}
+	a, err := b.InstantiateAgent(agent, createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInstantiatingAgent, err)
+	}
+	return newTicketMergerAgent(a, b.ticketRepository, owners), nil
+}
+
+func (b basicTicketAgentFactory) InstantiateTicketSplitterAgent(agent uuid.UUID, createdAt time.Time, owners TicketOwnerFinder, clients ticket.RepositoryClientWriter) (TicketSplitterAgent, error) {
+	if owners == nil {
+		return nil, fmt.Errorf("%w: %w", ErrInstantiatingAgent, ErrNilOwnerFinder)
+	}
+	if clients == nil {
+		return nil, fmt.Errorf("%w: %w", ErrInstantiatingAgent, ErrNilClientWriter)
+	}
+	tickets, err := ticket.NewFactory(b.clock, b.ids)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInstantiatingAgent, err)
+	}
+	a, err := b.InstantiateAgent(agent, createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInstantiatingAgent, err)
+	}
+	return newTicketSplitterAgent(a, b.ticketRepository, tickets, owners, clients), nil
 }
 
 func (b basicTicketAgentFactory) InstantiateAgent(agent uuid.UUID, createdAt time.Time) (Agent, error) {
-	newAgent, err := InstanceAgent(agent, createdAt, b.ticketRepository)
+	newAgent, err := instanceAgent(agent, createdAt, b.ticketRepository, b.clock, b.attachments)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", ErrInstantiatingAgent, err)
 
@@ -49,13 +162,14 @@ func (b basicTicketAgentFactory) InstantiateAgent(agent uuid.UUID, createdAt tim
 }
 
 func (b basicTicketAgentFactory) NewAgent() (Agent, error) {
-	newAgent, err := New(b.ticketRepository)
+	a, err := newAgent(b.ticketRepository, b.clock, b.ids, b.attachments)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", ErrCreatingAgent, err)
 	}
-	return newAgent, nil
+	return a, nil
 }
 
 var ErrNilRepository = errors.New("repository can't be nil")
+var ErrNilAttachments = errors.New("uploaded attachments can't be nil")
 var ErrCreatingAgent = errors.New("error creating agent")
 var ErrInstantiatingAgent = errors.New("error instantiating agent")
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"github.com/google/uuid"
 	"testing"
+	"ticketTao/entities"
 	"time"
 )
 
@@ -33,6 +34,51 @@ func TestNewTicketAgentFactory(t *testing.T) {
 	})
 }
 
+func TestNewTicketAgentFactoryWithSources(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC)
+	id := uuid.New()
+	clock := entities.ClockFunc(func() time.Time { return now })
+	ids := entities.IDSourceFunc(func() uuid.UUID { return id })
+
+	t.Run("It should return an error if a source is nil", func(t *testing.T) {
+		t.Parallel()
+		if _, err := NewTicketAgentFactoryWithSources(stubTicketRepository{}, nil, ids); !errors.Is(err, entities.ErrNilClock) {
+			t.Errorf("Error should be ErrNilClock, got %v", err)
+		}
+		if _, err := NewTicketAgentFactoryWithSources(stubTicketRepository{}, clock, nil); !errors.Is(err, entities.ErrNilIDSource) {
+			t.Errorf("Error should be ErrNilIDSource, got %v", err)
+		}
+	})
+	t.Run("New agents take their ID and creation time from the sources", func(t *testing.T) {
+		t.Parallel()
+		factory, err := NewTicketAgentFactoryWithSources(stubTicketRepository{}, clock, ids)
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		agent, err := factory.NewAgent()
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if agent.ID() != id || !agent.CreatedAt().Equal(now) {
+			t.Errorf("Expected agent %s created at %v, got %s created at %v", id, now, agent.ID(), agent.CreatedAt())
+		}
+	})
+	t.Run("The answers of the agents are written at the time of the clock", func(t *testing.T) {
+		t.Parallel()
+		factory, _ := NewTicketAgentFactoryWithSources(&fakeTicketRepository{}, clock, ids)
+		agent, _ := factory.InstantiateAgent(uuid.New(), now)
+		ticketID := uuid.New()
+		if err := agent.AnswerTicket(ticketID, "Test Comment"); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		tck, _ := agent.GetTicket(ticketID)
+		if !tck.Responses()[0].TimeStamp().Equal(now) {
+			t.Errorf("Expected the answer to be written at %v, got %v", now, tck.Responses()[0].TimeStamp())
+		}
+	})
+}
+
 func TestBasicTicketAgentFactory_NewAgent(t *testing.T) {
 	t.Parallel()
 	var factory Factory
@@ -0,0 +1,105 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"slices"
+	"ticketTao/entities"
+	"ticketTao/entities/macro"
+	"ticketTao/entities/ticket"
+)
+
+// ClientDirectory gives the name of the client that owns a ticket, for the canned response templates.
+type ClientDirectory interface {
+	TicketClientName(ticket uuid.UUID) (string, error)
+}
+
+// TicketMacroAgent is an Agent that can answer with canned responses and run macros, limited by its permissions.
+type TicketMacroAgent interface {
+	Agent
+	Permissions() []macro.Permission
+	// AnswerWithCannedResponse adds the canned response of the library, rendered for the ticket, as a response. It
+	// needs macro.PermissionAnswer.
+	AnswerWithCannedResponse(ticket uuid.UUID, response string) error
+	// RunMacro applies the actions of the macro of the library to the ticket. The agent needs the permissions of
+	// every action, otherwise the ticket is not changed.
+	RunMacro(ticket uuid.UUID, macro string) error
+}
+
+func newTicketMacroAgent(agent Agent, repo ticket.RepositoryAgentAccess, clock entities.Clock, library macro.Library, clients ClientDirectory, permissions []macro.Permission) TicketMacroAgent {
+	return ticketMacroAgent{
+		Agent:       agent,
+		repo:        repo,
+		clock:       clock,
+		library:     library,
+		clients:     clients,
+		permissions: slices.Clone(permissions),
+	}
+}
+
+type ticketMacroAgent struct {
+	Agent
+	repo        ticket.RepositoryAgentAccess
+	clock       entities.Clock
+	library     macro.Library
+	clients     ClientDirectory
+	permissions []macro.Permission
+}
+
+func (t ticketMacroAgent) Permissions() []macro.Permission {
+	return slices.Clone(t.permissions)
+}
+
+func (t ticketMacroAgent) AnswerWithCannedResponse(id uuid.UUID, response string) error {
+	if _, found := t.library.CannedResponse(response); !found {
+		return fmt.Errorf("%w: %s", macro.ErrUnknownCannedResponse, response)
+	}
+	return t.run(id, macro.Macro{Name: response, Actions: []macro.Action{macro.Reply{CannedResponse: response}}})
+}
+
+func (t ticketMacroAgent) RunMacro(id uuid.UUID, name string) error {
+	m, found := t.library.Macro(name)
+	if !found {
+		return fmt.Errorf("%w: %s", macro.ErrUnknownMacro, name)
+	}
+	return t.run(id, m)
+}
+
+func (t ticketMacroAgent) run(id uuid.UUID, m macro.Macro) error {
+	for _, permission := range m.Permissions() {
+		if !slices.Contains(t.permissions, permission) {
+			return fmt.Errorf("%w: %s", ErrPermissionDenied, permission)
+		}
+	}
+	tck, err := t.GetTicket(id)
+	if err != nil {
+		return fmt.Errorf("%w: %w", TicketRetrievalError, err)
+	}
+	clientName, err := t.clients.TicketClientName(id)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrRetrievingClient, err)
+	}
+	run := macro.Run{
+		Agent:     t.ID(),
+		At:        t.clock.Now(),
+		Variables: macro.Variables{ClientName: clientName, TicketID: tck.ID(), Title: tck.Title()},
+		Library:   t.library,
+	}
+	for _, action := range m.Actions {
+		if err := action.Apply(tck, run); err != nil {
+			return fmt.Errorf("%w %q: %w", ErrRunningMacro, m.Name, err)
+		}
+	}
+	err = t.repo.UpdateTicket(tck)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUpdatingTicket, err)
+	}
+	return nil
+}
+
+var ErrNilLibrary = errors.New("macro library cannot be nil")
+var ErrNilClientDirectory = errors.New("client directory cannot be nil")
+var ErrPermissionDenied = errors.New("the agent does not have the permission")
+var ErrRetrievingClient = errors.New("error while retrieving the client of the ticket")
+var ErrRunningMacro = errors.New("error while running macro")
@@ -0,0 +1,109 @@
+package agent
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"slices"
+	"testing"
+	"ticketTao/entities"
+	"ticketTao/entities/macro"
+	"ticketTao/entities/ticket"
+	"time"
+)
+
+func TestTicketMacroAgent(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC)
+	library, err := macro.NewLibrary(
+		[]macro.CannedResponse{{Name: "thanks", Template: "Thanks {{.ClientName}}, {{.Title}} is solved."}},
+		[]macro.Macro{{Name: "solve", Actions: []macro.Action{macro.Reply{CannedResponse: "thanks"}, macro.AddTag{Tag: "solved"}, macro.Close{}}}},
+	)
+	if err != nil {
+		t.Fatalf("Error should be nil, got %v", err)
+	}
+	newMacroAgent := func(t *testing.T, repo *fakeTicketRepository, permissions ...macro.Permission) TicketMacroAgent {
+		t.Helper()
+		factory, _ := NewTicketAgentFactoryWithSources(repo, entities.ClockFunc(func() time.Time { return now }), entities.RandomIDSource())
+		agent, err := factory.InstantiateTicketMacroAgent(uuid.New(), now, library, stubClientDirectory{name: "Ada"}, permissions...)
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		return agent
+	}
+
+	t.Run("It should need a library and a client directory", func(t *testing.T) {
+		t.Parallel()
+		factory, _ := NewTicketAgentFactory(&fakeTicketRepository{})
+		_, err := factory.InstantiateTicketMacroAgent(uuid.New(), now, nil, stubClientDirectory{})
+		assertErrors(t, err, ErrInstantiatingAgent, ErrNilLibrary)
+		_, err = factory.InstantiateTicketMacroAgent(uuid.New(), now, library, nil)
+		assertErrors(t, err, ErrInstantiatingAgent, ErrNilClientDirectory)
+	})
+	t.Run("It should answer with a rendered canned response", func(t *testing.T) {
+		t.Parallel()
+		repo := &fakeTicketRepository{}
+		agent := newMacroAgent(t, repo, macro.PermissionAnswer)
+		ticketID := uuid.New()
+
+		if err := agent.AnswerWithCannedResponse(ticketID, "thanks"); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+
+		response := repo.tickets[ticketID].Responses()[0]
+		if response.Content() != "Thanks Ada, Test Title is solved." || response.UserId() != agent.ID() || !response.TimeStamp().Equal(now) {
+			t.Errorf("Expected the rendered response by the agent, got %q by %s at %s", response.Content(), response.UserId(), response.TimeStamp())
+		}
+		assertErrors(t, agent.AnswerWithCannedResponse(ticketID, "missing"), macro.ErrUnknownCannedResponse)
+	})
+	t.Run("It should run every action of a macro", func(t *testing.T) {
+		t.Parallel()
+		repo := &fakeTicketRepository{}
+		agent := newMacroAgent(t, repo, macro.PermissionAnswer, macro.PermissionTag, macro.PermissionClose)
+		ticketID := uuid.New()
+
+		if err := agent.RunMacro(ticketID, "solve"); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+
+		tck := repo.tickets[ticketID]
+		if len(tck.Responses()) != 1 || !slices.Equal(tck.Tags(), []string{"solved"}) || tck.Status() != ticket.Closed {
+			t.Errorf("Expected the ticket to be answered, tagged and closed, got %d responses, %v, %s", len(tck.Responses()), tck.Tags(), tck.Status())
+		}
+		assertErrors(t, agent.RunMacro(ticketID, "missing"), macro.ErrUnknownMacro)
+	})
+	t.Run("It should not change the ticket without every permission of the macro", func(t *testing.T) {
+		t.Parallel()
+		repo := &fakeTicketRepository{}
+		agent := newMacroAgent(t, repo, macro.PermissionAnswer, macro.PermissionTag)
+		ticketID := uuid.New()
+
+		err := agent.RunMacro(ticketID, "solve")
+
+		assertErrors(t, err, ErrPermissionDenied)
+		if tck, ok := repo.tickets[ticketID]; ok && len(tck.Responses()) != 0 {
+			t.Error("The ticket should not be changed")
+		}
+		assertErrors(t, newMacroAgent(t, repo).AnswerWithCannedResponse(ticketID, "thanks"), ErrPermissionDenied)
+	})
+}
+
+type stubClientDirectory struct {
+	name string
+	err  error
+}
+
+func (s stubClientDirectory) TicketClientName(uuid.UUID) (string, error) {
+	return s.name, s.err
+}
+
+func assertErrors(t *testing.T, err error, expected ...error) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("Error should not be nil")
+	}
+	for _, e := range expected {
+		if !errors.Is(err, e) {
+			t.Errorf("Error should be %v, but is %s", e, err.Error())
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"slices"
+	"ticketTao/entities/ticket"
+)
+
+// TicketOwnerFinder gives the client that owns a ticket, ticket.RepositoryAgentLister implements it.
+type TicketOwnerFinder interface {
+	GetTicketOwner(ticket uuid.UUID) (uuid.UUID, error)
+}
+
+// TicketMergerAgent is an Agent that can merge duplicated tickets.
+type TicketMergerAgent interface {
+	Agent
+	// MergeTickets moves the responses, the attachments and the followers of the source ticket into the target ticket,
+	// and closes the source as a Duplicate of the target. Both tickets must belong to the same client.
+	MergeTickets(source, target uuid.UUID) error
+}
+
+func newTicketMergerAgent(agent Agent, repo ticket.RepositoryAgentAccess, owners TicketOwnerFinder) TicketMergerAgent {
+	return ticketMergerAgent{agent, repo, owners}
+}
+
+type ticketMergerAgent struct {
+	Agent
+	repo   ticket.RepositoryAgentAccess
+	owners TicketOwnerFinder
+}
+
+func (t ticketMergerAgent) MergeTickets(sourceID, targetID uuid.UUID) error {
+	if sourceID == targetID {
+		return ErrMergingTicketIntoItself
+	}
+	source, err := t.GetTicket(sourceID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", TicketRetrievalError, err)
+	}
+	target, err := t.GetTicket(targetID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", TicketRetrievalError, err)
+	}
+	if source.MergedInto() != uuid.Nil || target.MergedInto() != uuid.Nil {
+		return ErrTicketAlreadyMerged
+	}
+	if err := t.validateSameOwner(sourceID, targetID); err != nil {
+		return err
+	}
+
+	// The merged responses answered the source, so they are notes that do not count as answers to the target. The
+	// responses and attachments already in the target were moved by a merge that could not close the source.
+	for _, response := range source.Responses() {
+		if !hasResponse(target, response) {
+			target.MergeResponses(ticket.AsNote(response))
+		}
+	}
+	for _, attachment := range source.Attachments() {
+		if _, found := ticket.FindAttachment(target, attachment.ID); !found {
+			target.Attach(attachment)
+		}
+	}
+	for _, follower := range source.Followers() {
+		target.Follow(follower)
+	}
+	source.CloseAsDuplicateOf(targetID)
+
+	// The target is updated first, so a failure never leaves a closed source whose responses were not moved, and
+	// merging again after a failure does not move them twice.
+	if err := t.repo.UpdateTicket(target); err != nil {
+		return fmt.Errorf("%w: %w", ErrUpdatingTicket, err)
+	}
+	if err := t.repo.UpdateTicket(source); err != nil {
+		return fmt.Errorf("%w: %w", ErrUpdatingTicket, err)
+	}
+	return nil
+}
+
+// hasResponse returns whether the ticket has a response by the same user, at the same time and with the same content.
+func hasResponse(tck ticket.Ticket, response ticket.Response) bool {
+	return slices.ContainsFunc(tck.Responses(), func(r ticket.Response) bool {
+		return r.UserId() == response.UserId() && r.TimeStamp().Equal(response.TimeStamp()) && r.Content() == response.Content()
+	})
+}
+
+func (t ticketMergerAgent) validateSameOwner(source, target uuid.UUID) error {
+	sourceOwner, err := t.owners.GetTicketOwner(source)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrRetrievingClient, err)
+	}
+	targetOwner, err := t.owners.GetTicketOwner(target)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrRetrievingClient, err)
+	}
+	if sourceOwner != targetOwner {
+		return ErrDifferentOwners
+	}
+	return nil
+}
+
+var ErrNilOwnerFinder = errors.New("ticket owner finder cannot be nil")
+var ErrMergingTicketIntoItself = errors.New("a ticket cannot be merged into itself")
+var ErrTicketAlreadyMerged = errors.New("ticket was already merged")
+var ErrDifferentOwners = errors.New("the tickets belong to different clients")
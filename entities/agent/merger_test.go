@@ -0,0 +1,149 @@
+package agent
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"testing"
+	"ticketTao/entities/ticket"
+	"time"
+)
+
+func TestTicketMergerAgent(t *testing.T) {
+	t.Parallel()
+	start := time.Now().Add(-time.Hour)
+	client := uuid.New()
+	setup := func(t *testing.T) (TicketMergerAgent, *fakeTicketRepository, stubOwnerFinder) {
+		t.Helper()
+		repo := &fakeTicketRepository{}
+		owners := stubOwnerFinder{}
+		factory, _ := NewTicketAgentFactory(repo)
+		agent, err := factory.InstantiateTicketMergerAgent(uuid.New(), start, owners)
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		return agent, repo, owners
+	}
+	addTicket := func(repo *fakeTicketRepository, owners stubOwnerFinder, owner uuid.UUID, responses ...ticket.Response) ticket.Ticket {
+		tck, _ := repo.GetTicket(uuid.New())
+		for _, response := range responses {
+			tck.AddResponse(response)
+		}
+		owners[tck.ID()] = owner
+		return tck
+	}
+
+	t.Run("It should need an owner finder", func(t *testing.T) {
+		t.Parallel()
+		factory, _ := NewTicketAgentFactory(&fakeTicketRepository{})
+		_, err := factory.InstantiateTicketMergerAgent(uuid.New(), start, nil)
+		assertErrors(t, err, ErrInstantiatingAgent, ErrNilOwnerFinder)
+	})
+	t.Run("It should move the responses into the target and close the source as a duplicate", func(t *testing.T) {
+		t.Parallel()
+		agent, repo, owners := setup(t)
+		first := ticket.MakeResponse(client, "first", start)
+		second := ticket.MakeResponse(client, "second", start.Add(time.Minute))
+		third := ticket.MakeResponse(client, "third", start.Add(2*time.Minute))
+		target := addTicket(repo, owners, client, first, third)
+		source := addTicket(repo, owners, client, second)
+		attachment := ticket.Attachment{ID: uuid.New(), Name: "photo.png"}
+		source.Attach(attachment)
+
+		if err := agent.MergeTickets(source.ID(), target.ID()); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+
+		merged := repo.tickets[target.ID()]
+		responses := merged.Responses()
+		if len(responses) != 3 || responses[0] != first || responses[1].Content() != second.Content() || responses[2] != third {
+			t.Errorf("Expected the responses in timestamp order, got %v", responses)
+		}
+		if !responses[1].IsNote() || responses[0].IsNote() {
+			t.Error("Expected only the merged response to be a note")
+		}
+		if _, found := ticket.FindAttachment(merged, attachment.ID); !found {
+			t.Error("Expected the attachments to be moved to the target")
+		}
+		closed := repo.tickets[source.ID()]
+		if closed.Status() != ticket.Closed || closed.ClosingReason() != ticket.Duplicate || closed.MergedInto() != target.ID() {
+			t.Errorf("Expected the source to be closed as a duplicate of the target, got %s, %s, %s", closed.Status(), closed.ClosingReason(), closed.MergedInto())
+		}
+	})
+	t.Run("It should not merge a ticket into itself or an already merged ticket", func(t *testing.T) {
+		t.Parallel()
+		agent, repo, owners := setup(t)
+		source := addTicket(repo, owners, client)
+		target := addTicket(repo, owners, client)
+		other := addTicket(repo, owners, client)
+
+		assertErrors(t, agent.MergeTickets(source.ID(), source.ID()), ErrMergingTicketIntoItself)
+		_ = agent.MergeTickets(source.ID(), target.ID())
+		assertErrors(t, agent.MergeTickets(source.ID(), other.ID()), ErrTicketAlreadyMerged)
+		assertErrors(t, agent.MergeTickets(other.ID(), source.ID()), ErrTicketAlreadyMerged)
+	})
+	t.Run("Merging again after the source could not be closed does not move the responses twice", func(t *testing.T) {
+		t.Parallel()
+		repo := &copyingTicketRepository{}
+		owners := stubOwnerFinder{}
+		factory, _ := NewTicketAgentFactory(repo)
+		agent, _ := factory.InstantiateTicketMergerAgent(uuid.New(), start, owners)
+		target := addTicket(&repo.fakeTicketRepository, owners, client, ticket.MakeResponse(client, "first", start))
+		source := addTicket(&repo.fakeTicketRepository, owners, client, ticket.MakeResponse(client, "second", start.Add(time.Minute)))
+		source.Attach(ticket.Attachment{ID: uuid.New(), Name: "photo.png"})
+
+		repo.failing = source.ID()
+		assertErrors(t, agent.MergeTickets(source.ID(), target.ID()), ErrUpdatingTicket, errUpdateFailed)
+		repo.failing = uuid.Nil
+		if err := agent.MergeTickets(source.ID(), target.ID()); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+
+		merged := repo.tickets[target.ID()]
+		if len(merged.Responses()) != 2 || len(merged.Attachments()) != 1 {
+			t.Errorf("Expected 2 responses and 1 attachment, got %d and %d", len(merged.Responses()), len(merged.Attachments()))
+		}
+		if repo.tickets[source.ID()].MergedInto() != target.ID() {
+			t.Error("Expected the source to be merged into the target")
+		}
+	})
+	t.Run("It should not merge tickets of different clients", func(t *testing.T) {
+		t.Parallel()
+		agent, repo, owners := setup(t)
+		source := addTicket(repo, owners, client, ticket.MakeResponse(client, "private", start))
+		target := addTicket(repo, owners, uuid.New())
+
+		assertErrors(t, agent.MergeTickets(source.ID(), target.ID()), ErrDifferentOwners)
+		if len(repo.tickets[target.ID()].Responses()) != 0 {
+			t.Error("The target should not be changed")
+		}
+	})
+}
+
+type stubOwnerFinder map[uuid.UUID]uuid.UUID
+
+func (s stubOwnerFinder) GetTicketOwner(id uuid.UUID) (uuid.UUID, error) {
+	return s[id], nil
+}
+
+// copyingTicketRepository keeps copies of the tickets, as a persistence does, and fails the updates of a ticket.
+type copyingTicketRepository struct {
+	fakeTicketRepository
+	failing uuid.UUID
+}
+
+func (c *copyingTicketRepository) GetTicket(id uuid.UUID) (ticket.Ticket, error) {
+	tck, err := c.fakeTicketRepository.GetTicket(id)
+	if err != nil {
+		return nil, err
+	}
+	return ticket.Snapshot(tck), nil
+}
+
+func (c *copyingTicketRepository) UpdateTicket(tck ticket.Ticket) error {
+	if tck.ID() == c.failing {
+		return errUpdateFailed
+	}
+	return c.fakeTicketRepository.UpdateTicket(ticket.Snapshot(tck))
+}
+
+var errUpdateFailed = errors.New("connection lost")
@@ -0,0 +1,157 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"ticketTao/entities/ticket"
+)
+
+// SplitPart selects the responses of a ticket that become a new ticket.
+type SplitPart struct {
+	Title string
+	// Description defaults to the content of the first selected response.
+	Description string
+	// Responses are the indexes, in ticket.Ticket.Responses, of the responses copied to the new ticket.
+	Responses []int
+}
+
+// TicketSplitterAgent is an Agent that can split a ticket about several issues into new tickets.
+type TicketSplitterAgent interface {
+	Agent
+	// SplitTicket creates a ticket for every part, owned by the client of the original ticket and linked to it both
+	// ways, and returns their IDs. The split is recorded by a note of the agent in every ticket involved. A response
+	// can only be selected by one part. Nothing is created unless every part is valid, and if persisting fails after
+	// creating some tickets the error is a SplitError with them.
+	SplitTicket(ticket uuid.UUID, parts ...SplitPart) ([]uuid.UUID, error)
+}
+
+// SplitError is returned when a split fails after creating some of its tickets.
+type SplitError struct {
+	// Created are the tickets created before the failure, they are also returned by SplitTicket.
+	Created []uuid.UUID
+	Err     error
+}
+
+func (e SplitError) Error() string {
+	return fmt.Sprintf("split failed after creating tickets %v: %s", e.Created, e.Err)
+}
+
+func (e SplitError) Unwrap() error {
+	return e.Err
+}
+
+func newTicketSplitterAgent(agent Agent, repo ticket.RepositoryAgentAccess, tickets ticket.Factory, owners TicketOwnerFinder, clients ticket.RepositoryClientWriter) TicketSplitterAgent {
+	return ticketSplitterAgent{agent, repo, tickets, owners, clients}
+}
+
+type ticketSplitterAgent struct {
+	Agent
+	repo    ticket.RepositoryAgentAccess
+	tickets ticket.Factory
+	owners  TicketOwnerFinder
+	clients ticket.RepositoryClientWriter
+}
+
+func (t ticketSplitterAgent) SplitTicket(id uuid.UUID, parts ...SplitPart) ([]uuid.UUID, error) {
+	if len(parts) == 0 {
+		return nil, ErrNoSplitParts
+	}
+	original, err := t.GetTicket(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", TicketRetrievalError, err)
+	}
+	if err := validateSplitParts(original, parts); err != nil {
+		return nil, err
+	}
+	owner, err := t.owners.GetTicketOwner(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRetrievingClient, err)
+	}
+
+	split := make([]ticket.Ticket, 0, len(parts))
+	for _, part := range parts {
+		tck, err := t.newTicket(original, part)
+		if err != nil {
+			return nil, err
+		}
+		split = append(split, tck)
+	}
+
+	// The original is updated even if a ticket cannot be created, so it links to the tickets that were.
+	var created []uuid.UUID
+	var createErr error
+	for _, tck := range split {
+		if err := t.clients.CreateNewTicketForClient(owner, tck); err != nil {
+			createErr = fmt.Errorf("%w: %w", ErrCreatingTicket, err)
+			break
+		}
+		created = append(created, tck.ID())
+		original.AddSplit(tck.ID())
+		original.MergeResponses(t.tickets.NewNote(t.ID(), fmt.Sprintf("Split into ticket %s: %s", tck.ID(), tck.Title())))
+	}
+	if len(created) == 0 {
+		return nil, createErr
+	}
+	if err := t.repo.UpdateTicket(original); err != nil {
+		createErr = errors.Join(createErr, fmt.Errorf("%w: %w", ErrUpdatingTicket, err))
+	}
+	if createErr != nil {
+		return created, SplitError{Created: created, Err: createErr}
+	}
+	return created, nil
+}
+
+func (t ticketSplitterAgent) newTicket(original ticket.Ticket, part SplitPart) (ticket.Ticket, error) {
+	responses := make([]ticket.Response, 0, len(part.Responses)+1)
+	for _, i := range part.Responses {
+		responses = append(responses, original.Responses()[i])
+	}
+	description := part.Description
+	if description == "" {
+		description = responses[0].Content()
+	}
+	// The note is a merged response, so recording the split does not change the status of the new ticket.
+	responses = append(responses, t.tickets.NewNote(t.ID(), fmt.Sprintf("Split from ticket %s: %s", original.ID(), original.Title())))
+	tck, err := t.tickets.NewTicket(part.Title, description)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCreatingTicket, err)
+	}
+	data := ticket.DataFrom(tck)
+	data.SplitFrom = original.ID()
+	data.Priority = original.Priority()
+	data.Tags = original.Tags()
+	data.Category = original.Category()
+	tck, err = t.tickets.MakeTicket(tck.ID(), tck.CreatedAt(), data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCreatingTicket, err)
+	}
+	tck.MergeResponses(responses...)
+	return tck, nil
+}
+
+func validateSplitParts(original ticket.Ticket, parts []SplitPart) error {
+	selected := make(map[int]bool)
+	for _, part := range parts {
+		if len(part.Responses) == 0 {
+			return ErrNoSelectedResponses
+		}
+		for _, i := range part.Responses {
+			if i < 0 || i >= len(original.Responses()) {
+				return fmt.Errorf("%w: %d", ErrInvalidResponseIndex, i)
+			}
+			if selected[i] {
+				return fmt.Errorf("%w: %d", ErrResponseSelectedTwice, i)
+			}
+			selected[i] = true
+		}
+	}
+	return nil
+}
+
+var ErrNilClientWriter = errors.New("client ticket writer cannot be nil")
+var ErrNoSplitParts = errors.New("a ticket must be split into at least one part")
+var ErrNoSelectedResponses = errors.New("a split part must select at least one response")
+var ErrInvalidResponseIndex = errors.New("the ticket has no response at the index")
+var ErrResponseSelectedTwice = errors.New("a response cannot be selected by two split parts")
+var ErrCreatingTicket = errors.New("error while creating ticket")
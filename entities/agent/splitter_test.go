@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"slices"
+	"strings"
+	"testing"
+	"ticketTao/entities/ticket"
+	"time"
+)
+
+func TestTicketSplitterAgent(t *testing.T) {
+	t.Parallel()
+	start := time.Now().Add(-time.Hour)
+	client := uuid.New()
+	setup := func(t *testing.T) (TicketSplitterAgent, *fakeTicketRepository, *spyClientWriter, ticket.Ticket) {
+		t.Helper()
+		repo := &fakeTicketRepository{}
+		clients := &spyClientWriter{}
+		owners := stubOwnerFinder{}
+		factory, _ := NewTicketAgentFactory(repo)
+		agent, err := factory.InstantiateTicketSplitterAgent(uuid.New(), start, owners, clients)
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		original, _ := repo.GetTicket(uuid.New())
+		owners[original.ID()] = client
+		original.AddTag("vip")
+		original.AddResponse(ticket.MakeResponse(client, "My invoice is wrong", start))
+		original.AddResponse(ticket.MakeResponse(client, "And I cannot log in", start.Add(time.Minute)))
+		original.AddResponse(ticket.MakeResponse(client, "The login says my password expired", start.Add(2*time.Minute)))
+		return agent, repo, clients, original
+	}
+
+	t.Run("It should need an owner finder and a client writer", func(t *testing.T) {
+		t.Parallel()
+		factory, _ := NewTicketAgentFactory(&fakeTicketRepository{})
+		_, err := factory.InstantiateTicketSplitterAgent(uuid.New(), start, nil, &spyClientWriter{})
+		assertErrors(t, err, ErrInstantiatingAgent, ErrNilOwnerFinder)
+		_, err = factory.InstantiateTicketSplitterAgent(uuid.New(), start, stubOwnerFinder{}, nil)
+		assertErrors(t, err, ErrInstantiatingAgent, ErrNilClientWriter)
+	})
+	t.Run("It should create linked tickets for the owner with the selected responses", func(t *testing.T) {
+		t.Parallel()
+		agent, repo, clients, original := setup(t)
+
+		created, err := agent.SplitTicket(original.ID(), SplitPart{Title: "Cannot log in", Responses: []int{1, 2}})
+
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if len(created) != 1 || len(clients.created) != 1 || clients.owners[0] != client {
+			t.Fatalf("Expected one ticket created for the client, got %v", created)
+		}
+		split := clients.created[0]
+		if split.ID() != created[0] || split.SplitFrom() != original.ID() || split.Status() != ticket.Open {
+			t.Errorf("Expected an open ticket split from the original, got split from %s with status %s", split.SplitFrom(), split.Status())
+		}
+		if split.Description() != "And I cannot log in" || split.Tags()[0] != "vip" {
+			t.Errorf("Expected the description and the tags to come from the original, got %q and %v", split.Description(), split.Tags())
+		}
+		responses := split.Responses()
+		if len(responses) != 3 || responses[0].Content() != "And I cannot log in" || !strings.Contains(responses[2].Content(), original.ID().String()) {
+			t.Errorf("Expected the selected responses and the split note, got %d responses", len(responses))
+		}
+		updated := repo.tickets[original.ID()]
+		last := updated.Responses()[len(updated.Responses())-1]
+		if len(updated.Responses()) != 4 || last.UserId() != agent.ID() || !strings.Contains(last.Content(), split.ID().String()) {
+			t.Errorf("Expected the split to be recorded in the original ticket, got %q", last.Content())
+		}
+		if !slices.Equal(updated.SplitInto(), created) {
+			t.Errorf("Expected the original to link to %v, got %v", created, updated.SplitInto())
+		}
+	})
+	t.Run("It should validate the parts before creating any ticket", func(t *testing.T) {
+		t.Parallel()
+		agent, _, clients, original := setup(t)
+
+		_, err := agent.SplitTicket(original.ID())
+		assertErrors(t, err, ErrNoSplitParts)
+		_, err = agent.SplitTicket(original.ID(), SplitPart{Title: "empty"})
+		assertErrors(t, err, ErrNoSelectedResponses)
+		_, err = agent.SplitTicket(original.ID(), SplitPart{Title: "out of range", Responses: []int{3}})
+		assertErrors(t, err, ErrInvalidResponseIndex)
+		_, err = agent.SplitTicket(original.ID(), SplitPart{Title: "a", Responses: []int{0}}, SplitPart{Title: "b", Responses: []int{0, 1}})
+		assertErrors(t, err, ErrResponseSelectedTwice)
+		_, err = agent.SplitTicket(original.ID(), SplitPart{Title: "a", Responses: []int{0}}, SplitPart{Title: "", Responses: []int{1}})
+		assertErrors(t, err, ErrCreatingTicket)
+		if len(clients.created) != 0 {
+			t.Errorf("No ticket should be created, got %d", len(clients.created))
+		}
+	})
+	t.Run("It should report the tickets created before a failure", func(t *testing.T) {
+		t.Parallel()
+		agent, repo, clients, original := setup(t)
+		clients.failing = "Cannot log in"
+
+		created, err := agent.SplitTicket(original.ID(), SplitPart{Title: "Invoice", Responses: []int{0}}, SplitPart{Title: "Cannot log in", Responses: []int{1}})
+
+		var splitErr SplitError
+		if !errors.As(err, &splitErr) || !errors.Is(err, ErrCreatingTicket) {
+			t.Fatalf("Error should be a SplitError, got %v", err)
+		}
+		if len(created) != 1 || !slices.Equal(splitErr.Created, created) || clients.created[0].ID() != created[0] {
+			t.Errorf("Expected the invoice ticket to be reported as created, got %v", splitErr.Created)
+		}
+		if !slices.Equal(repo.tickets[original.ID()].SplitInto(), created) {
+			t.Errorf("Expected the original to link to the created tickets, got %v", repo.tickets[original.ID()].SplitInto())
+		}
+	})
+}
+
+type spyClientWriter struct {
+	owners  []uuid.UUID
+	created []ticket.Ticket
+	// failing is the title of the tickets that cannot be created.
+	failing string
+}
+
+func (s *spyClientWriter) CreateNewTicketForClient(owner uuid.UUID, tck ticket.Ticket) error {
+	if s.failing != "" && tck.Title() == s.failing {
+		return errUpdateFailed
+	}
+	s.owners = append(s.owners, owner)
+	s.created = append(s.created, tck)
+	return nil
+}
+
+func (s *spyClientWriter) UpdateTicketForClient(uuid.UUID, ticket.Ticket) error {
+	return nil
+}
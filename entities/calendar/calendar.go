@@ -0,0 +1,163 @@
+// Package calendar contains business-hours calendars, which measure time counting only the working hours of each
+// weekday, in a given time zone, and skipping holidays.
+package calendar
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// Calendar measures business time.
+type Calendar interface {
+	// BusinessTimeBetween returns the working time elapsed between two instants, it is zero when "to" is not after
+	// "from".
+	BusinessTimeBetween(from, to time.Time) time.Duration
+	// AddBusinessTime returns the instant at which the given amount of working time will have elapsed after start.
+	AddBusinessTime(start time.Time, d time.Duration) time.Time
+}
+
+// WorkingHours is a working interval within a day, expressed as offsets from midnight (e.g. 9h to 18h).
+type WorkingHours struct {
+	From time.Duration
+	To   time.Duration
+}
+
+// Week contains the working intervals of each weekday, days without intervals are not worked.
+type Week map[time.Weekday][]WorkingHours
+
+// Date is a day in the calendar's time zone.
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// WallClock returns a calendar where every instant is business time, so it measures plain elapsed time.
+func WallClock() Calendar {
+	return wallClock{}
+}
+
+// New creates a business-hours calendar for the given time zone. The week must contain at least one working interval,
+// and the intervals of a day cannot overlap.
+func New(location *time.Location, week Week, holidays []Date) (Calendar, error) {
+	if location == nil {
+		return nil, errors.Join(NewCalendarError, ErrNilLocation)
+	}
+	normalized := make(Week)
+	worked := false
+	for day, intervals := range week {
+		sorted := append([]WorkingHours(nil), intervals...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].From < sorted[j].From })
+		for i, interval := range sorted {
+			if interval.From < 0 || interval.To > 24*time.Hour || interval.From >= interval.To {
+				return nil, errors.Join(NewCalendarError, ErrInvalidWorkingHours)
+			}
+			if i > 0 && interval.From < sorted[i-1].To {
+				return nil, errors.Join(NewCalendarError, ErrOverlappingWorkingHours)
+			}
+			worked = true
+		}
+		normalized[day] = sorted
+	}
+	if !worked {
+		return nil, errors.Join(NewCalendarError, ErrNoWorkingHours)
+	}
+	holidaySet := make(map[Date]bool, len(holidays))
+	for _, holiday := range holidays {
+		holidaySet[holiday] = true
+	}
+	return businessCalendar{location: location, week: normalized, holidays: holidaySet}, nil
+}
+
+type wallClock struct{}
+
+func (wallClock) BusinessTimeBetween(from, to time.Time) time.Duration {
+	if !to.After(from) {
+		return 0
+	}
+	return to.Sub(from)
+}
+
+func (wallClock) AddBusinessTime(start time.Time, d time.Duration) time.Time {
+	return start.Add(d)
+}
+
+type businessCalendar struct {
+	location *time.Location
+	week     Week
+	holidays map[Date]bool
+}
+
+func (b businessCalendar) BusinessTimeBetween(from, to time.Time) time.Duration {
+	if !to.After(from) {
+		return 0
+	}
+	var elapsed time.Duration
+	for day := b.startOfDay(from); day.Before(to); day = b.nextDay(day) {
+		for _, interval := range b.intervals(day) {
+			start, end := b.at(day, interval.From), b.at(day, interval.To)
+			if start.Before(from) {
+				start = from
+			}
+			if end.After(to) {
+				end = to
+			}
+			if end.After(start) {
+				elapsed += end.Sub(start)
+			}
+		}
+	}
+	return elapsed
+}
+
+func (b businessCalendar) AddBusinessTime(start time.Time, d time.Duration) time.Time {
+	if d <= 0 {
+		return start
+	}
+	remaining := d
+	for day := b.startOfDay(start); ; day = b.nextDay(day) {
+		for _, interval := range b.intervals(day) {
+			from, to := b.at(day, interval.From), b.at(day, interval.To)
+			if !to.After(start) {
+				continue
+			}
+			if from.Before(start) {
+				from = start
+			}
+			if remaining <= to.Sub(from) {
+				return from.Add(remaining)
+			}
+			remaining -= to.Sub(from)
+		}
+	}
+}
+
+func (b businessCalendar) intervals(day time.Time) []WorkingHours {
+	if b.holidays[Date{day.Year(), day.Month(), day.Day()}] {
+		return nil
+	}
+	return b.week[day.Weekday()]
+}
+
+func (b businessCalendar) startOfDay(t time.Time) time.Time {
+	local := t.In(b.location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, b.location)
+}
+
+func (b businessCalendar) nextDay(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, b.location)
+}
+
+// at returns the instant of a day at the given offset, using the wall clock of the day, so working hours are kept
+// on the days that daylight saving time changes.
+func (b businessCalendar) at(day time.Time, offset time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, int(offset), b.location)
+}
+
+var NewCalendarError error = errors.New("error creating calendar")
+
+var ErrNilLocation error = errors.New("calendar time zone cannot be nil")
+var ErrInvalidWorkingHours error = errors.New("working hours must start before they end, within the same day")
+var ErrOverlappingWorkingHours error = errors.New("working hours of the same day cannot overlap")
+var ErrNoWorkingHours error = errors.New("calendar must have working hours")
@@ -0,0 +1,125 @@
+package calendar
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	t.Run("A calendar needs a time zone", func(t *testing.T) {
+		t.Parallel()
+		_, err := New(nil, officeWeek(), nil)
+		assertErrors(t, err, NewCalendarError, ErrNilLocation)
+	})
+	t.Run("A calendar needs working hours", func(t *testing.T) {
+		t.Parallel()
+		_, err := New(time.UTC, Week{}, nil)
+		assertErrors(t, err, NewCalendarError, ErrNoWorkingHours)
+	})
+	t.Run("Working hours must start before they end", func(t *testing.T) {
+		t.Parallel()
+		_, err := New(time.UTC, Week{time.Monday: {{From: 18 * time.Hour, To: 9 * time.Hour}}}, nil)
+		assertErrors(t, err, NewCalendarError, ErrInvalidWorkingHours)
+	})
+	t.Run("Working hours of a day cannot overlap", func(t *testing.T) {
+		t.Parallel()
+		_, err := New(time.UTC, Week{time.Monday: {
+			{From: 9 * time.Hour, To: 14 * time.Hour},
+			{From: 13 * time.Hour, To: 18 * time.Hour},
+		}}, nil)
+		assertErrors(t, err, NewCalendarError, ErrOverlappingWorkingHours)
+	})
+}
+
+func TestCalendar_BusinessTimeBetween(t *testing.T) {
+	t.Parallel()
+	mexicoCity, err := time.LoadLocation("America/Mexico_City")
+	if err != nil {
+		t.Skip("time zone database is not available")
+	}
+	holiday := Date{2024, time.March, 18}
+	cal, err := New(mexicoCity, officeWeek(), []Date{holiday})
+	if err != nil {
+		t.Fatalf("Error creating calendar: %v", err)
+	}
+	// 2024-03-15 is a Friday, 2024-03-18 is a Monday holiday.
+	friday := func(hour int) time.Time { return time.Date(2024, time.March, 15, hour, 0, 0, 0, mexicoCity) }
+	tuesday := func(hour int) time.Time { return time.Date(2024, time.March, 19, hour, 0, 0, 0, mexicoCity) }
+
+	t.Run("Only the working hours are counted", func(t *testing.T) {
+		t.Parallel()
+		assertDuration(t, cal.BusinessTimeBetween(friday(8), friday(20)), 8*time.Hour)
+	})
+	t.Run("The lunch break is not counted", func(t *testing.T) {
+		t.Parallel()
+		assertDuration(t, cal.BusinessTimeBetween(friday(13), friday(16)), 2*time.Hour)
+	})
+	t.Run("Weekends and holidays are not counted", func(t *testing.T) {
+		t.Parallel()
+		assertDuration(t, cal.BusinessTimeBetween(friday(17), tuesday(10)), 2*time.Hour)
+	})
+	t.Run("Instants in other time zones are measured in the calendar's time zone", func(t *testing.T) {
+		t.Parallel()
+		assertDuration(t, cal.BusinessTimeBetween(friday(17).UTC(), tuesday(10).UTC()), 2*time.Hour)
+	})
+	t.Run("The time is zero when the end is not after the start", func(t *testing.T) {
+		t.Parallel()
+		assertDuration(t, cal.BusinessTimeBetween(tuesday(10), friday(10)), 0)
+	})
+}
+
+func TestCalendar_AddBusinessTime(t *testing.T) {
+	t.Parallel()
+	cal, err := New(time.UTC, officeWeek(), []Date{{2024, time.March, 18}})
+	if err != nil {
+		t.Fatalf("Error creating calendar: %v", err)
+	}
+	friday := time.Date(2024, time.March, 15, 17, 0, 0, 0, time.UTC)
+
+	t.Run("The business time continues on the next working day", func(t *testing.T) {
+		t.Parallel()
+		due := cal.AddBusinessTime(friday, 4*time.Hour)
+		expected := time.Date(2024, time.March, 19, 12, 0, 0, 0, time.UTC)
+		if !due.Equal(expected) {
+			t.Errorf("Expected %v, got %v", expected, due)
+		}
+	})
+	t.Run("Adding business time and measuring it are consistent", func(t *testing.T) {
+		t.Parallel()
+		for _, d := range []time.Duration{time.Minute, 3 * time.Hour, 9 * time.Hour, 40 * time.Hour} {
+			assertDuration(t, cal.BusinessTimeBetween(friday, cal.AddBusinessTime(friday, d)), d)
+		}
+	})
+	t.Run("The wall clock calendar measures elapsed time", func(t *testing.T) {
+		t.Parallel()
+		wall := WallClock()
+		assertDuration(t, wall.BusinessTimeBetween(friday, wall.AddBusinessTime(friday, 72*time.Hour)), 72*time.Hour)
+	})
+}
+
+// officeWeek works from 9 to 18 with a lunch break from 14 to 15, Monday to Friday.
+func officeWeek() Week {
+	day := []WorkingHours{{From: 9 * time.Hour, To: 14 * time.Hour}, {From: 15 * time.Hour, To: 18 * time.Hour}}
+	return Week{time.Monday: day, time.Tuesday: day, time.Wednesday: day, time.Thursday: day, time.Friday: day}
+}
+
+func assertDuration(t *testing.T, got, want time.Duration) {
+	t.Helper()
+	if got != want {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func assertErrors(t *testing.T, err error, expected ...error) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("Expected an error, got nil")
+	}
+	for _, e := range expected {
+		if !errors.Is(err, e) {
+			t.Errorf("Expected error to be %v, got %v", e, err)
+		}
+	}
+}
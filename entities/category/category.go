@@ -0,0 +1,154 @@
+// Package category contains the managed, hierarchical taxonomy used to classify tickets. A category is identified by
+// its path: the names of the categories from the root, separated by "/", e.g. "billing/refunds".
+package category
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Separator separates the names of a category path.
+const Separator = "/"
+
+// Taxonomy is the tree of the categories that can be given to a ticket.
+type Taxonomy interface {
+	// Add adds a category, its parent must already exist.
+	Add(path string) error
+	// Remove removes a category, it must not have subcategories nor, when the taxonomy knows their Usage, tickets.
+	Remove(path string) error
+	Contains(path string) bool
+	// Children returns the paths of the direct subcategories, sorted. The empty path returns the root categories.
+	Children(path string) []string
+}
+
+// Usage tells whether a category is still given to tickets.
+type Usage interface {
+	// IsCategoryUsed returns whether any ticket has the category or one of its subcategories.
+	IsCategoryUsed(path string) (bool, error)
+}
+
+// NewTaxonomy creates a Taxonomy with the given categories, added in order. It does not know which categories are
+// used by tickets, so it should only be used when no ticket is categorized yet, e.g. in tests.
+func NewTaxonomy(paths ...string) (Taxonomy, error) {
+	return newTaxonomy(nil, paths...)
+}
+
+// NewTaxonomyWithUsage works like NewTaxonomy, but the categories used by tickets cannot be removed.
+func NewTaxonomyWithUsage(usage Usage, paths ...string) (Taxonomy, error) {
+	if usage == nil {
+		return nil, errors.Join(NewTaxonomyError, ErrNilUsage)
+	}
+	return newTaxonomy(usage, paths...)
+}
+
+func newTaxonomy(usage Usage, paths ...string) (Taxonomy, error) {
+	taxonomy := &memoryTaxonomy{children: map[string][]string{"": nil}, usage: usage}
+	for _, path := range paths {
+		if err := taxonomy.Add(path); err != nil {
+			return nil, errors.Join(NewTaxonomyError, err)
+		}
+	}
+	return taxonomy, nil
+}
+
+// Parent returns the path of the parent of a category, it is empty for the root categories.
+func Parent(path string) string {
+	i := strings.LastIndex(path, Separator)
+	if i < 0 {
+		return ""
+	}
+	return path[:i]
+}
+
+// IsWithin tells if the category is the ancestor or one of its subcategories.
+func IsWithin(path, ancestor string) bool {
+	return path == ancestor || strings.HasPrefix(path, ancestor+Separator)
+}
+
+type memoryTaxonomy struct {
+	mu sync.RWMutex
+	// children maps every category, and the root (""), to the sorted paths of its direct subcategories.
+	children map[string][]string
+	usage    Usage
+}
+
+func (m *memoryTaxonomy) Add(path string) error {
+	if err := validate(path); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.children[path]; ok {
+		return ErrCategoryExists
+	}
+	parent := Parent(path)
+	siblings, ok := m.children[parent]
+	if !ok {
+		return ErrUnknownParent
+	}
+	i, _ := slices.BinarySearch(siblings, path)
+	m.children[parent] = slices.Insert(siblings, i, path)
+	m.children[path] = nil
+	return nil
+}
+
+func (m *memoryTaxonomy) Remove(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	children, ok := m.children[path]
+	if !ok || path == "" {
+		return ErrUnknownCategory
+	}
+	if len(children) > 0 {
+		return ErrCategoryHasChildren
+	}
+	if m.usage != nil {
+		used, err := m.usage.IsCategoryUsed(path)
+		if err != nil {
+			return errors.Join(ErrCheckingUsage, err)
+		}
+		if used {
+			return ErrCategoryInUse
+		}
+	}
+	parent := Parent(path)
+	i, _ := slices.BinarySearch(m.children[parent], path)
+	m.children[parent] = slices.Delete(m.children[parent], i, i+1)
+	delete(m.children, path)
+	return nil
+}
+
+func (m *memoryTaxonomy) Contains(path string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.children[path]
+	return ok && path != ""
+}
+
+func (m *memoryTaxonomy) Children(path string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Clone(m.children[path])
+}
+
+func validate(path string) error {
+	for _, name := range strings.Split(path, Separator) {
+		if strings.TrimSpace(name) != name || name == "" {
+			return ErrInvalidPath
+		}
+	}
+	return nil
+}
+
+var NewTaxonomyError error = errors.New("error creating category taxonomy")
+
+var ErrInvalidPath error = errors.New("category path names cannot be empty or have surrounding spaces")
+var ErrCategoryExists error = errors.New("category already exists")
+var ErrUnknownParent error = errors.New("parent category does not exist")
+var ErrUnknownCategory error = errors.New("category does not exist")
+var ErrCategoryHasChildren error = errors.New("category has subcategories")
+var ErrCategoryInUse error = errors.New("category is used by tickets")
+var ErrCheckingUsage error = errors.New("error checking whether the category is used")
+var ErrNilUsage error = errors.New("category usage cannot be nil")
@@ -0,0 +1,111 @@
+package category
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestNewTaxonomy(t *testing.T) {
+	t.Parallel()
+	_, err := NewTaxonomy("billing/refunds")
+	assertErrors(t, err, NewTaxonomyError, ErrUnknownParent)
+	_, err = NewTaxonomy("billing", "billing//refunds")
+	assertErrors(t, err, NewTaxonomyError, ErrInvalidPath)
+	_, err = NewTaxonomy("billing", "billing")
+	assertErrors(t, err, NewTaxonomyError, ErrCategoryExists)
+}
+
+func TestTaxonomy(t *testing.T) {
+	t.Parallel()
+	taxonomy, err := NewTaxonomy("shipping", "billing", "billing/refunds", "billing/invoices", "shipping/returns")
+	if err != nil {
+		t.Fatalf("Error should be nil, got %v", err)
+	}
+
+	t.Run("Categories are found by their path", func(t *testing.T) {
+		if !taxonomy.Contains("billing/refunds") || taxonomy.Contains("refunds") || taxonomy.Contains("") {
+			t.Error("Only the added paths should be contained")
+		}
+		assertPaths(t, taxonomy.Children(""), "billing", "shipping")
+		assertPaths(t, taxonomy.Children("billing"), "billing/invoices", "billing/refunds")
+	})
+	t.Run("Only the categories without subcategories can be removed", func(t *testing.T) {
+		assertErrors(t, taxonomy.Remove("shipping"), ErrCategoryHasChildren)
+		assertErrors(t, taxonomy.Remove("inventory"), ErrUnknownCategory)
+		if err := taxonomy.Remove("shipping/returns"); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if err := taxonomy.Remove("shipping"); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		assertPaths(t, taxonomy.Children(""), "billing")
+	})
+}
+
+func TestTaxonomyWithUsage(t *testing.T) {
+	t.Parallel()
+	_, err := NewTaxonomyWithUsage(nil)
+	assertErrors(t, err, NewTaxonomyError, ErrNilUsage)
+
+	usage := fakeUsage{used: map[string]bool{"billing": true}}
+	taxonomy, err := NewTaxonomyWithUsage(usage, "billing", "shipping")
+	if err != nil {
+		t.Fatalf("Error should be nil, got %v", err)
+	}
+	assertErrors(t, taxonomy.Remove("billing"), ErrCategoryInUse)
+	if err := taxonomy.Remove("shipping"); err != nil {
+		t.Fatalf("Error should be nil, got %v", err)
+	}
+	assertPaths(t, taxonomy.Children(""), "billing")
+
+	failing, err := NewTaxonomyWithUsage(fakeUsage{err: errors.New("storage down")}, "billing")
+	if err != nil {
+		t.Fatalf("Error should be nil, got %v", err)
+	}
+	assertErrors(t, failing.Remove("billing"), ErrCheckingUsage)
+	if !failing.Contains("billing") {
+		t.Error("A category should not be removed when its usage cannot be checked")
+	}
+}
+
+type fakeUsage struct {
+	used map[string]bool
+	err  error
+}
+
+func (f fakeUsage) IsCategoryUsed(path string) (bool, error) {
+	return f.used[path], f.err
+}
+
+func TestIsWithin(t *testing.T) {
+	t.Parallel()
+	if !IsWithin("billing/refunds", "billing") || !IsWithin("billing", "billing") {
+		t.Error("A category should be within itself and its ancestors")
+	}
+	if IsWithin("billing-old", "billing") || IsWithin("billing", "billing/refunds") {
+		t.Error("A category should not be within a sibling or a descendant")
+	}
+	if Parent("billing/refunds") != "billing" || Parent("billing") != "" {
+		t.Error("Parent should remove the last name of the path")
+	}
+}
+
+func assertPaths(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func assertErrors(t *testing.T, err error, expected ...error) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("Error should not be nil")
+	}
+	for _, e := range expected {
+		if !errors.Is(err, e) {
+			t.Errorf("Error should be %v, but is %s", e, err.Error())
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"ticketTao/entities"
@@ -14,6 +15,7 @@ func NewBasicTicketClient(repository ticket.RepositoryClientAccess) TicketClient
 		creationTime:     time.Now(),
 		ticketRepository: repository,
 		id:               uuid.New(),
+		tickets:          ticket.SystemFactory(),
 	}
 }
 
@@ -23,6 +25,7 @@ func InstantiateBasicTicketClient(id uuid.UUID, ct time.Time, repository ticket.
 		creationTime:     ct,
 		ticketRepository: repository,
 		id:               id,
+		tickets:          ticket.SystemFactory(),
 	}
 }
 
@@ -37,31 +40,62 @@ type TicketClient interface {
 type TicketUser interface {
 	TicketClientReader
 	TicketWriter
+	TicketFollower
+	TicketRater
+}
+
+// TicketRater answers the satisfaction surveys of the client's closed tickets.
+type TicketRater interface {
+	// RateTicket rates the support of a ticket from ticket.MinRating to ticket.MaxRating, with the token sent when the
+	// ticket was closed. The comment is optional.
+	RateTicket(ticketId uuid.UUID, token string, rating int, comment string) error
+}
+
+// TicketFollower follows the tickets of other clients, to read them and get their updates.
+type TicketFollower interface {
+	FollowTicket(ticketId uuid.UUID) error
+	UnfollowTicket(ticketId uuid.UUID) error
 }
 
 type TicketClientReader interface {
 	TicketCount() (int, error)
 	GetTickets() ([]ticket.Ticket, error)
 	GetTicket(uuid uuid.UUID) (ticket.Ticket, error)
+	// QueryTickets returns a page of the client's tickets matching the query.
+	QueryTickets(query ticket.Query) (ticket.Page, error)
 }
 
 type TicketWriter interface {
-	CreateTicket(title string, description string) error
-	AddComment(ticketId uuid.UUID, comment string) error
+	// CreateTicket creates a new ticket owned by the client and returns its ID.
+	CreateTicket(title string, description string) (uuid.UUID, error)
+	// CreateTypedTicket creates a new ticket of a ticket type, with the values of its custom fields, and returns its
+	// ID. The repository rejects the ticket if the values are not valid for the type.
+	CreateTypedTicket(ticketType, title, description string, fields map[string]string) (uuid.UUID, error)
+	// AddComment adds a response to one of the client's tickets, with the attachments the client uploaded for the
+	// ticket, by ID.
+	AddComment(ticketId uuid.UUID, comment string, attachmentIds ...uuid.UUID) error
 }
 
 type basicTicketClient struct {
 	creationTime     time.Time
 	id               uuid.UUID
 	ticketRepository ticket.RepositoryClientAccess
+	// tickets creates the tickets and comments of the client.
+	tickets ticket.Factory
+	// attachments is nil when the client cannot attach files.
+	attachments ticket.UploadedAttachments
 }
 
-func (c *basicTicketClient) AddComment(ticketId uuid.UUID, comment string) error {
+func (c *basicTicketClient) AddComment(ticketId uuid.UUID, comment string, attachmentIds ...uuid.UUID) error {
 	tck, err := c.GetTicket(ticketId)
 	if err != nil {
 		return fmt.Errorf("could not get ticket to add comment: %w", err)
 	}
-	tck.AddResponse(ticket.NewResponse(c.id, comment))
+	attachments, err := c.claimAttachments(ticketId, attachmentIds)
+	if err != nil {
+		return fmt.Errorf("could not attach files to comment: %w", err)
+	}
+	tck.AddResponse(c.tickets.NewResponse(c.id, comment, attachments...))
 	err = c.ticketRepository.UpdateTicketForClient(c.id, tck)
 	if err != nil {
 		return fmt.Errorf("could not update ticket with comment: %w", err)
@@ -69,6 +103,56 @@ func (c *basicTicketClient) AddComment(ticketId uuid.UUID, comment string) error
 	return nil
 }
 
+func (c *basicTicketClient) claimAttachments(ticketId uuid.UUID, ids []uuid.UUID) ([]ticket.Attachment, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if c.attachments == nil {
+		return nil, ErrAttachmentsUnavailable
+	}
+	attachments := make([]ticket.Attachment, 0, len(ids))
+	for _, id := range ids {
+		attachment, err := c.attachments.ClaimAttachment(c.id, ticketId, id)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, nil
+}
+
+func (c *basicTicketClient) FollowTicket(ticketId uuid.UUID) error {
+	err := c.ticketRepository.FollowTicket(c.id, ticketId)
+	if err != nil {
+		return fmt.Errorf("could not follow ticket with id %s: %w", ticketId.String(), err)
+	}
+	return nil
+}
+
+func (c *basicTicketClient) UnfollowTicket(ticketId uuid.UUID) error {
+	err := c.ticketRepository.UnfollowTicket(c.id, ticketId)
+	if err != nil {
+		return fmt.Errorf("could not unfollow ticket with id %s: %w", ticketId.String(), err)
+	}
+	return nil
+}
+
+func (c *basicTicketClient) RateTicket(ticketId uuid.UUID, token string, rating int, comment string) error {
+	tck, err := c.GetTicket(ticketId)
+	if err != nil {
+		return fmt.Errorf("could not get ticket to rate: %w", err)
+	}
+	err = tck.RateSatisfaction(token, rating, comment)
+	if err != nil {
+		return fmt.Errorf("could not rate ticket: %w", err)
+	}
+	err = c.ticketRepository.UpdateTicketForClient(c.id, tck)
+	if err != nil {
+		return fmt.Errorf("could not update ticket with rating: %w", err)
+	}
+	return nil
+}
+
 func (c *basicTicketClient) ID() uuid.UUID {
 	return c.id
 }
@@ -89,6 +173,14 @@ func (c *basicTicketClient) GetTickets() ([]ticket.Ticket, error) {
 	return ticks, nil
 }
 
+func (c *basicTicketClient) QueryTickets(query ticket.Query) (ticket.Page, error) {
+	page, err := c.ticketRepository.QueryClientTickets(c.id, query)
+	if err != nil {
+		return ticket.Page{}, fmt.Errorf("could not query tickets: %w", err)
+	}
+	return page, nil
+}
+
 func (c *basicTicketClient) TicketCount() (int, error) {
 	count, err := c.ticketRepository.GetClientTicketCount(c.id)
 	if err != nil {
@@ -97,16 +189,28 @@ func (c *basicTicketClient) TicketCount() (int, error) {
 	return count, nil
 }
 
-func (c *basicTicketClient) CreateTicket(title string, description string) error {
-	newTicket, err := ticket.NewBasicTicket(title, description)
+func (c *basicTicketClient) CreateTicket(title string, description string) (uuid.UUID, error) {
+	newTicket, err := c.tickets.NewTicket(title, description)
 	if err != nil {
-		return fmt.Errorf("could not create ticket: %w", err)
+		return uuid.Nil, fmt.Errorf("could not create ticket: %w", err)
 	}
-	err = c.ticketRepository.CreateNewTicketForClient(c.id, newTicket)
+	return c.saveNewTicket(newTicket)
+}
+
+func (c *basicTicketClient) CreateTypedTicket(ticketType, title, description string, fields map[string]string) (uuid.UUID, error) {
+	newTicket, err := c.tickets.NewTypedTicket(ticketType, title, description, fields)
 	if err != nil {
-		return fmt.Errorf("could not create ticket: %w", err)
+		return uuid.Nil, fmt.Errorf("could not create ticket: %w", err)
 	}
-	return nil
+	return c.saveNewTicket(newTicket)
+}
+
+func (c *basicTicketClient) saveNewTicket(newTicket ticket.Ticket) (uuid.UUID, error) {
+	err := c.ticketRepository.CreateNewTicketForClient(c.id, newTicket)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("could not create ticket: %w", err)
+	}
+	return newTicket.ID(), nil
 }
 
 func (c *basicTicketClient) CreatedAt() time.Time {
@@ -125,3 +229,5 @@ func (c *basicTicketClient) CloseTicket(u uuid.UUID) error {
 	}
 	return nil
 }
+
+var ErrAttachmentsUnavailable error = errors.New("the client cannot attach files")
@@ -1,17 +1,20 @@
 package client
 
 import (
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/rogelioConsejo/golibs/helpers"
 	"testing"
+	"ticketTao/entities"
 	"ticketTao/entities/ticket"
 	"time"
 )
 
 func TestNewBasicTicketClient(t *testing.T) {
 	t.Parallel()
-	client := NewBasicTicketClient(makeFakeTicketRepository())
+	factory, _ := NewClientFactoryWithSources(makeFakeTicketRepository(), testClock, entities.RandomIDSource())
+	client := factory.NewBasicTicketClient()
 
 	t.Run("A basic client can be created and has a creation date", func(t *testing.T) {
 		t.Parallel()
@@ -44,16 +47,19 @@ func TestTicketClient(t *testing.T) {
 	secondDescription := helpers.MakeRandomString(100)
 
 	t.Run("A Client can create tickets", func(t *testing.T) {
-		err := client.CreateTicket(title, description)
+		ticketId, err := client.CreateTicket(title, description)
 		if err != nil {
 			t.Errorf("Expected no error, got %v", err)
 		}
+		if ticketId == uuid.Nil {
+			t.Errorf("Expected the id of the new ticket, got %s", ticketId.String())
+		}
 		count, _ := client.TicketCount()
 		if count != 1 {
 			t.Errorf("Expected ticket count to be 1, got %v", count)
 		}
 
-		_ = client.CreateTicket(secondTitle, secondDescription)
+		_, _ = client.CreateTicket(secondTitle, secondDescription)
 		count, _ = client.TicketCount()
 		if count != 2 {
 			t.Errorf("Expected ticket count to be 2, got %v", count)
@@ -125,8 +131,9 @@ func TestBasicTicketClient_CreateTicket(t *testing.T) {
 			creationTime:     time.Now(),
 			id:               uuid.New(),
 			ticketRepository: ticketRepository,
+			tickets:          ticket.SystemFactory(),
 		}
-		err := client.CreateTicket(title, description)
+		ticketId, err := client.CreateTicket(title, description)
 		if err != nil {
 			t.Errorf("Expected no error, got %v", err)
 		}
@@ -136,10 +143,32 @@ func TestBasicTicketClient_CreateTicket(t *testing.T) {
 			ticketRepository.calls,
 			arguments{
 				client.ID().String(),
+				ticketId.String(),
 			})
 	})
 }
 
+func TestBasicTicketClient_CreateTypedTicket(t *testing.T) {
+	t.Parallel()
+	t.Run("A client can create a ticket of a type with its custom fields", func(t *testing.T) {
+		ticketRepository := &fakeTicketRepository{}
+		client := basicTicketClient{
+			creationTime:     time.Now(),
+			id:               uuid.New(),
+			ticketRepository: ticketRepository,
+			tickets:          ticket.SystemFactory(),
+		}
+		ticketId, err := client.CreateTypedTicket("sales", "title", "description", map[string]string{"order": "SO-42"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		tck := ticketRepository.ticketIndex[ticketId]
+		if tck.Type() != "sales" || tck.Fields()["order"] != "SO-42" {
+			t.Errorf("Expected a sales ticket with its order, got %q, %v", tck.Type(), tck.Fields())
+		}
+	})
+}
+
 func TestBasicTicketClient_GetTickets(t *testing.T) {
 	t.Parallel()
 	t.Run("A client can get all their tickets", func(t *testing.T) {
@@ -148,6 +177,7 @@ func TestBasicTicketClient_GetTickets(t *testing.T) {
 			creationTime:     time.Now(),
 			id:               uuid.New(),
 			ticketRepository: ticketRepository,
+			tickets:          ticket.SystemFactory(),
 		}
 		_, _ = client.GetTickets()
 		if ticketRepository.calls == nil {
@@ -167,6 +197,7 @@ func TestBasicTicketClient_GetTicket(t *testing.T) {
 			creationTime:     time.Now(),
 			id:               uuid.New(),
 			ticketRepository: ticketRepository,
+			tickets:          ticket.SystemFactory(),
 		}
 		_, _ = client.GetTicket(uuid.New())
 		if ticketRepository.calls == nil {
@@ -181,6 +212,28 @@ func TestBasicTicketClient_GetTicket(t *testing.T) {
 	})
 }
 
+func TestBasicTicketClient_QueryTickets(t *testing.T) {
+	t.Parallel()
+	t.Run("A client can query a page of their tickets", func(t *testing.T) {
+		ticketRepository := makeSpyTicketRepository()
+		clientID := uuid.New()
+		client := basicTicketClient{
+			creationTime:     time.Now(),
+			id:               clientID,
+			ticketRepository: ticketRepository,
+			tickets:          ticket.SystemFactory(),
+		}
+		page, err := client.QueryTickets(ticket.Query{After: "cursor"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(page.Tickets) != 1 {
+			t.Errorf("Expected the page of the repository, got %v", page)
+		}
+		assertMethodCall(t, "QueryClientTickets", ticketRepository.calls, arguments{clientID.String(), "cursor"})
+	})
+}
+
 func TestBasicTicketClient_TicketCount(t *testing.T) {
 	t.Parallel()
 	t.Run("A client can get the count of their tickets", func(t *testing.T) {
@@ -189,6 +242,7 @@ func TestBasicTicketClient_TicketCount(t *testing.T) {
 			creationTime:     time.Now(),
 			id:               uuid.New(),
 			ticketRepository: ticketRepository,
+			tickets:          ticket.SystemFactory(),
 		}
 		_, _ = client.TicketCount()
 		if ticketRepository.calls == nil {
@@ -210,6 +264,7 @@ func TestBasicTicketClient_CloseTicket(t *testing.T) {
 			creationTime:     time.Now(),
 			id:               clientID,
 			ticketRepository: ticketRepository,
+			tickets:          ticket.SystemFactory(),
 		}
 		var err error
 		err = client.CloseTicket(stubTicket.ID())
@@ -232,6 +287,7 @@ func TestBasicTicketClient_AddResponse(t *testing.T) {
 			creationTime:     time.Now(),
 			id:               clientID,
 			ticketRepository: ticketRepository,
+			tickets:          ticket.SystemFactory(),
 		}
 		var err error
 		err = client.AddComment(stubTicket.ID(), "stub_comment")
@@ -241,9 +297,71 @@ func TestBasicTicketClient_AddResponse(t *testing.T) {
 		assertMethodCall(t, "GetTicket", ticketRepository.calls, arguments{clientID.String(), stubTicket.ID().String()})
 		assertMethodCall(t, "UpdateTicketForClient", ticketRepository.calls, arguments{clientID.String()})
 	})
+	t.Run("The attachments of a comment are claimed from the uploads of the client for the ticket", func(t *testing.T) {
+		ticketRepository := makeSpyTicketRepository()
+		clientID := uuid.New()
+		uploaded := ticket.Attachment{ID: uuid.New(), Name: "invoice.pdf", MIMEType: "application/pdf", Size: 8}
+		uploads := &fakeUploadedAttachments{attachments: map[uuid.UUID]ticket.Attachment{uploaded.ID: uploaded}}
+		client := basicTicketClient{
+			creationTime:     time.Now(),
+			id:               clientID,
+			ticketRepository: ticketRepository,
+			tickets:          ticket.SystemFactory(),
+			attachments:      uploads,
+		}
+		err := client.AddComment(stubTicket.ID(), "the invoice", uploaded.ID)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		expected := claim{user: clientID, ticket: stubTicket.ID(), attachment: uploaded.ID}
+		if len(uploads.claims) != 1 || uploads.claims[0] != expected {
+			t.Errorf("Expected the claim %v, got %v", expected, uploads.claims)
+		}
 
+		ticketRepository = makeSpyTicketRepository()
+		client.ticketRepository = ticketRepository
+		err = client.AddComment(stubTicket.ID(), "someone else's file", uuid.New())
+		if !errors.Is(err, errNotUploaded) {
+			t.Errorf("Expected %v, got %v", errNotUploaded, err)
+		}
+		if _, updated := ticketRepository.calls["UpdateTicketForClient"]; updated {
+			t.Error("The ticket should not be updated when an attachment cannot be claimed")
+		}
+	})
+	t.Run("A client without uploads cannot attach files", func(t *testing.T) {
+		client := basicTicketClient{
+			creationTime:     time.Now(),
+			id:               uuid.New(),
+			ticketRepository: makeSpyTicketRepository(),
+			tickets:          ticket.SystemFactory(),
+		}
+		err := client.AddComment(stubTicket.ID(), "the invoice", uuid.New())
+		if !errors.Is(err, ErrAttachmentsUnavailable) {
+			t.Errorf("Expected %v, got %v", ErrAttachmentsUnavailable, err)
+		}
+	})
+}
+
+type claim struct {
+	user, ticket, attachment uuid.UUID
+}
+
+type fakeUploadedAttachments struct {
+	attachments map[uuid.UUID]ticket.Attachment
+	claims      []claim
 }
 
+func (f *fakeUploadedAttachments) ClaimAttachment(user, ticketID, attachmentID uuid.UUID) (ticket.Attachment, error) {
+	f.claims = append(f.claims, claim{user: user, ticket: ticketID, attachment: attachmentID})
+	attachment, ok := f.attachments[attachmentID]
+	if !ok {
+		return ticket.Attachment{}, errNotUploaded
+	}
+	return attachment, nil
+}
+
+var errNotUploaded = errors.New("attachment was not uploaded")
+
 func assertMethodCall(t *testing.T, methodName method, calls calls, expected arguments) {
 	t.Helper()
 	if calls == nil {
@@ -276,11 +394,15 @@ func expectUpdatedTicketToBeClosed(t *testing.T, repository *spyTicketRepository
 	}
 }
 
+// testNow is the time of testClock.
+var testNow = time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC)
+
+var testClock = entities.ClockFunc(func() time.Time { return testNow })
+
 func checkClientCreationTime(t *testing.T, client TicketClient) {
 	t.Helper()
-	time.Sleep(11 * time.Millisecond)
-	if client.CreatedAt().After(time.Now().Add(-10 * time.Millisecond)) {
-		t.Errorf("Expected creation date to be in the past, got %v", client.CreatedAt())
+	if !client.CreatedAt().Equal(testNow) {
+		t.Errorf("Expected creation date to be %v, got %v", testNow, client.CreatedAt())
 	}
 }
 
@@ -327,6 +449,22 @@ func (r *fakeTicketRepository) GetClientTicketCount(_ uuid.UUID) (int, error) {
 	return len(r.tickets), nil
 }
 
+func (r *fakeTicketRepository) QueryClientTickets(_ uuid.UUID, query ticket.Query) (ticket.Page, error) {
+	return query.Paginate(r.tickets)
+}
+
+func (r *fakeTicketRepository) CountClientTicketsByTag(uuid.UUID, ticket.Query) (map[string]int, error) {
+	return nil, nil
+}
+
+func (r *fakeTicketRepository) FollowTicket(uuid.UUID, uuid.UUID) error {
+	return nil
+}
+
+func (r *fakeTicketRepository) UnfollowTicket(uuid.UUID, uuid.UUID) error {
+	return nil
+}
+
 func (r *fakeTicketRepository) GetTicket(_, ticket uuid.UUID) (ticket.Ticket, error) {
 	tck, ok := r.ticketIndex[ticket]
 	if !ok {
@@ -379,6 +517,38 @@ func (r *spyTicketRepository) GetClientTicketCount(clientID uuid.UUID) (int, err
 	return 0, nil
 }
 
+func (r *spyTicketRepository) QueryClientTickets(clientID uuid.UUID, query ticket.Query) (ticket.Page, error) {
+	if r.calls == nil {
+		r.calls = make(calls)
+	}
+	r.calls["QueryClientTickets"] = []string{clientID.String(), string(query.After)}
+	return ticket.Page{Tickets: []ticket.Ticket{stubTicket}}, nil
+}
+
+func (r *spyTicketRepository) CountClientTicketsByTag(clientID uuid.UUID, _ ticket.Query) (map[string]int, error) {
+	if r.calls == nil {
+		r.calls = make(calls)
+	}
+	r.calls["CountClientTicketsByTag"] = []string{clientID.String()}
+	return nil, nil
+}
+
+func (r *spyTicketRepository) FollowTicket(clientID, ticketID uuid.UUID) error {
+	if r.calls == nil {
+		r.calls = make(calls)
+	}
+	r.calls["FollowTicket"] = []string{clientID.String(), ticketID.String()}
+	return nil
+}
+
+func (r *spyTicketRepository) UnfollowTicket(clientID, ticketID uuid.UUID) error {
+	if r.calls == nil {
+		r.calls = make(calls)
+	}
+	r.calls["UnfollowTicket"] = []string{clientID.String(), ticketID.String()}
+	return nil
+}
+
 func (r *spyTicketRepository) CreateNewTicketForClient(client uuid.UUID, ticket ticket.Ticket) error {
 	if r.calls == nil {
 		r.calls = make(calls)
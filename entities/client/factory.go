@@ -1,7 +1,9 @@
 package client
 
 import (
+	"errors"
 	"github.com/google/uuid"
+	"ticketTao/entities"
 	"ticketTao/entities/ticket"
 	"time"
 )
@@ -10,9 +12,44 @@ import (
 func NewClientFactory(tr ticket.RepositoryClientAccess) Factory {
 	return basicTicketClientFactory{
 		ticketRepository: tr,
+		clock:            entities.SystemClock(),
+		ids:              entities.RandomIDSource(),
+		tickets:          ticket.SystemFactory(),
 	}
 }
 
+// NewClientFactoryWithSources creates a Factory whose clients, and the tickets and comments they create, read the
+// time from the clock and take their IDs from the ID source.
+func NewClientFactoryWithSources(tr ticket.RepositoryClientAccess, clock entities.Clock, ids entities.IDSource) (Factory, error) {
+	return newClientFactory(tr, clock, ids, nil)
+}
+
+// NewClientFactoryWithAttachments works like NewClientFactoryWithSources, and its clients can attach the files they
+// uploaded to their comments.
+func NewClientFactoryWithAttachments(tr ticket.RepositoryClientAccess, clock entities.Clock, ids entities.IDSource, attachments ticket.UploadedAttachments) (Factory, error) {
+	if attachments == nil {
+		return nil, errors.Join(NewClientFactoryError, ErrNilAttachments)
+	}
+	return newClientFactory(tr, clock, ids, attachments)
+}
+
+func newClientFactory(tr ticket.RepositoryClientAccess, clock entities.Clock, ids entities.IDSource, attachments ticket.UploadedAttachments) (Factory, error) {
+	if tr == nil {
+		return nil, errors.Join(NewClientFactoryError, ErrNilRepository)
+	}
+	tickets, err := ticket.NewFactory(clock, ids)
+	if err != nil {
+		return nil, errors.Join(NewClientFactoryError, err)
+	}
+	return basicTicketClientFactory{
+		ticketRepository: tr,
+		clock:            clock,
+		ids:              ids,
+		tickets:          tickets,
+		attachments:      attachments,
+	}, nil
+}
+
 type Factory interface {
 	NewBasicTicketClient() TicketClient
 	InstantiateBasicTicketClient(client uuid.UUID, time time.Time) TicketClient
@@ -20,12 +57,33 @@ type Factory interface {
 
 type basicTicketClientFactory struct {
 	ticketRepository ticket.RepositoryClientAccess
+	clock            entities.Clock
+	ids              entities.IDSource
+	tickets          ticket.Factory
+	attachments      ticket.UploadedAttachments
 }
 
 func (b basicTicketClientFactory) InstantiateBasicTicketClient(client uuid.UUID, time time.Time) TicketClient {
-	return InstantiateBasicTicketClient(client, time, b.ticketRepository)
+	return &basicTicketClient{
+		creationTime:     time,
+		ticketRepository: b.ticketRepository,
+		id:               client,
+		tickets:          b.tickets,
+		attachments:      b.attachments,
+	}
 }
 
 func (b basicTicketClientFactory) NewBasicTicketClient() TicketClient {
-	return NewBasicTicketClient(b.ticketRepository)
+	return &basicTicketClient{
+		creationTime:     b.clock.Now(),
+		ticketRepository: b.ticketRepository,
+		id:               b.ids.NewID(),
+		tickets:          b.tickets,
+		attachments:      b.attachments,
+	}
 }
+
+var NewClientFactoryError error = errors.New("error creating client factory")
+
+var ErrNilRepository error = errors.New("ticket repository cannot be nil")
+var ErrNilAttachments error = errors.New("uploaded attachments cannot be nil")
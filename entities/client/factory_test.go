@@ -1,8 +1,10 @@
 package client
 
 import (
+	"errors"
 	"github.com/google/uuid"
 	"testing"
+	"ticketTao/entities"
 	"time"
 )
 
@@ -26,6 +28,43 @@ func TestFactory(t *testing.T) {
 	})
 }
 
+func TestNewClientFactoryWithSources(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC)
+	id := uuid.New()
+	clock := entities.ClockFunc(func() time.Time { return now })
+	ids := entities.IDSourceFunc(func() uuid.UUID { return id })
+
+	t.Run("It should return an error if a source is nil", func(t *testing.T) {
+		t.Parallel()
+		if _, err := NewClientFactoryWithSources(makeFakeTicketRepository(), nil, ids); !errors.Is(err, entities.ErrNilClock) {
+			t.Errorf("Error should be ErrNilClock, got %v", err)
+		}
+		if _, err := NewClientFactoryWithSources(makeFakeTicketRepository(), clock, nil); !errors.Is(err, entities.ErrNilIDSource) {
+			t.Errorf("Error should be ErrNilIDSource, got %v", err)
+		}
+		if _, err := NewClientFactoryWithSources(nil, clock, ids); !errors.Is(err, ErrNilRepository) {
+			t.Errorf("Error should be ErrNilRepository, got %v", err)
+		}
+	})
+	t.Run("New clients and their tickets take their ID and creation time from the sources", func(t *testing.T) {
+		t.Parallel()
+		factory, err := NewClientFactoryWithSources(makeSpyTicketRepository(), clock, ids)
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		client := factory.NewBasicTicketClient()
+		assertClientValues(t, client, id, now)
+		ticketID, err := client.CreateTicket("title", "description")
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if ticketID != id {
+			t.Errorf("Expected the ticket to have id %s, got %s", id, ticketID)
+		}
+	})
+}
+
 func assertClientValues(t *testing.T, client TicketClient, id uuid.UUID, creationTime time.Time) {
 	t.Helper()
 	if client.ID() != id {
@@ -69,3 +108,22 @@ func assertClientIsNotNil(t *testing.T, client TicketClient) {
 		t.Fatal("Expected a client to be returned")
 	}
 }
+
+func TestNewClientFactoryWithAttachments(t *testing.T) {
+	t.Parallel()
+	clock := entities.SystemClock()
+	ids := entities.RandomIDSource()
+	_, err := NewClientFactoryWithAttachments(makeSpyTicketRepository(), clock, ids, nil)
+	if !errors.Is(err, NewClientFactoryError) || !errors.Is(err, ErrNilAttachments) {
+		t.Errorf("Error should be ErrNilAttachments, got %v", err)
+	}
+	uploads := &fakeUploadedAttachments{}
+	factory, err := NewClientFactoryWithAttachments(makeSpyTicketRepository(), clock, ids, uploads)
+	if err != nil {
+		t.Fatalf("Error should be nil, got %v", err)
+	}
+	err = factory.NewBasicTicketClient().AddComment(stubTicket.ID(), "the invoice", uuid.New())
+	if !errors.Is(err, errNotUploaded) || len(uploads.claims) != 1 {
+		t.Errorf("The clients should claim their attachments from the uploads, got %v", err)
+	}
+}
@@ -0,0 +1,358 @@
+// Package macro contains the library of canned responses and macros used by the agents. A canned response is a
+// text/template rendered with the Variables of the ticket, e.g. "Hello {{.ClientName}}". A macro applies several
+// actions to a ticket at once, every action needs a Permission from the agent that runs the macro.
+package macro
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"ticketTao/entities/ticket"
+	"time"
+)
+
+// Permission allows an agent to run the actions of a kind.
+type Permission string
+
+// PermissionAnswer allows adding responses to the tickets.
+const PermissionAnswer Permission = "answer"
+
+// PermissionChangeStatus allows changing the status of the tickets, other than closing them.
+const PermissionChangeStatus Permission = "change_status"
+
+// PermissionTag allows adding and removing tags.
+const PermissionTag Permission = "tag"
+
+// PermissionClose allows closing the tickets.
+const PermissionClose Permission = "close"
+
+// Variables are the values available to the canned response templates.
+type Variables struct {
+	ClientName string
+	TicketID   uuid.UUID
+	Title      string
+}
+
+// CannedResponse is a reusable answer, its Template is rendered with the Variables of the ticket.
+type CannedResponse struct {
+	Name     string
+	Template string
+}
+
+// Render returns the text of the response for the variables.
+func (c CannedResponse) Render(variables Variables) (string, error) {
+	tmpl, err := c.parse()
+	if err != nil {
+		return "", err
+	}
+	var text strings.Builder
+	if err := tmpl.Execute(&text, variables); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidTemplate, err)
+	}
+	return text.String(), nil
+}
+
+func (c CannedResponse) parse() (*template.Template, error) {
+	tmpl, err := template.New(c.Name).Option("missingkey=error").Parse(c.Template)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTemplate, err)
+	}
+	return tmpl, nil
+}
+
+func (c CannedResponse) validate() error {
+	if c.Name == "" {
+		return ErrEmptyName
+	}
+	if strings.TrimSpace(c.Template) == "" {
+		return ErrEmptyTemplate
+	}
+	tmpl, err := c.parse()
+	if err != nil {
+		return err
+	}
+	// Executing with empty variables finds the references to unknown variables.
+	if err := tmpl.Execute(io.Discard, Variables{}); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidTemplate, err)
+	}
+	return nil
+}
+
+// Run is the context in which the actions of a macro are applied.
+type Run struct {
+	Agent     uuid.UUID
+	At        time.Time
+	Variables Variables
+	Library   Library
+}
+
+// Action is a change made to a ticket by a macro.
+type Action interface {
+	// Permission is the permission the agent needs to apply the action.
+	Permission() Permission
+	Apply(tck ticket.Ticket, run Run) error
+}
+
+// Reply adds a response, by the agent, with the canned response of the library.
+type Reply struct {
+	CannedResponse string
+}
+
+func (r Reply) Permission() Permission {
+	return PermissionAnswer
+}
+
+func (r Reply) Apply(tck ticket.Ticket, run Run) error {
+	response, found := run.Library.CannedResponse(r.CannedResponse)
+	if !found {
+		return fmt.Errorf("%w: %s", ErrUnknownCannedResponse, r.CannedResponse)
+	}
+	text, err := response.Render(run.Variables)
+	if err != nil {
+		return err
+	}
+	tck.AddResponse(ticket.MakeResponse(run.Agent, text, run.At))
+	return nil
+}
+
+// SetStatus changes the status of the ticket to Open or InProgress, use Close to close it.
+type SetStatus struct {
+	Status ticket.Status
+}
+
+// settableStatuses are the statuses SetStatus can set, closing needs PermissionClose.
+var settableStatuses = []ticket.Status{ticket.Open, ticket.InProgress}
+
+func (s SetStatus) Permission() Permission {
+	return PermissionChangeStatus
+}
+
+func (s SetStatus) Apply(tck ticket.Ticket, _ Run) error {
+	tck.SetStatus(s.Status)
+	return nil
+}
+
+// AddTag adds a tag to the ticket.
+type AddTag struct {
+	Tag string
+}
+
+func (a AddTag) Permission() Permission {
+	return PermissionTag
+}
+
+func (a AddTag) Apply(tck ticket.Ticket, _ Run) error {
+	tck.AddTag(a.Tag)
+	return nil
+}
+
+// RemoveTag removes a tag from the ticket.
+type RemoveTag struct {
+	Tag string
+}
+
+func (r RemoveTag) Permission() Permission {
+	return PermissionTag
+}
+
+func (r RemoveTag) Apply(tck ticket.Ticket, _ Run) error {
+	tck.RemoveTag(r.Tag)
+	return nil
+}
+
+// Close closes the ticket with the reason, Resolved if it is empty.
+type Close struct {
+	Reason ticket.ClosingReason
+}
+
+func (c Close) Permission() Permission {
+	return PermissionClose
+}
+
+func (c Close) Apply(tck ticket.Ticket, _ Run) error {
+	if c.Reason == "" {
+		tck.Close()
+		return nil
+	}
+	tck.CloseWithReason(c.Reason)
+	return nil
+}
+
+// Macro is a named list of actions, applied in order.
+type Macro struct {
+	Name    string
+	Actions []Action
+}
+
+// Permissions returns the permissions needed to run the macro, without repetitions.
+func (m Macro) Permissions() []Permission {
+	var permissions []Permission
+	for _, action := range m.Actions {
+		if !slices.Contains(permissions, action.Permission()) {
+			permissions = append(permissions, action.Permission())
+		}
+	}
+	return permissions
+}
+
+func (m Macro) repliesWith(response string) bool {
+	return slices.ContainsFunc(m.Actions, func(action Action) bool {
+		reply, ok := action.(Reply)
+		return ok && reply.CannedResponse == response
+	})
+}
+
+func (m Macro) validate(library Library) error {
+	if m.Name == "" {
+		return ErrEmptyName
+	}
+	if len(m.Actions) == 0 {
+		return ErrNoActions
+	}
+	for _, action := range m.Actions {
+		if action == nil {
+			return ErrNilAction
+		}
+		if reply, ok := action.(Reply); ok {
+			if _, found := library.CannedResponse(reply.CannedResponse); !found {
+				return fmt.Errorf("%w: %s", ErrUnknownCannedResponse, reply.CannedResponse)
+			}
+		}
+		if status, ok := action.(SetStatus); ok && !slices.Contains(settableStatuses, status.Status) {
+			return fmt.Errorf("%w: %q", ErrInvalidStatus, status.Status)
+		}
+	}
+	return nil
+}
+
+// Library keeps the canned responses and the macros, by name.
+type Library interface {
+	// AddCannedResponse adds a canned response, or replaces the one with the same name.
+	AddCannedResponse(CannedResponse) error
+	CannedResponse(name string) (CannedResponse, bool)
+	// CannedResponses returns the canned responses sorted by name.
+	CannedResponses() []CannedResponse
+	// RemoveCannedResponse removes a canned response, it must not be used by a macro.
+	RemoveCannedResponse(name string) error
+	// AddMacro adds a macro, or replaces the one with the same name. The canned responses it replies with must be
+	// in the library.
+	AddMacro(Macro) error
+	Macro(name string) (Macro, bool)
+	// Macros returns the macros sorted by name.
+	Macros() []Macro
+	RemoveMacro(name string)
+}
+
+// NewLibrary creates an in-memory Library with the canned responses and the macros.
+func NewLibrary(responses []CannedResponse, macros []Macro) (Library, error) {
+	library := &memoryLibrary{responses: make(map[string]CannedResponse), macros: make(map[string]Macro)}
+	for _, response := range responses {
+		if err := library.AddCannedResponse(response); err != nil {
+			return nil, errors.Join(NewLibraryError, err)
+		}
+	}
+	for _, macro := range macros {
+		if err := library.AddMacro(macro); err != nil {
+			return nil, errors.Join(NewLibraryError, err)
+		}
+	}
+	return library, nil
+}
+
+type memoryLibrary struct {
+	mu        sync.RWMutex
+	responses map[string]CannedResponse
+	macros    map[string]Macro
+}
+
+func (m *memoryLibrary) AddCannedResponse(response CannedResponse) error {
+	if err := response.validate(); err != nil {
+		return fmt.Errorf("canned response %q: %w", response.Name, err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.responses[response.Name] = response
+	return nil
+}
+
+func (m *memoryLibrary) CannedResponse(name string) (CannedResponse, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	response, found := m.responses[name]
+	return response, found
+}
+
+func (m *memoryLibrary) CannedResponses() []CannedResponse {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	responses := make([]CannedResponse, 0, len(m.responses))
+	for _, response := range m.responses {
+		responses = append(responses, response)
+	}
+	slices.SortFunc(responses, func(a, b CannedResponse) int { return strings.Compare(a.Name, b.Name) })
+	return responses
+}
+
+func (m *memoryLibrary) RemoveCannedResponse(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, macro := range m.macros {
+		if macro.repliesWith(name) {
+			return fmt.Errorf("%w: %s is used by %s", ErrCannedResponseInUse, name, macro.Name)
+		}
+	}
+	delete(m.responses, name)
+	return nil
+}
+
+func (m *memoryLibrary) AddMacro(macro Macro) error {
+	if err := macro.validate(m); err != nil {
+		return fmt.Errorf("macro %q: %w", macro.Name, err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	macro.Actions = slices.Clone(macro.Actions)
+	m.macros[macro.Name] = macro
+	return nil
+}
+
+func (m *memoryLibrary) Macro(name string) (Macro, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	macro, found := m.macros[name]
+	return macro, found
+}
+
+func (m *memoryLibrary) Macros() []Macro {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	macros := make([]Macro, 0, len(m.macros))
+	for _, macro := range m.macros {
+		macros = append(macros, macro)
+	}
+	slices.SortFunc(macros, func(a, b Macro) int { return strings.Compare(a.Name, b.Name) })
+	return macros
+}
+
+func (m *memoryLibrary) RemoveMacro(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.macros, name)
+}
+
+var NewLibraryError error = errors.New("error creating macro library")
+
+var ErrEmptyName error = errors.New("name cannot be empty")
+var ErrEmptyTemplate error = errors.New("canned response template cannot be empty")
+var ErrInvalidTemplate error = errors.New("invalid canned response template")
+var ErrUnknownCannedResponse error = errors.New("unknown canned response")
+var ErrCannedResponseInUse error = errors.New("canned response is used by a macro")
+var ErrNoActions error = errors.New("macro must have at least one action")
+var ErrNilAction error = errors.New("macro action cannot be nil")
+var ErrInvalidStatus error = errors.New("macro can only set the Open or InProgress status, use Close to close the ticket")
+var ErrUnknownMacro error = errors.New("unknown macro")
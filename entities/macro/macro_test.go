@@ -0,0 +1,143 @@
+package macro
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"slices"
+	"testing"
+	"ticketTao/entities/ticket"
+	"time"
+)
+
+func TestCannedResponse_Render(t *testing.T) {
+	t.Parallel()
+	t.Run("The template is rendered with the variables of the ticket", func(t *testing.T) {
+		t.Parallel()
+		id := uuid.New()
+		response := CannedResponse{Name: "thanks", Template: "Hi {{.ClientName}}, ticket {{.TicketID}} ({{.Title}}) is solved."}
+
+		text, err := response.Render(Variables{ClientName: "Ada", TicketID: id, Title: "Refund"})
+
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if expected := "Hi Ada, ticket " + id.String() + " (Refund) is solved."; text != expected {
+			t.Errorf("Expected %q, got %q", expected, text)
+		}
+	})
+}
+
+func TestNewLibrary(t *testing.T) {
+	t.Parallel()
+	t.Run("It should reject invalid canned responses", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewLibrary([]CannedResponse{{Name: "", Template: "hi"}}, nil)
+		assertErrors(t, err, NewLibraryError, ErrEmptyName)
+		_, err = NewLibrary([]CannedResponse{{Name: "empty", Template: "  "}}, nil)
+		assertErrors(t, err, NewLibraryError, ErrEmptyTemplate)
+		_, err = NewLibrary([]CannedResponse{{Name: "broken", Template: "Hi {{.ClientName"}}, nil)
+		assertErrors(t, err, NewLibraryError, ErrInvalidTemplate)
+		_, err = NewLibrary([]CannedResponse{{Name: "unknown", Template: "Hi {{.Email}}"}}, nil)
+		assertErrors(t, err, NewLibraryError, ErrInvalidTemplate)
+	})
+	t.Run("It should reject invalid macros", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewLibrary(nil, []Macro{{Name: "nothing"}})
+		assertErrors(t, err, NewLibraryError, ErrNoActions)
+		_, err = NewLibrary(nil, []Macro{{Name: "nil", Actions: []Action{nil}}})
+		assertErrors(t, err, NewLibraryError, ErrNilAction)
+		_, err = NewLibrary(nil, []Macro{{Name: "reply", Actions: []Action{Reply{CannedResponse: "missing"}}}})
+		assertErrors(t, err, NewLibraryError, ErrUnknownCannedResponse)
+		_, err = NewLibrary(nil, []Macro{{Name: "close", Actions: []Action{SetStatus{Status: ticket.Closed}}}})
+		assertErrors(t, err, NewLibraryError, ErrInvalidStatus)
+		_, err = NewLibrary(nil, []Macro{{Name: "pending", Actions: []Action{SetStatus{Status: "Pending"}}}})
+		assertErrors(t, err, NewLibraryError, ErrInvalidStatus)
+	})
+}
+
+func TestLibrary(t *testing.T) {
+	t.Parallel()
+	t.Run("Canned responses and macros are listed by name", func(t *testing.T) {
+		t.Parallel()
+		library, err := NewLibrary(
+			[]CannedResponse{{Name: "welcome", Template: "Hi"}, {Name: "thanks", Template: "Thanks"}},
+			[]Macro{{Name: "solve", Actions: []Action{Reply{CannedResponse: "thanks"}, Close{}}}, {Name: "escalate", Actions: []Action{AddTag{Tag: "vip"}}}},
+		)
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+
+		var responses, macros []string
+		for _, response := range library.CannedResponses() {
+			responses = append(responses, response.Name)
+		}
+		for _, macro := range library.Macros() {
+			macros = append(macros, macro.Name)
+		}
+		if !slices.Equal(responses, []string{"thanks", "welcome"}) || !slices.Equal(macros, []string{"escalate", "solve"}) {
+			t.Errorf("Expected sorted names, got %v and %v", responses, macros)
+		}
+	})
+	t.Run("A canned response used by a macro cannot be removed", func(t *testing.T) {
+		t.Parallel()
+		library, _ := NewLibrary(
+			[]CannedResponse{{Name: "thanks", Template: "Thanks"}},
+			[]Macro{{Name: "solve", Actions: []Action{Reply{CannedResponse: "thanks"}}}},
+		)
+
+		assertErrors(t, library.RemoveCannedResponse("thanks"), ErrCannedResponseInUse)
+		library.RemoveMacro("solve")
+		if err := library.RemoveCannedResponse("thanks"); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if _, found := library.CannedResponse("thanks"); found {
+			t.Error("The canned response should have been removed")
+		}
+	})
+}
+
+func TestMacro(t *testing.T) {
+	t.Parallel()
+	t.Run("The actions are applied in order", func(t *testing.T) {
+		t.Parallel()
+		library, _ := NewLibrary([]CannedResponse{{Name: "thanks", Template: "Thanks {{.ClientName}}"}}, nil)
+		macro := Macro{Name: "solve", Actions: []Action{Reply{CannedResponse: "thanks"}, AddTag{Tag: "Solved"}, Close{Reason: ticket.NoResponse}}}
+		tck, _ := ticket.NewBasicTicket("title", "description")
+		agent := uuid.New()
+		at := time.Now()
+		run := Run{Agent: agent, At: at, Variables: Variables{ClientName: "Ada"}, Library: library}
+
+		for _, action := range macro.Actions {
+			if err := action.Apply(tck, run); err != nil {
+				t.Fatalf("Error should be nil, got %v", err)
+			}
+		}
+
+		response := tck.Responses()[0]
+		if response.UserId() != agent || response.Content() != "Thanks Ada" || !response.TimeStamp().Equal(at) {
+			t.Errorf("Expected the rendered response by the agent, got %v", response)
+		}
+		if !slices.Equal(tck.Tags(), []string{"solved"}) || tck.Status() != ticket.Closed || tck.ClosingReason() != ticket.NoResponse {
+			t.Errorf("Expected the ticket to be tagged and closed, got %v, %s, %s", tck.Tags(), tck.Status(), tck.ClosingReason())
+		}
+	})
+	t.Run("The permissions of a macro are not repeated", func(t *testing.T) {
+		t.Parallel()
+		macro := Macro{Name: "m", Actions: []Action{AddTag{Tag: "a"}, RemoveTag{Tag: "b"}, SetStatus{Status: ticket.Open}}}
+		if permissions := macro.Permissions(); !slices.Equal(permissions, []Permission{PermissionTag, PermissionChangeStatus}) {
+			t.Errorf("Expected tag and change status permissions, got %v", permissions)
+		}
+	})
+}
+
+func assertErrors(t *testing.T, err error, expected ...error) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("Error should not be nil")
+	}
+	for _, e := range expected {
+		if !errors.Is(err, e) {
+			t.Errorf("Error should be %v, but is %s", e, err.Error())
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// Package sla contains the service level agreement policies, which define how fast the tickets should get their first
+// agent response and be resolved, and a Tracker that evaluates tickets against them.
+package sla
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"ticketTao/entities"
+	"ticketTao/entities/calendar"
+	"ticketTao/entities/ticket"
+	"time"
+)
+
+// Policy defines the deadlines of a ticket, measured from its creation time. A zero duration means there is no
+// deadline.
+type Policy struct {
+	Name          string
+	FirstResponse time.Duration
+	Resolution    time.Duration
+	// AtRiskWithin marks a ticket as at risk when one of its pending deadlines is closer than this duration.
+	AtRiskWithin time.Duration
+	// Calendar measures the durations of the policy, it uses wall-clock time when it is nil.
+	Calendar calendar.Calendar
+}
+
+func (p Policy) calendar() calendar.Calendar {
+	if p.Calendar == nil {
+		return calendar.WallClock()
+	}
+	return p.Calendar
+}
+
+// Policies selects the policy of a ticket. The policy of the client's organization takes precedence over the policy of
+// the ticket's priority, which takes precedence over the default one.
+type Policies struct {
+	Default        Policy
+	ByPriority     map[ticket.Priority]Policy
+	ByOrganization map[uuid.UUID]Policy
+}
+
+// OrganizationResolver maps a client to the organization it belongs to.
+type OrganizationResolver interface {
+	// GetClientOrganization returns uuid.Nil when the client does not belong to an organization.
+	GetClientOrganization(client uuid.UUID) (uuid.UUID, error)
+}
+
+// Status is the result of evaluating a ticket against its policy.
+type Status struct {
+	Ticket uuid.UUID
+	Policy string
+	// Age is the time the ticket has been open, up to its closing time, measured with the policy's calendar.
+	Age              time.Duration
+	FirstResponseDue time.Time
+	// FirstResponseAt is the timestamp of the first response not written by the ticket's owner, ignoring the notes,
+	// it is zero if no agent has answered yet.
+	FirstResponseAt time.Time
+	// FirstResponseTime is the time it took to get the first agent response, measured with the policy's calendar.
+	FirstResponseTime time.Duration
+	ResolutionDue     time.Time
+	ResolvedAt        time.Time
+	FirstResponseMet  bool
+	ResolutionMet     bool
+	// FirstResponseBreached and ResolutionBreached are true when the deadline passed before the ticket was answered
+	// or resolved, including deadlines that are passing right now.
+	FirstResponseBreached bool
+	ResolutionBreached    bool
+	AtRisk                bool
+}
+
+// Breached returns true if any deadline of the ticket was missed.
+func (s Status) Breached() bool {
+	return s.FirstResponseBreached || s.ResolutionBreached
+}
+
+// RepositoryAgentReader defines the SLA queries that an agent ticket repository can answer.
+type RepositoryAgentReader interface {
+	GetTicketSLA(ticket uuid.UUID) (Status, error)
+	// GetAtRiskTickets returns the non-closed tickets that are close to missing a deadline.
+	GetAtRiskTickets() ([]Status, error)
+	// GetBreachedTickets returns the non-closed tickets that already missed a deadline.
+	GetBreachedTickets() ([]Status, error)
+}
+
+// NewTracker creates a Tracker, the organization resolver can be nil when there are no organization policies and the
+// clock can be nil to use the system time.
+func NewTracker(policies Policies, organizations OrganizationResolver, clock entities.Clock) (Tracker, error) {
+	if len(policies.ByOrganization) > 0 && organizations == nil {
+		return nil, errors.Join(NewTrackerError, ErrNilOrganizationResolver)
+	}
+	if clock == nil {
+		clock = entities.SystemClock()
+	}
+	return basicTracker{policies: policies, organizations: organizations, clock: clock}, nil
+}
+
+// Tracker evaluates tickets against their SLA policy.
+type Tracker interface {
+	// Evaluate computes the deadlines of a ticket owned by the given client and whether they were met.
+	Evaluate(tck ticket.Ticket, owner uuid.UUID) (Status, error)
+}
+
+type basicTracker struct {
+	policies      Policies
+	organizations OrganizationResolver
+	clock         entities.Clock
+}
+
+func (b basicTracker) Evaluate(tck ticket.Ticket, owner uuid.UUID) (Status, error) {
+	if tck == nil {
+		return Status{}, errors.Join(EvaluateError, ticket.ErrNilTicket)
+	}
+	policy, err := b.policyFor(tck, owner)
+	if err != nil {
+		return Status{}, errors.Join(EvaluateError, err)
+	}
+	now := b.clock.Now()
+	cal := policy.calendar()
+	status := Status{
+		Ticket:          tck.ID(),
+		Policy:          policy.Name,
+		FirstResponseAt: FirstAgentResponseTime(tck, owner),
+	}
+	if tck.Status() == ticket.Closed {
+		status.ResolvedAt = tck.ClosedAt()
+	}
+	status.Age = Age(cal, tck, now)
+	if !status.FirstResponseAt.IsZero() {
+		status.FirstResponseTime = cal.BusinessTimeBetween(tck.CreatedAt(), status.FirstResponseAt)
+	}
+	if policy.FirstResponse > 0 {
+		status.FirstResponseDue = cal.AddBusinessTime(tck.CreatedAt(), policy.FirstResponse)
+		status.FirstResponseMet, status.FirstResponseBreached = checkDeadline(status.FirstResponseDue, status.FirstResponseAt, now)
+		status.AtRisk = isAtRisk(cal, status.FirstResponseDue, status.FirstResponseAt, now, policy.AtRiskWithin)
+	}
+	if policy.Resolution > 0 {
+		status.ResolutionDue = cal.AddBusinessTime(tck.CreatedAt(), policy.Resolution)
+		status.ResolutionMet, status.ResolutionBreached = checkDeadline(status.ResolutionDue, status.ResolvedAt, now)
+		status.AtRisk = status.AtRisk || isAtRisk(cal, status.ResolutionDue, status.ResolvedAt, now, policy.AtRiskWithin)
+	}
+	if status.Breached() {
+		status.AtRisk = false
+	}
+	return status, nil
+}
+
+func (b basicTracker) policyFor(tck ticket.Ticket, owner uuid.UUID) (Policy, error) {
+	if len(b.policies.ByOrganization) > 0 {
+		organization, err := b.organizations.GetClientOrganization(owner)
+		if err != nil {
+			return Policy{}, errors.Join(ErrResolvingOrganization, err)
+		}
+		if policy, ok := b.policies.ByOrganization[organization]; ok && organization != uuid.Nil {
+			return policy, nil
+		}
+	}
+	if policy, ok := b.policies.ByPriority[tck.Priority()]; ok {
+		return policy, nil
+	}
+	return b.policies.Default, nil
+}
+
+// Age returns the business time a ticket has been open, until now or until its closing time if it is closed.
+func Age(cal calendar.Calendar, tck ticket.Ticket, now time.Time) time.Duration {
+	end := now
+	if tck.Status() == ticket.Closed && !tck.ClosedAt().IsZero() {
+		end = tck.ClosedAt()
+	}
+	return cal.BusinessTimeBetween(tck.CreatedAt(), end)
+}
+
+// ResponseTimes returns the business time between the creation of a ticket and each of its responses, in the order of
+// the responses.
+func ResponseTimes(cal calendar.Calendar, tck ticket.Ticket) []time.Duration {
+	times := make([]time.Duration, 0, len(tck.Responses()))
+	for _, response := range tck.Responses() {
+		times = append(times, cal.BusinessTimeBetween(tck.CreatedAt(), response.TimeStamp()))
+	}
+	return times
+}
+
+// FirstAgentResponseTime returns the timestamp of the first response that was not written by the ticket's owner and is
+// not a note, or zero if there is none.
+func FirstAgentResponseTime(tck ticket.Ticket, owner uuid.UUID) time.Time {
+	for _, response := range tck.Responses() {
+		if response.UserId() != owner && !response.IsNote() {
+			return response.TimeStamp()
+		}
+	}
+	return time.Time{}
+}
+
+// checkDeadline returns whether the deadline was met by the given time, and whether it was breached, either because
+// it was done late or because it is not done yet and the deadline already passed.
+func checkDeadline(due, doneAt, now time.Time) (met, breached bool) {
+	if !doneAt.IsZero() {
+		return !doneAt.After(due), doneAt.After(due)
+	}
+	return false, now.After(due)
+}
+
+// isAtRisk returns whether a pending deadline has less than the given business time left.
+func isAtRisk(cal calendar.Calendar, due, doneAt, now time.Time, within time.Duration) bool {
+	if !doneAt.IsZero() || within <= 0 || now.After(due) {
+		return false
+	}
+	return cal.BusinessTimeBetween(now, due) <= within
+}
+
+var NewTrackerError error = errors.New("error creating SLA tracker")
+var EvaluateError error = errors.New("error evaluating ticket SLA")
+
+var ErrNilOrganizationResolver error = errors.New("organization resolver cannot be nil when there are organization policies")
+var ErrResolvingOrganization error = errors.New("error resolving the client's organization")
@@ -0,0 +1,188 @@
+package sla
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"testing"
+	"ticketTao/entities"
+	"ticketTao/entities/calendar"
+	"ticketTao/entities/ticket"
+	"time"
+)
+
+func TestNewTracker(t *testing.T) {
+	t.Parallel()
+	t.Run("It should require an organization resolver when there are organization policies", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewTracker(Policies{ByOrganization: map[uuid.UUID]Policy{uuid.New(): {}}}, nil, nil)
+		assertErrors(t, err, NewTrackerError, ErrNilOrganizationResolver)
+	})
+}
+
+func TestTracker_Evaluate(t *testing.T) {
+	t.Parallel()
+	created := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	owner := uuid.New()
+	agent := uuid.New()
+	policy := Policy{Name: "standard", FirstResponse: 4 * time.Hour, Resolution: 48 * time.Hour, AtRiskWithin: time.Hour}
+
+	t.Run("The deadlines are computed from the creation time", func(t *testing.T) {
+		t.Parallel()
+		tracker := makeTracker(t, Policies{Default: policy}, created.Add(time.Hour))
+		status, err := tracker.Evaluate(makeTicket(t, created, ticket.Normal), owner)
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if !status.FirstResponseDue.Equal(created.Add(4*time.Hour)) || !status.ResolutionDue.Equal(created.Add(48*time.Hour)) {
+			t.Errorf("Unexpected deadlines %v and %v", status.FirstResponseDue, status.ResolutionDue)
+		}
+		if status.Breached() || status.AtRisk {
+			t.Errorf("A new ticket should be neither breached nor at risk: %+v", status)
+		}
+	})
+	t.Run("The first response is the first one not written by the owner", func(t *testing.T) {
+		t.Parallel()
+		tracker := makeTracker(t, Policies{Default: policy}, created.Add(10*time.Hour))
+		tck := makeTicket(t, created, ticket.Normal)
+		tck.AddResponse(ticket.MakeResponse(owner, "more details", created.Add(time.Hour)))
+		tck.AddResponse(ticket.MakeResponse(agent, "an answer", created.Add(5*time.Hour)))
+
+		status, _ := tracker.Evaluate(tck, owner)
+
+		if !status.FirstResponseAt.Equal(created.Add(5 * time.Hour)) {
+			t.Errorf("Expected the agent response time, got %v", status.FirstResponseAt)
+		}
+		if !status.FirstResponseBreached || status.FirstResponseMet {
+			t.Error("A first response after the deadline should breach the SLA")
+		}
+	})
+	t.Run("The notes are not the first response", func(t *testing.T) {
+		t.Parallel()
+		tracker := makeTracker(t, Policies{Default: policy}, created.Add(10*time.Hour))
+		tck := makeTicket(t, created, ticket.Normal)
+		tck.MergeResponses(ticket.MakeNote(agent, "Split from another ticket", created.Add(time.Minute)))
+		tck.AddResponse(ticket.MakeNote(uuid.New(), "Are you still there?", created.Add(2*time.Hour)))
+		tck.AddResponse(ticket.MakeResponse(agent, "an answer", created.Add(3*time.Hour)))
+
+		status, _ := tracker.Evaluate(tck, owner)
+
+		if !status.FirstResponseAt.Equal(created.Add(3 * time.Hour)) {
+			t.Errorf("Expected the agent response time, got %v", status.FirstResponseAt)
+		}
+	})
+	t.Run("A ticket without an answer is at risk close to the deadline and breached after it", func(t *testing.T) {
+		t.Parallel()
+		tck := makeTicket(t, created, ticket.Normal)
+
+		atRisk, _ := makeTracker(t, Policies{Default: policy}, created.Add(3*time.Hour+30*time.Minute)).Evaluate(tck, owner)
+		breached, _ := makeTracker(t, Policies{Default: policy}, created.Add(5*time.Hour)).Evaluate(tck, owner)
+
+		if !atRisk.AtRisk || atRisk.Breached() {
+			t.Errorf("Expected the ticket to be at risk: %+v", atRisk)
+		}
+		if breached.AtRisk || !breached.FirstResponseBreached {
+			t.Errorf("Expected the ticket to be breached: %+v", breached)
+		}
+	})
+	t.Run("The resolution is measured with the closing time", func(t *testing.T) {
+		t.Parallel()
+		tck, _ := ticket.MakeBasicTicket(uuid.New(), created, ticket.Data{
+			Title:     "title",
+			Status:    ticket.Closed,
+			ClosedAt:  created.Add(24 * time.Hour),
+			Responses: []ticket.Response{ticket.MakeResponse(agent, "fixed", created.Add(time.Hour))},
+		})
+
+		status, _ := makeTracker(t, Policies{Default: policy}, created.Add(72*time.Hour)).Evaluate(tck, owner)
+
+		if !status.ResolutionMet || status.Breached() || status.AtRisk {
+			t.Errorf("Expected the SLA to be met: %+v", status)
+		}
+	})
+	t.Run("The deadlines and durations are measured with the policy's calendar", func(t *testing.T) {
+		t.Parallel()
+		weekday := []calendar.WorkingHours{{From: 9 * time.Hour, To: 18 * time.Hour}}
+		cal, _ := calendar.New(time.UTC, calendar.Week{time.Friday: weekday, time.Monday: weekday}, nil)
+		businessPolicy := Policy{Name: "business", FirstResponse: 4 * time.Hour, Calendar: cal}
+		friday := time.Date(2024, time.March, 15, 16, 0, 0, 0, time.UTC)
+		monday := time.Date(2024, time.March, 18, 10, 0, 0, 0, time.UTC)
+		tck := makeTicket(t, friday, ticket.Normal)
+		tck.AddResponse(ticket.MakeResponse(agent, "an answer", monday))
+
+		status, _ := makeTracker(t, Policies{Default: businessPolicy}, monday.Add(time.Hour)).Evaluate(tck, owner)
+
+		if !status.FirstResponseDue.Equal(time.Date(2024, time.March, 18, 11, 0, 0, 0, time.UTC)) {
+			t.Errorf("Expected the deadline to skip the weekend, got %v", status.FirstResponseDue)
+		}
+		assertEqual(t, "first response time", status.FirstResponseTime, 3*time.Hour)
+		assertEqual(t, "age", status.Age, 4*time.Hour)
+		if !status.FirstResponseMet {
+			t.Error("The first response should be on time in business hours")
+		}
+		responseTimes := ResponseTimes(cal, tck)
+		if len(responseTimes) != 1 || responseTimes[0] != 3*time.Hour {
+			t.Errorf("Unexpected response times %v", responseTimes)
+		}
+	})
+	t.Run("The organization policy takes precedence over the priority policy", func(t *testing.T) {
+		t.Parallel()
+		organization := uuid.New()
+		policies := Policies{
+			Default:        policy,
+			ByPriority:     map[ticket.Priority]Policy{ticket.Urgent: {Name: "urgent"}},
+			ByOrganization: map[uuid.UUID]Policy{organization: {Name: "premium"}},
+		}
+		tracker, _ := NewTracker(policies, fakeOrganizationResolver{owner: organization}, nil)
+
+		premium, _ := tracker.Evaluate(makeTicket(t, created, ticket.Urgent), owner)
+		urgent, _ := tracker.Evaluate(makeTicket(t, created, ticket.Urgent), uuid.New())
+		standard, _ := tracker.Evaluate(makeTicket(t, created, ticket.Low), uuid.New())
+
+		assertEqual(t, "policy", premium.Policy, "premium")
+		assertEqual(t, "policy", urgent.Policy, "urgent")
+		assertEqual(t, "policy", standard.Policy, "standard")
+	})
+}
+
+func makeTracker(t *testing.T, policies Policies, now time.Time) Tracker {
+	t.Helper()
+	tracker, err := NewTracker(policies, nil, entities.ClockFunc(func() time.Time { return now }))
+	if err != nil {
+		t.Fatalf("Error creating tracker: %v", err)
+	}
+	return tracker
+}
+
+func makeTicket(t *testing.T, created time.Time, priority ticket.Priority) ticket.Ticket {
+	t.Helper()
+	tck, err := ticket.MakeBasicTicket(uuid.New(), created, ticket.Data{Title: "title", Status: ticket.Open, Priority: priority})
+	if err != nil {
+		t.Fatalf("Error creating ticket: %v", err)
+	}
+	return tck
+}
+
+type fakeOrganizationResolver map[uuid.UUID]uuid.UUID
+
+func (f fakeOrganizationResolver) GetClientOrganization(client uuid.UUID) (uuid.UUID, error) {
+	return f[client], nil
+}
+
+func assertEqual(t *testing.T, field string, got, want interface{}) {
+	t.Helper()
+	if got != want {
+		t.Errorf("Expected %s to be %v, got %v", field, want, got)
+	}
+}
+
+func assertErrors(t *testing.T, err error, expected ...error) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("Expected an error, got nil")
+	}
+	for _, e := range expected {
+		if !errors.Is(err, e) {
+			t.Errorf("Expected error to be %v, got %v", e, err)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package entities
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Clock tells the current time to the entity factories, so time can be controlled by tests, imports and the
+// time-based features.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function to the Clock interface.
+type ClockFunc func() time.Time
+
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock returns a Clock that uses the system time.
+func SystemClock() Clock {
+	return ClockFunc(time.Now)
+}
+
+// IDSource generates the IDs of the new entities.
+type IDSource interface {
+	NewID() uuid.UUID
+}
+
+// IDSourceFunc adapts a function to the IDSource interface.
+type IDSourceFunc func() uuid.UUID
+
+func (f IDSourceFunc) NewID() uuid.UUID {
+	return f()
+}
+
+// RandomIDSource returns an IDSource that generates random (version 4) UUIDs.
+func RandomIDSource() IDSource {
+	return IDSourceFunc(uuid.New)
+}
+
+var ErrNilClock error = errors.New("clock cannot be nil")
+var ErrNilIDSource error = errors.New("ID source cannot be nil")
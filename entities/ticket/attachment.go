@@ -0,0 +1,38 @@
+package ticket
+
+import (
+	"github.com/google/uuid"
+	"slices"
+)
+
+// Attachment describes a file attached to a ticket or to a response. The content of the file is kept in a blob store,
+// under the ID of the attachment.
+type Attachment struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	MIMEType string    `json:"mime_type"`
+	Size     int64     `json:"size"`
+	// Checksum is the hex-encoded SHA-256 of the content.
+	Checksum string `json:"checksum"`
+}
+
+// UploadedAttachments gives the attachments the users uploaded, to add them to their tickets.
+type UploadedAttachments interface {
+	// ClaimAttachment returns the attachment, described from its stored content, if the user uploaded it for the
+	// ticket.
+	ClaimAttachment(user, ticketID, attachmentID uuid.UUID) (Attachment, error)
+}
+
+// FindAttachment returns the attachment of the ticket, or of one of its responses, with the given ID.
+func FindAttachment(tck Ticket, id uuid.UUID) (Attachment, bool) {
+	isTheAttachment := func(a Attachment) bool { return a.ID == id }
+	if i := slices.IndexFunc(tck.Attachments(), isTheAttachment); i >= 0 {
+		return tck.Attachments()[i], true
+	}
+	for _, response := range tck.Responses() {
+		if i := slices.IndexFunc(response.Attachments(), isTheAttachment); i >= 0 {
+			return response.Attachments()[i], true
+		}
+	}
+	return Attachment{}, false
+}
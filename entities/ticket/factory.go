@@ -0,0 +1,159 @@
+package ticket
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"maps"
+	"slices"
+	"ticketTao/entities"
+	"time"
+)
+
+// NewFactory creates a Factory that reads the time from the clock and the IDs of the new tickets from the ID source.
+func NewFactory(clock entities.Clock, ids entities.IDSource) (Factory, error) {
+	if clock == nil {
+		return nil, errors.Join(NewFactoryError, entities.ErrNilClock)
+	}
+	if ids == nil {
+		return nil, errors.Join(NewFactoryError, entities.ErrNilIDSource)
+	}
+	return basicFactory{clock: clock, ids: ids}, nil
+}
+
+// Factory creates tickets and responses. The tickets it creates also read their closing time from its clock.
+type Factory interface {
+	// NewTicket creates an open ticket with a new ID, created now. A ticket cannot be created with an empty title.
+	NewTicket(title, description string) (Ticket, error)
+	// NewTypedTicket works like NewTicket, but the ticket has a type and the values of its custom fields.
+	NewTypedTicket(ticketType, title, description string, fields map[string]string) (Ticket, error)
+	// MakeTicket instances an existing ticket, its creation time cannot be in the future.
+	MakeTicket(id uuid.UUID, creationTime time.Time, data Data) (Ticket, error)
+	// NewResponse creates a response written now, with the attachments.
+	NewResponse(user uuid.UUID, content string, attachments ...Attachment) Response
+	// NewNote creates a note written now, see Response.IsNote.
+	NewNote(user uuid.UUID, content string) Response
+}
+
+// SystemFactory returns the Factory that uses the system time and random IDs.
+func SystemFactory() Factory {
+	return systemFactory
+}
+
+var systemFactory = basicFactory{clock: entities.SystemClock(), ids: entities.RandomIDSource()}
+
+type basicFactory struct {
+	clock entities.Clock
+	ids   entities.IDSource
+}
+
+func (b basicFactory) NewTicket(title, description string) (Ticket, error) {
+	if title == "" {
+		return nil, errors.Join(NewBasicTicketError, ErrEmptyTitle)
+	}
+	return &basicTicket{
+		id:           b.ids.NewID(),
+		creationTime: b.clock.Now(),
+		title:        title,
+		description:  description,
+		status:       Open,
+		priority:     Normal,
+		clock:        b.clock,
+	}, nil
+}
+
+func (b basicFactory) NewTypedTicket(ticketType, title, description string, fields map[string]string) (Ticket, error) {
+	if ticketType == "" {
+		return nil, errors.Join(NewBasicTicketError, ErrEmptyType)
+	}
+	tck, err := b.NewTicket(title, description)
+	if err != nil {
+		return nil, err
+	}
+	basic := tck.(*basicTicket)
+	basic.ticketType = ticketType
+	for name, value := range fields {
+		basic.SetField(name, value)
+	}
+	return basic, nil
+}
+
+// followers returns the followers sorted and without repetitions.
+func followers(users []uuid.UUID) []uuid.UUID {
+	var sorted []uuid.UUID
+	for _, user := range users {
+		if user != uuid.Nil {
+			sorted = append(sorted, user)
+		}
+	}
+	slices.SortFunc(sorted, compareIDs)
+	return slices.Compact(sorted)
+}
+
+func (b basicFactory) MakeTicket(id uuid.UUID, creationTime time.Time, data Data) (Ticket, error) {
+	if id == uuid.Nil {
+		return nil, errors.Join(NewBasicTicketError, entities.ErrNilID)
+	}
+	if creationTime.IsZero() {
+		return nil, errors.Join(NewBasicTicketError, entities.ErrNilCreationTime)
+	}
+	if creationTime.After(b.clock.Now()) {
+		return nil, errors.Join(NewBasicTicketError, entities.ErrFutureCreationTime)
+	}
+	if data.Title == "" {
+		return nil, errors.Join(NewBasicTicketError, ErrEmptyTitle)
+	}
+	if data.Status == "" {
+		return nil, errors.Join(NewBasicTicketError, ErrEmptyStatus)
+	}
+	if data.Priority == "" {
+		data.Priority = Normal
+	}
+	return b.makeTicket(id, creationTime, data), nil
+}
+
+// makeTicket instances a ticket without validating its data.
+func (b basicFactory) makeTicket(id uuid.UUID, creationTime time.Time, data Data) *basicTicket {
+	var tags []string
+	for _, tag := range data.Tags {
+		tag = NormalizeTag(tag)
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	slices.Sort(tags)
+	return &basicTicket{
+		creationTime:      creationTime,
+		title:             data.Title,
+		description:       data.Description,
+		status:            data.Status,
+		id:                id,
+		responses:         data.Responses,
+		priority:          data.Priority,
+		closingTime:       data.ClosedAt,
+		reason:            data.ClosingReason,
+		mergedInto:        data.MergedInto,
+		splitFrom:         data.SplitFrom,
+		splitInto:         slices.Clone(data.SplitInto),
+		followers:         followers(data.Followers),
+		satisfaction:      data.Satisfaction,
+		pastSatisfactions: slices.Clone(data.PastSatisfactions),
+		history:           slices.Clone(data.History),
+		assignee:          data.Assignee,
+		tags:              slices.Compact(tags),
+		category:          data.Category,
+		ticketType:        data.Type,
+		fields:            maps.Clone(data.Fields),
+		attachments:       slices.Clone(data.Attachments),
+		clock:             b.clock,
+	}
+}
+
+func (b basicFactory) NewResponse(user uuid.UUID, content string, attachments ...Attachment) Response {
+	return MakeResponse(user, content, b.clock.Now(), attachments...)
+}
+
+func (b basicFactory) NewNote(user uuid.UUID, content string) Response {
+	return MakeNote(user, content, b.clock.Now())
+}
+
+var NewFactoryError error = errors.New("error creating ticket factory")
@@ -0,0 +1,56 @@
+package ticket
+
+import (
+	"github.com/google/uuid"
+	"testing"
+	"ticketTao/entities"
+	"time"
+)
+
+func TestNewFactory(t *testing.T) {
+	t.Parallel()
+	_, err := NewFactory(nil, entities.RandomIDSource())
+	assertErrors(t, err, NewFactoryError, entities.ErrNilClock)
+	_, err = NewFactory(entities.SystemClock(), nil)
+	assertErrors(t, err, NewFactoryError, entities.ErrNilIDSource)
+}
+
+func TestFactory(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC)
+	id := uuid.New()
+	clock := entities.ClockFunc(func() time.Time { return now })
+	factory, err := NewFactory(clock, entities.IDSourceFunc(func() uuid.UUID { return id }))
+	if err != nil {
+		t.Fatalf("Error should be nil, got %v", err)
+	}
+
+	t.Run("New tickets take their ID and creation time from the factory", func(t *testing.T) {
+		t.Parallel()
+		tck, err := factory.NewTicket("title", "description")
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		assertEqual(t, "ID", tck.ID(), id)
+		assertEqual(t, "creation time", tck.CreatedAt(), now)
+		assertEqual(t, "status", tck.Status(), Open)
+	})
+	t.Run("The tickets of the factory are closed at the time of its clock", func(t *testing.T) {
+		t.Parallel()
+		tck, _ := factory.MakeTicket(uuid.New(), now.Add(-time.Hour), Data{Title: "title", Status: Open})
+		tck.Close()
+		assertEqual(t, "closing time", tck.ClosedAt(), now)
+	})
+	t.Run("A ticket cannot be created after the time of the clock", func(t *testing.T) {
+		t.Parallel()
+		_, err := factory.MakeTicket(uuid.New(), now.Add(time.Second), Data{Title: "title", Status: Open})
+		assertErrors(t, err, NewBasicTicketError, entities.ErrFutureCreationTime)
+	})
+	t.Run("New responses are written at the time of the clock", func(t *testing.T) {
+		t.Parallel()
+		author := uuid.New()
+		response := factory.NewResponse(author, "content")
+		assertEqual(t, "author", response.UserId(), author)
+		assertEqual(t, "timestamp", response.TimeStamp(), now)
+	})
+}
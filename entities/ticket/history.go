@@ -0,0 +1,36 @@
+package ticket
+
+import (
+	"slices"
+	"time"
+)
+
+// StatusChange records a change of the status of a ticket.
+type StatusChange struct {
+	From Status    `json:"from"`
+	To   Status    `json:"to"`
+	At   time.Time `json:"at"`
+}
+
+// WasReopened tells if the ticket went back from Closed to another status at some point of its history.
+func WasReopened(tck Ticket) bool {
+	for _, change := range tck.History() {
+		if change.From == Closed && change.To != Closed {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *basicTicket) History() []StatusChange {
+	return slices.Clone(b.history)
+}
+
+// setStatus changes the status of the ticket, recording the change in its history.
+func (b *basicTicket) setStatus(status Status) {
+	if status == b.status {
+		return
+	}
+	b.history = append(b.history, StatusChange{From: b.status, To: status, At: b.clock.Now()})
+	b.status = status
+}
@@ -0,0 +1,40 @@
+package ticket
+
+import (
+	"testing"
+	"ticketTao/entities"
+	"time"
+)
+
+func TestBasicTicket_History(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC)
+	factory, _ := NewFactory(entities.ClockFunc(func() time.Time { return now }), entities.RandomIDSource())
+	tck, _ := factory.NewTicket("title", "description")
+
+	tck.AddResponse(factory.NewResponse(tck.ID(), "first"))
+	tck.AddResponse(factory.NewResponse(tck.ID(), "second"))
+	tck.Close()
+	if WasReopened(tck) {
+		t.Error("The ticket should not be reopened yet")
+	}
+	tck.SetStatus(Open)
+
+	expected := []StatusChange{{Open, InProgress, now}, {InProgress, Closed, now}, {Closed, Open, now}}
+	history := tck.History()
+	if len(history) != len(expected) {
+		t.Fatalf("Expected history %v, got %v", expected, history)
+	}
+	for i := range expected {
+		if history[i] != expected[i] {
+			t.Errorf("Expected change %v, got %v", expected[i], history[i])
+		}
+	}
+	if !WasReopened(tck) {
+		t.Error("The ticket should be reopened")
+	}
+	copied, _ := factory.MakeTicket(tck.ID(), tck.CreatedAt(), DataFrom(tck))
+	if len(copied.History()) != len(expected) {
+		t.Errorf("The history should be kept by copies, got %v", copied.History())
+	}
+}
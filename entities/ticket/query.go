@@ -0,0 +1,249 @@
+package ticket
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"slices"
+	"strconv"
+	"strings"
+	"ticketTao/entities/category"
+	"time"
+)
+
+// DefaultPageSize is the number of tickets in a Page when the Query has no limit.
+const DefaultPageSize = 50
+
+// MaxPageSize is the maximum number of tickets in a Page.
+const MaxPageSize = 500
+
+// SortOrder defines the order of the tickets returned by a Query.
+type SortOrder string
+
+// OldestFirst sorts the tickets by creation time, it is the default order.
+const OldestFirst SortOrder = "OldestFirst"
+
+// NewestFirst sorts the tickets by creation time, the most recent first.
+const NewestFirst SortOrder = "NewestFirst"
+
+// RecentlyUpdatedFirst sorts the tickets by their LastActivity, the most recent first.
+const RecentlyUpdatedFirst SortOrder = "RecentlyUpdatedFirst"
+
+// Query specifies which tickets to retrieve and in which order. The zero values of the filters match every ticket.
+type Query struct {
+	// Client restricts the query to the tickets of a client. The client repositories always set it.
+	Client     uuid.UUID
+	Statuses   []Status
+	Priorities []Priority
+	// CreatedFrom and CreatedTo bound the creation time, the former inclusive and the latter exclusive.
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	// UpdatedSince keeps the tickets whose LastActivity is not before it.
+	UpdatedSince time.Time
+	Assignee     uuid.UUID
+	// Tags keeps the tickets that have every tag.
+	Tags []string
+	// Category keeps the tickets of the category or of its subcategories.
+	Category string
+	Type     string
+	// Fields keeps the tickets whose custom fields have the given values.
+	Fields map[string]string
+	// Text keeps the tickets whose title, description or responses contain it, ignoring case.
+	Text string
+	Sort SortOrder
+	// Limit is the size of the page, DefaultPageSize when it is 0.
+	Limit int
+	// After is the cursor returned with the previous page, it is empty for the first page.
+	After Cursor
+}
+
+// Cursor is an opaque position in the results of a Query. It is only valid for the Query that returned it.
+type Cursor string
+
+// Page is a page of the results of a Query.
+type Page struct {
+	Tickets []Ticket
+	// Next is the cursor of the next page, it is empty when this is the last page.
+	Next Cursor
+}
+
+// LastActivity returns the time of the last change known of a ticket: its creation, its last response or its closing.
+func LastActivity(tck Ticket) time.Time {
+	last := tck.CreatedAt()
+	if responses := tck.Responses(); len(responses) > 0 && responses[len(responses)-1].TimeStamp().After(last) {
+		last = responses[len(responses)-1].TimeStamp()
+	}
+	if tck.ClosedAt().After(last) {
+		last = tck.ClosedAt()
+	}
+	return last
+}
+
+// Validate returns an error if the query cannot be run.
+func (q Query) Validate() error {
+	if q.Limit < 0 || q.Limit > MaxPageSize {
+		return ErrInvalidPageSize
+	}
+	switch q.Sort {
+	case "", OldestFirst, NewestFirst, RecentlyUpdatedFirst:
+	default:
+		return ErrUnknownSortOrder
+	}
+	if !q.CreatedFrom.IsZero() && !q.CreatedTo.IsZero() && !q.CreatedFrom.Before(q.CreatedTo) {
+		return ErrInvalidTimeRange
+	}
+	if q.After != "" {
+		if _, err := q.After.decode(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Matches tells if a ticket owned by the client passes the filters of the query.
+func (q Query) Matches(tck Ticket, owner uuid.UUID) bool {
+	if q.Client != uuid.Nil && owner != q.Client {
+		return false
+	}
+	if len(q.Statuses) > 0 && !slices.Contains(q.Statuses, tck.Status()) {
+		return false
+	}
+	if len(q.Priorities) > 0 && !slices.Contains(q.Priorities, tck.Priority()) {
+		return false
+	}
+	if !q.CreatedFrom.IsZero() && tck.CreatedAt().Before(q.CreatedFrom) {
+		return false
+	}
+	if !q.CreatedTo.IsZero() && !tck.CreatedAt().Before(q.CreatedTo) {
+		return false
+	}
+	if !q.UpdatedSince.IsZero() && LastActivity(tck).Before(q.UpdatedSince) {
+		return false
+	}
+	if q.Assignee != uuid.Nil && tck.Assignee() != q.Assignee {
+		return false
+	}
+	for _, tag := range q.Tags {
+		if _, found := slices.BinarySearch(tck.Tags(), NormalizeTag(tag)); !found {
+			return false
+		}
+	}
+	if q.Category != "" && tck.Category() != q.Category && !strings.HasPrefix(tck.Category(), q.Category+category.Separator) {
+		return false
+	}
+	if q.Type != "" && tck.Type() != q.Type {
+		return false
+	}
+	if len(q.Fields) > 0 {
+		fields := tck.Fields()
+		for name, value := range q.Fields {
+			if fields[name] != value {
+				return false
+			}
+		}
+	}
+	if q.Text != "" && !containsText(tck, strings.ToLower(q.Text)) {
+		return false
+	}
+	return true
+}
+
+// Paginate sorts the tickets that matched the query and returns the page after its cursor. It lets the persistence
+// drivers that cannot run the query natively filter with Matches and paginate in memory.
+func (q Query) Paginate(tickets []Ticket) (Page, error) {
+	if err := q.Validate(); err != nil {
+		return Page{}, err
+	}
+	sorted := slices.Clone(tickets)
+	slices.SortStableFunc(sorted, func(a, b Ticket) int {
+		return q.compare(q.positionOf(a), q.positionOf(b))
+	})
+	start := 0
+	if q.After != "" {
+		after, _ := q.After.decode()
+		start, _ = slices.BinarySearchFunc(sorted, after, func(tck Ticket, after position) int {
+			if q.compare(q.positionOf(tck), after) <= 0 {
+				return -1
+			}
+			return 1
+		})
+	}
+	limit := q.Limit
+	if limit == 0 {
+		limit = DefaultPageSize
+	}
+	end := min(start+limit, len(sorted))
+	page := Page{Tickets: sorted[start:end]}
+	if end < len(sorted) {
+		page.Next = q.positionOf(sorted[end-1]).encode()
+	}
+	return page, nil
+}
+
+// position is the place of a ticket in the sort order, ties are broken by ID.
+type position struct {
+	at time.Time
+	id uuid.UUID
+}
+
+func (q Query) positionOf(tck Ticket) position {
+	if q.Sort == RecentlyUpdatedFirst {
+		return position{at: LastActivity(tck), id: tck.ID()}
+	}
+	return position{at: tck.CreatedAt(), id: tck.ID()}
+}
+
+func (q Query) compare(a, b position) int {
+	c := a.at.Compare(b.at)
+	if q.Sort == NewestFirst || q.Sort == RecentlyUpdatedFirst {
+		c = -c
+	}
+	if c != 0 {
+		return c
+	}
+	return strings.Compare(a.id.String(), b.id.String())
+}
+
+func (p position) encode() Cursor {
+	raw := strconv.FormatInt(p.at.UnixNano(), 10) + ":" + p.id.String()
+	return Cursor(base64.RawURLEncoding.EncodeToString([]byte(raw)))
+}
+
+func (c Cursor) decode() (position, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(string(c))
+	if err != nil {
+		return position{}, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	nanos, id, found := strings.Cut(string(raw), ":")
+	if !found {
+		return position{}, ErrInvalidCursor
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return position{}, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return position{}, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	return position{at: time.Unix(0, n), id: parsed}, nil
+}
+
+func containsText(tck Ticket, text string) bool {
+	if strings.Contains(strings.ToLower(tck.Title()), text) ||
+		strings.Contains(strings.ToLower(tck.Description()), text) {
+		return true
+	}
+	for _, response := range tck.Responses() {
+		if strings.Contains(strings.ToLower(response.Content()), text) {
+			return true
+		}
+	}
+	return false
+}
+
+var ErrInvalidPageSize error = fmt.Errorf("page size must be between 0 and %d", MaxPageSize)
+var ErrUnknownSortOrder error = errors.New("unknown ticket sort order")
+var ErrInvalidTimeRange error = errors.New("the start of a time range must be before its end")
+var ErrInvalidCursor error = errors.New("invalid page cursor")
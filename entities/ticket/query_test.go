@@ -0,0 +1,110 @@
+package ticket
+
+import (
+	"github.com/google/uuid"
+	"testing"
+	"time"
+)
+
+func TestQuery_Validate(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC)
+	assertErrors(t, Query{Limit: MaxPageSize + 1}.Validate(), ErrInvalidPageSize)
+	assertErrors(t, Query{Sort: "Random"}.Validate(), ErrUnknownSortOrder)
+	assertErrors(t, Query{CreatedFrom: start, CreatedTo: start}.Validate(), ErrInvalidTimeRange)
+	assertErrors(t, Query{After: "not a cursor"}.Validate(), ErrInvalidCursor)
+	if err := (Query{Limit: 10, Sort: NewestFirst}).Validate(); err != nil {
+		t.Errorf("Expected a valid query, got %v", err)
+	}
+}
+
+func TestQuery_Matches(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC)
+	owner := uuid.New()
+	agent := uuid.New()
+	tck, _ := MakeBasicTicket(uuid.New(), start, Data{Title: "Invoice PDF", Status: Open, Priority: High})
+	tck.AddResponse(MakeResponse(agent, "Could you resend the Order number?", start.Add(48*time.Hour)))
+	tck.AssignTo(agent)
+	tck.AddTag("billing")
+	tck.AddTag("vip")
+	tck.Categorize("billing/refunds")
+	tck.SetField("invoice", "F-1024")
+
+	matching := []Query{
+		{},
+		{Client: owner, Statuses: []Status{Open, InProgress}, Priorities: []Priority{High}, Assignee: agent},
+		{CreatedFrom: start, CreatedTo: start.Add(time.Hour)},
+		{UpdatedSince: start.Add(24 * time.Hour)},
+		{Text: "order NUMBER"},
+		{Tags: []string{"VIP", "billing"}, Category: "billing"},
+		{Category: "billing/refunds"},
+		{Fields: map[string]string{"invoice": "F-1024"}},
+	}
+	for _, query := range matching {
+		if !query.Matches(tck, owner) {
+			t.Errorf("Expected %+v to match the ticket", query)
+		}
+	}
+	notMatching := []Query{
+		{Client: uuid.New()},
+		{Statuses: []Status{Closed}},
+		{Priorities: []Priority{Low, Normal}},
+		{CreatedFrom: start.Add(time.Second)},
+		{CreatedTo: start},
+		{UpdatedSince: start.Add(72 * time.Hour)},
+		{Assignee: uuid.New()},
+		{Text: "shipping"},
+		{Tags: []string{"vip", "shipping"}},
+		{Category: "billing/refunds/partial"},
+		{Category: "bill"},
+		{Type: "billing"},
+		{Fields: map[string]string{"invoice": "F-1025"}},
+	}
+	for _, query := range notMatching {
+		if query.Matches(tck, owner) {
+			t.Errorf("Expected %+v not to match the ticket", query)
+		}
+	}
+}
+
+func TestQuery_Paginate(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC)
+	var tickets []Ticket
+	for i := range 5 {
+		tck, _ := MakeBasicTicket(uuid.New(), start.Add(time.Duration(i)*time.Hour), Data{Title: "title", Status: Open})
+		tickets = append(tickets, tck)
+	}
+	tickets[1].AddResponse(MakeResponse(uuid.New(), "latest activity", start.Add(24*time.Hour)))
+
+	t.Run("The cursor returns the next page until the last one", func(t *testing.T) {
+		t.Parallel()
+		query := Query{Limit: 2}
+		var got []Ticket
+		for pages := 0; ; pages++ {
+			if pages > 3 {
+				t.Fatal("Expected 3 pages")
+			}
+			page, err := query.Paginate(tickets)
+			if err != nil {
+				t.Fatalf("Error should be nil, got %v", err)
+			}
+			got = append(got, page.Tickets...)
+			if page.Next == "" {
+				break
+			}
+			query.After = page.Next
+		}
+		assertEqualArrays(t, "tickets", got, tickets)
+	})
+	t.Run("The tickets are sorted by the order of the query", func(t *testing.T) {
+		t.Parallel()
+		page, _ := Query{Sort: NewestFirst, Limit: 2}.Paginate(tickets)
+		assertEqualArrays(t, "newest", page.Tickets, []Ticket{tickets[4], tickets[3]})
+		page, _ = Query{Sort: RecentlyUpdatedFirst, Limit: 2}.Paginate(tickets)
+		assertEqualArrays(t, "recently updated", page.Tickets, []Ticket{tickets[1], tickets[4]})
+		page, _ = Query{Sort: RecentlyUpdatedFirst, Limit: 2, After: page.Next}.Paginate(tickets)
+		assertEqualArrays(t, "recently updated", page.Tickets, []Ticket{tickets[3], tickets[2]})
+	})
+}
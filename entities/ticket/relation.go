@@ -0,0 +1,125 @@
+package ticket
+
+import (
+	"errors"
+	"github.com/google/uuid"
+)
+
+// RelationKind is the type of a link between two tickets. Every kind has an inverse, the kind of the link seen from
+// the other ticket, e.g. the child of a ParentOf link sees it as ChildOf.
+type RelationKind string
+
+// ParentOf links a ticket to one of its children, e.g. an outage to the reports of the clients affected by it.
+const ParentOf RelationKind = "parent_of"
+
+// ChildOf is the inverse of ParentOf, a ticket can only have one parent.
+const ChildOf RelationKind = "child_of"
+
+// Blocks links a ticket to a ticket that cannot be solved before it.
+const Blocks RelationKind = "blocks"
+
+// BlockedBy is the inverse of Blocks.
+const BlockedBy RelationKind = "blocked_by"
+
+// RelatedTo links two tickets about related problems, it is its own inverse.
+const RelatedTo RelationKind = "related_to"
+
+// DuplicateOf links a ticket to the ticket about the same problem, a ticket can only duplicate one ticket.
+const DuplicateOf RelationKind = "duplicate_of"
+
+// DuplicatedBy is the inverse of DuplicateOf.
+const DuplicatedBy RelationKind = "duplicated_by"
+
+var inverseKinds = map[RelationKind]RelationKind{
+	ParentOf:     ChildOf,
+	ChildOf:      ParentOf,
+	Blocks:       BlockedBy,
+	BlockedBy:    Blocks,
+	RelatedTo:    RelatedTo,
+	DuplicateOf:  DuplicatedBy,
+	DuplicatedBy: DuplicateOf,
+}
+
+// Inverse returns the kind of the link seen from the other ticket.
+func (k RelationKind) Inverse() RelationKind {
+	return inverseKinds[k]
+}
+
+// Validate returns ErrUnknownRelationKind if the kind is not one of the defined kinds.
+func (k RelationKind) Validate() error {
+	if _, ok := inverseKinds[k]; !ok {
+		return ErrUnknownRelationKind
+	}
+	return nil
+}
+
+// Acyclic tells if the links of the kind cannot form cycles, e.g. a ticket cannot be its own grandparent.
+func (k RelationKind) Acyclic() bool {
+	return k.canonical() != RelatedTo
+}
+
+// canonical returns the kind used to store the links, between a kind and its inverse.
+func (k RelationKind) canonical() RelationKind {
+	switch k {
+	case ChildOf, BlockedBy, DuplicatedBy:
+		return k.Inverse()
+	default:
+		return k
+	}
+}
+
+// Link is a typed, directed link between two tickets, "From Kind To", e.g. "outage ParentOf report".
+type Link struct {
+	From uuid.UUID    `json:"from"`
+	Kind RelationKind `json:"kind"`
+	To   uuid.UUID    `json:"to"`
+}
+
+// Canonical returns the same link using the stored kinds: ParentOf, Blocks, RelatedTo or DuplicateOf. The RelatedTo
+// links, which have no direction, go from the lowest ID to the highest.
+func (l Link) Canonical() Link {
+	if l.Kind.canonical() != l.Kind {
+		return Link{From: l.To, Kind: l.Kind.Inverse(), To: l.From}
+	}
+	if l.Kind == RelatedTo && l.To.String() < l.From.String() {
+		return Link{From: l.To, Kind: RelatedTo, To: l.From}
+	}
+	return l
+}
+
+// Relation is a link seen from one of its tickets: the kind of the link for that ticket and the other ticket.
+type Relation struct {
+	Kind   RelationKind `json:"kind"`
+	Ticket uuid.UUID    `json:"ticket"`
+}
+
+// RelationFrom returns the link seen from the ticket, which must be one of its ends.
+func (l Link) RelationFrom(ticket uuid.UUID) Relation {
+	if l.From == ticket {
+		return Relation{Kind: l.Kind, Ticket: l.To}
+	}
+	return Relation{Kind: l.Kind.Inverse(), Ticket: l.From}
+}
+
+// RelationGraph contains the tickets reachable from a root ticket following its links, in any direction.
+type RelationGraph struct {
+	Root    uuid.UUID   `json:"root"`
+	Tickets []uuid.UUID `json:"tickets"`
+	// Links are canonical.
+	Links []Link `json:"links"`
+}
+
+// RepositoryRelations is an interface that defines the management of the links between tickets, used by the agents.
+type RepositoryRelations interface {
+	// LinkTickets links two existing tickets, it should return an error if the link already exists, if it is a
+	// self link, or if the link would make a cycle of an acyclic kind.
+	LinkTickets(link Link) error
+	UnlinkTickets(link Link) error
+	// GetRelations returns the relations of the ticket.
+	GetRelations(ticket uuid.UUID) ([]Relation, error)
+	// GetRelationGraph returns the tickets and links reachable from the ticket, up to depth links away. A depth of 0
+	// means no limit.
+	GetRelationGraph(ticket uuid.UUID, depth int) (RelationGraph, error)
+}
+
+var ErrUnknownRelationKind error = errors.New("unknown relation kind")
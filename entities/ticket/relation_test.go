@@ -0,0 +1,34 @@
+package ticket
+
+import (
+	"github.com/google/uuid"
+	"testing"
+)
+
+func TestLink(t *testing.T) {
+	t.Parallel()
+	a, b := uuid.New(), uuid.New()
+	low, high := a, b
+	if high.String() < low.String() {
+		low, high = high, low
+	}
+	t.Run("Canonical links use the stored kinds", func(t *testing.T) {
+		t.Parallel()
+		assertEqual(t, "child of", Link{From: a, Kind: ChildOf, To: b}.Canonical(), Link{From: b, Kind: ParentOf, To: a})
+		assertEqual(t, "blocked by", Link{From: a, Kind: BlockedBy, To: b}.Canonical(), Link{From: b, Kind: Blocks, To: a})
+		assertEqual(t, "duplicated by", Link{From: a, Kind: DuplicatedBy, To: b}.Canonical(), Link{From: b, Kind: DuplicateOf, To: a})
+		assertEqual(t, "related to", Link{From: high, Kind: RelatedTo, To: low}.Canonical(), Link{From: low, Kind: RelatedTo, To: high})
+	})
+	t.Run("A link is seen from each of its tickets with inverse kinds", func(t *testing.T) {
+		t.Parallel()
+		link := Link{From: a, Kind: Blocks, To: b}
+		assertEqual(t, "from", link.RelationFrom(a), Relation{Kind: Blocks, Ticket: b})
+		assertEqual(t, "to", link.RelationFrom(b), Relation{Kind: BlockedBy, Ticket: a})
+	})
+	t.Run("Only related links can make cycles", func(t *testing.T) {
+		t.Parallel()
+		assertEqual(t, "related", RelatedTo.Acyclic(), false)
+		assertEqual(t, "child of", ChildOf.Acyclic(), true)
+		assertErrors(t, RelationKind("caused_by").Validate(), ErrUnknownRelationKind)
+	})
+}
@@ -10,6 +10,7 @@ import (
 type RepositoryClientAccess interface {
 	RepositoryClientReader
 	RepositoryClientWriter
+	RepositoryClientFollower
 }
 
 // RepositoryAgentAccess is an interface that defines the methods that a ticket repository should implement
@@ -21,12 +22,58 @@ type RepositoryAgentAccess interface {
 	UpdateTicket(tck Ticket) error
 }
 
+// RepositoryAgentLister is an interface that defines the methods used by the background jobs that go through every
+// ticket on behalf of the agents.
+type RepositoryAgentLister interface {
+	// GetOpenTickets returns the non-closed tickets ordered by creation date, the oldest first.
+	GetOpenTickets() ([]Ticket, error)
+	// GetTicketOwner returns the id of the client that owns the ticket.
+	GetTicketOwner(ticket uuid.UUID) (uuid.UUID, error)
+}
+
+// RepositoryAgentQuerier is an interface that defines the filtered and paginated access to every ticket, used by the
+// agents.
+type RepositoryAgentQuerier interface {
+	// QueryTickets returns a page of the tickets matching the query, it should return an error if the query is not
+	// valid.
+	QueryTickets(query Query) (Page, error)
+	// CountTicketsByTag returns how many of the tickets matching the query have each tag, ignoring the pagination of
+	// the query.
+	CountTicketsByTag(query Query) (map[string]int, error)
+}
+
+// RepositoryAgentSearcher is an interface that defines the full-text search over every ticket, used by the agents.
+type RepositoryAgentSearcher interface {
+	// SearchTickets returns the tickets whose title, description or responses contain every word of the query, the
+	// most relevant first. A limit of 0 means no limit.
+	SearchTickets(query string, limit int) ([]Ticket, error)
+}
+
+// RepositoryClientSearcher is an interface that defines the full-text search over the tickets of a client.
+type RepositoryClientSearcher interface {
+	// SearchClientTickets works like RepositoryAgentSearcher.SearchTickets, but it only returns the client's tickets.
+	SearchClientTickets(client uuid.UUID, query string, limit int) ([]Ticket, error)
+}
+
 type RepositoryClientReader interface {
 	// GetTicket takes the client id to check that the client has access to the ticket, it should return an error
 	// if the client does not have access to the ticket
 	GetTicket(client, ticket uuid.UUID) (Ticket, error)
 	GetAllClientTickets(client uuid.UUID) ([]Ticket, error)
 	GetClientTicketCount(client uuid.UUID) (int, error)
+	// QueryClientTickets works like RepositoryAgentQuerier.QueryTickets, but it only returns the client's tickets.
+	QueryClientTickets(client uuid.UUID, query Query) (Page, error)
+	// CountClientTicketsByTag works like RepositoryAgentQuerier.CountTicketsByTag, but it only counts the client's
+	// tickets.
+	CountClientTicketsByTag(client uuid.UUID, query Query) (map[string]int, error)
+}
+
+// RepositoryClientFollower is an interface that defines how clients follow the tickets of other clients. The followers
+// of a ticket can read it through RepositoryClientReader.GetTicket, but only its owner can change it.
+type RepositoryClientFollower interface {
+	// FollowTicket should return an error if the client is not allowed to follow the ticket, or if it owns it.
+	FollowTicket(client, ticket uuid.UUID) error
+	UnfollowTicket(client, ticket uuid.UUID) error
 }
 
 type RepositoryClientWriter interface {
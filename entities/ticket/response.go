@@ -2,6 +2,7 @@ package ticket
 
 import (
 	"github.com/google/uuid"
+	"slices"
 	"time"
 )
 
@@ -9,24 +10,47 @@ type Response interface {
 	UserId() uuid.UUID
 	Content() string
 	TimeStamp() time.Time
+	Attachments() []Attachment
+	// IsNote returns whether the response is a note rather than an answer to the ticket's conversation: the reminders
+	// and notes recorded by the system and the responses merged from other tickets. Notes do not count as the
+	// first agent response of a ticket.
+	IsNote() bool
 }
 
 // NewResponse is a function that creates a new basicResponse object with the given user ID and content.
 // The function takes a user ID of type uuid.UUID and a content string, and returns a basicResponse object.
-func NewResponse(id uuid.UUID, s string) Response {
-	return basicResponse{
-		userId:    id,
-		content:   s,
-		timeStamp: time.Now(),
+func NewResponse(id uuid.UUID, s string, attachments ...Attachment) Response {
+	return systemFactory.NewResponse(id, s, attachments...)
+}
+
+func MakeResponse(id uuid.UUID, s string, t time.Time, attachments ...Attachment) Response {
+	return &basicResponse{
+		userId:      id,
+		content:     s,
+		timeStamp:   t,
+		attachments: slices.Clone(attachments),
 	}
 }
 
-func MakeResponse(id uuid.UUID, s string, t time.Time) Response {
-	return basicResponse{
-		userId:    id,
-		content:   s,
-		timeStamp: t,
+// MakeNote creates a response that is a note, see Response.IsNote.
+func MakeNote(id uuid.UUID, s string, t time.Time, attachments ...Attachment) Response {
+	note := MakeResponse(id, s, t, attachments...).(*basicResponse)
+	note.note = true
+	return note
+}
+
+// AsNote returns a copy of the response that is a note, e.g. to merge it into another ticket.
+func AsNote(response Response) Response {
+	return MakeNote(response.UserId(), response.Content(), response.TimeStamp(), response.Attachments()...)
+}
+
+// RewriteResponse returns a copy of the response with another author, content and attachments, keeping its timestamp
+// and whether it is a note.
+func RewriteResponse(response Response, id uuid.UUID, s string, attachments ...Attachment) Response {
+	if response.IsNote() {
+		return MakeNote(id, s, response.TimeStamp(), attachments...)
 	}
+	return MakeResponse(id, s, response.TimeStamp(), attachments...)
 }
 
 // basicResponse represents a response object with a user ID and content.
@@ -34,18 +58,29 @@ type basicResponse struct {
 	timeStamp time.Time
 	userId    uuid.UUID
 	content   string
+	// attachments are the files sent with the response.
+	attachments []Attachment
+	note        bool
 }
 
 // UserId returns the user ID of the user who created the response.
-func (r basicResponse) UserId() uuid.UUID {
+func (r *basicResponse) UserId() uuid.UUID {
 	return r.userId
 }
 
-func (r basicResponse) Content() string {
+func (r *basicResponse) Content() string {
 	return r.content
 }
 
 // TimeStamp returns the time when the response was created.
-func (r basicResponse) TimeStamp() time.Time {
+func (r *basicResponse) TimeStamp() time.Time {
 	return r.timeStamp
 }
+
+func (r *basicResponse) Attachments() []Attachment {
+	return slices.Clone(r.attachments)
+}
+
+func (r *basicResponse) IsNote() bool {
+	return r.note
+}
@@ -10,7 +10,7 @@ func TestNewResponse(t *testing.T) {
 	// TestNewResponse tests the creation of a new basicResponse object.
 	var id uuid.UUID = uuid.New()
 	const content string = "test content"
-	var response Response = NewResponse(id, content)
+	var response Response = testFactory.NewResponse(id, content)
 
 	assertResponseProperties(t, id, content, response)
 
@@ -37,18 +37,35 @@ func TestMakeResponse(t *testing.T) {
 	})
 }
 
+func TestMakeNote(t *testing.T) {
+	t.Parallel()
+	id, timeStamp := uuid.New(), time.Now().Add(-time.Minute)
+	t.Run("A note keeps being a note when it is rewritten", func(t *testing.T) {
+		t.Parallel()
+		note := MakeNote(id, "a reminder", timeStamp)
+		if !note.IsNote() || MakeResponse(id, "an answer", timeStamp).IsNote() {
+			t.Fatal("Only the notes should be notes")
+		}
+		rewritten := RewriteResponse(note, uuid.New(), "rewritten")
+		if !rewritten.IsNote() || !rewritten.TimeStamp().Equal(timeStamp) || rewritten.Content() != "rewritten" {
+			t.Errorf("Unexpected rewritten note %v", rewritten)
+		}
+	})
+	t.Run("A response can be copied as a note", func(t *testing.T) {
+		t.Parallel()
+		response := MakeResponse(id, "an answer", timeStamp)
+		note := AsNote(response)
+		assertResponseProperties(t, id, "an answer", note)
+		if !note.IsNote() || response.IsNote() {
+			t.Error("Only the copy should be a note")
+		}
+	})
+}
+
 func assertResponseTimestamp(t *testing.T, response Response) {
 	t.Helper()
-	timeStamp := response.TimeStamp()
-	if timeStamp.IsZero() {
-		t.Error("Expected timestamp to be set")
-	}
-	if timeStamp.After(time.Now()) {
-		t.Error("Expected timestamp to be in the past")
-	}
-	time.Sleep(1 * time.Millisecond)
-	if !response.TimeStamp().Equal(timeStamp) {
-		t.Errorf("Expected timestamp to be %v, got %v", timeStamp, response.TimeStamp())
+	if !response.TimeStamp().Equal(testNow) {
+		t.Errorf("Expected timestamp to be %v, got %v", testNow, response.TimeStamp())
 	}
 }
 
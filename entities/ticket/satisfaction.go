@@ -0,0 +1,90 @@
+package ticket
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"github.com/google/uuid"
+	"slices"
+	"time"
+)
+
+// MinRating and MaxRating bound the satisfaction ratings.
+const (
+	MinRating = 1
+	MaxRating = 5
+)
+
+// Satisfaction is the satisfaction survey of a closed ticket. The client answers it once, while the ticket is closed,
+// with the token sent when the ticket was closed.
+type Satisfaction struct {
+	// TokenHash is the hex-encoded SHA-256 of the survey token, the token itself is never stored.
+	TokenHash string `json:"token_hash"`
+	// Agent is the agent that closed the ticket, the rating is attributed to them.
+	Agent       uuid.UUID `json:"agent"`
+	RequestedAt time.Time `json:"requested_at"`
+	// Rating is 0 until the survey is answered.
+	Rating  int       `json:"rating"`
+	Comment string    `json:"comment"`
+	RatedAt time.Time `json:"rated_at"`
+}
+
+// Requested tells if a survey was requested for the ticket.
+func (s Satisfaction) Requested() bool {
+	return s.TokenHash != ""
+}
+
+// Rated tells if the client answered the survey.
+func (s Satisfaction) Rated() bool {
+	return s.Rating != 0
+}
+
+// HashSurveyToken returns the hash of a survey token, as stored in Satisfaction.TokenHash.
+func HashSurveyToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (b *basicTicket) Satisfaction() Satisfaction {
+	return b.satisfaction
+}
+
+func (b *basicTicket) PastSatisfactions() []Satisfaction {
+	return slices.Clone(b.pastSatisfactions)
+}
+
+func (b *basicTicket) RequestSatisfaction(agent uuid.UUID, token string) {
+	if b.satisfaction.Rated() {
+		b.pastSatisfactions = append(b.pastSatisfactions, b.satisfaction)
+	}
+	b.satisfaction = Satisfaction{TokenHash: HashSurveyToken(token), Agent: agent, RequestedAt: b.clock.Now()}
+}
+
+func (b *basicTicket) RateSatisfaction(token string, rating int, comment string) error {
+	if !b.satisfaction.Requested() {
+		return ErrNoSurvey
+	}
+	if b.status != Closed {
+		return ErrSurveyTicketNotClosed
+	}
+	if b.satisfaction.Rated() {
+		return ErrSurveyAlreadyAnswered
+	}
+	if subtle.ConstantTimeCompare([]byte(HashSurveyToken(token)), []byte(b.satisfaction.TokenHash)) != 1 {
+		return ErrInvalidSurveyToken
+	}
+	if rating < MinRating || rating > MaxRating {
+		return ErrInvalidRating
+	}
+	b.satisfaction.Rating = rating
+	b.satisfaction.Comment = comment
+	b.satisfaction.RatedAt = b.clock.Now()
+	return nil
+}
+
+var ErrNoSurvey error = errors.New("no satisfaction survey was requested for the ticket")
+var ErrSurveyTicketNotClosed error = errors.New("the satisfaction survey can only be answered while the ticket is closed")
+var ErrSurveyAlreadyAnswered error = errors.New("the satisfaction survey was already answered")
+var ErrInvalidSurveyToken error = errors.New("invalid satisfaction survey token")
+var ErrInvalidRating error = errors.New("the rating must be between 1 and 5")
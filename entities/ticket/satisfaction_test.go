@@ -0,0 +1,75 @@
+package ticket
+
+import (
+	"github.com/google/uuid"
+	"testing"
+	"ticketTao/entities"
+	"time"
+)
+
+func TestBasicTicket_Satisfaction(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC)
+	factory, _ := NewFactory(entities.ClockFunc(func() time.Time { return now }), entities.RandomIDSource())
+	newTicket := func(t *testing.T) Ticket {
+		t.Helper()
+		tck, err := factory.NewTicket("title", "description")
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		return tck
+	}
+	t.Run("A requested survey is answered once with its token", func(t *testing.T) {
+		t.Parallel()
+		tck := newTicket(t)
+		agent := uuid.New()
+		tck.Close()
+		tck.RequestSatisfaction(agent, "secret")
+
+		assertErrors(t, tck.RateSatisfaction("guess", 5, ""), ErrInvalidSurveyToken)
+		if err := tck.RateSatisfaction("secret", 4, "Quick answer"); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		assertErrors(t, tck.RateSatisfaction("secret", 1, ""), ErrSurveyAlreadyAnswered)
+
+		expected := Satisfaction{TokenHash: HashSurveyToken("secret"), Agent: agent, RequestedAt: now, Rating: 4, Comment: "Quick answer", RatedAt: now}
+		assertEqual(t, "satisfaction", tck.Satisfaction(), expected)
+		copied, _ := MakeBasicTicket(tck.ID(), tck.CreatedAt(), DataFrom(tck))
+		assertEqual(t, "copied satisfaction", copied.Satisfaction(), expected)
+	})
+	t.Run("A survey must be requested and rated from 1 to 5", func(t *testing.T) {
+		t.Parallel()
+		tck := newTicket(t)
+		tck.Close()
+		assertErrors(t, tck.RateSatisfaction("secret", 3, ""), ErrNoSurvey)
+		tck.RequestSatisfaction(uuid.New(), "secret")
+		assertErrors(t, tck.RateSatisfaction("secret", 0, ""), ErrInvalidRating)
+		assertErrors(t, tck.RateSatisfaction("secret", 6, ""), ErrInvalidRating)
+	})
+	t.Run("A survey cannot be answered once the ticket is reopened", func(t *testing.T) {
+		t.Parallel()
+		tck := newTicket(t)
+		tck.Close()
+		tck.RequestSatisfaction(uuid.New(), "secret")
+		tck.SetStatus(InProgress)
+		assertErrors(t, tck.RateSatisfaction("secret", 5, ""), ErrSurveyTicketNotClosed)
+	})
+	t.Run("A new survey keeps the answered one in the past surveys", func(t *testing.T) {
+		t.Parallel()
+		tck := newTicket(t)
+		agent := uuid.New()
+		tck.Close()
+		tck.RequestSatisfaction(agent, "unanswered")
+		tck.RequestSatisfaction(agent, "first")
+		_ = tck.RateSatisfaction("first", 2, "Not fixed")
+		answered := tck.Satisfaction()
+		tck.SetStatus(InProgress)
+		tck.Close()
+		tck.RequestSatisfaction(agent, "second")
+
+		assertEqualArrays(t, "past satisfactions", tck.PastSatisfactions(), []Satisfaction{answered})
+		assertEqual(t, "satisfaction", tck.Satisfaction().Rated(), false)
+		copied, _ := MakeBasicTicket(tck.ID(), tck.CreatedAt(), DataFrom(tck))
+		assertEqualArrays(t, "copied past satisfactions", copied.PastSatisfactions(), []Satisfaction{answered})
+	})
+}
@@ -3,8 +3,12 @@
 package ticket
 
 import (
+	"bytes"
 	"errors"
 	"github.com/google/uuid"
+	"maps"
+	"slices"
+	"strings"
 	"ticketTao/entities"
 	"time"
 )
@@ -12,38 +16,18 @@ import (
 // NewBasicTicket creates a new basic ticket with the given title and description (it has a new ID and creation time
 // and is open by default). A ticket cannot be created with an empty title.
 func NewBasicTicket(title, description string) (Ticket, error) {
-	if title == "" {
-		return nil, errors.Join(NewBasicTicketError, ErrEmptyTitle)
-	}
-	return &basicTicket{
-		id:           uuid.New(),
-		creationTime: time.Now(),
-		title:        title,
-		description:  description,
-		status:       Open,
-	}, nil
+	return systemFactory.NewTicket(title, description)
+}
+
+// NewTypedTicket creates a new basic ticket of the given type, with the values of its custom fields. The fields are
+// not validated against the type, the repositories do it.
+func NewTypedTicket(ticketType, title, description string, fields map[string]string) (Ticket, error) {
+	return systemFactory.NewTypedTicket(ticketType, title, description, fields)
 }
 
 // MakeBasicTicket creates a new basic ticket with the given ID, creation time, and data.
 func MakeBasicTicket(id uuid.UUID, creationTime time.Time, data Data) (Ticket, error) {
-	if id == uuid.Nil {
-		return nil, errors.Join(NewBasicTicketError, entities.ErrNilID)
-	}
-	if creationTime.IsZero() {
-		return nil, errors.Join(NewBasicTicketError, entities.ErrNilCreationTime)
-
-	}
-	if creationTime.After(time.Now()) {
-		return nil, errors.Join(NewBasicTicketError, entities.ErrFutureCreationTime)
-	}
-	if data.Title == "" {
-		return nil, errors.Join(NewBasicTicketError, ErrEmptyTitle)
-	}
-	if data.Status == "" {
-		return nil, errors.Join(NewBasicTicketError, ErrEmptyStatus)
-	}
-	return &basicTicket{
-		creationTime, data.Title, data.Description, data.Status, id, data.Responses}, nil
+	return systemFactory.MakeTicket(id, creationTime, data)
 }
 
 // Ticket represents an interface for a ticket.
@@ -53,10 +37,76 @@ type Ticket interface {
 	Title() string
 	Description() string
 	Status() Status
-	// AddResponse adds a response to the ticket.
+	// AddResponse adds a response to the ticket and sets it InProgress. A closed ticket is reopened: its closing time,
+	// closing reason and merge are cleared.
 	AddResponse(Response)
 	Responses() []Response
+	// Close closes the ticket as Resolved and records the closing time.
 	Close()
+	// CloseWithReason closes the ticket and records the closing time and the reason.
+	CloseWithReason(ClosingReason)
+	// ClosedAt returns the time the ticket was closed, it is zero if the ticket is not closed.
+	ClosedAt() time.Time
+	// ClosingReason returns why the ticket was closed, it is empty if the ticket is not closed.
+	ClosingReason() ClosingReason
+	// CloseAsDuplicateOf closes the ticket as a Duplicate that was merged into the target ticket.
+	CloseAsDuplicateOf(target uuid.UUID)
+	// MergedInto returns the ticket this one was merged into, it is uuid.Nil if the ticket was not merged.
+	MergedInto() uuid.UUID
+	// MergeResponses adds the responses of a duplicated ticket, keeping every response in timestamp order. It does
+	// not change the status of the ticket.
+	MergeResponses(responses ...Response)
+	// SplitFrom returns the ticket this one was split from, it is uuid.Nil if the ticket was not split from another.
+	SplitFrom() uuid.UUID
+	// SplitInto returns the tickets split from this one, in the order they were split.
+	SplitInto() []uuid.UUID
+	// AddSplit records a ticket split from this one, adding it twice does nothing.
+	AddSplit(child uuid.UUID)
+	// Followers returns the users, other than the owner, that follow the ticket to get its updates, sorted.
+	Followers() []uuid.UUID
+	// Follow adds a follower, following the ticket twice does nothing.
+	Follow(user uuid.UUID)
+	Unfollow(user uuid.UUID)
+	IsFollowedBy(user uuid.UUID) bool
+	// Satisfaction returns the latest satisfaction survey.
+	Satisfaction() Satisfaction
+	// PastSatisfactions returns the answered surveys replaced by newer ones, the oldest first.
+	PastSatisfactions() []Satisfaction
+	// RequestSatisfaction starts a satisfaction survey for the rating of the agent. A previous survey is kept in
+	// PastSatisfactions if it was answered, otherwise it is replaced. Only the hash of the token is kept.
+	RequestSatisfaction(agent uuid.UUID, token string)
+	// RateSatisfaction answers the survey, it returns an error if the ticket is not closed, if the token is not the
+	// survey's, if the survey was already answered or if the rating is not between MinRating and MaxRating.
+	RateSatisfaction(token string, rating int, comment string) error
+	// SetStatus changes the status of the ticket, Closed closes it as Resolved and any other status reopens it.
+	SetStatus(Status)
+	// History returns the changes of the status of the ticket, the oldest first.
+	History() []StatusChange
+	Priority() Priority
+	SetPriority(Priority)
+	// Assignee returns the agent in charge of the ticket, it is uuid.Nil if the ticket is not assigned.
+	Assignee() uuid.UUID
+	// AssignTo assigns the ticket to an agent, uuid.Nil unassigns it.
+	AssignTo(agent uuid.UUID)
+	// Tags returns the normalized tags of the ticket, sorted.
+	Tags() []string
+	// AddTag adds the normalized tag to the ticket, adding an existing or empty tag does nothing.
+	AddTag(tag string)
+	RemoveTag(tag string)
+	// Category returns the path of the category of the ticket (see the category package), it is empty if the ticket
+	// is not categorized.
+	Category() string
+	Categorize(category string)
+	// Type returns the name of the ticket type (see the tickettype package) that defines the custom fields of the
+	// ticket, it is empty for untyped tickets.
+	Type() string
+	// Fields returns a copy of the custom field values, by field name.
+	Fields() map[string]string
+	// SetField sets the value of a custom field, the empty value removes it.
+	SetField(name, value string)
+	// Attachments returns the files attached to the ticket itself, not to its responses.
+	Attachments() []Attachment
+	Attach(Attachment)
 }
 
 // Data represents the data of a ticket.
@@ -65,6 +115,79 @@ type Data struct {
 	Description string     `json:"description"`
 	Status      Status     `json:"status"`
 	Responses   []Response `json:"responses"`
+	// Priority defaults to Normal when it is empty.
+	Priority      Priority          `json:"priority"`
+	ClosedAt      time.Time         `json:"closed_at"`
+	ClosingReason ClosingReason     `json:"closing_reason"`
+	Assignee      uuid.UUID         `json:"assignee"`
+	Tags          []string          `json:"tags"`
+	Category      string            `json:"category"`
+	Type          string            `json:"type"`
+	Fields        map[string]string `json:"fields"`
+	Attachments   []Attachment      `json:"attachments"`
+	// MergedInto is only set for the tickets closed as Duplicate.
+	MergedInto uuid.UUID `json:"merged_into"`
+	// SplitFrom is only set for the tickets created by splitting another ticket.
+	SplitFrom uuid.UUID `json:"split_from"`
+	// SplitInto is only set for the tickets split into other tickets.
+	SplitInto    []uuid.UUID  `json:"split_into"`
+	Followers    []uuid.UUID  `json:"followers"`
+	Satisfaction Satisfaction `json:"satisfaction"`
+	// PastSatisfactions are only set for the tickets closed, and surveyed, more than once.
+	PastSatisfactions []Satisfaction `json:"past_satisfactions"`
+	History           []StatusChange `json:"history"`
+}
+
+// DataFrom returns the data of a ticket, e.g. to persist it. The responses are copied.
+func DataFrom(tck Ticket) Data {
+	return Data{
+		Title:             tck.Title(),
+		Description:       tck.Description(),
+		Status:            tck.Status(),
+		Responses:         append([]Response(nil), tck.Responses()...),
+		Priority:          tck.Priority(),
+		ClosedAt:          tck.ClosedAt(),
+		ClosingReason:     tck.ClosingReason(),
+		Assignee:          tck.Assignee(),
+		Tags:              tck.Tags(),
+		Category:          tck.Category(),
+		Type:              tck.Type(),
+		Fields:            tck.Fields(),
+		Attachments:       tck.Attachments(),
+		MergedInto:        tck.MergedInto(),
+		SplitFrom:         tck.SplitFrom(),
+		SplitInto:         tck.SplitInto(),
+		Followers:         tck.Followers(),
+		Satisfaction:      tck.Satisfaction(),
+		PastSatisfactions: tck.PastSatisfactions(),
+		History:           tck.History(),
+	}
+}
+
+// Snapshot returns a copy of the ticket that does not change when the ticket does, e.g. to publish it in an event.
+// The copy keeps the clock of the ticket.
+func Snapshot(tck Ticket) Ticket {
+	return factoryOf(tck).makeTicket(tck.ID(), tck.CreatedAt(), DataFrom(tck))
+}
+
+// Rewrite instances the ticket with other data, like Factory.MakeTicket, keeping its ID, creation time and clock.
+func Rewrite(tck Ticket, data Data) (Ticket, error) {
+	return factoryOf(tck).MakeTicket(tck.ID(), tck.CreatedAt(), data)
+}
+
+// factoryOf returns a factory that uses the clock of the ticket.
+func factoryOf(tck Ticket) basicFactory {
+	factory := systemFactory
+	if basic, ok := tck.(*basicTicket); ok {
+		factory.clock = basic.clock
+	}
+	return factory
+}
+
+// NormalizeTag returns the tag in lower case, without surrounding spaces and with the inner spaces replaced by "-", so
+// "Late Delivery" and "late-delivery" are the same tag.
+func NormalizeTag(tag string) string {
+	return strings.Join(strings.Fields(strings.ToLower(tag)), "-")
 }
 
 // Status represents the status of a ticket.
@@ -80,22 +203,232 @@ const InProgress Status = "InProgress"
 // Closed is the status of a ticket when it is done.
 const Closed Status = "Closed"
 
+// ClosingReason represents why a ticket was closed.
+type ClosingReason string
+
+// Resolved is the closing reason of the tickets whose problem was solved.
+const Resolved ClosingReason = "Resolved"
+
+// NoResponse is the closing reason of the tickets closed because the client stopped answering.
+const NoResponse ClosingReason = "NoResponse"
+
+// Duplicate is the closing reason of the tickets merged into another ticket about the same problem.
+const Duplicate ClosingReason = "Duplicate"
+
+// Priority represents how urgently a ticket should be attended.
+type Priority string
+
+// Low is the priority of tickets that can wait.
+const Low Priority = "Low"
+
+// Normal is the priority of a ticket when it is created.
+const Normal Priority = "Normal"
+
+// High is the priority of tickets that should be attended before the normal ones.
+const High Priority = "High"
+
+// Urgent is the priority of tickets that should be attended immediately.
+const Urgent Priority = "Urgent"
+
 type basicTicket struct {
-	creationTime time.Time
-	title        string
-	description  string
-	status       Status
-	id           uuid.UUID
-	responses    []Response
+	creationTime      time.Time
+	title             string
+	description       string
+	status            Status
+	id                uuid.UUID
+	responses         []Response
+	priority          Priority
+	closingTime       time.Time
+	reason            ClosingReason
+	assignee          uuid.UUID
+	tags              []string
+	category          string
+	ticketType        string
+	fields            map[string]string
+	attachments       []Attachment
+	mergedInto        uuid.UUID
+	splitFrom         uuid.UUID
+	splitInto         []uuid.UUID
+	followers         []uuid.UUID
+	satisfaction      Satisfaction
+	pastSatisfactions []Satisfaction
+	history           []StatusChange
+	clock             entities.Clock
 }
 
 func (b *basicTicket) Close() {
-	b.status = Closed
+	b.CloseWithReason(Resolved)
+}
+
+func (b *basicTicket) CloseWithReason(reason ClosingReason) {
+	b.setStatus(Closed)
+	b.closingTime = b.clock.Now()
+	b.reason = reason
+	b.mergedInto = uuid.Nil
+}
+
+func (b *basicTicket) CloseAsDuplicateOf(target uuid.UUID) {
+	b.CloseWithReason(Duplicate)
+	b.mergedInto = target
+}
+
+func (b *basicTicket) MergedInto() uuid.UUID {
+	return b.mergedInto
+}
+
+func (b *basicTicket) SplitFrom() uuid.UUID {
+	return b.splitFrom
+}
+
+func (b *basicTicket) SplitInto() []uuid.UUID {
+	return slices.Clone(b.splitInto)
+}
+
+func (b *basicTicket) AddSplit(child uuid.UUID) {
+	if child == uuid.Nil || slices.Contains(b.splitInto, child) {
+		return
+	}
+	b.splitInto = append(b.splitInto, child)
+}
+
+func (b *basicTicket) Followers() []uuid.UUID {
+	return slices.Clone(b.followers)
+}
+
+func (b *basicTicket) Follow(user uuid.UUID) {
+	if user == uuid.Nil {
+		return
+	}
+	i, found := slices.BinarySearchFunc(b.followers, user, compareIDs)
+	if !found {
+		b.followers = slices.Insert(b.followers, i, user)
+	}
+}
+
+func (b *basicTicket) Unfollow(user uuid.UUID) {
+	i, found := slices.BinarySearchFunc(b.followers, user, compareIDs)
+	if found {
+		b.followers = slices.Delete(b.followers, i, i+1)
+	}
+}
+
+func (b *basicTicket) IsFollowedBy(user uuid.UUID) bool {
+	_, found := slices.BinarySearchFunc(b.followers, user, compareIDs)
+	return found
+}
+
+func compareIDs(a, b uuid.UUID) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+func (b *basicTicket) MergeResponses(responses ...Response) {
+	merged := slices.Concat(b.responses, responses)
+	slices.SortStableFunc(merged, func(x, y Response) int {
+		return x.TimeStamp().Compare(y.TimeStamp())
+	})
+	b.responses = merged
+}
+
+func (b *basicTicket) SetStatus(status Status) {
+	if status == Closed {
+		b.Close()
+		return
+	}
+	b.setStatus(status)
+	b.reopen()
+}
+
+func (b *basicTicket) ClosedAt() time.Time {
+	return b.closingTime
+}
+
+func (b *basicTicket) ClosingReason() ClosingReason {
+	return b.reason
+}
+
+func (b *basicTicket) Priority() Priority {
+	return b.priority
+}
+
+func (b *basicTicket) SetPriority(priority Priority) {
+	b.priority = priority
+}
+
+func (b *basicTicket) Assignee() uuid.UUID {
+	return b.assignee
+}
+
+func (b *basicTicket) AssignTo(agent uuid.UUID) {
+	b.assignee = agent
+}
+
+func (b *basicTicket) Tags() []string {
+	return slices.Clone(b.tags)
+}
+
+func (b *basicTicket) AddTag(tag string) {
+	tag = NormalizeTag(tag)
+	if tag == "" {
+		return
+	}
+	i, found := slices.BinarySearch(b.tags, tag)
+	if !found {
+		b.tags = slices.Insert(b.tags, i, tag)
+	}
+}
+
+func (b *basicTicket) RemoveTag(tag string) {
+	i, found := slices.BinarySearch(b.tags, NormalizeTag(tag))
+	if found {
+		b.tags = slices.Delete(b.tags, i, i+1)
+	}
+}
+
+func (b *basicTicket) Category() string {
+	return b.category
+}
+
+func (b *basicTicket) Categorize(category string) {
+	b.category = category
+}
+
+func (b *basicTicket) Type() string {
+	return b.ticketType
+}
+
+func (b *basicTicket) Fields() map[string]string {
+	return maps.Clone(b.fields)
+}
+
+func (b *basicTicket) SetField(name, value string) {
+	if value == "" {
+		delete(b.fields, name)
+		return
+	}
+	if b.fields == nil {
+		b.fields = make(map[string]string)
+	}
+	b.fields[name] = value
+}
+
+func (b *basicTicket) Attachments() []Attachment {
+	return slices.Clone(b.attachments)
+}
+
+func (b *basicTicket) Attach(attachment Attachment) {
+	b.attachments = append(b.attachments, attachment)
 }
 
 func (b *basicTicket) AddResponse(response Response) {
 	b.responses = append(b.responses, response)
-	b.status = InProgress
+	b.setStatus(InProgress)
+	b.reopen()
+}
+
+func (b *basicTicket) reopen() {
+	b.closingTime = time.Time{}
+	b.reason = ""
+	b.mergedInto = uuid.Nil
 }
 
 func (b *basicTicket) Responses() []Response {
@@ -125,3 +458,4 @@ func (b *basicTicket) CreatedAt() time.Time {
 var NewBasicTicketError error = errors.New("error creating new basic ticket")
 var ErrEmptyTitle error = errors.New("ticket title cannot be empty")
 var ErrEmptyStatus error = errors.New("ticket status cannot be empty")
+var ErrEmptyType error = errors.New("ticket type cannot be empty")
@@ -16,7 +16,7 @@ func TestMakeEmptyBasicTicket(t *testing.T) {
 		t.Parallel()
 		title := helpers.MakeRandomString(10)
 		description := helpers.MakeRandomString(100)
-		ticket, _ := NewBasicTicket(title, description)
+		ticket, _ := testFactory.NewTicket(title, description)
 		assertEqual(t, "title", ticket.Title(), title)
 		assertEqual(t, "description", ticket.Description(), description)
 		assertIdNotNil(t, ticket)
@@ -66,6 +66,37 @@ func TestMakeBasicTicket(t *testing.T) {
 		assertEqualArrays(t, "responses", tck.Responses(), responses)
 	})
 
+	t.Run("A basic ticket keeps its priority and closing time", func(t *testing.T) {
+		t.Parallel()
+
+		var data Data
+		data.Title = title
+		data.Status = Closed
+		data.Priority = High
+		data.ClosedAt = creationTime.Add(time.Millisecond)
+		data.ClosingReason = NoResponse
+
+		tck, err := MakeBasicTicket(id, creationTime, data)
+		if err != nil {
+			t.Fatalf("Error creating basic ticket: %v", err)
+		}
+
+		assertEqual(t, "priority", tck.Priority(), High)
+		assertEqual(t, "closing time", tck.ClosedAt(), data.ClosedAt)
+		assertEqual(t, "closing reason", tck.ClosingReason(), NoResponse)
+	})
+
+	t.Run("A basic ticket has a 'Normal' priority when the data does not have one", func(t *testing.T) {
+		t.Parallel()
+
+		tck, err := MakeBasicTicket(id, creationTime, Data{Title: title, Status: status})
+		if err != nil {
+			t.Fatalf("Error creating basic ticket: %v", err)
+		}
+
+		assertEqual(t, "priority", tck.Priority(), Normal)
+	})
+
 	t.Run("A basic ticket cannot be created with an empty title", func(t *testing.T) {
 		t.Parallel()
 
@@ -180,7 +211,7 @@ func TestTicket(t *testing.T) {
 		t.Parallel()
 		title := helpers.MakeRandomString(10)
 		description := helpers.MakeRandomString(100)
-		ticket, _ := NewBasicTicket(title, description)
+		ticket, _ := testFactory.NewTicket(title, description)
 		assertEqual(t, "title", ticket.Title(), title)
 		assertEqual(t, "description", ticket.Description(), description)
 		checkTicketCreationTime(t, ticket)
@@ -199,6 +230,14 @@ func TestTicket(t *testing.T) {
 		ticket := makeBasicTicket(t)
 		assertEqual(t, "status", ticket.Status(), Open)
 	})
+
+	t.Run("A ticket is created with a 'Normal' priority", func(t *testing.T) {
+		t.Parallel()
+		ticket := makeBasicTicket(t)
+		assertEqual(t, "priority", ticket.Priority(), Normal)
+		ticket.SetPriority(Urgent)
+		assertEqual(t, "priority", ticket.Priority(), Urgent)
+	})
 }
 
 func TestBasicTicket_AddResponse(t *testing.T) {
@@ -220,6 +259,27 @@ func TestBasicTicket_Close(t *testing.T) {
 	if status != Closed {
 		t.Errorf("Expected status to be 'Closed', got %v", status)
 	}
+	if ticket.ClosedAt().IsZero() || ticket.ClosedAt().Before(ticket.CreatedAt()) {
+		t.Errorf("Expected the closing time to be recorded, got %v", ticket.ClosedAt())
+	}
+	assertEqual(t, "closing reason", ticket.ClosingReason(), Resolved)
+
+	t.Run("A ticket is reopened when a response is added", func(t *testing.T) {
+		ticket.AddResponse(NewResponse(uuid.New(), "reopen"))
+		checkInProgressStatus(t, ticket)
+		if !ticket.ClosedAt().IsZero() {
+			t.Errorf("Expected the closing time to be cleared, got %v", ticket.ClosedAt())
+		}
+		assertEqual(t, "closing reason", ticket.ClosingReason(), ClosingReason(""))
+	})
+}
+
+func TestBasicTicket_CloseWithReason(t *testing.T) {
+	t.Parallel()
+	ticket := makeBasicTicket(t)
+	ticket.CloseWithReason(NoResponse)
+	assertEqual(t, "status", ticket.Status(), Closed)
+	assertEqual(t, "closing reason", ticket.ClosingReason(), NoResponse)
 }
 
 func assertEqual(t *testing.T, field string, got, want interface{}) {
@@ -263,11 +323,15 @@ func checkInProgressStatus(t *testing.T, ticket Ticket) {
 	}
 }
 
+// testNow is the time of the clock of testFactory, when it creates the tickets and responses of the tests.
+var testNow = time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC)
+
+var testFactory, _ = NewFactory(entities.ClockFunc(func() time.Time { return testNow }), entities.RandomIDSource())
+
 func checkTicketCreationTime(t *testing.T, ticket Ticket) {
 	t.Helper()
-	time.Sleep(11 * time.Millisecond)
-	if ticket.CreatedAt().After(time.Now().Add(-10 * time.Millisecond)) {
-		t.Errorf("Expected creation date to be in the past, got %v", ticket.CreatedAt())
+	if !ticket.CreatedAt().Equal(testNow) {
+		t.Errorf("Expected creation date to be %v, got %v", testNow, ticket.CreatedAt())
 	}
 }
 
@@ -275,7 +339,7 @@ func makeBasicTicket(t *testing.T) Ticket {
 	t.Helper()
 	const title = "A title"
 	const description = "A description"
-	ticket, _ := NewBasicTicket(title, description)
+	ticket, _ := testFactory.NewTicket(title, description)
 	if ticket == nil {
 		t.Errorf("Expected a ticket, got nil")
 	}
@@ -311,3 +375,210 @@ func assertErrors(t *testing.T, err error, expected ...error) {
 		}
 	}
 }
+
+func TestBasicTicket_Tags(t *testing.T) {
+	t.Parallel()
+	t.Run("Tags are normalized, sorted and not repeated", func(t *testing.T) {
+		t.Parallel()
+		ticket := makeBasicTicket(t)
+		ticket.AddTag("Shipping")
+		ticket.AddTag(" late  Delivery ")
+		ticket.AddTag("shipping")
+		ticket.AddTag("  ")
+		assertEqualArrays(t, "tags", ticket.Tags(), []string{"late-delivery", "shipping"})
+		ticket.RemoveTag("Late Delivery")
+		assertEqualArrays(t, "tags", ticket.Tags(), []string{"shipping"})
+	})
+	t.Run("The tags of the data are normalized", func(t *testing.T) {
+		t.Parallel()
+		ticket, _ := MakeBasicTicket(uuid.New(), time.Now(), Data{Title: "title", Status: Open, Tags: []string{"VIP", "billing", "vip"}})
+		assertEqualArrays(t, "tags", ticket.Tags(), []string{"billing", "vip"})
+	})
+}
+
+func TestNewTypedTicket(t *testing.T) {
+	t.Parallel()
+	t.Run("A typed ticket has a type and custom fields", func(t *testing.T) {
+		t.Parallel()
+		fields := map[string]string{"order": "SO-42", "empty": ""}
+		ticket, err := NewTypedTicket("sales", "title", "description", fields)
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		assertEqual(t, "type", ticket.Type(), "sales")
+		assertEqual(t, "fields", len(ticket.Fields()), 1)
+		assertEqual(t, "order", ticket.Fields()["order"], "SO-42")
+		ticket.SetField("order", "")
+		assertEqual(t, "fields", len(ticket.Fields()), 0)
+	})
+	t.Run("A typed ticket must have a type", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewTypedTicket("", "title", "description", nil)
+		assertErrors(t, err, NewBasicTicketError, ErrEmptyType)
+	})
+}
+
+func TestFindAttachment(t *testing.T) {
+	t.Parallel()
+	t.Run("Attachments are found in the ticket and in its responses", func(t *testing.T) {
+		t.Parallel()
+		ticket := makeBasicTicket(t)
+		screenshot := Attachment{ID: uuid.New(), Name: "screenshot.png", MIMEType: "image/png", Size: 10}
+		invoice := Attachment{ID: uuid.New(), Name: "invoice.pdf", MIMEType: "application/pdf", Size: 20}
+		ticket.Attach(screenshot)
+		ticket.AddResponse(NewResponse(uuid.New(), "here it is", invoice))
+
+		found, ok := FindAttachment(ticket, screenshot.ID)
+		assertEqual(t, "ticket attachment", found, screenshot)
+		assertEqual(t, "found", ok, true)
+		found, ok = FindAttachment(ticket, invoice.ID)
+		assertEqual(t, "response attachment", found, invoice)
+		assertEqual(t, "found", ok, true)
+		_, ok = FindAttachment(ticket, uuid.New())
+		assertEqual(t, "found", ok, false)
+	})
+	t.Run("The attachments of the data are kept in copies", func(t *testing.T) {
+		t.Parallel()
+		ticket := makeBasicTicket(t)
+		ticket.Attach(Attachment{ID: uuid.New(), Name: "log.txt"})
+		copied, _ := MakeBasicTicket(ticket.ID(), ticket.CreatedAt(), DataFrom(ticket))
+		assertEqual(t, "attachments", len(copied.Attachments()), 1)
+	})
+}
+
+func TestBasicTicket_SetStatus(t *testing.T) {
+	t.Parallel()
+	t.Run("Setting the Closed status closes the ticket and any other status reopens it", func(t *testing.T) {
+		t.Parallel()
+		ticket := makeBasicTicket(t)
+		ticket.SetStatus(Closed)
+		assertEqual(t, "reason", ticket.ClosingReason(), Resolved)
+		assertEqual(t, "closed", ticket.ClosedAt().IsZero(), false)
+		ticket.SetStatus(Open)
+		assertEqual(t, "status", ticket.Status(), Open)
+		assertEqual(t, "reason", ticket.ClosingReason(), ClosingReason(""))
+		assertEqual(t, "closed", ticket.ClosedAt().IsZero(), true)
+	})
+}
+
+func TestBasicTicket_Merge(t *testing.T) {
+	t.Parallel()
+	t.Run("Merged responses are kept in timestamp order without changing the status", func(t *testing.T) {
+		t.Parallel()
+		start := time.Now().Add(-time.Hour)
+		ticket := makeBasicTicket(t)
+		first := MakeResponse(uuid.New(), "first", start)
+		third := MakeResponse(uuid.New(), "third", start.Add(20*time.Minute))
+		ticket.AddResponse(first)
+		ticket.AddResponse(third)
+		ticket.SetStatus(Open)
+
+		second := MakeResponse(uuid.New(), "second", start.Add(10*time.Minute))
+		fourth := MakeResponse(uuid.New(), "fourth", start.Add(30*time.Minute))
+		ticket.MergeResponses(fourth, second)
+
+		assertEqualArrays(t, "responses", ticket.Responses(), []Response{first, second, third, fourth})
+		assertEqual(t, "status", ticket.Status(), Open)
+	})
+	t.Run("A ticket closed as a duplicate points to the target until it is reopened", func(t *testing.T) {
+		t.Parallel()
+		ticket := makeBasicTicket(t)
+		target := uuid.New()
+		ticket.CloseAsDuplicateOf(target)
+		assertEqual(t, "reason", ticket.ClosingReason(), Duplicate)
+		assertEqual(t, "merged into", ticket.MergedInto(), target)
+		copied, _ := MakeBasicTicket(ticket.ID(), ticket.CreatedAt(), DataFrom(ticket))
+		assertEqual(t, "copied merged into", copied.MergedInto(), target)
+
+		ticket.AddResponse(NewResponse(uuid.New(), "it is not a duplicate"))
+		assertEqual(t, "merged into", ticket.MergedInto(), uuid.Nil)
+	})
+}
+
+func TestBasicTicket_Followers(t *testing.T) {
+	t.Parallel()
+	t.Run("Followers are kept once and can unfollow", func(t *testing.T) {
+		t.Parallel()
+		ticket := makeBasicTicket(t)
+		first, second := uuid.New(), uuid.New()
+		ticket.Follow(first)
+		ticket.Follow(second)
+		ticket.Follow(first)
+		ticket.Follow(uuid.Nil)
+		assertEqual(t, "followers", len(ticket.Followers()), 2)
+		assertEqual(t, "following", ticket.IsFollowedBy(second), true)
+		ticket.Unfollow(second)
+		assertEqualArrays(t, "followers", ticket.Followers(), []uuid.UUID{first})
+		assertEqual(t, "following", ticket.IsFollowedBy(second), false)
+	})
+	t.Run("The followers of the data are sorted and not repeated", func(t *testing.T) {
+		t.Parallel()
+		follower := uuid.New()
+		ticket, _ := MakeBasicTicket(uuid.New(), time.Now(), Data{Title: "title", Status: Open, Followers: []uuid.UUID{follower, uuid.Nil, follower}})
+		assertEqualArrays(t, "followers", ticket.Followers(), []uuid.UUID{follower})
+		assertEqual(t, "following", ticket.IsFollowedBy(follower), true)
+	})
+}
+
+func TestBasicTicket_AddSplit(t *testing.T) {
+	t.Parallel()
+	ticket := makeBasicTicket(t)
+	first, second := uuid.New(), uuid.New()
+	ticket.AddSplit(first)
+	ticket.AddSplit(second)
+	ticket.AddSplit(first)
+	ticket.AddSplit(uuid.Nil)
+	assertEqualArrays(t, "split into", ticket.SplitInto(), []uuid.UUID{first, second})
+	assertEqualArrays(t, "persisted split into", Snapshot(ticket).SplitInto(), []uuid.UUID{first, second})
+}
+
+func TestSnapshot(t *testing.T) {
+	t.Parallel()
+	t.Run("The snapshot does not change when the ticket does", func(t *testing.T) {
+		t.Parallel()
+		ticket := makeBasicTicket(t)
+		ticket.AddTag("billing")
+		snapshot := Snapshot(ticket)
+		ticket.AddTag("urgent")
+		ticket.Close()
+		assertEqual(t, "id", snapshot.ID(), ticket.ID())
+		assertEqual(t, "status", snapshot.Status(), Open)
+		assertEqualArrays(t, "tags", snapshot.Tags(), []string{"billing"})
+	})
+	t.Run("The snapshot keeps the clock of the ticket", func(t *testing.T) {
+		t.Parallel()
+		now := time.Now().Add(time.Hour)
+		factory, _ := NewFactory(entities.ClockFunc(func() time.Time { return now }), entities.RandomIDSource())
+		ticket, _ := factory.NewTicket("title", "description")
+		snapshot := Snapshot(ticket)
+		snapshot.Close()
+		assertEqual(t, "closed at", snapshot.ClosedAt(), now)
+	})
+}
+
+func TestRewrite(t *testing.T) {
+	t.Parallel()
+	t.Run("The rewritten ticket keeps the ID, creation time and clock of the ticket", func(t *testing.T) {
+		t.Parallel()
+		now := time.Now().Add(time.Hour)
+		factory, _ := NewFactory(entities.ClockFunc(func() time.Time { return now }), entities.RandomIDSource())
+		ticket, _ := factory.NewTicket("title", "description")
+		data := DataFrom(ticket)
+		data.Title = "another title"
+
+		rewritten, err := Rewrite(ticket, data)
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		rewritten.Close()
+		assertEqual(t, "id", rewritten.ID(), ticket.ID())
+		assertEqual(t, "title", rewritten.Title(), "another title")
+		assertEqual(t, "closed at", rewritten.ClosedAt(), now)
+	})
+	t.Run("The data is validated", func(t *testing.T) {
+		t.Parallel()
+		ticket := makeBasicTicket(t)
+		_, err := Rewrite(ticket, Data{Status: Open})
+		assertErrors(t, err, NewBasicTicketError, ErrEmptyTitle)
+	})
+}
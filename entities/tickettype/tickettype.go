@@ -0,0 +1,214 @@
+// Package tickettype contains the ticket types, which define the custom fields that the tickets of each type have,
+// e.g. the order number of the sales tickets or the SKU of the inventory ones.
+package tickettype
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"ticketTao/entities/ticket"
+	"time"
+)
+
+// Kind defines which values a custom field accepts. The values are always stored as strings.
+type Kind string
+
+// String fields accept any value.
+const String Kind = "String"
+
+// Number fields accept finite decimal numbers, e.g. "42", "-3.5" or "1e3".
+const Number Kind = "Number"
+
+// Date fields accept dates in the DateLayout format.
+const Date Kind = "Date"
+
+// Enum fields accept one of the options of the field.
+const Enum Kind = "Enum"
+
+// Reference fields accept the UUID of another entity, e.g. an ERP order.
+const Reference Kind = "Reference"
+
+// DateLayout is the format of the values of the Date fields.
+const DateLayout = time.DateOnly
+
+// Field defines a custom field of a ticket type.
+type Field struct {
+	Name     string
+	Kind     Kind
+	Required bool
+	// Options are the values accepted by the Enum fields.
+	Options []string
+}
+
+// Type is a ticket type.
+type Type struct {
+	Name   string
+	Fields []Field
+}
+
+// Registry keeps the ticket types and validates the tickets against them.
+type Registry interface {
+	Register(Type) error
+	Get(name string) (Type, bool)
+	// Validate checks that the ticket type exists and that the ticket has valid values for its fields. Untyped
+	// tickets are valid if they have no custom fields.
+	Validate(tck ticket.Ticket) error
+}
+
+// NewRegistry creates a Registry with the given types.
+func NewRegistry(types ...Type) (Registry, error) {
+	registry := &memoryRegistry{types: make(map[string]Type)}
+	for _, t := range types {
+		if err := registry.Register(t); err != nil {
+			return nil, errors.Join(NewRegistryError, err)
+		}
+	}
+	return registry, nil
+}
+
+// ValidateFields checks the values of the custom fields of a ticket of the type.
+func (t Type) ValidateFields(values map[string]string) error {
+	var failures []error
+	for _, field := range t.Fields {
+		value, ok := values[field.Name]
+		if !ok {
+			if field.Required {
+				failures = append(failures, fmt.Errorf("%w: %s", ErrMissingField, field.Name))
+			}
+			continue
+		}
+		if err := field.validate(value); err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", field.Name, err))
+		}
+	}
+	for name := range values {
+		if !slices.ContainsFunc(t.Fields, func(field Field) bool { return field.Name == name }) {
+			failures = append(failures, fmt.Errorf("%w: %s", ErrUnknownField, name))
+		}
+	}
+	return errors.Join(failures...)
+}
+
+func (t Type) validate() error {
+	if t.Name == "" {
+		return ticket.ErrEmptyType
+	}
+	names := make(map[string]bool)
+	for _, field := range t.Fields {
+		if field.Name == "" {
+			return ErrEmptyFieldName
+		}
+		if names[field.Name] {
+			return fmt.Errorf("%w: %s", ErrDuplicatedField, field.Name)
+		}
+		names[field.Name] = true
+		switch field.Kind {
+		case String, Number, Date, Reference:
+		case Enum:
+			if len(field.Options) == 0 {
+				return fmt.Errorf("%w: %s", ErrNoOptions, field.Name)
+			}
+		default:
+			return fmt.Errorf("%w: %s", ErrUnknownKind, field.Kind)
+		}
+	}
+	return nil
+}
+
+func (f Field) validate(value string) error {
+	var err error
+	switch f.Kind {
+	case Number:
+		err = validateNumber(value)
+	case Date:
+		_, err = time.Parse(DateLayout, value)
+	case Reference:
+		_, err = uuid.Parse(value)
+	case Enum:
+		if !slices.Contains(f.Options, value) {
+			err = fmt.Errorf("%q is not one of %v", value, f.Options)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidValue, err)
+	}
+	return nil
+}
+
+// validateNumber accepts the decimal numbers, optionally with an exponent, and rejects the hexadecimal ones, the
+// infinities and NaN that strconv.ParseFloat also accepts.
+func validateNumber(value string) error {
+	decimal := func(r rune) bool { return strings.ContainsRune("0123456789+-.eE", r) }
+	if value == "" || strings.ContainsFunc(value, func(r rune) bool { return !decimal(r) }) {
+		return fmt.Errorf("%q is not a decimal number", value)
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return err
+	}
+	if math.IsNaN(number) || math.IsInf(number, 0) {
+		return fmt.Errorf("%q is not a finite number", value)
+	}
+	return nil
+}
+
+type memoryRegistry struct {
+	mu    sync.RWMutex
+	types map[string]Type
+}
+
+func (m *memoryRegistry) Register(t Type) error {
+	if err := t.validate(); err != nil {
+		return fmt.Errorf("ticket type %q: %w", t.Name, err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.types[t.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicatedType, t.Name)
+	}
+	m.types[t.Name] = t
+	return nil
+}
+
+func (m *memoryRegistry) Get(name string) (Type, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	t, ok := m.types[name]
+	return t, ok
+}
+
+func (m *memoryRegistry) Validate(tck ticket.Ticket) error {
+	if tck.Type() == "" {
+		if len(tck.Fields()) > 0 {
+			return errors.Join(ValidateError, ErrFieldsWithoutType)
+		}
+		return nil
+	}
+	t, ok := m.Get(tck.Type())
+	if !ok {
+		return errors.Join(ValidateError, fmt.Errorf("%w: %s", ErrUnknownType, tck.Type()))
+	}
+	if err := t.ValidateFields(tck.Fields()); err != nil {
+		return errors.Join(ValidateError, err)
+	}
+	return nil
+}
+
+var NewRegistryError error = errors.New("error creating ticket type registry")
+var ValidateError error = errors.New("invalid custom fields")
+
+var ErrDuplicatedType error = errors.New("ticket type already registered")
+var ErrUnknownType error = errors.New("unknown ticket type")
+var ErrEmptyFieldName error = errors.New("custom field name cannot be empty")
+var ErrDuplicatedField error = errors.New("custom field names must be unique")
+var ErrUnknownKind error = errors.New("unknown custom field kind")
+var ErrNoOptions error = errors.New("enum fields must have options")
+var ErrMissingField error = errors.New("missing required custom field")
+var ErrUnknownField error = errors.New("unknown custom field")
+var ErrInvalidValue error = errors.New("invalid custom field value")
+var ErrFieldsWithoutType error = errors.New("untyped tickets cannot have custom fields")
@@ -0,0 +1,107 @@
+package tickettype
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"testing"
+	"ticketTao/entities/ticket"
+)
+
+var sales = Type{Name: "sales", Fields: []Field{
+	{Name: "order", Kind: Reference, Required: true},
+	{Name: "amount", Kind: Number},
+	{Name: "delivery", Kind: Date},
+	{Name: "channel", Kind: Enum, Options: []string{"web", "store"}},
+	{Name: "notes", Kind: String},
+}}
+
+func TestNewRegistry(t *testing.T) {
+	t.Parallel()
+	_, err := NewRegistry(sales, sales)
+	assertErrors(t, err, NewRegistryError, ErrDuplicatedType)
+	_, err = NewRegistry(Type{Name: "t", Fields: []Field{{Name: "f", Kind: "Color"}}})
+	assertErrors(t, err, NewRegistryError, ErrUnknownKind)
+	_, err = NewRegistry(Type{Name: "t", Fields: []Field{{Name: "f", Kind: Enum}}})
+	assertErrors(t, err, NewRegistryError, ErrNoOptions)
+	_, err = NewRegistry(Type{Name: "t", Fields: []Field{{Name: "f", Kind: String}, {Name: "f", Kind: Number}}})
+	assertErrors(t, err, NewRegistryError, ErrDuplicatedField)
+}
+
+func TestRegistry_Validate(t *testing.T) {
+	t.Parallel()
+	registry, err := NewRegistry(sales)
+	if err != nil {
+		t.Fatalf("Error should be nil, got %v", err)
+	}
+
+	t.Run("Tickets with valid values are valid", func(t *testing.T) {
+		t.Parallel()
+		tck, _ := ticket.NewTypedTicket("sales", "title", "", map[string]string{
+			"order": uuid.NewString(), "amount": "-3.5", "delivery": "2024-03-04", "channel": "web", "notes": "gift",
+		})
+		if err := registry.Validate(tck); err != nil {
+			t.Errorf("Error should be nil, got %v", err)
+		}
+		untyped, _ := ticket.NewBasicTicket("title", "")
+		if err := registry.Validate(untyped); err != nil {
+			t.Errorf("Error should be nil, got %v", err)
+		}
+	})
+	t.Run("Every invalid field is reported", func(t *testing.T) {
+		t.Parallel()
+		tck, _ := ticket.NewTypedTicket("sales", "title", "", map[string]string{
+			"amount": "ten", "delivery": "04/03/2024", "channel": "phone", "color": "red",
+		})
+		err := registry.Validate(tck)
+		assertErrors(t, err, ValidateError, ErrMissingField, ErrInvalidValue, ErrUnknownField)
+	})
+	t.Run("The type must be registered", func(t *testing.T) {
+		t.Parallel()
+		tck, _ := ticket.NewTypedTicket("billing", "title", "", nil)
+		assertErrors(t, registry.Validate(tck), ValidateError, ErrUnknownType)
+		untyped, _ := ticket.NewBasicTicket("title", "")
+		untyped.SetField("order", uuid.NewString())
+		assertErrors(t, registry.Validate(untyped), ValidateError, ErrFieldsWithoutType)
+	})
+}
+
+func TestField_validate(t *testing.T) {
+	t.Parallel()
+	number := Field{Name: "amount", Kind: Number}
+	for _, test := range []struct {
+		value string
+		valid bool
+	}{
+		{"42", true},
+		{"-3.5", true},
+		{"+.5", true},
+		{"1e3", true},
+		{"", false},
+		{"ten", false},
+		{"NaN", false},
+		{"Inf", false},
+		{"-Infinity", false},
+		{"0x1p-2", false},
+		{"1e999", false},
+	} {
+		err := number.validate(test.value)
+		if test.valid && err != nil {
+			t.Errorf("Expected %q to be valid, got %v", test.value, err)
+		}
+		if !test.valid && !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("Expected %q to be invalid, got %v", test.value, err)
+		}
+	}
+}
+
+func assertErrors(t *testing.T, err error, expected ...error) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("Error should not be nil")
+	}
+	for _, e := range expected {
+		if !errors.Is(err, e) {
+			t.Errorf("Error should be %v, but is %s", e, err.Error())
+		}
+	}
+}
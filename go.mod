@@ -5,4 +5,5 @@ go 1.22.1
 require (
 	github.com/google/uuid v1.6.0
 	github.com/rogelioConsejo/golibs v0.5.1
+	golang.org/x/text v0.21.0
 )
@@ -0,0 +1,241 @@
+// Package attachment stores the files attached to the tickets and their responses, enforcing size and type limits,
+// lets only their uploaders attach them, and gives access to them only to the agents and to the clients that own the
+// tickets.
+package attachment
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"ticketTao/entities/ticket"
+)
+
+// Limits restrict the files that can be uploaded.
+type Limits struct {
+	// MaxSize is the maximum size of a file, in bytes.
+	MaxSize int64
+	// AllowedTypes are the MIME types accepted, a type can use a wildcard subtype, e.g. "image/*". A wildcard does
+	// not match the types that can run scripts, e.g. SVG images, those must be listed on their own.
+	AllowedTypes []string
+}
+
+// scriptableTypes are the types that browsers may run scripts from.
+var scriptableTypes = []string{"image/svg+xml"}
+
+// sniffedBytes is the length of the start of the content used to sniff its type.
+const sniffedBytes = 512
+
+// DefaultLimits accept images, PDFs and plain text files up to 10 MiB.
+var DefaultLimits = Limits{
+	MaxSize:      10 << 20,
+	AllowedTypes: []string{"image/*", "application/pdf", "text/plain", "text/csv"},
+}
+
+// File is a file being uploaded.
+type File struct {
+	Name string
+	// MIMEType is the declared type of the file, it may have parameters, e.g. "text/plain; charset=utf-8". The type is
+	// sniffed from the content, the declared one only tells apart the text types, e.g. CSV, sniffed as plain text.
+	MIMEType string
+	Content  io.Reader
+}
+
+// Service uploads the attachments and opens them for the users allowed to see them.
+type Service interface {
+	// Upload stores the file that the user uploads for a ticket and returns the attachment. Only the user can claim it,
+	// and only for that ticket.
+	Upload(uploader, ticketID uuid.UUID, file File) (ticket.Attachment, error)
+	ticket.UploadedAttachments
+	// OpenForClient opens an attachment of a ticket, or of its responses, if the client owns the ticket. The caller
+	// must close the content.
+	OpenForClient(client, ticketID, attachmentID uuid.UUID) (ticket.Attachment, io.ReadCloser, error)
+	// OpenForAgent opens an attachment of any ticket, or of its responses. The caller must close the content.
+	OpenForAgent(ticketID, attachmentID uuid.UUID) (ticket.Attachment, io.ReadCloser, error)
+}
+
+// NewService creates a Service that keeps the content in the store and who uploaded it in the uploads. The client
+// repository checks that the clients own the tickets they download from.
+func NewService(store BlobStore, uploads UploadStore, limits Limits, clients ticket.RepositoryClientReader, agents ticket.RepositoryAgentAccess) (Service, error) {
+	if store == nil {
+		return nil, errors.Join(NewServiceError, ErrNilBlobStore)
+	}
+	if uploads == nil {
+		return nil, errors.Join(NewServiceError, ErrNilUploadStore)
+	}
+	if clients == nil || agents == nil {
+		return nil, errors.Join(NewServiceError, ErrNilRepository)
+	}
+	if limits.MaxSize <= 0 {
+		return nil, errors.Join(NewServiceError, ErrInvalidMaxSize)
+	}
+	return basicService{store: store, uploads: uploads, limits: limits, clients: clients, agents: agents}, nil
+}
+
+type basicService struct {
+	store   BlobStore
+	uploads UploadStore
+	limits  Limits
+	clients ticket.RepositoryClientReader
+	agents  ticket.RepositoryAgentAccess
+}
+
+func (b basicService) Upload(uploader, ticketID uuid.UUID, file File) (ticket.Attachment, error) {
+	if uploader == uuid.Nil || ticketID == uuid.Nil {
+		return ticket.Attachment{}, errors.Join(UploadError, ErrNilUploadTarget)
+	}
+	if file.Name == "" {
+		return ticket.Attachment{}, errors.Join(UploadError, ErrEmptyName)
+	}
+	head := make([]byte, sniffedBytes)
+	n, err := io.ReadFull(file.Content, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return ticket.Attachment{}, errors.Join(UploadError, err)
+	}
+	head = head[:n]
+	mimeType := contentType(file.MIMEType, head)
+	if !b.limits.allows(mimeType) {
+		return ticket.Attachment{}, errors.Join(UploadError, fmt.Errorf("%w: %s", ErrTypeNotAllowed, mimeType))
+	}
+
+	id := uuid.New()
+	content := io.MultiReader(bytes.NewReader(head), file.Content)
+	size, checksum, err := b.put(id, content)
+	if err != nil {
+		return ticket.Attachment{}, errors.Join(UploadError, err)
+	}
+	upload := Upload{Uploader: uploader, TicketID: ticketID, Name: path.Base(file.Name), MIMEType: mimeType}
+	err = b.uploads.SaveUpload(id, upload)
+	if err != nil {
+		_ = b.store.Delete(id)
+		return ticket.Attachment{}, errors.Join(UploadError, err)
+	}
+	return upload.attachment(id, size, checksum), nil
+}
+
+// put stores the content, unless it is larger than the limit, and returns its size and checksum.
+func (b basicService) put(id uuid.UUID, content io.Reader) (int64, string, error) {
+	hash := sha256.New()
+	counter := &countingReader{reader: io.LimitReader(content, b.limits.MaxSize+1)}
+	err := b.store.Put(id, io.TeeReader(counter, hash))
+	if err != nil {
+		return 0, "", err
+	}
+	if counter.read > b.limits.MaxSize {
+		_ = b.store.Delete(id)
+		return 0, "", ErrTooLarge
+	}
+	return counter.read, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// ClaimAttachment describes the attachment from the stored content, rather than from what the user sends, if the
+// user uploaded it for the ticket.
+func (b basicService) ClaimAttachment(user, ticketID, attachmentID uuid.UUID) (ticket.Attachment, error) {
+	upload, err := b.uploads.GetUpload(attachmentID)
+	if err != nil {
+		return ticket.Attachment{}, errors.Join(ClaimError, err)
+	}
+	if upload.Uploader != user || upload.TicketID != ticketID {
+		return ticket.Attachment{}, errors.Join(ClaimError, ErrNotUploadedForTicket)
+	}
+	content, err := b.store.Get(attachmentID)
+	if err != nil {
+		return ticket.Attachment{}, errors.Join(ClaimError, err)
+	}
+	defer content.Close()
+	hash := sha256.New()
+	size, err := io.Copy(hash, content)
+	if err != nil {
+		return ticket.Attachment{}, errors.Join(ClaimError, err)
+	}
+	return upload.attachment(attachmentID, size, hex.EncodeToString(hash.Sum(nil))), nil
+}
+
+func (u Upload) attachment(id uuid.UUID, size int64, checksum string) ticket.Attachment {
+	return ticket.Attachment{ID: id, Name: u.Name, MIMEType: u.MIMEType, Size: size, Checksum: checksum}
+}
+
+func (b basicService) OpenForClient(client, ticketID, attachmentID uuid.UUID) (ticket.Attachment, io.ReadCloser, error) {
+	tck, err := b.clients.GetTicket(client, ticketID)
+	if err != nil {
+		return ticket.Attachment{}, nil, errors.Join(OpenError, err)
+	}
+	return b.open(tck, attachmentID)
+}
+
+func (b basicService) OpenForAgent(ticketID, attachmentID uuid.UUID) (ticket.Attachment, io.ReadCloser, error) {
+	tck, err := b.agents.GetTicket(ticketID)
+	if err != nil {
+		return ticket.Attachment{}, nil, errors.Join(OpenError, err)
+	}
+	return b.open(tck, attachmentID)
+}
+
+func (b basicService) open(tck ticket.Ticket, attachmentID uuid.UUID) (ticket.Attachment, io.ReadCloser, error) {
+	attachment, found := ticket.FindAttachment(tck, attachmentID)
+	if !found {
+		return ticket.Attachment{}, nil, errors.Join(OpenError, ErrAttachmentNotFound)
+	}
+	content, err := b.store.Get(attachmentID)
+	if err != nil {
+		return ticket.Attachment{}, nil, errors.Join(OpenError, err)
+	}
+	return attachment, content, nil
+}
+
+// contentType sniffs the type of the content. Text sniffed as plain text takes the declared text type, if any.
+func contentType(declared string, head []byte) string {
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	declaredType, _, err := mime.ParseMediaType(declared)
+	if err == nil && sniffed == "text/plain" && strings.HasPrefix(declaredType, "text/") {
+		return declaredType
+	}
+	return sniffed
+}
+
+func (l Limits) allows(mimeType string) bool {
+	for _, allowed := range l.AllowedTypes {
+		if allowed == mimeType {
+			return true
+		}
+		if matched, _ := path.Match(allowed, mimeType); matched && !slices.Contains(scriptableTypes, mimeType) {
+			return true
+		}
+	}
+	return false
+}
+
+type countingReader struct {
+	reader io.Reader
+	read   int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.read += int64(n)
+	return n, err
+}
+
+var NewServiceError error = errors.New("error creating attachment service")
+var UploadError error = errors.New("error uploading attachment")
+var OpenError error = errors.New("error opening attachment")
+var ClaimError error = errors.New("error claiming attachment")
+
+var ErrNilBlobStore error = errors.New("blob store cannot be nil")
+var ErrNilUploadStore error = errors.New("upload store cannot be nil")
+var ErrNilRepository error = errors.New("ticket repository cannot be nil")
+var ErrInvalidMaxSize error = errors.New("attachment maximum size must be positive")
+var ErrNilUploadTarget error = errors.New("attachment uploader and ticket cannot be nil")
+var ErrEmptyName error = errors.New("attachment name cannot be empty")
+var ErrTypeNotAllowed error = errors.New("attachment type is not allowed")
+var ErrTooLarge error = errors.New("attachment is too large")
+var ErrAttachmentNotFound error = errors.New("attachment not found in the ticket")
+var ErrNotUploadedForTicket error = errors.New("attachment was not uploaded by the user for the ticket")
@@ -0,0 +1,331 @@
+package attachment
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/google/uuid"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"ticketTao/entities/ticket"
+	"ticketTao/interactors/ticket/persistence/memory"
+	"ticketTao/interactors/ticket/repository"
+)
+
+func TestNewService(t *testing.T) {
+	t.Parallel()
+	t.Run("It should return an error when a dependency is missing", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewService(nil, NewMemoryUploadStore(), DefaultLimits, nil, nil)
+		assertErrors(t, err, NewServiceError, ErrNilBlobStore)
+		_, err = NewService(newTempStore(t), nil, DefaultLimits, nil, nil)
+		assertErrors(t, err, NewServiceError, ErrNilUploadStore)
+		_, err = NewService(newTempStore(t), NewMemoryUploadStore(), DefaultLimits, nil, nil)
+		assertErrors(t, err, NewServiceError, ErrNilRepository)
+	})
+	t.Run("It should return an error when the maximum size is not positive", func(t *testing.T) {
+		t.Parallel()
+		clients, agents := newRepositories(t)
+		_, err := NewService(newTempStore(t), NewMemoryUploadStore(), Limits{}, clients, agents)
+		assertErrors(t, err, NewServiceError, ErrInvalidMaxSize)
+	})
+}
+
+func TestService_Upload(t *testing.T) {
+	t.Parallel()
+	t.Run("An uploaded file is described by the attachment and kept in the store", func(t *testing.T) {
+		t.Parallel()
+		store := newTempStore(t)
+		service := newService(t, store, DefaultLimits)
+		content := "the logs of the failure"
+
+		attachment, err := service.Upload(uuid.New(), uuid.New(), File{Name: "../logs/app.txt", MIMEType: "text/plain; charset=utf-8", Content: strings.NewReader(content)})
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+
+		sum := sha256.Sum256([]byte(content))
+		expected := ticket.Attachment{ID: attachment.ID, Name: "app.txt", MIMEType: "text/plain", Size: int64(len(content)), Checksum: hex.EncodeToString(sum[:])}
+		if attachment != expected {
+			t.Errorf("Expected attachment %v, got %v", expected, attachment)
+		}
+		assertContent(t, store, attachment.ID, content)
+	})
+	t.Run("Only the allowed types can be uploaded", func(t *testing.T) {
+		t.Parallel()
+		service := newService(t, newTempStore(t), Limits{MaxSize: 100, AllowedTypes: []string{"image/*"}})
+		uploader, ticketID := uuid.New(), uuid.New()
+
+		attachment, err := service.Upload(uploader, ticketID, File{Name: "photo.jpg", MIMEType: "", Content: strings.NewReader(jpeg)})
+		if err != nil {
+			t.Errorf("Error should be nil, got %v", err)
+		}
+		if attachment.MIMEType != "image/jpeg" {
+			t.Errorf("Expected the type to be sniffed as image/jpeg, got %s", attachment.MIMEType)
+		}
+		_, err = service.Upload(uploader, ticketID, File{Name: "run.exe", MIMEType: "application/octet-stream", Content: strings.NewReader("exe")})
+		assertErrors(t, err, UploadError, ErrTypeNotAllowed)
+		_, err = service.Upload(uploader, ticketID, File{Name: "photo.jpg", MIMEType: "image/jpeg", Content: strings.NewReader("MZ\x90\x00\x03\x00")})
+		assertErrors(t, err, UploadError, ErrTypeNotAllowed)
+	})
+	t.Run("SVG images are not allowed by an image wildcard", func(t *testing.T) {
+		t.Parallel()
+		service := newService(t, newTempStore(t), Limits{MaxSize: 1000, AllowedTypes: []string{"image/*"}})
+
+		svg := `<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`
+		_, err := service.Upload(uuid.New(), uuid.New(), File{Name: "logo.svg", MIMEType: "image/svg+xml", Content: strings.NewReader(svg)})
+		assertErrors(t, err, UploadError, ErrTypeNotAllowed)
+		if DefaultLimits.allows("image/svg+xml") || !(Limits{AllowedTypes: []string{"image/svg+xml"}}).allows("image/svg+xml") {
+			t.Error("SVG images should only be allowed when they are listed")
+		}
+	})
+	t.Run("Text files take the declared text type", func(t *testing.T) {
+		t.Parallel()
+		service := newService(t, newTempStore(t), DefaultLimits)
+
+		attachment, err := service.Upload(uuid.New(), uuid.New(), File{Name: "orders.csv", MIMEType: "text/csv", Content: strings.NewReader("id,total\n1,10\n")})
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if attachment.MIMEType != "text/csv" {
+			t.Errorf("Expected the type text/csv, got %s", attachment.MIMEType)
+		}
+		_, err = service.Upload(uuid.New(), uuid.New(), File{Name: "page.html", MIMEType: "text/plain", Content: strings.NewReader("<html><script>alert(1)</script></html>")})
+		assertErrors(t, err, UploadError, ErrTypeNotAllowed)
+	})
+	t.Run("A file larger than the limit is rejected and not kept", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		store, _ := NewFileSystemBlobStore(dir)
+		service := newService(t, store, Limits{MaxSize: 4, AllowedTypes: []string{"text/plain"}})
+
+		_, err := service.Upload(uuid.New(), uuid.New(), File{Name: "big.txt", MIMEType: "text/plain", Content: strings.NewReader("12345")})
+		assertErrors(t, err, UploadError, ErrTooLarge)
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != 0 {
+			t.Errorf("Expected the store to be empty, got %d files", len(entries))
+		}
+
+		_, err = service.Upload(uuid.New(), uuid.New(), File{Name: "small.txt", MIMEType: "text/plain", Content: strings.NewReader("1234")})
+		if err != nil {
+			t.Errorf("A file of the maximum size should be accepted, got %v", err)
+		}
+	})
+}
+
+func TestService_ClaimAttachment(t *testing.T) {
+	t.Parallel()
+	service := newService(t, newTempStore(t), DefaultLimits)
+	uploader, ticketID := uuid.New(), uuid.New()
+	uploaded, err := service.Upload(uploader, ticketID, File{Name: "invoice.pdf", MIMEType: "application/pdf", Content: strings.NewReader(pdf)})
+	if err != nil {
+		t.Fatalf("Error should be nil, got %v", err)
+	}
+
+	t.Run("The uploader claims the attachment for its ticket, described from the stored content", func(t *testing.T) {
+		t.Parallel()
+		claimed, err := service.ClaimAttachment(uploader, ticketID, uploaded.ID)
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if claimed != uploaded {
+			t.Errorf("Expected %v, got %v", uploaded, claimed)
+		}
+	})
+	t.Run("Other users and other tickets cannot claim the attachment", func(t *testing.T) {
+		t.Parallel()
+		_, err := service.ClaimAttachment(uuid.New(), ticketID, uploaded.ID)
+		assertErrors(t, err, ClaimError, ErrNotUploadedForTicket)
+		_, err = service.ClaimAttachment(uploader, uuid.New(), uploaded.ID)
+		assertErrors(t, err, ClaimError, ErrNotUploadedForTicket)
+		_, err = service.ClaimAttachment(uploader, ticketID, uuid.New())
+		assertErrors(t, err, ClaimError, ErrUploadNotFound)
+	})
+	t.Run("Uploads need their uploader and ticket", func(t *testing.T) {
+		t.Parallel()
+		_, err := service.Upload(uuid.Nil, ticketID, File{Name: "invoice.pdf", Content: strings.NewReader(pdf)})
+		assertErrors(t, err, UploadError, ErrNilUploadTarget)
+		_, err = service.Upload(uploader, uuid.Nil, File{Name: "invoice.pdf", Content: strings.NewReader(pdf)})
+		assertErrors(t, err, UploadError, ErrNilUploadTarget)
+	})
+}
+
+func TestFileSystemUploadStore(t *testing.T) {
+	t.Parallel()
+	uploads, err := NewFileSystemUploadStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Error should be nil, got %v", err)
+	}
+	id := uuid.New()
+	upload := Upload{Uploader: uuid.New(), TicketID: uuid.New(), Name: "invoice.pdf", MIMEType: "application/pdf"}
+	if err := uploads.SaveUpload(id, upload); err != nil {
+		t.Fatalf("Error should be nil, got %v", err)
+	}
+	found, err := uploads.GetUpload(id)
+	if err != nil || found != upload {
+		t.Errorf("Expected %v, got %v with error %v", upload, found, err)
+	}
+	_, err = uploads.GetUpload(uuid.New())
+	assertErrors(t, err, ErrUploadNotFound)
+}
+
+func TestService_Open(t *testing.T) {
+	t.Parallel()
+	setup := func(t *testing.T) (Service, uuid.UUID, ticket.Ticket, ticket.Attachment) {
+		t.Helper()
+		clients, agents := newRepositories(t)
+		service, _ := NewService(newTempStore(t), NewMemoryUploadStore(), DefaultLimits, clients, agents)
+		owner := uuid.New()
+		tck, _ := ticket.NewBasicTicket("title", "description")
+		attachment, _ := service.Upload(owner, tck.ID(), File{Name: "invoice.pdf", MIMEType: "application/pdf", Content: strings.NewReader(pdf)})
+		tck.AddResponse(ticket.NewResponse(owner, "the invoice", attachment))
+		if err := clients.CreateNewTicketForClient(owner, tck); err != nil {
+			t.Fatalf("Error creating ticket: %v", err)
+		}
+		return service, owner, tck, attachment
+	}
+	t.Run("The owner of the ticket can download its attachments", func(t *testing.T) {
+		t.Parallel()
+		service, owner, tck, attachment := setup(t)
+
+		found, content, err := service.OpenForClient(owner, tck.ID(), attachment.ID)
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		defer content.Close()
+		read, _ := io.ReadAll(content)
+		if found != attachment || string(read) != pdf {
+			t.Errorf("Expected %v with the uploaded content, got %v with %q", attachment, found, read)
+		}
+	})
+	t.Run("Other clients cannot download the attachments", func(t *testing.T) {
+		t.Parallel()
+		service, _, tck, attachment := setup(t)
+
+		_, _, err := service.OpenForClient(uuid.New(), tck.ID(), attachment.ID)
+		assertErrors(t, err, OpenError, repository.ErrTicketNotAccessible)
+	})
+	t.Run("Attachments of other tickets cannot be opened through a ticket", func(t *testing.T) {
+		t.Parallel()
+		service, owner, tck, _ := setup(t)
+		other, _ := service.Upload(owner, tck.ID(), File{Name: "other.pdf", MIMEType: "application/pdf", Content: strings.NewReader(pdf)})
+
+		_, _, err := service.OpenForClient(owner, tck.ID(), other.ID)
+		assertErrors(t, err, OpenError, ErrAttachmentNotFound)
+		_, _, err = service.OpenForAgent(tck.ID(), other.ID)
+		assertErrors(t, err, OpenError, ErrAttachmentNotFound)
+	})
+	t.Run("Agents can download the attachments of any ticket", func(t *testing.T) {
+		t.Parallel()
+		service, _, tck, attachment := setup(t)
+
+		_, content, err := service.OpenForAgent(tck.ID(), attachment.ID)
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		_ = content.Close()
+	})
+}
+
+func TestFileSystemBlobStore(t *testing.T) {
+	t.Parallel()
+	t.Run("It should return an error when the directory is empty", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewFileSystemBlobStore("")
+		assertErrors(t, err, NewBlobStoreError, ErrEmptyDirectory)
+	})
+	t.Run("Blobs can be stored, read and deleted", func(t *testing.T) {
+		t.Parallel()
+		store := newTempStore(t)
+		id := uuid.New()
+
+		if err := store.Put(id, strings.NewReader("content")); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		assertContent(t, store, id, "content")
+		if err := store.Delete(id); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		_, err := store.Get(id)
+		assertErrors(t, err, ErrBlobNotFound)
+		assertErrors(t, store.Delete(id), ErrBlobNotFound)
+	})
+	t.Run("A failed write leaves nothing behind", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		store, _ := NewFileSystemBlobStore(dir)
+
+		err := store.Put(uuid.New(), io.MultiReader(strings.NewReader("partial"), failingReader{}))
+		assertErrors(t, err, errRead)
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != 0 {
+			t.Errorf("Expected the store to be empty, got %d files", len(entries))
+		}
+	})
+}
+
+func newTempStore(t *testing.T) BlobStore {
+	t.Helper()
+	store, err := NewFileSystemBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Error creating blob store: %v", err)
+	}
+	return store
+}
+
+func newRepositories(t *testing.T) (ticket.RepositoryClientAccess, ticket.RepositoryAgentAccess) {
+	t.Helper()
+	tp := memory.NewTicketPersistence()
+	clients, _ := repository.GetClientTicketRepository(tp)
+	agents, _ := repository.GetAgentTicketRepository(tp)
+	return clients, agents
+}
+
+func newService(t *testing.T, store BlobStore, limits Limits) Service {
+	t.Helper()
+	clients, agents := newRepositories(t)
+	service, err := NewService(store, NewMemoryUploadStore(), limits, clients, agents)
+	if err != nil {
+		t.Fatalf("Error creating service: %v", err)
+	}
+	return service
+}
+
+func assertContent(t *testing.T, store BlobStore, id uuid.UUID, expected string) {
+	t.Helper()
+	content, err := store.Get(id)
+	if err != nil {
+		t.Fatalf("Error reading blob: %v", err)
+	}
+	defer content.Close()
+	read, _ := io.ReadAll(content)
+	if string(read) != expected {
+		t.Errorf("Expected content %q, got %q", expected, read)
+	}
+}
+
+// jpeg and pdf start like the files of their types.
+const jpeg = "\xff\xd8\xff\xe0\x00\x10JFIF"
+const pdf = "%PDF-1.7"
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errRead
+}
+
+var errRead = errors.New("connection reset")
+
+func assertErrors(t *testing.T, err error, expected ...error) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("Error should not be nil")
+	}
+	for _, e := range expected {
+		if !errors.Is(err, e) {
+			t.Errorf("Error should be %v, but is %s", e, err.Error())
+		}
+	}
+}
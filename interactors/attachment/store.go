@@ -0,0 +1,80 @@
+package attachment
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// BlobStore keeps the content of the attachments, by attachment ID.
+type BlobStore interface {
+	// Put stores the content, it should not leave partial content behind when it fails.
+	Put(id uuid.UUID, content io.Reader) error
+	// Get opens the content, the caller must close it. It should return ErrBlobNotFound if there is no content.
+	Get(id uuid.UUID) (io.ReadCloser, error)
+	Delete(id uuid.UUID) error
+}
+
+// NewFileSystemBlobStore creates a BlobStore that keeps every blob in a file of the directory, which is created if it
+// does not exist.
+func NewFileSystemBlobStore(dir string) (BlobStore, error) {
+	if dir == "" {
+		return nil, errors.Join(NewBlobStoreError, ErrEmptyDirectory)
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, errors.Join(NewBlobStoreError, err)
+	}
+	return fileSystemBlobStore{dir: dir}, nil
+}
+
+type fileSystemBlobStore struct {
+	dir string
+}
+
+func (f fileSystemBlobStore) Put(id uuid.UUID, content io.Reader) error {
+	temp, err := os.CreateTemp(f.dir, ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	_, err = io.Copy(temp, content)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), f.path(id))
+}
+
+func (f fileSystemBlobStore) Get(id uuid.UUID) (io.ReadCloser, error) {
+	file, err := os.Open(f.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrBlobNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func (f fileSystemBlobStore) Delete(id uuid.UUID) error {
+	err := os.Remove(f.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrBlobNotFound, id)
+	}
+	return err
+}
+
+func (f fileSystemBlobStore) path(id uuid.UUID) string {
+	return filepath.Join(f.dir, id.String())
+}
+
+var NewBlobStoreError error = errors.New("error creating blob store")
+
+var ErrEmptyDirectory error = errors.New("blob store directory cannot be empty")
+var ErrBlobNotFound error = errors.New("blob not found")
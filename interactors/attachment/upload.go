@@ -0,0 +1,97 @@
+package attachment
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"sync"
+)
+
+// Upload records who uploaded an attachment and for which ticket, so that only its uploader can add it to that ticket.
+type Upload struct {
+	Uploader uuid.UUID `json:"uploader"`
+	TicketID uuid.UUID `json:"ticket_id"`
+	// Name is the base name of the uploaded file.
+	Name string `json:"name"`
+	// MIMEType is the type sniffed from the content.
+	MIMEType string `json:"mime_type"`
+}
+
+// UploadStore keeps the uploads, by attachment ID.
+type UploadStore interface {
+	SaveUpload(id uuid.UUID, upload Upload) error
+	// GetUpload returns the upload of the attachment, it should return ErrUploadNotFound if there is none.
+	GetUpload(id uuid.UUID) (Upload, error)
+}
+
+// NewMemoryUploadStore creates an UploadStore that keeps the uploads in memory.
+func NewMemoryUploadStore() UploadStore {
+	return &memoryUploadStore{uploads: make(map[uuid.UUID]Upload)}
+}
+
+type memoryUploadStore struct {
+	mu      sync.RWMutex
+	uploads map[uuid.UUID]Upload
+}
+
+func (m *memoryUploadStore) SaveUpload(id uuid.UUID, upload Upload) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.uploads[id] = upload
+	return nil
+}
+
+func (m *memoryUploadStore) GetUpload(id uuid.UUID) (Upload, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	upload, ok := m.uploads[id]
+	if !ok {
+		return Upload{}, fmt.Errorf("%w: %s", ErrUploadNotFound, id)
+	}
+	return upload, nil
+}
+
+// NewFileSystemUploadStore creates an UploadStore that keeps every upload in a JSON file of the directory, which is
+// created if it does not exist. It should not be the directory of the blobs.
+func NewFileSystemUploadStore(dir string) (UploadStore, error) {
+	files, err := NewFileSystemBlobStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	return fileSystemUploadStore{files: files}, nil
+}
+
+type fileSystemUploadStore struct {
+	files BlobStore
+}
+
+func (f fileSystemUploadStore) SaveUpload(id uuid.UUID, upload Upload) error {
+	content, err := json.Marshal(upload)
+	if err != nil {
+		return err
+	}
+	return f.files.Put(id, bytes.NewReader(content))
+}
+
+func (f fileSystemUploadStore) GetUpload(id uuid.UUID) (Upload, error) {
+	file, err := f.files.Get(id)
+	if errors.Is(err, ErrBlobNotFound) {
+		return Upload{}, fmt.Errorf("%w: %s", ErrUploadNotFound, id)
+	}
+	if err != nil {
+		return Upload{}, err
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return Upload{}, err
+	}
+	var upload Upload
+	err = json.Unmarshal(content, &upload)
+	return upload, err
+}
+
+var ErrUploadNotFound error = errors.New("upload not found")
@@ -0,0 +1,234 @@
+// Package autoclose contains a job that closes the tickets left waiting on the client. The client is reminded first,
+// and the ticket is closed with the ticket.NoResponse reason if they still do not answer. The job is a scheduler.Job,
+// so it can be run periodically.
+package autoclose
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"strings"
+	"sync"
+	"ticketTao/entities/calendar"
+	"ticketTao/entities/ticket"
+	"ticketTao/interactors/scheduler"
+	"time"
+)
+
+// Policy configures when the tickets waiting on the client are reminded and closed.
+type Policy struct {
+	// RemindAfter is how long the last agent response waits for the client before the client is reminded.
+	RemindAfter time.Duration
+	// CloseAfter is how long the ticket waits after the reminder before it is closed.
+	CloseAfter time.Duration
+	// Calendar measures the waiting time, it uses wall-clock time when it is nil.
+	Calendar calendar.Calendar
+	// Author writes the closing response, usually a system user.
+	Author uuid.UUID
+	// Message is the content of the closing response, it cannot be empty.
+	Message string
+}
+
+// Reminder is sent to the client of a ticket that is about to be closed.
+type Reminder struct {
+	Ticket ticket.Ticket
+	Client uuid.UUID
+	// WaitingSince is the time of the agent response the client did not answer, a ticket is reminded only once per
+	// waiting period.
+	WaitingSince time.Time
+	At           time.Time
+	ClosesAt     time.Time
+}
+
+// ReminderSender delivers reminders, e.g. by email.
+type ReminderSender interface {
+	SendReminder(Reminder) error
+}
+
+// ReminderLog remembers the reminders that were sent.
+type ReminderLog interface {
+	// RemindedAt returns when the ticket was reminded during the waiting period, and false if it was not.
+	RemindedAt(ticket uuid.UUID, waitingSince time.Time) (time.Time, bool)
+	Record(Reminder)
+}
+
+// TicketRepository is the access to the tickets needed by the job.
+type TicketRepository interface {
+	ticket.RepositoryAgentAccess
+	ticket.RepositoryAgentLister
+}
+
+// Result lists the tickets reminded and closed by a run of the job.
+type Result struct {
+	Reminded []uuid.UUID
+	Closed   []uuid.UUID
+}
+
+// NewMemoryReminderLog creates an empty in-memory ReminderLog.
+func NewMemoryReminderLog() ReminderLog {
+	return &memoryReminderLog{reminders: make(map[reminderKey]time.Time)}
+}
+
+// NewJob creates a Job that applies the policy to the open tickets of the repository.
+func NewJob(repository TicketRepository, policy Policy, sender ReminderSender, clock scheduler.Clock, log ReminderLog) (Job, error) {
+	if repository == nil {
+		return nil, errors.Join(NewJobError, ErrNilRepository)
+	}
+	if sender == nil {
+		return nil, errors.Join(NewJobError, ErrNilReminderSender)
+	}
+	if clock == nil {
+		return nil, errors.Join(NewJobError, scheduler.ErrNilClock)
+	}
+	if log == nil {
+		return nil, errors.Join(NewJobError, ErrNilReminderLog)
+	}
+	if policy.RemindAfter <= 0 || policy.CloseAfter <= 0 {
+		return nil, errors.Join(NewJobError, ErrInvalidWaitingTime)
+	}
+	if policy.Author == uuid.Nil {
+		return nil, errors.Join(NewJobError, ErrNilAuthor)
+	}
+	if strings.TrimSpace(policy.Message) == "" {
+		return nil, errors.Join(NewJobError, ErrEmptyMessage)
+	}
+	if policy.Calendar == nil {
+		policy.Calendar = calendar.WallClock()
+	}
+	return basicJob{repository: repository, policy: policy, sender: sender, clock: clock, log: log}, nil
+}
+
+// Job reminds and closes the tickets waiting on the client.
+type Job interface {
+	scheduler.Job
+	// Process applies the policy once. A failing ticket does not stop the processing of the rest, its error is joined
+	// to the returned error.
+	Process() (Result, error)
+}
+
+type basicJob struct {
+	repository TicketRepository
+	policy     Policy
+	sender     ReminderSender
+	clock      scheduler.Clock
+	log        ReminderLog
+}
+
+func (b basicJob) Run() error {
+	_, err := b.Process()
+	return err
+}
+
+func (b basicJob) Process() (Result, error) {
+	tickets, err := b.repository.GetOpenTickets()
+	if err != nil {
+		return Result{}, errors.Join(ProcessError, err)
+	}
+	var result Result
+	var failures []error
+	for _, tck := range tickets {
+		reminded, closed, err := b.processTicket(tck)
+		if err != nil {
+			failures = append(failures, fmt.Errorf("ticket %s: %w", tck.ID(), err))
+		}
+		if reminded {
+			result.Reminded = append(result.Reminded, tck.ID())
+		}
+		if closed {
+			result.Closed = append(result.Closed, tck.ID())
+		}
+	}
+	if len(failures) > 0 {
+		return result, errors.Join(ProcessError, errors.Join(failures...))
+	}
+	return result, nil
+}
+
+func (b basicJob) processTicket(tck ticket.Ticket) (reminded bool, closed bool, err error) {
+	last := lastPublicResponse(tck)
+	if last == nil {
+		return false, false, nil
+	}
+	owner, err := b.repository.GetTicketOwner(tck.ID())
+	if err != nil {
+		return false, false, err
+	}
+	if last.UserId() == owner {
+		return false, false, nil
+	}
+	now := b.clock.Now()
+	since := last.TimeStamp()
+	remindedAt, wasReminded := b.log.RemindedAt(tck.ID(), since)
+	if !wasReminded {
+		if b.policy.Calendar.BusinessTimeBetween(since, now) < b.policy.RemindAfter {
+			return false, false, nil
+		}
+		reminder := Reminder{
+			Ticket:       tck,
+			Client:       owner,
+			WaitingSince: since,
+			At:           now,
+			ClosesAt:     b.policy.Calendar.AddBusinessTime(now, b.policy.CloseAfter),
+		}
+		if err := b.sender.SendReminder(reminder); err != nil {
+			return false, false, fmt.Errorf("%w: %w", ErrSendingReminder, err)
+		}
+		b.log.Record(reminder)
+		return true, false, nil
+	}
+	if b.policy.Calendar.BusinessTimeBetween(remindedAt, now) < b.policy.CloseAfter {
+		return false, false, nil
+	}
+	tck.AddResponse(ticket.MakeNote(b.policy.Author, b.policy.Message, now))
+	tck.CloseWithReason(ticket.NoResponse)
+	if err := b.repository.UpdateTicket(tck); err != nil {
+		return false, false, err
+	}
+	return false, true, nil
+}
+
+// lastPublicResponse returns the latest response of the ticket that is not a note, or nil if there is none. The notes
+// are not seen by the client, so the client is not waited on because of them.
+func lastPublicResponse(tck ticket.Ticket) ticket.Response {
+	responses := tck.Responses()
+	for i := len(responses) - 1; i >= 0; i-- {
+		if !responses[i].IsNote() {
+			return responses[i]
+		}
+	}
+	return nil
+}
+
+type reminderKey struct {
+	ticket       uuid.UUID
+	waitingSince time.Time
+}
+
+type memoryReminderLog struct {
+	mu        sync.RWMutex
+	reminders map[reminderKey]time.Time
+}
+
+func (m *memoryReminderLog) RemindedAt(ticket uuid.UUID, waitingSince time.Time) (time.Time, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	at, ok := m.reminders[reminderKey{ticket, waitingSince.UTC()}]
+	return at, ok
+}
+
+func (m *memoryReminderLog) Record(reminder Reminder) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reminders[reminderKey{reminder.Ticket.ID(), reminder.WaitingSince.UTC()}] = reminder.At
+}
+
+var NewJobError error = errors.New("error creating auto-close job")
+var ProcessError error = errors.New("error auto-closing tickets")
+
+var ErrNilRepository error = errors.New("ticket repository cannot be nil")
+var ErrNilReminderSender error = errors.New("reminder sender cannot be nil")
+var ErrNilReminderLog error = errors.New("reminder log cannot be nil")
+var ErrInvalidWaitingTime error = errors.New("auto-close waiting times must be positive")
+var ErrNilAuthor error = errors.New("closing response author cannot be nil")
+var ErrEmptyMessage error = errors.New("closing response message cannot be empty")
+var ErrSendingReminder error = errors.New("error sending auto-close reminder")
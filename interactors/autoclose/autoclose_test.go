@@ -0,0 +1,278 @@
+package autoclose
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"testing"
+	"ticketTao/entities"
+	"ticketTao/entities/agent"
+	"ticketTao/entities/ticket"
+	"time"
+)
+
+func TestNewJob(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{}
+	policy := Policy{RemindAfter: time.Hour, CloseAfter: time.Hour, Author: uuid.New()}
+	t.Run("It should return an error when a dependency is missing", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewJob(nil, policy, &spySender{}, clock, NewMemoryReminderLog())
+		assertErrors(t, err, NewJobError, ErrNilRepository)
+		_, err = NewJob(newFakeRepository(), policy, nil, clock, NewMemoryReminderLog())
+		assertErrors(t, err, NewJobError, ErrNilReminderSender)
+		_, err = NewJob(newFakeRepository(), policy, &spySender{}, clock, nil)
+		assertErrors(t, err, NewJobError, ErrNilReminderLog)
+	})
+	t.Run("It should validate the policy", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewJob(newFakeRepository(), Policy{RemindAfter: time.Hour, Author: uuid.New()}, &spySender{}, clock, NewMemoryReminderLog())
+		assertErrors(t, err, NewJobError, ErrInvalidWaitingTime)
+		_, err = NewJob(newFakeRepository(), Policy{RemindAfter: time.Hour, CloseAfter: time.Hour}, &spySender{}, clock, NewMemoryReminderLog())
+		assertErrors(t, err, NewJobError, ErrNilAuthor)
+		_, err = NewJob(newFakeRepository(), Policy{RemindAfter: time.Hour, CloseAfter: time.Hour, Author: uuid.New(), Message: " "}, &spySender{}, clock, NewMemoryReminderLog())
+		assertErrors(t, err, NewJobError, ErrEmptyMessage)
+	})
+}
+
+func TestJob_Process(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	system := uuid.New()
+	policy := Policy{RemindAfter: 5 * day, CloseAfter: 2 * day, Author: system, Message: "Closed without response"}
+
+	t.Run("A ticket waiting on the client is reminded and then closed for no response", func(t *testing.T) {
+		t.Parallel()
+		clock := &fakeClock{now: start.Add(5 * day)}
+		repository := newFakeRepository()
+		repository.clock = clock
+		owner := uuid.New()
+		tck := repository.add(t, owner, start)
+		tck.AddResponse(ticket.MakeResponse(uuid.New(), "Could you send the invoice?", start))
+		sender := &spySender{}
+		job := makeJob(t, repository, policy, sender, clock)
+
+		result, err := job.Process()
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if len(result.Reminded) != 1 || len(result.Closed) != 0 {
+			t.Fatalf("Expected the ticket to be reminded, got %v", result)
+		}
+		if len(sender.sent) != 1 || sender.sent[0].Client != owner || !sender.sent[0].ClosesAt.Equal(start.Add(7*day)) {
+			t.Errorf("Expected a reminder to the client announcing the closing time, got %v", sender.sent)
+		}
+
+		clock.now = start.Add(6 * day)
+		result, _ = job.Process()
+		if len(result.Reminded) != 0 || len(result.Closed) != 0 || len(sender.sent) != 1 {
+			t.Errorf("Nothing should happen before the closing time, got %v", result)
+		}
+
+		clock.now = start.Add(7 * day)
+		result, err = job.Process()
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if len(result.Closed) != 1 || result.Closed[0] != tck.ID() {
+			t.Fatalf("Expected the ticket to be closed, got %v", result)
+		}
+		closed := repository.tickets[tck.ID()]
+		if closed.Status() != ticket.Closed || closed.ClosingReason() != ticket.NoResponse {
+			t.Errorf("Expected the ticket to be closed for no response, got %s, %s", closed.Status(), closed.ClosingReason())
+		}
+		last := closed.Responses()[len(closed.Responses())-1]
+		if last.UserId() != system || last.Content() != policy.Message || !last.TimeStamp().Equal(clock.now) {
+			t.Errorf("Expected the closing response of the policy, got %v", last)
+		}
+		if !closed.ClosedAt().Equal(clock.now) {
+			t.Errorf("Expected the ticket to be closed at %v, got %v", clock.now, closed.ClosedAt())
+		}
+	})
+	t.Run("A client answer after the reminder keeps the ticket open", func(t *testing.T) {
+		t.Parallel()
+		repository := newFakeRepository()
+		owner := uuid.New()
+		tck := repository.add(t, owner, start)
+		tck.AddResponse(ticket.MakeResponse(uuid.New(), "Could you send the invoice?", start))
+		clock := &fakeClock{now: start.Add(5 * day)}
+		job := makeJob(t, repository, policy, &spySender{}, clock)
+		_, _ = job.Process()
+
+		tck.AddResponse(ticket.MakeResponse(owner, "Here it is", start.Add(6*day)))
+		clock.now = start.Add(10 * day)
+		result, _ := job.Process()
+
+		if len(result.Closed) != 0 || repository.tickets[tck.ID()].Status() == ticket.Closed {
+			t.Errorf("The ticket should not be closed, got %v", result)
+		}
+	})
+	t.Run("Tickets without public responses or waiting on an agent are ignored", func(t *testing.T) {
+		t.Parallel()
+		repository := newFakeRepository()
+		owner := uuid.New()
+		repository.add(t, owner, start)
+		waitingOnAgent := repository.add(t, owner, start)
+		waitingOnAgent.AddResponse(ticket.MakeResponse(owner, "Any news?", start))
+		noted := repository.add(t, owner, start)
+		noted.AddResponse(ticket.MakeNote(uuid.New(), "Asked billing", start))
+		notedAfterClient := repository.add(t, owner, start)
+		notedAfterClient.AddResponse(ticket.MakeResponse(owner, "Any news?", start))
+		notedAfterClient.AddResponse(ticket.MakeNote(uuid.New(), "Still checking", start.Add(time.Hour)))
+		sender := &spySender{}
+		job := makeJob(t, repository, policy, sender, &fakeClock{now: start.Add(30 * day)})
+
+		result, err := job.Process()
+
+		if err != nil || len(result.Reminded) != 0 || len(sender.sent) != 0 {
+			t.Errorf("No ticket should be reminded, got %v, %v", result, err)
+		}
+	})
+	t.Run("A split ticket that no agent answered is not reminded nor closed", func(t *testing.T) {
+		t.Parallel()
+		clock := &fakeClock{now: start}
+		repository := newFakeRepository()
+		repository.clock = clock
+		owner := uuid.New()
+		original := repository.add(t, owner, start)
+		original.AddResponse(ticket.MakeResponse(owner, "My invoice is wrong and I cannot log in", start))
+		agents, _ := agent.NewTicketAgentFactoryWithSources(repository, clock, entities.RandomIDSource())
+		splitter, _ := agents.InstantiateTicketSplitterAgent(uuid.New(), start, repository, repository)
+		created, err := splitter.SplitTicket(original.ID(), agent.SplitPart{Title: "Login", Responses: []int{0}})
+		if err != nil || len(created) != 1 {
+			t.Fatalf("Expected the ticket to be split, got %v, %v", created, err)
+		}
+		sender := &spySender{}
+		job := makeJob(t, repository, policy, sender, clock)
+
+		for _, now := range []time.Time{start.Add(5 * day), start.Add(7 * day)} {
+			clock.now = now
+			result, err := job.Process()
+			if err != nil || len(result.Reminded) != 0 || len(result.Closed) != 0 || len(sender.sent) != 0 {
+				t.Errorf("The tickets wait on an agent and should be left alone, got %v, %v", result, err)
+			}
+		}
+	})
+	t.Run("A failing reminder does not stop the processing of other tickets", func(t *testing.T) {
+		t.Parallel()
+		repository := newFakeRepository()
+		for range 2 {
+			tck := repository.add(t, uuid.New(), start)
+			tck.AddResponse(ticket.MakeResponse(uuid.New(), "Could you send the invoice?", start))
+		}
+		sender := &spySender{err: errors.New("smtp is down")}
+		job := makeJob(t, repository, policy, sender, &fakeClock{now: start.Add(5 * day)})
+
+		result, err := job.Process()
+
+		assertErrors(t, err, ProcessError, ErrSendingReminder)
+		if len(sender.sent) != 2 || len(result.Reminded) != 0 {
+			t.Errorf("Expected both tickets to be attempted and none reminded, got %d, %v", len(sender.sent), result)
+		}
+	})
+}
+
+func makeJob(t *testing.T, repository TicketRepository, policy Policy, sender ReminderSender, clock *fakeClock) Job {
+	t.Helper()
+	job, err := NewJob(repository, policy, sender, clock, NewMemoryReminderLog())
+	if err != nil {
+		t.Fatalf("Error creating job: %v", err)
+	}
+	return job
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	return f.now
+}
+
+func (f *fakeClock) After(time.Duration) <-chan time.Time {
+	return make(chan time.Time)
+}
+
+type spySender struct {
+	sent []Reminder
+	err  error
+}
+
+func (s *spySender) SendReminder(reminder Reminder) error {
+	s.sent = append(s.sent, reminder)
+	return s.err
+}
+
+type fakeRepository struct {
+	tickets map[uuid.UUID]ticket.Ticket
+	owners  map[uuid.UUID]uuid.UUID
+	order   []uuid.UUID
+	// clock is the clock of the added tickets, they use the system time when it is nil.
+	clock entities.Clock
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{tickets: make(map[uuid.UUID]ticket.Ticket), owners: make(map[uuid.UUID]uuid.UUID)}
+}
+
+func (f *fakeRepository) add(t *testing.T, owner uuid.UUID, created time.Time) ticket.Ticket {
+	t.Helper()
+	factory := ticket.SystemFactory()
+	if f.clock != nil {
+		factory, _ = ticket.NewFactory(f.clock, entities.RandomIDSource())
+	}
+	tck, err := factory.MakeTicket(uuid.New(), created, ticket.Data{Title: "title", Status: ticket.Open})
+	if err != nil {
+		t.Fatalf("Error creating ticket: %v", err)
+	}
+	f.tickets[tck.ID()] = tck
+	f.owners[tck.ID()] = owner
+	f.order = append(f.order, tck.ID())
+	return tck
+}
+
+func (f *fakeRepository) GetTicket(id uuid.UUID) (ticket.Ticket, error) {
+	return f.tickets[id], nil
+}
+
+func (f *fakeRepository) UpdateTicket(tck ticket.Ticket) error {
+	f.tickets[tck.ID()] = tck
+	return nil
+}
+
+func (f *fakeRepository) GetOpenTickets() ([]ticket.Ticket, error) {
+	var open []ticket.Ticket
+	for _, id := range f.order {
+		if f.tickets[id].Status() != ticket.Closed {
+			open = append(open, f.tickets[id])
+		}
+	}
+	return open, nil
+}
+
+func (f *fakeRepository) CreateNewTicketForClient(client uuid.UUID, tck ticket.Ticket) error {
+	f.tickets[tck.ID()] = tck
+	f.owners[tck.ID()] = client
+	f.order = append(f.order, tck.ID())
+	return nil
+}
+
+func (f *fakeRepository) UpdateTicketForClient(_ uuid.UUID, tck ticket.Ticket) error {
+	return f.UpdateTicket(tck)
+}
+
+func (f *fakeRepository) GetTicketOwner(id uuid.UUID) (uuid.UUID, error) {
+	return f.owners[id], nil
+}
+
+func assertErrors(t *testing.T, err error, expected ...error) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("Error should not be nil")
+	}
+	for _, e := range expected {
+		if !errors.Is(err, e) {
+			t.Errorf("Error should be %v, but is %s", e, err.Error())
+		}
+	}
+}
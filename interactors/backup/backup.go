@@ -0,0 +1,279 @@
+// Package backup exports every ticket to a versioned archive, for backups and compliance, and restores them.
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"ticketTao/entities"
+	"ticketTao/entities/ticket"
+	"ticketTao/interactors/ticket/repository"
+	"time"
+)
+
+// FormatName identifies the archives of this package, in their header.
+const FormatName = "tickettao-backup"
+
+// Version is the version of the archives written by this package. Restoring an archive of another version fails.
+const Version = 1
+
+// Format is the layout of an archive.
+type Format string
+
+const (
+	// JSONLines archives have the header on their first line and a ticket entry on each following line.
+	JSONLines Format = "jsonl"
+	// Tar archives have the header in HeaderFile followed by a file per ticket entry, under TicketsDirectory.
+	Tar Format = "tar"
+)
+
+// HeaderFile and TicketsDirectory are the paths of the files of the Tar archives.
+const (
+	HeaderFile       = "backup.json"
+	TicketsDirectory = "tickets/"
+)
+
+// Filter restricts an export to the tickets of a client and to a range of creation times, the zero values do not
+// restrict it.
+type Filter struct {
+	Client uuid.UUID `json:"client"`
+	// From and To bound the creation time, the former inclusive and the latter exclusive.
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// Header describes an archive.
+type Header struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	Filter     Filter    `json:"filter"`
+}
+
+// Entry is a ticket in an archive, with its owner.
+type Entry struct {
+	ID        uuid.UUID `json:"id"`
+	Owner     uuid.UUID `json:"owner"`
+	CreatedAt time.Time `json:"created_at"`
+	ticket.Data
+	// Responses replaces the responses of the data, which cannot be encoded.
+	Responses []Response `json:"responses"`
+	// Links are the canonical links from the ticket, so a filtered archive only has the links from its tickets.
+	Links []ticket.Link `json:"links,omitempty"`
+}
+
+// Response is a response of a ticket in an archive.
+type Response struct {
+	UserID      uuid.UUID           `json:"user_id"`
+	Content     string              `json:"content"`
+	TimeStamp   time.Time           `json:"timestamp"`
+	Attachments []ticket.Attachment `json:"attachments"`
+	Note        bool                `json:"note,omitempty"`
+}
+
+// EntryFrom returns the entry of a ticket owned by the client, without its links.
+func EntryFrom(tck ticket.Ticket, owner uuid.UUID) Entry {
+	entry := Entry{ID: tck.ID(), Owner: owner, CreatedAt: tck.CreatedAt(), Data: ticket.DataFrom(tck)}
+	entry.Data.Responses = nil
+	for _, response := range tck.Responses() {
+		entry.Responses = append(entry.Responses, ResponseFrom(response))
+	}
+	return entry
+}
+
+// ResponseFrom returns the archived form of a response.
+func ResponseFrom(response ticket.Response) Response {
+	return Response{
+		UserID:      response.UserId(),
+		Content:     response.Content(),
+		TimeStamp:   response.TimeStamp(),
+		Attachments: response.Attachments(),
+		Note:        response.IsNote(),
+	}
+}
+
+func (r Response) response() ticket.Response {
+	if r.Note {
+		return ticket.MakeNote(r.UserID, r.Content, r.TimeStamp, r.Attachments...)
+	}
+	return ticket.MakeResponse(r.UserID, r.Content, r.TimeStamp, r.Attachments...)
+}
+
+// Ticket rebuilds the ticket of the entry, with the validations of ticket.MakeBasicTicket.
+func (e Entry) Ticket() (ticket.Ticket, error) {
+	data := e.Data
+	data.Responses = nil
+	for _, response := range e.Responses {
+		data.Responses = append(data.Responses, response.response())
+	}
+	return ticket.MakeBasicTicket(e.ID, e.CreatedAt, data)
+}
+
+// Exporter writes the tickets of a persistence driver to archives.
+type Exporter interface {
+	// Export writes the tickets matching the filter, oldest first, and returns how many were written. The tickets are
+	// read a page at a time, so the archive is streamed.
+	Export(w io.Writer, format Format, filter Filter) (int, error)
+}
+
+// NewExporter creates an Exporter that reads the tickets and their links from the persistence drivers.
+func NewExporter(tp repository.TicketPersistence, rp repository.RelationPersistence) (Exporter, error) {
+	return NewExporterWithClock(tp, rp, entities.SystemClock())
+}
+
+// NewExporterWithClock works like NewExporter, but the archives are dated with the clock.
+func NewExporterWithClock(tp repository.TicketPersistence, rp repository.RelationPersistence, clock entities.Clock) (Exporter, error) {
+	if tp == nil {
+		return nil, errors.Join(NewExporterError, repository.NilPersistenceDriverError)
+	}
+	if rp == nil {
+		return nil, errors.Join(NewExporterError, repository.ErrNilRelationPersistence)
+	}
+	if clock == nil {
+		return nil, errors.Join(NewExporterError, entities.ErrNilClock)
+	}
+	return basicExporter{persistence: tp, relations: rp, clock: clock}, nil
+}
+
+type basicExporter struct {
+	persistence repository.TicketPersistence
+	relations   repository.RelationPersistence
+	clock       entities.Clock
+}
+
+func (b basicExporter) Export(w io.Writer, format Format, filter Filter) (int, error) {
+	query := ticket.Query{
+		Client:      filter.Client,
+		CreatedFrom: filter.From,
+		CreatedTo:   filter.To,
+		Sort:        ticket.OldestFirst,
+		Limit:       ticket.MaxPageSize,
+	}
+	if err := query.Validate(); err != nil {
+		return 0, errors.Join(ExportError, err)
+	}
+	writer, err := newEntryWriter(w, format)
+	if err != nil {
+		return 0, errors.Join(ExportError, err)
+	}
+	if err := writer.writeHeader(Header{FormatName, Version, b.clock.Now().UTC(), filter}); err != nil {
+		return 0, errors.Join(ExportError, err)
+	}
+	count := 0
+	for {
+		page, err := b.persistence.QueryTickets(query)
+		if err != nil {
+			return count, errors.Join(ExportError, err)
+		}
+		for _, tck := range page.Tickets {
+			owner, err := b.persistence.GetTicketOwner(tck.ID())
+			if err != nil {
+				return count, errors.Join(ExportError, err)
+			}
+			entry := EntryFrom(tck, owner)
+			if entry.Links, err = b.linksFrom(tck.ID()); err != nil {
+				return count, errors.Join(ExportError, err)
+			}
+			if err := writer.writeEntry(entry); err != nil {
+				return count, errors.Join(ExportError, err)
+			}
+			count++
+		}
+		if page.Next == "" {
+			break
+		}
+		query.After = page.Next
+	}
+	if err := writer.close(); err != nil {
+		return count, errors.Join(ExportError, err)
+	}
+	return count, nil
+}
+
+// linksFrom returns the links stored from the ticket, the links to it are in the entries of the other tickets.
+func (b basicExporter) linksFrom(id uuid.UUID) ([]ticket.Link, error) {
+	links, err := b.relations.GetLinks(id)
+	if err != nil {
+		return nil, err
+	}
+	var from []ticket.Link
+	for _, link := range links {
+		if link.From == id {
+			from = append(from, link)
+		}
+	}
+	return from, nil
+}
+
+type entryWriter interface {
+	writeHeader(Header) error
+	writeEntry(Entry) error
+	close() error
+}
+
+func newEntryWriter(w io.Writer, format Format) (entryWriter, error) {
+	switch format {
+	case JSONLines:
+		buffered := bufio.NewWriter(w)
+		return jsonLinesWriter{buffered, json.NewEncoder(buffered)}, nil
+	case Tar:
+		return tarWriter{tar.NewWriter(w)}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+type jsonLinesWriter struct {
+	buffered *bufio.Writer
+	encoder  *json.Encoder
+}
+
+func (j jsonLinesWriter) writeHeader(header Header) error {
+	return j.encoder.Encode(header)
+}
+
+func (j jsonLinesWriter) writeEntry(entry Entry) error {
+	return j.encoder.Encode(entry)
+}
+
+func (j jsonLinesWriter) close() error {
+	return j.buffered.Flush()
+}
+
+type tarWriter struct {
+	writer *tar.Writer
+}
+
+func (t tarWriter) writeFile(name string, value any, modified time.Time) error {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	header := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), ModTime: modified, Typeflag: tar.TypeReg}
+	if err := t.writer.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = t.writer.Write(content)
+	return err
+}
+
+func (t tarWriter) writeHeader(header Header) error {
+	return t.writeFile(HeaderFile, header, header.ExportedAt)
+}
+
+func (t tarWriter) writeEntry(entry Entry) error {
+	return t.writeFile(TicketsDirectory+entry.ID.String()+".json", entry, entry.CreatedAt)
+}
+
+func (t tarWriter) close() error {
+	return t.writer.Close()
+}
+
+var NewExporterError error = errors.New("error creating ticket exporter")
+var ExportError error = errors.New("error exporting tickets")
+
+var ErrUnknownFormat error = errors.New("unknown archive format")
@@ -0,0 +1,203 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"strings"
+	"testing"
+	"ticketTao/entities"
+	"ticketTao/entities/ticket"
+	"ticketTao/interactors/ticket/persistence/memory"
+	"ticketTao/interactors/ticket/repository"
+	"time"
+)
+
+var created = time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC)
+
+func TestExportAndRestore(t *testing.T) {
+	t.Parallel()
+	source := memory.NewTicketPersistence()
+	alice, bob := uuid.New(), uuid.New()
+	first := saveTicket(t, source, alice, created, func(tck ticket.Ticket) {
+		tck.AddResponse(ticket.MakeResponse(alice, "hello", created.Add(time.Hour), ticket.Attachment{ID: uuid.New(), Name: "a.pdf"}))
+		tck.AddTag("billing")
+		tck.MergeResponses(ticket.MakeNote(bob, "merged", created.Add(2*time.Hour)))
+		tck.Close()
+	})
+	other := saveTicket(t, source, bob, created.Add(time.Hour), nil)
+	child := saveTicket(t, source, alice, created.Add(2*time.Hour), nil)
+	saveTicket(t, source, alice, created.Add(48*time.Hour), nil)
+	relations := memory.NewRelationPersistence()
+	_ = relations.SaveLink(ticket.Link{From: first.ID(), Kind: ticket.ParentOf, To: child.ID()})
+	_ = relations.SaveLink(ticket.Link{From: first.ID(), Kind: ticket.Blocks, To: other.ID()})
+	exporter, _ := NewExporter(source, relations)
+
+	for _, format := range []Format{JSONLines, Tar} {
+		t.Run("It should restore the exported tickets from a "+string(format)+" archive", func(t *testing.T) {
+			t.Parallel()
+			var archive bytes.Buffer
+			count, err := exporter.Export(&archive, format, Filter{Client: alice, To: created.Add(24 * time.Hour)})
+			if err != nil || count != 2 {
+				t.Fatalf("Expected two tickets to be exported, got %d, %v", count, err)
+			}
+			target, targetRelations := memory.NewTicketPersistence(), memory.NewRelationPersistence()
+			restorer, _ := NewRestorer(target, targetRelations)
+
+			summary, err := restorer.Restore(&archive, format)
+
+			if err != nil || summary.Restored != 2 {
+				t.Fatalf("Expected the tickets to be restored, got %+v, %v", summary, err)
+			}
+			if len(summary.Errors) != 1 || summary.Errors[0].Position != 1 || summary.Errors[0].ID != first.ID() {
+				t.Errorf("Expected the link to the ticket left out of the archive to be reported, got %v", summary.Errors)
+			}
+			if links, _ := targetRelations.GetLinks(child.ID()); len(links) != 1 || links[0].From != first.ID() {
+				t.Errorf("Expected the link between the restored tickets to be restored, got %v", links)
+			}
+			if summary.Header.Version != Version || summary.Header.Filter.Client != alice {
+				t.Errorf("Expected the header of the archive, got %+v", summary.Header)
+			}
+			restored, err := target.GetTicket(first.ID())
+			if err != nil {
+				t.Fatalf("Error should be nil, got %v", err)
+			}
+			owner, _ := target.GetTicketOwner(first.ID())
+			if owner != alice || !restored.CreatedAt().Equal(first.CreatedAt()) || restored.Status() != ticket.Closed ||
+				len(restored.History()) != 2 || restored.Tags()[0] != "billing" {
+				t.Errorf("Expected the ticket to be restored as it was, got %+v", ticket.DataFrom(restored))
+			}
+			responses := restored.Responses()
+			if len(responses) != 2 || responses[0].Content() != "hello" || responses[0].Attachments()[0].Name != "a.pdf" ||
+				responses[0].IsNote() || !responses[1].IsNote() {
+				t.Errorf("Expected the responses to be restored, got %v", responses)
+			}
+		})
+	}
+	t.Run("It should skip the tickets that already exist", func(t *testing.T) {
+		t.Parallel()
+		var archive bytes.Buffer
+		_, _ = exporter.Export(&archive, JSONLines, Filter{})
+		restorer, _ := NewRestorer(source, relations)
+
+		summary, err := restorer.Restore(&archive, JSONLines)
+
+		if err != nil || summary.Restored != 0 || len(summary.Errors) != 4 || summary.Errors[3].Position != 4 {
+			t.Errorf("Expected every ticket to be skipped, got %+v, %v", summary, err)
+		}
+	})
+}
+
+func TestRestorer_Restore(t *testing.T) {
+	t.Parallel()
+	header, _ := json.Marshal(Header{Format: FormatName, Version: Version})
+	entry := func(e Entry) string {
+		line, _ := json.Marshal(e)
+		return string(line)
+	}
+	valid := Entry{ID: uuid.New(), Owner: uuid.New(), CreatedAt: created, Data: ticket.Data{Title: "title", Status: ticket.Open}}
+	nilID, future, noOwner := valid, valid, valid
+	nilID.ID = uuid.Nil
+	future.ID, future.CreatedAt = uuid.New(), time.Now().Add(time.Hour)
+	noOwner.ID, noOwner.Owner = uuid.New(), uuid.Nil
+	t.Run("It should validate the entries like MakeBasicTicket", func(t *testing.T) {
+		t.Parallel()
+		restorer, _ := NewRestorer(memory.NewTicketPersistence(), memory.NewRelationPersistence())
+		archive := strings.Join([]string{string(header), entry(valid), entry(nilID), entry(future), entry(noOwner)}, "\n")
+
+		summary, err := restorer.Restore(strings.NewReader(archive), JSONLines)
+
+		if err != nil || summary.Restored != 1 {
+			t.Fatalf("Expected the valid entry to be restored, got %+v, %v", summary, err)
+		}
+		expected := []error{entities.ErrNilID, entities.ErrFutureCreationTime, ErrNilOwner}
+		for i, e := range expected {
+			if !errors.Is(summary.Errors[i], e) || summary.Errors[i].Position != i+2 {
+				t.Errorf("Expected error %v at entry %d, got %v", e, i+2, summary.Errors[i])
+			}
+		}
+	})
+	t.Run("It should reject archives of other versions", func(t *testing.T) {
+		t.Parallel()
+		restorer, _ := NewRestorer(memory.NewTicketPersistence(), memory.NewRelationPersistence())
+		_, err := restorer.Restore(strings.NewReader(`{"format":"tickettao-backup","version":2}`), JSONLines)
+		assertErrors(t, err, RestoreError, ErrUnsupportedArchive)
+		_, err = restorer.Restore(strings.NewReader(""), JSONLines)
+		assertErrors(t, err, RestoreError, ErrMissingHeader)
+		_, err = restorer.Restore(strings.NewReader(""), "zip")
+		assertErrors(t, err, RestoreError, ErrUnknownFormat)
+	})
+	t.Run("It should reject tar archives that do not start with the header", func(t *testing.T) {
+		t.Parallel()
+		var archive bytes.Buffer
+		writer := tar.NewWriter(&archive)
+		_ = writer.WriteHeader(&tar.Header{Name: "other.json", Size: 2, Mode: 0o644})
+		_, _ = writer.Write([]byte("{}"))
+		_ = writer.Close()
+		restorer, _ := NewRestorer(memory.NewTicketPersistence(), memory.NewRelationPersistence())
+
+		_, err := restorer.Restore(&archive, Tar)
+
+		assertErrors(t, err, RestoreError, ErrMissingHeader, ErrUnexpectedFile)
+	})
+}
+
+func TestExporter_Export(t *testing.T) {
+	t.Parallel()
+	clock := entities.ClockFunc(func() time.Time { return created })
+	exporter, _ := NewExporterWithClock(memory.NewTicketPersistence(), memory.NewRelationPersistence(), clock)
+	t.Run("It should date the archive with the clock", func(t *testing.T) {
+		t.Parallel()
+		var archive bytes.Buffer
+		_, _ = exporter.Export(&archive, JSONLines, Filter{})
+		var header Header
+		if err := json.NewDecoder(&archive).Decode(&header); err != nil || !header.ExportedAt.Equal(created) {
+			t.Errorf("Expected the archive to be exported at %v, got %v, %v", created, header.ExportedAt, err)
+		}
+	})
+	t.Run("It should reject unknown formats and invalid filters", func(t *testing.T) {
+		t.Parallel()
+		_, err := exporter.Export(&bytes.Buffer{}, "zip", Filter{})
+		assertErrors(t, err, ExportError, ErrUnknownFormat)
+		_, err = exporter.Export(&bytes.Buffer{}, JSONLines, Filter{From: created, To: created})
+		assertErrors(t, err, ExportError, ticket.ErrInvalidTimeRange)
+	})
+	t.Run("It should reject nil dependencies", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewExporter(nil, memory.NewRelationPersistence())
+		assertErrors(t, err, NewExporterError, repository.NilPersistenceDriverError)
+		_, err = NewExporter(memory.NewTicketPersistence(), nil)
+		assertErrors(t, err, NewExporterError, repository.ErrNilRelationPersistence)
+		_, err = NewExporterWithClock(memory.NewTicketPersistence(), memory.NewRelationPersistence(), nil)
+		assertErrors(t, err, NewExporterError, entities.ErrNilClock)
+		_, err = NewRestorer(memory.NewTicketPersistence(), nil)
+		assertErrors(t, err, NewRestorerError, repository.ErrNilRelationPersistence)
+	})
+}
+
+func saveTicket(t *testing.T, tp repository.TicketPersistence, owner uuid.UUID, createdAt time.Time, change func(ticket.Ticket)) ticket.Ticket {
+	t.Helper()
+	tck, _ := ticket.MakeBasicTicket(uuid.New(), createdAt, ticket.Data{Title: "title", Status: ticket.Open})
+	if err := tp.SaveNewTicketForClient(owner, tck); err != nil {
+		t.Fatalf("Error saving ticket: %v", err)
+	}
+	if change != nil {
+		change(tck)
+		_ = tp.UpdateTicket(tck)
+	}
+	return tck
+}
+
+func assertErrors(t *testing.T, err error, expected ...error) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("Error should not be nil")
+	}
+	for _, e := range expected {
+		if !errors.Is(err, e) {
+			t.Errorf("Error should be %v, but is %s", e, err.Error())
+		}
+	}
+}
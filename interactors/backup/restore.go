@@ -0,0 +1,184 @@
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"slices"
+	"strings"
+	"ticketTao/entities/ticket"
+	"ticketTao/interactors/ticket/repository"
+)
+
+// EntryError is the reason an entry of an archive was not restored.
+type EntryError struct {
+	// Position is the position of the entry in the archive, from 1.
+	Position int
+	ID       uuid.UUID
+	Err      error
+}
+
+func (e EntryError) Error() string {
+	return fmt.Sprintf("entry %d (ticket %s): %s", e.Position, e.ID, e.Err)
+}
+
+func (e EntryError) Unwrap() error {
+	return e.Err
+}
+
+// Summary reports what a restore did.
+type Summary struct {
+	Header   Header
+	Restored int
+	Errors   []EntryError
+}
+
+// Restorer saves the tickets of an archive in a persistence driver.
+type Restorer interface {
+	// Restore saves every valid entry of the archive for its owner. The entries whose ticket cannot be rebuilt, e.g.
+	// because of a nil ID or a future creation time, or cannot be saved, e.g. because the ticket already exists, are
+	// reported and skipped. The links of the restored tickets are saved after every ticket, those that cannot be, e.g.
+	// because the other ticket is not in the archive nor in the driver, are reported with their entry. It returns an
+	// error if the archive is not a backup of the supported version or if it cannot be read.
+	Restore(r io.Reader, format Format) (Summary, error)
+}
+
+// NewRestorer creates a Restorer that saves the tickets and their links with the persistence drivers. The ticket
+// driver decides whether restoring a ticket publishes events, so it should usually not be decorated with the
+// notifications. It also decides whether the tickets are indexed: when it is not decorated with the search index, the
+// index should be rebuilt with search.Index.Rebuild after restoring. The links are checked like those of
+// repository.GetRelationRepository, so the relation driver should not be used by another repository meanwhile.
+func NewRestorer(tp repository.TicketPersistence, rp repository.RelationPersistence) (Restorer, error) {
+	relations, err := repository.GetRelationRepository(tp, rp)
+	if err != nil {
+		return nil, errors.Join(NewRestorerError, err)
+	}
+	return basicRestorer{persistence: tp, relations: relations}, nil
+}
+
+type basicRestorer struct {
+	persistence repository.TicketPersistence
+	relations   ticket.RepositoryRelations
+}
+
+// restoredLinks are the links of a restored entry, saved once every ticket is restored.
+type restoredLinks struct {
+	position int
+	id       uuid.UUID
+	links    []ticket.Link
+}
+
+func (b basicRestorer) Restore(r io.Reader, format Format) (Summary, error) {
+	reader, err := newEntryReader(r, format)
+	if err != nil {
+		return Summary{}, errors.Join(RestoreError, err)
+	}
+	var summary Summary
+	if err := reader.next(&summary.Header); err != nil {
+		return Summary{}, errors.Join(RestoreError, ErrMissingHeader, err)
+	}
+	if summary.Header.Format != FormatName || summary.Header.Version != Version {
+		return Summary{}, errors.Join(RestoreError,
+			fmt.Errorf("%w: %s version %d", ErrUnsupportedArchive, summary.Header.Format, summary.Header.Version))
+	}
+	var pending []restoredLinks
+	for position := 1; ; position++ {
+		var entry Entry
+		err := reader.next(&entry)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return summary, errors.Join(RestoreError, fmt.Errorf("entry %d: %w", position, err))
+		}
+		if err := b.restore(entry); err != nil {
+			summary.Errors = append(summary.Errors, EntryError{position, entry.ID, err})
+			continue
+		}
+		summary.Restored++
+		if len(entry.Links) > 0 {
+			pending = append(pending, restoredLinks{position, entry.ID, entry.Links})
+		}
+	}
+	for _, restored := range pending {
+		for _, link := range restored.links {
+			if link.From != restored.id {
+				summary.Errors = append(summary.Errors, EntryError{restored.position, restored.id, ErrForeignLink})
+				continue
+			}
+			if err := b.relations.LinkTickets(link); err != nil {
+				summary.Errors = append(summary.Errors, EntryError{restored.position, restored.id, err})
+			}
+		}
+	}
+	slices.SortStableFunc(summary.Errors, func(a, b EntryError) int { return a.Position - b.Position })
+	return summary, nil
+}
+
+func (b basicRestorer) restore(entry Entry) error {
+	if entry.Owner == uuid.Nil {
+		return ErrNilOwner
+	}
+	tck, err := entry.Ticket()
+	if err != nil {
+		return err
+	}
+	return b.persistence.SaveNewTicketForClient(entry.Owner, tck)
+}
+
+type entryReader interface {
+	// next decodes the next header or entry, it returns io.EOF after the last one.
+	next(value any) error
+}
+
+func newEntryReader(r io.Reader, format Format) (entryReader, error) {
+	switch format {
+	case JSONLines:
+		return jsonLinesReader{json.NewDecoder(bufio.NewReader(r))}, nil
+	case Tar:
+		return &tarReader{reader: tar.NewReader(r)}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+type jsonLinesReader struct {
+	decoder *json.Decoder
+}
+
+func (j jsonLinesReader) next(value any) error {
+	return j.decoder.Decode(value)
+}
+
+type tarReader struct {
+	reader    *tar.Reader
+	hasHeader bool
+}
+
+func (t *tarReader) next(value any) error {
+	file, err := t.reader.Next()
+	if err != nil {
+		return err
+	}
+	if !t.hasHeader && file.Name != HeaderFile {
+		return fmt.Errorf("%w: %s", ErrUnexpectedFile, file.Name)
+	}
+	if t.hasHeader && !strings.HasPrefix(file.Name, TicketsDirectory) {
+		return fmt.Errorf("%w: %s", ErrUnexpectedFile, file.Name)
+	}
+	t.hasHeader = true
+	return json.NewDecoder(t.reader).Decode(value)
+}
+
+var NewRestorerError error = errors.New("error creating ticket restorer")
+var RestoreError error = errors.New("error restoring tickets")
+
+var ErrMissingHeader error = errors.New("the archive does not start with a backup header")
+var ErrUnsupportedArchive error = errors.New("unsupported archive")
+var ErrUnexpectedFile error = errors.New("unexpected file in the archive")
+var ErrForeignLink error = errors.New("the link does not start from the ticket of its entry")
+var ErrNilOwner error = errors.New("ticket owner cannot be nil")
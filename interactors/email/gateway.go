@@ -0,0 +1,215 @@
+// Package email contains a gateway that turns the emails sent by clients into tickets, or into comments on existing
+// tickets when the email replies to one of them.
+package email
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"ticketTao/entities/client"
+)
+
+// NoSubjectTitle is the title of the tickets created from emails without a subject.
+const NoSubjectTitle = "(no subject)"
+
+// SubjectTag returns the tag that identifies a ticket in an email subject, e.g. "[#<ticket id>]". Replies to emails
+// carrying this tag are added to the ticket, even when the mail client drops the In-Reply-To header.
+func SubjectTag(ticketId uuid.UUID) string {
+	return "[#" + ticketId.String() + "]"
+}
+
+// ClientResolver maps the sender of an email to the client that owns the tickets.
+type ClientResolver interface {
+	// ResolveClient returns the client with the given email address, or ErrUnknownSender if there is none.
+	ResolveClient(address string) (client.TicketClient, error)
+}
+
+// ThreadStore remembers which ticket each ingested message belongs to, so replies can be matched with their ticket
+// and messages are not ingested twice.
+type ThreadStore interface {
+	TicketForMessage(messageId string) (uuid.UUID, bool)
+	Remember(messageId string, ticketId uuid.UUID)
+}
+
+// NewMemoryThreadStore creates an empty in-memory ThreadStore.
+func NewMemoryThreadStore() ThreadStore {
+	return &memoryThreadStore{threads: make(map[string]uuid.UUID)}
+}
+
+// NewGateway creates a Gateway that uses the resolver to find the client of each message.
+func NewGateway(resolver ClientResolver, threads ThreadStore) (Gateway, error) {
+	if resolver == nil {
+		return nil, errors.Join(NewGatewayError, ErrNilClientResolver)
+	}
+	if threads == nil {
+		return nil, errors.Join(NewGatewayError, ErrNilThreadStore)
+	}
+	return basicGateway{resolver: resolver, threads: threads}, nil
+}
+
+// Gateway ingests emails as tickets or ticket comments.
+type Gateway interface {
+	// Ingest parses a message and creates a ticket for it, or adds it as a comment when it replies to an existing
+	// ticket.
+	Ingest(raw []byte) (Result, error)
+	// IngestSource ingests every message of the source, a failing message does not stop the ingestion of the rest.
+	IngestSource(Source) (Report, error)
+}
+
+// Result describes what the gateway did with a message.
+type Result struct {
+	MessageID string
+	Ticket    uuid.UUID
+	// Created is true when a new ticket was created, and false when the message was added as a comment.
+	Created bool
+	// Duplicate is true when the message had already been ingested, in which case nothing was done.
+	Duplicate bool
+}
+
+// Report contains the results of ingesting a Source, the failures are indexed by the origin of the message.
+type Report struct {
+	Results  []Result
+	Failures map[string]error
+}
+
+type basicGateway struct {
+	resolver ClientResolver
+	threads  ThreadStore
+}
+
+func (b basicGateway) IngestSource(source Source) (Report, error) {
+	messages, err := source.Messages()
+	if err != nil {
+		return Report{}, errors.Join(IngestError, err)
+	}
+	report := Report{Failures: make(map[string]error)}
+	for _, message := range messages {
+		result, err := b.Ingest(message.Data)
+		if err != nil {
+			report.Failures[message.Origin] = err
+			continue
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report, nil
+}
+
+func (b basicGateway) Ingest(raw []byte) (Result, error) {
+	message, err := Parse(bytes.NewReader(raw))
+	if err != nil {
+		return Result{}, errors.Join(IngestError, err)
+	}
+	if message.ID != "" {
+		if ticketId, ok := b.threads.TicketForMessage(message.ID); ok {
+			return Result{MessageID: message.ID, Ticket: ticketId, Duplicate: true}, nil
+		}
+	}
+	sender, err := b.resolver.ResolveClient(message.From)
+	if err != nil {
+		return Result{}, errors.Join(IngestError, err)
+	}
+
+	result := Result{MessageID: message.ID}
+	if ticketId, ok := b.findThread(message); ok {
+		err = sender.AddComment(ticketId, replyContent(message))
+		if err != nil {
+			return Result{}, errors.Join(IngestError, fmt.Errorf("%w: %w", ErrAddingComment, err))
+		}
+		result.Ticket = ticketId
+	} else {
+		ticketId, err = sender.CreateTicket(ticketTitle(message.Subject), message.Body)
+		if err != nil {
+			return Result{}, errors.Join(IngestError, fmt.Errorf("%w: %w", ErrCreatingTicket, err))
+		}
+		result.Ticket = ticketId
+		result.Created = true
+	}
+	if message.ID != "" {
+		b.threads.Remember(message.ID, result.Ticket)
+	}
+	return result, nil
+}
+
+// findThread looks for the ticket of a reply, first by its In-Reply-To and References headers and then by the ticket
+// tag in its subject.
+func (b basicGateway) findThread(message Message) (uuid.UUID, bool) {
+	for _, id := range slices.Concat(message.InReplyTo, message.References) {
+		if ticketId, ok := b.threads.TicketForMessage(id); ok {
+			return ticketId, true
+		}
+	}
+	match := subjectTagPattern.FindStringSubmatch(message.Subject)
+	if match == nil {
+		return uuid.Nil, false
+	}
+	ticketId, err := uuid.Parse(match[1])
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return ticketId, true
+}
+
+func replyContent(message Message) string {
+	content := stripQuotedReply(message.Body)
+	if content == "" {
+		return message.Body
+	}
+	return content
+}
+
+func ticketTitle(subject string) string {
+	title := strings.TrimSpace(subjectTagPattern.ReplaceAllString(subject, ""))
+	for {
+		lower := strings.ToLower(title)
+		trimmed := false
+		for _, prefix := range replyPrefixes {
+			if strings.HasPrefix(lower, prefix) {
+				title = strings.TrimSpace(title[len(prefix):])
+				trimmed = true
+				break
+			}
+		}
+		if !trimmed {
+			break
+		}
+	}
+	if title == "" {
+		return NoSubjectTitle
+	}
+	return title
+}
+
+var replyPrefixes = []string{"re:", "fw:", "fwd:", "rv:"}
+var subjectTagPattern = regexp.MustCompile(`\[#([0-9a-fA-F-]{36})]`)
+
+type memoryThreadStore struct {
+	mu      sync.RWMutex
+	threads map[string]uuid.UUID
+}
+
+func (m *memoryThreadStore) TicketForMessage(messageId string) (uuid.UUID, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ticketId, ok := m.threads[messageId]
+	return ticketId, ok
+}
+
+func (m *memoryThreadStore) Remember(messageId string, ticketId uuid.UUID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.threads[messageId] = ticketId
+}
+
+var NewGatewayError error = errors.New("error creating email gateway")
+var IngestError error = errors.New("error ingesting email message")
+
+var ErrNilClientResolver error = errors.New("client resolver cannot be nil")
+var ErrNilThreadStore error = errors.New("thread store cannot be nil")
+var ErrUnknownSender error = errors.New("email sender is not a known client")
+var ErrCreatingTicket error = errors.New("error creating ticket from email")
+var ErrAddingComment error = errors.New("error adding email reply to ticket")
@@ -0,0 +1,210 @@
+package email
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"path/filepath"
+	"testing"
+	"ticketTao/entities/client"
+	"ticketTao/entities/ticket"
+	"time"
+)
+
+func TestNewGateway(t *testing.T) {
+	t.Parallel()
+	_, err := NewGateway(nil, NewMemoryThreadStore())
+	assertErrors(t, err, NewGatewayError, ErrNilClientResolver)
+	_, err = NewGateway(fakeClientResolver{}, nil)
+	assertErrors(t, err, NewGatewayError, ErrNilThreadStore)
+}
+
+func TestGateway_Ingest(t *testing.T) {
+	t.Parallel()
+	t.Run("A message that is not a reply creates a new ticket for the sender", func(t *testing.T) {
+		t.Parallel()
+		gateway, repository := setupGateway(t)
+
+		result, err := gateway.Ingest([]byte(makeRawMessage("<1@mail.test>", "", "Re: Invoice without PDF", "The PDF is missing")))
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+
+		if !result.Created {
+			t.Error("A new ticket should be created")
+		}
+		tck := repository.tickets[result.Ticket]
+		if tck == nil {
+			t.Fatal("The ticket should be saved")
+		}
+		if tck.Title() != "Invoice without PDF" || tck.Description() != "The PDF is missing" {
+			t.Errorf("Unexpected ticket title %q and description %q", tck.Title(), tck.Description())
+		}
+	})
+	t.Run("A reply to an ingested message is added as a comment to its ticket", func(t *testing.T) {
+		t.Parallel()
+		gateway, repository := setupGateway(t)
+		first, _ := gateway.Ingest([]byte(makeRawMessage("<1@mail.test>", "", "Invoice", "The PDF is missing")))
+
+		result, err := gateway.Ingest([]byte(makeRawMessage("<2@mail.test>", "<1@mail.test>", "Re: Invoice", "Any news?\n> The PDF is missing")))
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+
+		if result.Created || result.Ticket != first.Ticket {
+			t.Errorf("The reply should be added to ticket %s, got %+v", first.Ticket, result)
+		}
+		responses := repository.tickets[first.Ticket].Responses()
+		if len(responses) != 1 || responses[0].Content() != "Any news?" {
+			t.Errorf("Expected the reply without quoted text as a response, got %v", responses)
+		}
+	})
+	t.Run("A message with a ticket tag in its subject is added as a comment to that ticket", func(t *testing.T) {
+		t.Parallel()
+		gateway, repository := setupGateway(t)
+		first, _ := gateway.Ingest([]byte(makeRawMessage("<1@mail.test>", "", "Invoice", "The PDF is missing")))
+
+		subject := "Re: Invoice " + SubjectTag(first.Ticket)
+		result, err := gateway.Ingest([]byte(makeRawMessage("<2@mail.test>", "<unknown@mail.test>", subject, "Still missing")))
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+
+		if result.Created || result.Ticket != first.Ticket {
+			t.Errorf("The reply should be added to ticket %s, got %+v", first.Ticket, result)
+		}
+		if len(repository.tickets[first.Ticket].Responses()) != 1 {
+			t.Error("The reply should be added as a response")
+		}
+	})
+	t.Run("A message is not ingested twice", func(t *testing.T) {
+		t.Parallel()
+		gateway, repository := setupGateway(t)
+		raw := []byte(makeRawMessage("<1@mail.test>", "", "Invoice", "The PDF is missing"))
+		_, _ = gateway.Ingest(raw)
+
+		result, err := gateway.Ingest(raw)
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+
+		if !result.Duplicate || len(repository.tickets) != 1 {
+			t.Errorf("The message should be reported as a duplicate, got %+v", result)
+		}
+	})
+	t.Run("A message from an unknown sender is rejected", func(t *testing.T) {
+		t.Parallel()
+		gateway, err := NewGateway(fakeClientResolver{}, NewMemoryThreadStore())
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+
+		_, err = gateway.Ingest([]byte(makeRawMessage("<1@mail.test>", "", "Invoice", "The PDF is missing")))
+		assertErrors(t, err, IngestError, ErrUnknownSender)
+	})
+}
+
+func TestGateway_IngestSource(t *testing.T) {
+	t.Parallel()
+	gateway, repository := setupGateway(t)
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "new", "1"), makeRawMessage("<1@mail.test>", "", "Invoice", "The PDF is missing"))
+	writeFile(t, filepath.Join(dir, "new", "2"), makeRawMessage("<2@mail.test>", "<1@mail.test>", "Re: Invoice", "Any news?"))
+	writeFile(t, filepath.Join(dir, "new", "3"), "not an email")
+
+	report, err := gateway.IngestSource(NewMaildirSource(dir))
+	if err != nil {
+		t.Fatalf("Error should be nil, got %v", err)
+	}
+
+	if len(report.Results) != 2 || len(report.Failures) != 1 {
+		t.Errorf("Expected 2 results and 1 failure, got %+v", report)
+	}
+	if len(repository.tickets) != 1 {
+		t.Errorf("Expected 1 ticket, got %d", len(repository.tickets))
+	}
+}
+
+func setupGateway(t *testing.T) (Gateway, *fakeTicketRepository) {
+	t.Helper()
+	repository := &fakeTicketRepository{tickets: make(map[uuid.UUID]ticket.Ticket), owners: make(map[uuid.UUID]uuid.UUID)}
+	sender := client.NewClientFactory(repository).InstantiateBasicTicketClient(uuid.New(), time.Now())
+	gateway, err := NewGateway(fakeClientResolver{"ana@client.test": sender}, NewMemoryThreadStore())
+	if err != nil {
+		t.Fatalf("Error creating gateway: %v", err)
+	}
+	return gateway, repository
+}
+
+type fakeClientResolver map[string]client.TicketClient
+
+func (f fakeClientResolver) ResolveClient(address string) (client.TicketClient, error) {
+	c, ok := f[address]
+	if !ok {
+		return nil, ErrUnknownSender
+	}
+	return c, nil
+}
+
+type fakeTicketRepository struct {
+	tickets map[uuid.UUID]ticket.Ticket
+	owners  map[uuid.UUID]uuid.UUID
+}
+
+func (f *fakeTicketRepository) GetTicket(clientId, ticketId uuid.UUID) (ticket.Ticket, error) {
+	if f.owners[ticketId] != clientId {
+		return nil, errTicketNotFound
+	}
+	return f.tickets[ticketId], nil
+}
+
+func (f *fakeTicketRepository) GetAllClientTickets(uuid.UUID) ([]ticket.Ticket, error) {
+	return nil, nil
+}
+
+func (f *fakeTicketRepository) GetClientTicketCount(uuid.UUID) (int, error) {
+	return len(f.tickets), nil
+}
+
+func (f *fakeTicketRepository) QueryClientTickets(uuid.UUID, ticket.Query) (ticket.Page, error) {
+	return ticket.Page{}, nil
+}
+
+func (f *fakeTicketRepository) CountClientTicketsByTag(uuid.UUID, ticket.Query) (map[string]int, error) {
+	return nil, nil
+}
+
+func (f *fakeTicketRepository) FollowTicket(uuid.UUID, uuid.UUID) error {
+	return nil
+}
+
+func (f *fakeTicketRepository) UnfollowTicket(uuid.UUID, uuid.UUID) error {
+	return nil
+}
+
+func (f *fakeTicketRepository) CreateNewTicketForClient(clientId uuid.UUID, tck ticket.Ticket) error {
+	f.tickets[tck.ID()] = tck
+	f.owners[tck.ID()] = clientId
+	return nil
+}
+
+func (f *fakeTicketRepository) UpdateTicketForClient(clientId uuid.UUID, tck ticket.Ticket) error {
+	if f.owners[tck.ID()] != clientId {
+		return errTicketNotFound
+	}
+	f.tickets[tck.ID()] = tck
+	return nil
+}
+
+func assertErrors(t *testing.T, err error, expected ...error) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("Error should not be nil")
+	}
+	for _, e := range expected {
+		if !errors.Is(err, e) {
+			t.Errorf("Error should be %v, but is %s", e, err.Error())
+		}
+	}
+}
+
+var errTicketNotFound = errors.New("ticket not found")
@@ -0,0 +1,183 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/text/encoding/htmlindex"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// Message is the part of an RFC 5322 message the gateway needs to create tickets and responses.
+type Message struct {
+	ID         string
+	InReplyTo  []string
+	References []string
+	// From is the sender's address, without the display name and in lower case.
+	From    string
+	Subject string
+	// Body is the plain text content of the message.
+	Body string
+	Date time.Time
+}
+
+// Parse reads an RFC 5322 message, decoding MIME encoded headers and bodies. When the message is multipart, the first
+// text/plain part is used as its body.
+func Parse(r io.Reader) (Message, error) {
+	raw, err := mail.ReadMessage(r)
+	if err != nil {
+		return Message{}, errors.Join(ParseMessageError, err)
+	}
+	from, err := mail.ParseAddress(raw.Header.Get("From"))
+	if err != nil {
+		return Message{}, errors.Join(ParseMessageError, ErrInvalidSender, err)
+	}
+	decoder := &mime.WordDecoder{CharsetReader: decodeCharset}
+	subject, err := decoder.DecodeHeader(raw.Header.Get("Subject"))
+	if err != nil {
+		subject = raw.Header.Get("Subject")
+	}
+	body, err := readBody(raw.Header.Get("Content-Type"), raw.Header.Get("Content-Transfer-Encoding"), raw.Body)
+	if err != nil {
+		return Message{}, errors.Join(ParseMessageError, err)
+	}
+	date, _ := raw.Header.Date()
+	return Message{
+		ID:         normalizeMessageID(raw.Header.Get("Message-ID")),
+		InReplyTo:  parseMessageIDs(raw.Header.Get("In-Reply-To")),
+		References: parseMessageIDs(raw.Header.Get("References")),
+		From:       strings.ToLower(from.Address),
+		Subject:    strings.TrimSpace(subject),
+		Body:       strings.TrimSpace(body),
+		Date:       date,
+	}, nil
+}
+
+func readBody(contentType, transferEncoding string, body io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if contentType == "" || err != nil {
+		mediaType = "text/plain"
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		return readMultipartBody(params["boundary"], body)
+	}
+	if mediaType != "text/plain" {
+		return "", ErrNoTextBody
+	}
+	text, err := decodeCharset(params["charset"], decodeTransferEncoding(transferEncoding, body))
+	if err != nil {
+		return "", err
+	}
+	decoded, err := io.ReadAll(text)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrDecodingBody, err)
+	}
+	return string(decoded), nil
+}
+
+func readMultipartBody(boundary string, body io.Reader) (string, error) {
+	if boundary == "" {
+		return "", ErrNoTextBody
+	}
+	reader := multipart.NewReader(body, boundary)
+	for {
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			return "", ErrNoTextBody
+		}
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrDecodingBody, err)
+		}
+		text, err := readBody(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+		if errors.Is(err, ErrNoTextBody) {
+			continue
+		}
+		return text, err
+	}
+}
+
+func decodeTransferEncoding(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, newlineSkipper{body})
+	default:
+		return body
+	}
+}
+
+// decodeCharset converts a text in the given charset to UTF-8, the text is kept as is when the charset is missing.
+func decodeCharset(charset string, text io.Reader) (io.Reader, error) {
+	switch strings.ToLower(strings.TrimSpace(charset)) {
+	case "", "utf-8", "utf8", "us-ascii":
+		return text, nil
+	}
+	encoding, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCharset, charset)
+	}
+	return encoding.NewDecoder().Reader(text), nil
+}
+
+// newlineSkipper removes the line breaks that split base64 encoded bodies.
+type newlineSkipper struct {
+	reader io.Reader
+}
+
+func (n newlineSkipper) Read(p []byte) (int, error) {
+	read, err := n.reader.Read(p)
+	kept := p[:0]
+	for _, b := range p[:read] {
+		if b != '\r' && b != '\n' {
+			kept = append(kept, b)
+		}
+	}
+	return len(kept), err
+}
+
+func parseMessageIDs(header string) []string {
+	var ids []string
+	for _, field := range strings.Fields(header) {
+		if id := normalizeMessageID(field); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func normalizeMessageID(id string) string {
+	return strings.Trim(strings.TrimSpace(id), "<>")
+}
+
+// stripQuotedReply removes the quoted text that mail clients append to replies, so only the new content is added to
+// the ticket's conversation.
+func stripQuotedReply(body string) string {
+	var kept bytes.Buffer
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "On ") && strings.HasSuffix(trimmed, "wrote:") {
+			break
+		}
+		kept.WriteString(line)
+		kept.WriteString("\n")
+	}
+	return strings.TrimSpace(kept.String())
+}
+
+var ParseMessageError error = errors.New("error parsing email message")
+
+var ErrInvalidSender error = errors.New("email sender address is not valid")
+var ErrNoTextBody error = errors.New("email message does not have a plain text body")
+var ErrDecodingBody error = errors.New("error decoding email body")
+var ErrUnsupportedCharset error = errors.New("email charset is not supported")
@@ -0,0 +1,189 @@
+package email
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	t.Run("It should parse the headers and plain text body of a message", func(t *testing.T) {
+		t.Parallel()
+		message, err := Parse(strings.NewReader(makeRawMessage("<a@mail.test>", "<b@mail.test>", "=?UTF-8?Q?Factura_sin_PDF?=", "Hola")))
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if message.ID != "a@mail.test" {
+			t.Errorf("Expected message id to be a@mail.test, got %s", message.ID)
+		}
+		if len(message.InReplyTo) != 1 || message.InReplyTo[0] != "b@mail.test" {
+			t.Errorf("Expected in-reply-to to be b@mail.test, got %v", message.InReplyTo)
+		}
+		if message.From != "ana@client.test" {
+			t.Errorf("Expected sender to be ana@client.test, got %s", message.From)
+		}
+		if message.Subject != "Factura sin PDF" {
+			t.Errorf("Expected the subject to be decoded, got %s", message.Subject)
+		}
+		if message.Body != "Hola" {
+			t.Errorf("Expected body to be Hola, got %s", message.Body)
+		}
+	})
+	t.Run("It should use the first plain text part of a multipart message", func(t *testing.T) {
+		t.Parallel()
+		raw := "From: Ana <ana@client.test>\r\n" +
+			"Subject: Invoice\r\n" +
+			"Content-Type: multipart/alternative; boundary=\"XYZ\"\r\n" +
+			"\r\n" +
+			"--XYZ\r\n" +
+			"Content-Type: text/html\r\n" +
+			"\r\n" +
+			"<p>html</p>\r\n" +
+			"--XYZ\r\n" +
+			"Content-Type: text/plain; charset=utf-8\r\n" +
+			"Content-Transfer-Encoding: quoted-printable\r\n" +
+			"\r\n" +
+			"Factura =C3=BAnica\r\n" +
+			"--XYZ--\r\n"
+		message, err := Parse(strings.NewReader(raw))
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if message.Body != "Factura única" {
+			t.Errorf("Expected the plain text part to be decoded, got %q", message.Body)
+		}
+	})
+	t.Run("It should decode base64 bodies", func(t *testing.T) {
+		t.Parallel()
+		raw := "From: ana@client.test\r\n" +
+			"Content-Type: text/plain\r\n" +
+			"Content-Transfer-Encoding: base64\r\n" +
+			"\r\n" +
+			"SG9sYSBt\r\ndW5kbw==\r\n"
+		message, err := Parse(strings.NewReader(raw))
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if message.Body != "Hola mundo" {
+			t.Errorf("Expected body to be decoded, got %q", message.Body)
+		}
+	})
+	t.Run("It should decode the bodies and subjects in other charsets", func(t *testing.T) {
+		t.Parallel()
+		raw := "From: ana@client.test\r\n" +
+			"Subject: =?windows-1252?Q?Caf=E9?=\r\n" +
+			"Content-Type: text/plain; charset=ISO-8859-1\r\n" +
+			"Content-Transfer-Encoding: quoted-printable\r\n" +
+			"\r\n" +
+			"Factura =FAnica\r\n"
+		message, err := Parse(strings.NewReader(raw))
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if message.Subject != "Café" {
+			t.Errorf("Expected the subject to be converted to UTF-8, got %q", message.Subject)
+		}
+		if message.Body != "Factura única" {
+			t.Errorf("Expected the body to be converted to UTF-8, got %q", message.Body)
+		}
+	})
+	t.Run("It should return an error when the charset of the body is not supported", func(t *testing.T) {
+		t.Parallel()
+		raw := "From: ana@client.test\r\n" +
+			"Content-Type: text/plain; charset=x-unknown\r\n" +
+			"\r\n" +
+			"body\r\n"
+		_, err := Parse(strings.NewReader(raw))
+		assertErrors(t, err, ParseMessageError, ErrUnsupportedCharset)
+	})
+	t.Run("It should return an error when the sender is not valid", func(t *testing.T) {
+		t.Parallel()
+		_, err := Parse(strings.NewReader("From: nobody\r\n\r\nbody"))
+		assertErrors(t, err, ParseMessageError, ErrInvalidSender)
+	})
+}
+
+func TestStripQuotedReply(t *testing.T) {
+	t.Parallel()
+	body := "Thanks, it works now.\n\nOn Mon, Jan 1, 2024 at 10:00 Support wrote:\n> Try again\n> please"
+	if got := stripQuotedReply(body); got != "Thanks, it works now." {
+		t.Errorf("Expected the quoted text to be removed, got %q", got)
+	}
+}
+
+func TestSources(t *testing.T) {
+	t.Parallel()
+	t.Run("A maildir source reads the messages in new and cur", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "new", "1"), makeRawMessage("<1@mail.test>", "", "one", "one"))
+		writeFile(t, filepath.Join(dir, "cur", "2"), makeRawMessage("<2@mail.test>", "", "two", "two"))
+		writeFile(t, filepath.Join(dir, "tmp", "3"), makeRawMessage("<3@mail.test>", "", "three", "three"))
+
+		messages, err := NewMaildirSource(dir).Messages()
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if len(messages) != 2 {
+			t.Errorf("Expected 2 messages, got %d", len(messages))
+		}
+	})
+	t.Run("An mbox source splits the messages of every file", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		mbox := "From ana@client.test Mon Jan  1 10:00:00 2024\n" +
+			strings.ReplaceAll(makeRawMessage("<1@mail.test>", "", "one", ">From the start"), "\r\n", "\n") + "\n" +
+			"From ana@client.test Mon Jan  1 11:00:00 2024\n" +
+			strings.ReplaceAll(makeRawMessage("<2@mail.test>", "", "two", "two"), "\r\n", "\n")
+		writeFile(t, filepath.Join(dir, "inbox.mbox"), mbox)
+
+		messages, err := NewMboxSource(dir).Messages()
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if len(messages) != 2 {
+			t.Fatalf("Expected 2 messages, got %d", len(messages))
+		}
+		message, err := Parse(strings.NewReader(string(messages[0].Data)))
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if message.Body != "From the start" {
+			t.Errorf("Expected the escaped From line to be restored, got %q", message.Body)
+		}
+	})
+	t.Run("An mbox source returns an error when a line is too long to be read", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		mbox := "From ana@client.test Mon Jan  1 10:00:00 2024\n" + strings.Repeat("a", 17*1024*1024) + "\n"
+		writeFile(t, filepath.Join(dir, "inbox.mbox"), mbox)
+
+		_, err := NewMboxSource(dir).Messages()
+		assertErrors(t, err, ReadSourceError, bufio.ErrTooLong)
+	})
+}
+
+func makeRawMessage(id, inReplyTo, subject, body string) string {
+	raw := "From: Ana <Ana@Client.test>\r\n" +
+		"Message-ID: " + id + "\r\n"
+	if inReplyTo != "" {
+		raw += "In-Reply-To: " + inReplyTo + "\r\n"
+	}
+	return raw + "Subject: " + subject + "\r\n" +
+		"Date: Mon, 01 Jan 2024 10:00:00 +0000\r\n" +
+		"\r\n" +
+		body + "\r\n"
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Error creating directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Error writing file: %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package email
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// RawMessage is an unparsed message read from a Source.
+type RawMessage struct {
+	// Origin tells where the message was read from, it is used in the ingestion reports.
+	Origin string
+	Data   []byte
+}
+
+// Source provides the messages to be ingested.
+type Source interface {
+	Messages() ([]RawMessage, error)
+}
+
+// NewMaildirSource reads the messages of a maildir, from its "new" and "cur" subdirectories, or from the directory
+// itself when it does not have them.
+func NewMaildirSource(dir string) Source {
+	return maildirSource{dir}
+}
+
+// NewMboxSource reads the messages of every mbox file in a directory.
+func NewMboxSource(dir string) Source {
+	return mboxSource{dir}
+}
+
+type maildirSource struct {
+	dir string
+}
+
+func (m maildirSource) Messages() ([]RawMessage, error) {
+	dirs := []string{filepath.Join(m.dir, "new"), filepath.Join(m.dir, "cur")}
+	if !isDir(dirs[0]) && !isDir(dirs[1]) {
+		dirs = []string{m.dir}
+	}
+	var messages []RawMessage
+	for _, dir := range dirs {
+		if !isDir(dir) {
+			continue
+		}
+		files, err := listFiles(dir)
+		if err != nil {
+			return nil, errors.Join(ReadSourceError, err)
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, errors.Join(ReadSourceError, err)
+			}
+			messages = append(messages, RawMessage{Origin: file, Data: data})
+		}
+	}
+	return messages, nil
+}
+
+type mboxSource struct {
+	dir string
+}
+
+func (m mboxSource) Messages() ([]RawMessage, error) {
+	files, err := listFiles(m.dir)
+	if err != nil {
+		return nil, errors.Join(ReadSourceError, err)
+	}
+	var messages []RawMessage
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, errors.Join(ReadSourceError, err)
+		}
+		split, err := splitMbox(data)
+		if err != nil {
+			return nil, errors.Join(ReadSourceError, fmt.Errorf("%s: %w", file, err))
+		}
+		for i, message := range split {
+			messages = append(messages, RawMessage{Origin: fmt.Sprintf("%s#%d", file, i+1), Data: message})
+		}
+	}
+	return messages, nil
+}
+
+// splitMbox splits an mboxrd file on its "From " separator lines, unescaping the ">From " lines of the bodies.
+func splitMbox(data []byte) ([][]byte, error) {
+	var messages [][]byte
+	var current *bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "From ") {
+			if current != nil {
+				messages = append(messages, current.Bytes())
+			}
+			current = new(bytes.Buffer)
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if strings.HasPrefix(strings.TrimLeft(line, ">"), "From ") {
+			line = line[1:]
+		}
+		current.WriteString(line)
+		current.WriteString("\r\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if current != nil {
+		messages = append(messages, current.Bytes())
+	}
+	return messages, nil
+}
+
+func listFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && !strings.HasPrefix(entry.Name(), ".") {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+var ReadSourceError error = errors.New("error reading email source")
@@ -0,0 +1,178 @@
+// Package escalation contains an engine that escalates the tickets that have been waiting for too long, following
+// configurable rules. The engine is a scheduler.Job, so it can be run periodically.
+package escalation
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"sync"
+	"ticketTao/entities/ticket"
+	"ticketTao/interactors/scheduler"
+	"time"
+)
+
+// TicketRepository is the access to the tickets needed by the engine.
+type TicketRepository interface {
+	ticket.RepositoryAgentAccess
+	ticket.RepositoryAgentLister
+}
+
+// History remembers the escalations that already happened, so a rule fires only once per waiting period.
+type History interface {
+	WasEscalated(ticket uuid.UUID, rule string, waitingSince time.Time) bool
+	Record(Escalation)
+}
+
+// NewMemoryHistory creates an empty in-memory History.
+func NewMemoryHistory() History {
+	return &memoryHistory{escalations: make(map[historyKey]bool)}
+}
+
+// NewEngine creates an Engine that applies the rules, in order, to the open tickets of the repository.
+func NewEngine(repository TicketRepository, rules []Rule, clock scheduler.Clock, history History) (Engine, error) {
+	if repository == nil {
+		return nil, errors.Join(NewEngineError, ErrNilRepository)
+	}
+	if clock == nil {
+		return nil, errors.Join(NewEngineError, scheduler.ErrNilClock)
+	}
+	if history == nil {
+		return nil, errors.Join(NewEngineError, ErrNilHistory)
+	}
+	names := make(map[string]bool)
+	for _, rule := range rules {
+		if err := rule.validate(); err != nil {
+			return nil, errors.Join(NewEngineError, fmt.Errorf("rule %q: %w", rule.Name, err))
+		}
+		if names[rule.Name] {
+			return nil, errors.Join(NewEngineError, ErrDuplicatedRule)
+		}
+		names[rule.Name] = true
+	}
+	return basicEngine{repository: repository, rules: rules, clock: clock, history: history}, nil
+}
+
+// Engine escalates the tickets that match its rules.
+type Engine interface {
+	scheduler.Job
+	// Escalate applies the rules once and returns the escalations that happened. A failing ticket does not stop the
+	// escalation of the rest, its error is joined to the returned error.
+	Escalate() ([]Escalation, error)
+}
+
+type basicEngine struct {
+	repository TicketRepository
+	rules      []Rule
+	clock      scheduler.Clock
+	history    History
+}
+
+func (b basicEngine) Run() error {
+	_, err := b.Escalate()
+	return err
+}
+
+func (b basicEngine) Escalate() ([]Escalation, error) {
+	tickets, err := b.repository.GetOpenTickets()
+	if err != nil {
+		return nil, errors.Join(EscalateError, err)
+	}
+	var escalations []Escalation
+	var failures []error
+	for _, tck := range tickets {
+		escalated, err := b.escalateTicket(tck)
+		escalations = append(escalations, escalated...)
+		if err != nil {
+			failures = append(failures, fmt.Errorf("ticket %s: %w", tck.ID(), err))
+		}
+	}
+	if len(failures) > 0 {
+		return escalations, errors.Join(EscalateError, errors.Join(failures...))
+	}
+	return escalations, nil
+}
+
+// escalateTicket applies the actions of the rules that fire and persists the ticket, then records the escalations and
+// applies their effects. The changes are not persisted when an action fails.
+func (b basicEngine) escalateTicket(tck ticket.Ticket) ([]Escalation, error) {
+	owner, err := b.repository.GetTicketOwner(tck.ID())
+	if err != nil {
+		return nil, err
+	}
+	now := b.clock.Now()
+	var escalations []Escalation
+	var fired []Rule
+	for _, rule := range b.rules {
+		if tck.Status() == ticket.Closed {
+			break
+		}
+		since, waiting := rule.waitingSince(tck, owner)
+		if !waiting || rule.calendar().BusinessTimeBetween(since, now) < rule.After {
+			continue
+		}
+		if b.history.WasEscalated(tck.ID(), rule.Name, since) {
+			continue
+		}
+		escalation := Escalation{Rule: rule.Name, Ticket: tck.ID(), Owner: owner, WaitingSince: since, At: now}
+		for _, action := range rule.Actions {
+			if _, ok := action.(Effect); ok {
+				continue
+			}
+			if err := action.Apply(tck, escalation); err != nil {
+				return nil, fmt.Errorf("rule %q: %w", rule.Name, err)
+			}
+		}
+		escalations = append(escalations, escalation)
+		fired = append(fired, rule)
+	}
+	if len(escalations) == 0 {
+		return nil, nil
+	}
+	if err := b.repository.UpdateTicket(tck); err != nil {
+		return nil, err
+	}
+	var failures []error
+	for i, escalation := range escalations {
+		b.history.Record(escalation)
+		for _, action := range fired[i].Actions {
+			if _, ok := action.(Effect); !ok {
+				continue
+			}
+			if err := action.Apply(tck, escalation); err != nil {
+				failures = append(failures, fmt.Errorf("rule %q: %w", escalation.Rule, err))
+			}
+		}
+	}
+	return escalations, errors.Join(failures...)
+}
+
+type historyKey struct {
+	ticket       uuid.UUID
+	rule         string
+	waitingSince time.Time
+}
+
+type memoryHistory struct {
+	mu          sync.RWMutex
+	escalations map[historyKey]bool
+}
+
+func (m *memoryHistory) WasEscalated(ticket uuid.UUID, rule string, waitingSince time.Time) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.escalations[historyKey{ticket, rule, waitingSince.UTC()}]
+}
+
+func (m *memoryHistory) Record(escalation Escalation) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.escalations[historyKey{escalation.Ticket, escalation.Rule, escalation.WaitingSince.UTC()}] = true
+}
+
+var NewEngineError error = errors.New("error creating escalation engine")
+var EscalateError error = errors.New("error escalating tickets")
+
+var ErrNilRepository error = errors.New("ticket repository cannot be nil")
+var ErrNilHistory error = errors.New("escalation history cannot be nil")
+var ErrDuplicatedRule error = errors.New("escalation rule names must be unique")
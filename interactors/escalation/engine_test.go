@@ -0,0 +1,309 @@
+package escalation
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"testing"
+	"ticketTao/entities/ticket"
+	"time"
+)
+
+func TestNewEngine(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{}
+	t.Run("It should return an error when a dependency is missing", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewEngine(nil, nil, clock, NewMemoryHistory())
+		assertErrors(t, err, NewEngineError, ErrNilRepository)
+		_, err = NewEngine(newFakeRepository(), nil, clock, nil)
+		assertErrors(t, err, NewEngineError, ErrNilHistory)
+	})
+	t.Run("It should validate the rules", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewEngine(newFakeRepository(), []Rule{{Name: "r", Trigger: "Unknown", After: time.Hour, Actions: []Action{RaisePriority{}}}}, clock, NewMemoryHistory())
+		assertErrors(t, err, NewEngineError, ErrUnknownTrigger)
+		_, err = NewEngine(newFakeRepository(), []Rule{{Name: "r", Trigger: NoAgentReply, After: time.Hour}}, clock, NewMemoryHistory())
+		assertErrors(t, err, NewEngineError, ErrNoActions)
+		rule := Rule{Name: "r", Trigger: NoAgentReply, After: time.Hour, Actions: []Action{RaisePriority{}}}
+		_, err = NewEngine(newFakeRepository(), []Rule{rule, rule}, clock, NewMemoryHistory())
+		assertErrors(t, err, NewEngineError, ErrDuplicatedRule)
+	})
+}
+
+func TestEngine_Escalate(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC)
+	system := uuid.New()
+
+	t.Run("A ticket without an agent reply is raised and its supervisor notified", func(t *testing.T) {
+		t.Parallel()
+		repository := newFakeRepository()
+		owner := uuid.New()
+		tck := repository.add(t, owner, start)
+		notifier := &spyNotifier{}
+		clock := &fakeClock{now: start.Add(3 * time.Hour)}
+		engine := makeEngine(t, repository, clock, Rule{
+			Name:    "no agent reply in 4h",
+			Trigger: NoAgentReply,
+			After:   4 * time.Hour,
+			Actions: []Action{RaisePriority{}, Notify{Notifier: notifier, Recipient: "supervisor@tickettao.test"}},
+		})
+
+		escalations, err := engine.Escalate()
+		if err != nil || len(escalations) != 0 {
+			t.Fatalf("Nothing should be escalated before 4h, got %v, %v", escalations, err)
+		}
+
+		clock.now = start.Add(4 * time.Hour)
+		escalations, err = engine.Escalate()
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if len(escalations) != 1 || escalations[0].Owner != owner {
+			t.Fatalf("Expected one escalation, got %v", escalations)
+		}
+		if repository.tickets[tck.ID()].Priority() != ticket.High {
+			t.Errorf("Expected the priority to be raised to High, got %s", repository.tickets[tck.ID()].Priority())
+		}
+		if len(notifier.sent) != 1 || notifier.sent[0].Recipient != "supervisor@tickettao.test" {
+			t.Errorf("Expected the supervisor to be notified, got %v", notifier.sent)
+		}
+	})
+	t.Run("A rule fires once per waiting period", func(t *testing.T) {
+		t.Parallel()
+		repository := newFakeRepository()
+		owner := uuid.New()
+		tck := repository.add(t, owner, start)
+		clock := &fakeClock{now: start.Add(5 * time.Hour)}
+		engine := makeEngine(t, repository, clock, Rule{
+			Name: "raise", Trigger: NoAgentReply, After: 4 * time.Hour, Actions: []Action{RaisePriority{}},
+		})
+
+		_, _ = engine.Escalate()
+		clock.now = start.Add(6 * time.Hour)
+		escalations, _ := engine.Escalate()
+		if len(escalations) != 0 {
+			t.Errorf("The rule should not fire twice for the same waiting period, got %v", escalations)
+		}
+
+		tck.AddResponse(ticket.MakeResponse(owner, "hello?", clock.now))
+		_ = repository.UpdateTicket(tck)
+		clock.now = start.Add(10 * time.Hour)
+		escalations, _ = engine.Escalate()
+		if len(escalations) != 1 || repository.tickets[tck.ID()].Priority() != ticket.Urgent {
+			t.Errorf("The rule should fire again after a new client response, got %v", escalations)
+		}
+	})
+	t.Run("The internal notes do not count as replies", func(t *testing.T) {
+		t.Parallel()
+		repository := newFakeRepository()
+		owner := uuid.New()
+		noted := repository.add(t, owner, start)
+		noted.AddResponse(ticket.MakeNote(uuid.New(), "Asked billing", start.Add(time.Hour)))
+		waiting := repository.add(t, owner, start)
+		waiting.AddResponse(ticket.MakeResponse(uuid.New(), "Could you send the invoice?", start.Add(time.Hour)))
+		waiting.AddResponse(ticket.MakeNote(uuid.New(), "Reminder to self", start.Add(2*time.Hour)))
+		clock := &fakeClock{now: start.Add(5 * time.Hour)}
+		engine := makeEngine(t, repository, clock,
+			Rule{Name: "no agent reply", Trigger: NoAgentReply, After: 4 * time.Hour, Actions: []Action{RaisePriority{}}},
+			Rule{Name: "client unresponsive", Trigger: ClientUnresponsive, After: 4 * time.Hour, Actions: []Action{RaisePriority{}}},
+		)
+
+		escalations, err := engine.Escalate()
+
+		if err != nil || len(escalations) != 2 {
+			t.Fatalf("Expected both tickets to be escalated, got %v, %v", escalations, err)
+		}
+		expected := map[uuid.UUID]string{noted.ID(): "no agent reply", waiting.ID(): "client unresponsive"}
+		for _, escalation := range escalations {
+			if escalation.Rule != expected[escalation.Ticket] {
+				t.Errorf("Expected ticket %s to be escalated by %q, got %q", escalation.Ticket, expected[escalation.Ticket], escalation.Rule)
+			}
+		}
+	})
+	t.Run("A ticket waiting on an unresponsive client is closed with a system response", func(t *testing.T) {
+		t.Parallel()
+		repository := newFakeRepository()
+		owner := uuid.New()
+		waiting := repository.add(t, owner, start)
+		waiting.AddResponse(ticket.MakeResponse(uuid.New(), "Could you send the invoice?", start.Add(time.Hour)))
+		answered := repository.add(t, owner, start)
+		answered.AddResponse(ticket.MakeResponse(uuid.New(), "Could you send the invoice?", start.Add(time.Hour)))
+		answered.AddResponse(ticket.MakeResponse(owner, "Here it is", start.Add(2*time.Hour)))
+		clock := &fakeClock{now: start.Add(10*24*time.Hour + time.Hour)}
+		engine := makeEngine(t, repository, clock, Rule{
+			Name:    "client unresponsive for 10 days",
+			Trigger: ClientUnresponsive,
+			After:   10 * 24 * time.Hour,
+			Actions: []Action{CloseWithResponse{Author: system, Message: "Closed after 10 days without response", Reason: ticket.NoResponse}},
+		})
+
+		escalations, err := engine.Escalate()
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+
+		if len(escalations) != 1 || escalations[0].Ticket != waiting.ID() {
+			t.Fatalf("Expected only ticket %s to be escalated, got %v", waiting.ID(), escalations)
+		}
+		closed := repository.tickets[waiting.ID()]
+		if closed.Status() != ticket.Closed || closed.ClosingReason() != ticket.NoResponse {
+			t.Errorf("Expected the ticket to be closed for no response, got %s, %s", closed.Status(), closed.ClosingReason())
+		}
+		last := closed.Responses()[len(closed.Responses())-1]
+		if last.UserId() != system || !last.TimeStamp().Equal(clock.now) {
+			t.Errorf("Expected a system response at the escalation time, got %v", last)
+		}
+	})
+	t.Run("A failing action does not stop the escalation of other tickets", func(t *testing.T) {
+		t.Parallel()
+		repository := newFakeRepository()
+		repository.add(t, uuid.New(), start)
+		repository.add(t, uuid.New(), start)
+		notifier := &spyNotifier{err: errors.New("smtp is down")}
+		engine := makeEngine(t, repository, &fakeClock{now: start.Add(5 * time.Hour)}, Rule{
+			Name: "notify", Trigger: NoAgentReply, After: 4 * time.Hour, Actions: []Action{Notify{Notifier: notifier}},
+		})
+
+		_, err := engine.Escalate()
+
+		assertErrors(t, err, EscalateError, ErrNotifying)
+		if len(notifier.sent) != 2 {
+			t.Errorf("Expected both tickets to be attempted, got %d", len(notifier.sent))
+		}
+	})
+	t.Run("The notifications are sent only once the ticket is persisted", func(t *testing.T) {
+		t.Parallel()
+		repository := newFakeRepository()
+		repository.add(t, uuid.New(), start)
+		repository.updateErr = errors.New("database is down")
+		notifier := &spyNotifier{}
+		engine := makeEngine(t, repository, &fakeClock{now: start.Add(5 * time.Hour)}, Rule{
+			Name: "notify", Trigger: NoAgentReply, After: 4 * time.Hour,
+			Actions: []Action{Notify{Notifier: notifier}, RaisePriority{}},
+		})
+
+		escalations, err := engine.Escalate()
+
+		assertErrors(t, err, EscalateError, repository.updateErr)
+		if len(escalations) != 0 || len(notifier.sent) != 0 {
+			t.Errorf("Nothing should be escalated nor notified, got %v and %d notifications", escalations, len(notifier.sent))
+		}
+	})
+	t.Run("A failing notification does not undo the escalation", func(t *testing.T) {
+		t.Parallel()
+		repository := newFakeRepository()
+		tck := repository.add(t, uuid.New(), start)
+		notifier := &spyNotifier{err: errors.New("smtp is down")}
+		engine := makeEngine(t, repository, &fakeClock{now: start.Add(5 * time.Hour)}, Rule{
+			Name: "notify", Trigger: NoAgentReply, After: 4 * time.Hour,
+			Actions: []Action{Notify{Notifier: notifier}, RaisePriority{}},
+		})
+
+		escalations, err := engine.Escalate()
+		assertErrors(t, err, EscalateError, ErrNotifying)
+		if len(escalations) != 1 || repository.tickets[tck.ID()].Priority() != ticket.High {
+			t.Fatalf("Expected the ticket to be escalated, got %v", escalations)
+		}
+		if notifier.sent[0].Ticket.Priority() != ticket.High {
+			t.Error("Expected the notification to be sent with the persisted ticket")
+		}
+
+		escalations, _ = engine.Escalate()
+		if len(escalations) != 0 || len(notifier.sent) != 1 {
+			t.Errorf("Expected the escalation to be recorded, got %v and %d notifications", escalations, len(notifier.sent))
+		}
+	})
+}
+
+func makeEngine(t *testing.T, repository TicketRepository, clock *fakeClock, rules ...Rule) Engine {
+	t.Helper()
+	engine, err := NewEngine(repository, rules, clock, NewMemoryHistory())
+	if err != nil {
+		t.Fatalf("Error creating engine: %v", err)
+	}
+	return engine
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	return f.now
+}
+
+func (f *fakeClock) After(time.Duration) <-chan time.Time {
+	return make(chan time.Time)
+}
+
+type spyNotifier struct {
+	sent []Notification
+	err  error
+}
+
+func (s *spyNotifier) Notify(notification Notification) error {
+	s.sent = append(s.sent, notification)
+	return s.err
+}
+
+type fakeRepository struct {
+	tickets   map[uuid.UUID]ticket.Ticket
+	owners    map[uuid.UUID]uuid.UUID
+	order     []uuid.UUID
+	updateErr error
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{tickets: make(map[uuid.UUID]ticket.Ticket), owners: make(map[uuid.UUID]uuid.UUID)}
+}
+
+func (f *fakeRepository) add(t *testing.T, owner uuid.UUID, created time.Time) ticket.Ticket {
+	t.Helper()
+	tck, err := ticket.MakeBasicTicket(uuid.New(), created, ticket.Data{Title: "title", Status: ticket.Open})
+	if err != nil {
+		t.Fatalf("Error creating ticket: %v", err)
+	}
+	f.tickets[tck.ID()] = tck
+	f.owners[tck.ID()] = owner
+	f.order = append(f.order, tck.ID())
+	return tck
+}
+
+func (f *fakeRepository) GetTicket(id uuid.UUID) (ticket.Ticket, error) {
+	return f.tickets[id], nil
+}
+
+func (f *fakeRepository) UpdateTicket(tck ticket.Ticket) error {
+	if f.updateErr != nil {
+		return f.updateErr
+	}
+	f.tickets[tck.ID()] = tck
+	return nil
+}
+
+func (f *fakeRepository) GetOpenTickets() ([]ticket.Ticket, error) {
+	var open []ticket.Ticket
+	for _, id := range f.order {
+		if f.tickets[id].Status() != ticket.Closed {
+			open = append(open, f.tickets[id])
+		}
+	}
+	return open, nil
+}
+
+func (f *fakeRepository) GetTicketOwner(id uuid.UUID) (uuid.UUID, error) {
+	return f.owners[id], nil
+}
+
+func assertErrors(t *testing.T, err error, expected ...error) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("Error should not be nil")
+	}
+	for _, e := range expected {
+		if !errors.Is(err, e) {
+			t.Errorf("Error should be %v, but is %s", e, err.Error())
+		}
+	}
+}
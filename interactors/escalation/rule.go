@@ -0,0 +1,180 @@
+package escalation
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"ticketTao/entities/calendar"
+	"ticketTao/entities/ticket"
+	"time"
+)
+
+// Trigger defines which kind of waiting a rule measures.
+type Trigger string
+
+// NoAgentReply measures how long the client has been waiting for an agent, since the ticket was created or since the
+// last response written by the client.
+const NoAgentReply Trigger = "NoAgentReply"
+
+// ClientUnresponsive measures how long an agent has been waiting for the client, since the last response written by
+// someone else than the client.
+const ClientUnresponsive Trigger = "ClientUnresponsive"
+
+// Rule escalates the tickets that have been waiting for longer than After.
+type Rule struct {
+	// Name identifies the rule, it must be unique because it is used to avoid escalating a ticket twice.
+	Name    string
+	Trigger Trigger
+	After   time.Duration
+	// Calendar measures the waiting time, it uses wall-clock time when it is nil.
+	Calendar calendar.Calendar
+	Actions  []Action
+}
+
+// Escalation describes a rule that fired for a ticket.
+type Escalation struct {
+	Rule   string
+	Ticket uuid.UUID
+	Owner  uuid.UUID
+	// WaitingSince is the instant the measured waiting started, a rule fires only once per waiting period.
+	WaitingSince time.Time
+	At           time.Time
+}
+
+// Action is something done to a ticket when a rule fires. The changes made to the ticket are persisted by the engine
+// after every action of the rule has been applied.
+type Action interface {
+	Apply(tck ticket.Ticket, escalation Escalation) error
+}
+
+// Effect is an Action with side effects outside the ticket, like a notification. The engine applies the effects once
+// the ticket is persisted and the escalation recorded, so they never happen for an escalation that failed.
+type Effect interface {
+	Action
+	// AfterUpdate marks the action as an effect, it does nothing.
+	AfterUpdate()
+}
+
+// RaisePriority raises the priority of the ticket one level, up to Urgent.
+type RaisePriority struct{}
+
+func (RaisePriority) Apply(tck ticket.Ticket, _ Escalation) error {
+	switch tck.Priority() {
+	case ticket.Low:
+		tck.SetPriority(ticket.Normal)
+	case ticket.Normal:
+		tck.SetPriority(ticket.High)
+	default:
+		tck.SetPriority(ticket.Urgent)
+	}
+	return nil
+}
+
+// Notification is sent by the Notify action.
+type Notification struct {
+	Recipient  string
+	Escalation Escalation
+	Ticket     ticket.Ticket
+}
+
+// Notifier delivers notifications, e.g. by email or chat.
+type Notifier interface {
+	Notify(Notification) error
+}
+
+// Notify sends a notification about the escalation to the recipient, e.g. a supervisor.
+type Notify struct {
+	Notifier  Notifier
+	Recipient string
+}
+
+func (Notify) AfterUpdate() {}
+
+func (n Notify) Apply(tck ticket.Ticket, escalation Escalation) error {
+	if n.Notifier == nil {
+		return ErrNilNotifier
+	}
+	err := n.Notifier.Notify(Notification{Recipient: n.Recipient, Escalation: escalation, Ticket: tck})
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrNotifying, err)
+	}
+	return nil
+}
+
+// CloseWithResponse adds a response with the message, written by the author (usually a system user), and closes the
+// ticket with the reason, or as ticket.Resolved when it is empty.
+type CloseWithResponse struct {
+	Author  uuid.UUID
+	Message string
+	Reason  ticket.ClosingReason
+}
+
+func (c CloseWithResponse) Apply(tck ticket.Ticket, escalation Escalation) error {
+	tck.AddResponse(ticket.MakeNote(c.Author, c.Message, escalation.At))
+	if c.Reason == "" {
+		tck.Close()
+		return nil
+	}
+	tck.CloseWithReason(c.Reason)
+	return nil
+}
+
+func (r Rule) validate() error {
+	if r.Name == "" {
+		return ErrEmptyRuleName
+	}
+	if r.Trigger != NoAgentReply && r.Trigger != ClientUnresponsive {
+		return ErrUnknownTrigger
+	}
+	if r.After <= 0 {
+		return ErrInvalidWaitingTime
+	}
+	if len(r.Actions) == 0 {
+		return ErrNoActions
+	}
+	return nil
+}
+
+func (r Rule) calendar() calendar.Calendar {
+	if r.Calendar == nil {
+		return calendar.WallClock()
+	}
+	return r.Calendar
+}
+
+// waitingSince returns when the waiting measured by the rule started, and false if the ticket is not waiting in the
+// way the rule measures. The notes are not seen by the client, so they do not count as replies.
+func (r Rule) waitingSince(tck ticket.Ticket, owner uuid.UUID) (time.Time, bool) {
+	last := lastPublicResponse(tck)
+	switch r.Trigger {
+	case NoAgentReply:
+		if last == nil {
+			return tck.CreatedAt(), true
+		}
+		return last.TimeStamp(), last.UserId() == owner
+	case ClientUnresponsive:
+		if last == nil {
+			return time.Time{}, false
+		}
+		return last.TimeStamp(), last.UserId() != owner
+	}
+	return time.Time{}, false
+}
+
+// lastPublicResponse returns the latest response of the ticket that is not a note, or nil if there is none.
+func lastPublicResponse(tck ticket.Ticket) ticket.Response {
+	responses := tck.Responses()
+	for i := len(responses) - 1; i >= 0; i-- {
+		if !responses[i].IsNote() {
+			return responses[i]
+		}
+	}
+	return nil
+}
+
+var ErrEmptyRuleName error = errors.New("escalation rule name cannot be empty")
+var ErrUnknownTrigger error = errors.New("unknown escalation trigger")
+var ErrInvalidWaitingTime error = errors.New("escalation waiting time must be positive")
+var ErrNoActions error = errors.New("escalation rule must have actions")
+var ErrNilNotifier error = errors.New("notifier cannot be nil")
+var ErrNotifying error = errors.New("error sending escalation notification")
@@ -0,0 +1,92 @@
+// Package follow notifies the followers of a ticket about its updates. The followers are the users, other than the
+// owner, that follow the ticket (see ticket.Ticket.Follow).
+package follow
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"slices"
+	"ticketTao/interactors/ticket/events"
+)
+
+// Notification is an update of a ticket addressed to one of its followers.
+type Notification struct {
+	Recipient uuid.UUID
+	Event     events.Event
+}
+
+// Notifier delivers the notifications, e.g. by email or push.
+type Notifier interface {
+	Notify(Notification) error
+}
+
+// FollowerNotifier notifies the followers of the tickets about the ticket lifecycle events.
+type FollowerNotifier interface {
+	// Recipients returns the followers to notify of the event. The author of a response is not notified of it.
+	Recipients(events.Event) []uuid.UUID
+	// Handle notifies every recipient of the event, a failing notification does not stop the rest, its error is
+	// joined to the returned error.
+	Handle(events.Event) error
+}
+
+// NewFollowerNotifier creates a FollowerNotifier that delivers the notifications through the notifier.
+func NewFollowerNotifier(notifier Notifier) (FollowerNotifier, error) {
+	if notifier == nil {
+		return nil, errors.Join(NewFollowerNotifierError, ErrNilNotifier)
+	}
+	return basicFollowerNotifier{notifier}, nil
+}
+
+// Subscribe registers the follower notifier as an asynchronous subscriber, the errors of the notifications are passed
+// to onError, which can be nil to ignore them.
+func Subscribe(subscriber events.Subscriber, notifier FollowerNotifier, onError func(error)) error {
+	if subscriber == nil {
+		return errors.Join(events.SubscribeError, events.ErrNilSubscriber)
+	}
+	if notifier == nil {
+		return errors.Join(events.SubscribeError, ErrNilNotifier)
+	}
+	return subscriber.SubscribeAsync(func(e events.Event) {
+		if err := notifier.Handle(e); err != nil && onError != nil {
+			onError(err)
+		}
+	})
+}
+
+type basicFollowerNotifier struct {
+	notifier Notifier
+}
+
+func (b basicFollowerNotifier) Recipients(e events.Event) []uuid.UUID {
+	switch typed := e.(type) {
+	case events.ResponseAdded:
+		return slices.DeleteFunc(typed.Ticket.Followers(), func(follower uuid.UUID) bool {
+			return follower == typed.Response.UserId()
+		})
+	case events.StatusChanged:
+		return typed.Ticket.Followers()
+	case events.TicketClosed:
+		return typed.Ticket.Followers()
+	default:
+		return nil
+	}
+}
+
+func (b basicFollowerNotifier) Handle(e events.Event) error {
+	var failures []error
+	for _, recipient := range b.Recipients(e) {
+		if err := b.notifier.Notify(Notification{Recipient: recipient, Event: e}); err != nil {
+			failures = append(failures, fmt.Errorf("follower %s: %w", recipient, err))
+		}
+	}
+	if len(failures) > 0 {
+		return errors.Join(HandleError, errors.Join(failures...))
+	}
+	return nil
+}
+
+var NewFollowerNotifierError error = errors.New("error creating follower notifier")
+var HandleError error = errors.New("error notifying followers")
+
+var ErrNilNotifier error = errors.New("notifier cannot be nil")
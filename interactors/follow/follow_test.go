@@ -0,0 +1,102 @@
+package follow
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"slices"
+	"testing"
+	"ticketTao/entities/ticket"
+	"ticketTao/interactors/ticket/events"
+	"time"
+)
+
+func TestNewFollowerNotifier(t *testing.T) {
+	t.Parallel()
+	t.Run("It should return an error when the notifier is nil", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewFollowerNotifier(nil)
+		assertErrors(t, err, NewFollowerNotifierError, ErrNilNotifier)
+	})
+}
+
+func TestFollowerNotifier(t *testing.T) {
+	t.Parallel()
+	follower, author := uuid.New(), uuid.New()
+	tck, _ := ticket.NewBasicTicket("title", "description")
+	tck.Follow(follower)
+	tck.Follow(author)
+
+	t.Run("The followers are notified of the updates of the ticket, except of their own responses", func(t *testing.T) {
+		t.Parallel()
+		notifier := &spyNotifier{}
+		followers, _ := NewFollowerNotifier(notifier)
+		response := ticket.NewResponse(author, "any news?")
+
+		_ = followers.Handle(events.ResponseAdded{Ticket: tck, Response: response, At: time.Now()})
+		_ = followers.Handle(events.TicketClosed{Ticket: tck, At: time.Now()})
+		_ = followers.Handle(events.TicketCreated{Ticket: tck, Client: uuid.New(), At: time.Now()})
+
+		if len(notifier.sent) != 3 || notifier.sent[0].Recipient != follower {
+			t.Fatalf("Expected the follower to be notified of the response and both followers of the closure, got %v", notifier.sent)
+		}
+		if !slices.Equal(followers.Recipients(events.TicketClosed{Ticket: tck}), tck.Followers()) {
+			t.Error("Expected every follower to be a recipient of the closure")
+		}
+	})
+	t.Run("A failing notification does not stop the rest", func(t *testing.T) {
+		t.Parallel()
+		notifier := &spyNotifier{err: errors.New("push service is down")}
+		followers, _ := NewFollowerNotifier(notifier)
+
+		err := followers.Handle(events.TicketClosed{Ticket: tck, At: time.Now()})
+
+		assertErrors(t, err, HandleError, notifier.err)
+		if len(notifier.sent) != 2 {
+			t.Errorf("Expected both followers to be attempted, got %d", len(notifier.sent))
+		}
+	})
+	t.Run("It should not subscribe without a subscriber or a notifier", func(t *testing.T) {
+		t.Parallel()
+		followers, _ := NewFollowerNotifier(&spyNotifier{})
+		assertErrors(t, Subscribe(nil, followers, nil), events.SubscribeError, events.ErrNilSubscriber)
+		assertErrors(t, Subscribe(events.NewBus(), nil, nil), events.SubscribeError, ErrNilNotifier)
+	})
+	t.Run("Subscribed notifiers report their errors", func(t *testing.T) {
+		t.Parallel()
+		bus := events.NewBus()
+		followers, _ := NewFollowerNotifier(&spyNotifier{err: errors.New("push service is down")})
+		var reported []error
+		if err := Subscribe(bus, followers, func(err error) { reported = append(reported, err) }); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+
+		bus.Publish(events.TicketClosed{Ticket: tck, At: time.Now()})
+		bus.Close()
+
+		if len(reported) != 1 {
+			t.Errorf("Expected one error to be reported, got %v", reported)
+		}
+	})
+}
+
+type spyNotifier struct {
+	sent []Notification
+	err  error
+}
+
+func (s *spyNotifier) Notify(notification Notification) error {
+	s.sent = append(s.sent, notification)
+	return s.err
+}
+
+func assertErrors(t *testing.T, err error, expected ...error) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("Error should not be nil")
+	}
+	for _, e := range expected {
+		if !errors.Is(err, e) {
+			t.Errorf("Error should be %v, but is %s", e, err.Error())
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// The columns of the CSV exports. Only the ticket ID, the client, the creation time and the title are required.
+const (
+	ColumnID                = "id"
+	ColumnClient            = "client"
+	ColumnCreatedAt         = "created_at"
+	ColumnTitle             = "title"
+	ColumnDescription       = "description"
+	ColumnStatus            = "status"
+	ColumnPriority          = "priority"
+	ColumnClosedAt          = "closed_at"
+	ColumnAssignee          = "assignee"
+	ColumnTags              = "tags"
+	ColumnResponseUser      = "response_user"
+	ColumnResponseContent   = "response_content"
+	ColumnResponseCreatedAt = "response_created_at"
+)
+
+// TagSeparator separates the tags in the tags column.
+const TagSeparator = ";"
+
+var requiredColumns = []string{ColumnID, ColumnClient, ColumnCreatedAt, ColumnTitle}
+
+// ReadCSV reads a CSV export with a header and a row per message. The rows of a ticket share its ID, the ticket
+// columns are read from its first row and every row with a response user adds a response to the ticket. The ticket
+// columns of the later rows must be empty or equal to those of the first row, the others are added to the Errors of
+// the record. The rows are numbered from 1, the header being row 1.
+func ReadCSV(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.Join(ReadExportError, err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+	for _, required := range requiredColumns {
+		if _, ok := columns[required]; !ok {
+			return nil, errors.Join(ReadExportError, fmt.Errorf("%w: %s", ErrMissingColumn, required))
+		}
+	}
+
+	var records []Record
+	byID := make(map[string]int)
+	for row := 2; ; row++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, errors.Join(ReadExportError, err)
+		}
+		value := func(column string) string {
+			i, ok := columns[column]
+			if !ok || i >= len(fields) {
+				return ""
+			}
+			return fields[i]
+		}
+		read := Record{
+			Row:         row,
+			ID:          value(ColumnID),
+			Client:      value(ColumnClient),
+			CreatedAt:   value(ColumnCreatedAt),
+			Title:       value(ColumnTitle),
+			Description: value(ColumnDescription),
+			Status:      value(ColumnStatus),
+			Priority:    value(ColumnPriority),
+			ClosedAt:    value(ColumnClosedAt),
+			Assignee:    value(ColumnAssignee),
+			Tags:        splitTags(value(ColumnTags)),
+		}
+		i, found := byID[read.ID]
+		if !found || read.ID == "" {
+			i = len(records)
+			byID[read.ID] = i
+			records = append(records, read)
+		} else if column := conflictingColumn(records[i], read); column != "" {
+			records[i].Errors = append(records[i].Errors,
+				RowError{row, read.ID, fmt.Errorf("%w: %s", ErrConflictingRow, column)})
+		}
+		if user := value(ColumnResponseUser); user != "" {
+			records[i].Responses = append(records[i].Responses, LegacyResponse{
+				Row:       row,
+				User:      user,
+				Content:   value(ColumnResponseContent),
+				CreatedAt: value(ColumnResponseCreatedAt),
+			})
+		}
+	}
+}
+
+// conflictingColumn returns the first ticket column that is set in a later row of the ticket with another value than
+// in its first row, or an empty string.
+func conflictingColumn(first, later Record) string {
+	columns := []struct {
+		name         string
+		first, later string
+	}{
+		{ColumnClient, first.Client, later.Client},
+		{ColumnCreatedAt, first.CreatedAt, later.CreatedAt},
+		{ColumnTitle, first.Title, later.Title},
+		{ColumnDescription, first.Description, later.Description},
+		{ColumnStatus, first.Status, later.Status},
+		{ColumnPriority, first.Priority, later.Priority},
+		{ColumnClosedAt, first.ClosedAt, later.ClosedAt},
+		{ColumnAssignee, first.Assignee, later.Assignee},
+		{ColumnTags, strings.Join(first.Tags, TagSeparator), strings.Join(later.Tags, TagSeparator)},
+	}
+	for _, column := range columns {
+		if column.later != "" && column.later != column.first {
+			return column.name
+		}
+	}
+	return ""
+}
+
+func splitTags(tags string) []string {
+	if tags == "" {
+		return nil
+	}
+	return slices.DeleteFunc(strings.Split(tags, TagSeparator), func(tag string) bool {
+		return strings.TrimSpace(tag) == ""
+	})
+}
+
+// ReadJSON reads a JSON export with an array of tickets shaped like Record. The tickets are numbered from 1, in the
+// order of the array.
+func ReadJSON(r io.Reader) ([]Record, error) {
+	var records []Record
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, errors.Join(ReadExportError, err)
+	}
+	for i := range records {
+		records[i].Row = i + 1
+		for j := range records[i].Responses {
+			records[i].Responses[j].Row = i + 1
+		}
+	}
+	return records, nil
+}
+
+var ReadExportError error = errors.New("error reading legacy export")
+
+var ErrMissingColumn error = errors.New("the export lacks a required column")
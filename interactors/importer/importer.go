@@ -0,0 +1,315 @@
+// Package importer migrates the tickets exported by a legacy helpdesk, keeping their original IDs, creation times and
+// conversations.
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"slices"
+	"ticketTao/entities/ticket"
+	"time"
+)
+
+// LegacyNamespace is the namespace of the name-based UUIDs given to the legacy tickets whose ID is not a UUID, so
+// importing a ticket twice gives it the same ID.
+var LegacyNamespace = uuid.MustParse("6f1f4c52-8a57-4b1e-9d3c-2a6de3a0c1b7")
+
+// TicketID returns the ID of an imported ticket: the legacy ID itself when it is a UUID, a UUID derived from it
+// otherwise.
+func TicketID(legacyID string) uuid.UUID {
+	if id, err := uuid.Parse(legacyID); err == nil {
+		return id
+	}
+	return uuid.NewSHA1(LegacyNamespace, []byte(legacyID))
+}
+
+// Record is a legacy ticket as read from an export, before validation. The times are formatted as RFC 3339.
+type Record struct {
+	// Row is the position of the ticket in the export, used to report its errors.
+	Row         int              `json:"-"`
+	ID          string           `json:"id"`
+	Client      string           `json:"client"`
+	CreatedAt   string           `json:"created_at"`
+	Title       string           `json:"title"`
+	Description string           `json:"description"`
+	Status      string           `json:"status"`
+	Priority    string           `json:"priority"`
+	ClosedAt    string           `json:"closed_at"`
+	Assignee    string           `json:"assignee"`
+	Tags        []string         `json:"tags"`
+	Responses   []LegacyResponse `json:"responses"`
+	// Errors are the problems found while reading the ticket, such as CSV rows disagreeing with its first row. A
+	// record with errors is not imported.
+	Errors []RowError `json:"-"`
+}
+
+// LegacyResponse is a message of the conversation of a legacy ticket, written by a client or an agent.
+type LegacyResponse struct {
+	Row       int    `json:"-"`
+	User      string `json:"user"`
+	Content   string `json:"content"`
+	CreatedAt string `json:"created_at"`
+}
+
+// UserMapping maps the legacy user IDs to the IDs of the clients and agents.
+type UserMapping interface {
+	Client(legacyID string) (uuid.UUID, bool)
+	Agent(legacyID string) (uuid.UUID, bool)
+}
+
+// Users is a UserMapping backed by maps.
+type Users struct {
+	Clients map[string]uuid.UUID
+	Agents  map[string]uuid.UUID
+}
+
+func (u Users) Client(legacyID string) (uuid.UUID, bool) {
+	id, ok := u.Clients[legacyID]
+	return id, ok
+}
+
+func (u Users) Agent(legacyID string) (uuid.UUID, bool) {
+	id, ok := u.Agents[legacyID]
+	return id, ok
+}
+
+// RowError is the reason a ticket of the export was not imported.
+type RowError struct {
+	Row      int
+	LegacyID string
+	Err      error
+}
+
+func (e RowError) Error() string {
+	return fmt.Sprintf("row %d (ticket %q): %s", e.Row, e.LegacyID, e.Err)
+}
+
+func (e RowError) Unwrap() error {
+	return e.Err
+}
+
+// Result reports what an import did, or would do in a dry run.
+type Result struct {
+	DryRun bool
+	// Tickets maps the legacy IDs of the imported tickets, or of the valid ones in a dry run, to their IDs.
+	Tickets map[string]uuid.UUID
+	// Errors are sorted by row.
+	Errors []RowError
+}
+
+// Importer creates the tickets of the legacy exports. A ticket with any invalid row is skipped and reported, the
+// other tickets are still imported.
+type Importer interface {
+	// ImportCSV imports an export with a row per message, see ReadCSV.
+	ImportCSV(csv io.Reader, dryRun bool) (Result, error)
+	// ImportJSON imports an export with an array of tickets, see ReadJSON.
+	ImportJSON(json io.Reader, dryRun bool) (Result, error)
+	// Import validates the records and, unless it is a dry run, saves the valid tickets for their clients.
+	Import(records []Record, dryRun bool) (Result, error)
+}
+
+// TicketReader gets the saved tickets, like a repository.TicketPersistence.
+type TicketReader interface {
+	GetTicket(id uuid.UUID) (ticket.Ticket, error)
+}
+
+// NewImporter creates an Importer that saves the tickets with the writer. The writer decides whether importing a
+// ticket publishes events, so it should usually not be decorated with the notifications. The reader is used to skip
+// the tickets that were already imported, even in a dry run.
+func NewImporter(writer ticket.RepositoryClientWriter, reader TicketReader, users UserMapping) (Importer, error) {
+	if writer == nil {
+		return nil, errors.Join(NewImporterError, ErrNilWriter)
+	}
+	if reader == nil {
+		return nil, errors.Join(NewImporterError, ErrNilReader)
+	}
+	if users == nil {
+		return nil, errors.Join(NewImporterError, ErrNilUserMapping)
+	}
+	return basicImporter{writer: writer, reader: reader, users: users}, nil
+}
+
+type basicImporter struct {
+	writer ticket.RepositoryClientWriter
+	reader TicketReader
+	users  UserMapping
+}
+
+func (b basicImporter) ImportCSV(csv io.Reader, dryRun bool) (Result, error) {
+	records, err := ReadCSV(csv)
+	if err != nil {
+		return Result{}, errors.Join(ImportError, err)
+	}
+	return b.Import(records, dryRun)
+}
+
+func (b basicImporter) ImportJSON(json io.Reader, dryRun bool) (Result, error) {
+	records, err := ReadJSON(json)
+	if err != nil {
+		return Result{}, errors.Join(ImportError, err)
+	}
+	return b.Import(records, dryRun)
+}
+
+func (b basicImporter) Import(records []Record, dryRun bool) (Result, error) {
+	result := Result{DryRun: dryRun, Tickets: make(map[string]uuid.UUID)}
+	seen := make(map[string]bool)
+	for _, record := range records {
+		if seen[record.ID] && record.ID != "" {
+			result.Errors = append(result.Errors, RowError{record.Row, record.ID, ErrDuplicatedLegacyID})
+			continue
+		}
+		seen[record.ID] = true
+		if len(record.Errors) > 0 {
+			result.Errors = append(result.Errors, record.Errors...)
+			continue
+		}
+		owner, tck, rowErr := b.makeTicket(record)
+		if rowErr != nil {
+			result.Errors = append(result.Errors, *rowErr)
+			continue
+		}
+		if _, err := b.reader.GetTicket(tck.ID()); err == nil {
+			result.Errors = append(result.Errors, RowError{record.Row, record.ID, ErrAlreadyImported})
+			continue
+		}
+		if !dryRun {
+			if err := b.writer.CreateNewTicketForClient(owner, tck); err != nil {
+				result.Errors = append(result.Errors, RowError{record.Row, record.ID, errors.Join(ErrSavingTicket, err)})
+				continue
+			}
+		}
+		result.Tickets[record.ID] = tck.ID()
+	}
+	slices.SortStableFunc(result.Errors, func(a, b RowError) int { return a.Row - b.Row })
+	return result, nil
+}
+
+// makeTicket validates the record and returns its owner and ticket.
+func (b basicImporter) makeTicket(record Record) (uuid.UUID, ticket.Ticket, *RowError) {
+	fail := func(row int, err error) (uuid.UUID, ticket.Ticket, *RowError) {
+		return uuid.Nil, nil, &RowError{Row: row, LegacyID: record.ID, Err: err}
+	}
+	if record.ID == "" {
+		return fail(record.Row, ErrMissingLegacyID)
+	}
+	owner, ok := b.users.Client(record.Client)
+	if !ok {
+		return fail(record.Row, fmt.Errorf("%w: %q", ErrUnknownClient, record.Client))
+	}
+	createdAt, err := parseTime("created_at", record.CreatedAt)
+	if err != nil {
+		return fail(record.Row, err)
+	}
+	data := ticket.Data{
+		Title:       record.Title,
+		Description: record.Description,
+		Status:      ticket.Status(record.Status),
+		Priority:    ticket.Priority(record.Priority),
+		Tags:        record.Tags,
+	}
+	if data.Status == "" {
+		data.Status = ticket.Open
+	}
+	if !slices.Contains(statuses, data.Status) {
+		return fail(record.Row, fmt.Errorf("%w: %q", ErrUnknownStatus, record.Status))
+	}
+	if data.Priority != "" && !slices.Contains(priorities, data.Priority) {
+		return fail(record.Row, fmt.Errorf("%w: %q", ErrUnknownPriority, record.Priority))
+	}
+	if record.Assignee != "" {
+		if data.Assignee, ok = b.users.Agent(record.Assignee); !ok {
+			return fail(record.Row, fmt.Errorf("%w: %q", ErrUnknownAgent, record.Assignee))
+		}
+	}
+	for _, legacy := range record.Responses {
+		response, err := b.makeResponse(legacy, createdAt)
+		if err != nil {
+			return fail(legacy.Row, err)
+		}
+		data.Responses = append(data.Responses, response)
+	}
+	slices.SortStableFunc(data.Responses, func(a, b ticket.Response) int {
+		return a.TimeStamp().Compare(b.TimeStamp())
+	})
+	if data.Status == ticket.Closed {
+		if data.ClosedAt, err = b.closingTime(record, createdAt, data.Responses); err != nil {
+			return fail(record.Row, err)
+		}
+		data.ClosingReason = ticket.Resolved
+	}
+	tck, err := ticket.MakeBasicTicket(TicketID(record.ID), createdAt, data)
+	if err != nil {
+		return fail(record.Row, err)
+	}
+	return owner, tck, nil
+}
+
+func (b basicImporter) makeResponse(legacy LegacyResponse, ticketCreation time.Time) (ticket.Response, error) {
+	user, ok := b.users.Client(legacy.User)
+	if !ok {
+		if user, ok = b.users.Agent(legacy.User); !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownUser, legacy.User)
+		}
+	}
+	at, err := parseTime("response created_at", legacy.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if at.Before(ticketCreation) {
+		return nil, ErrResponseBeforeTicket
+	}
+	return ticket.MakeResponse(user, legacy.Content, at), nil
+}
+
+// closingTime returns the closing time of the record, the time of its last message when the export does not have it.
+// The responses are sorted by time.
+func (b basicImporter) closingTime(record Record, createdAt time.Time, responses []ticket.Response) (time.Time, error) {
+	if record.ClosedAt == "" {
+		if len(responses) == 0 {
+			return createdAt, nil
+		}
+		return responses[len(responses)-1].TimeStamp(), nil
+	}
+	closedAt, err := parseTime("closed_at", record.ClosedAt)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if closedAt.Before(createdAt) {
+		return time.Time{}, ErrClosedBeforeCreation
+	}
+	return closedAt, nil
+}
+
+func parseTime(field, value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w %s: %w", ErrInvalidTime, field, err)
+	}
+	return t, nil
+}
+
+var statuses = []ticket.Status{ticket.Open, ticket.InProgress, ticket.Closed}
+var priorities = []ticket.Priority{ticket.Low, ticket.Normal, ticket.High, ticket.Urgent}
+
+var NewImporterError error = errors.New("error creating ticket importer")
+var ImportError error = errors.New("error importing tickets")
+
+var ErrNilWriter error = errors.New("ticket writer cannot be nil")
+var ErrNilReader error = errors.New("ticket reader cannot be nil")
+var ErrNilUserMapping error = errors.New("user mapping cannot be nil")
+var ErrMissingLegacyID error = errors.New("legacy ticket ID is missing")
+var ErrDuplicatedLegacyID error = errors.New("legacy ticket ID appears more than once")
+var ErrUnknownClient error = errors.New("unknown legacy client")
+var ErrUnknownAgent error = errors.New("unknown legacy agent")
+var ErrUnknownUser error = errors.New("unknown legacy user")
+var ErrUnknownStatus error = errors.New("unknown ticket status")
+var ErrUnknownPriority error = errors.New("unknown ticket priority")
+var ErrInvalidTime error = errors.New("invalid time")
+var ErrResponseBeforeTicket error = errors.New("response was written before the ticket was created")
+var ErrClosedBeforeCreation error = errors.New("ticket was closed before it was created")
+var ErrAlreadyImported error = errors.New("ticket was already imported")
+var ErrConflictingRow error = errors.New("row disagrees with the first row of its ticket")
+var ErrSavingTicket error = errors.New("error saving ticket")
@@ -0,0 +1,213 @@
+package importer
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"strings"
+	"testing"
+	"ticketTao/entities/ticket"
+	"ticketTao/interactors/ticket/persistence/memory"
+	"ticketTao/interactors/ticket/repository"
+	"time"
+)
+
+const legacyCSV = `id,client,created_at,title,description,status,priority,closed_at,assignee,tags,response_user,response_content,response_created_at
+L-1,c1,2023-01-02T10:00:00Z,Broken invoice,Total is wrong,Closed,High,2023-01-03T10:00:00Z,a1,billing;invoices,c1,Any news?,2023-01-02T12:00:00Z
+L-1,,,,,,,,,,a1,Fixed,2023-01-03T09:00:00Z
+L-2,c2,2023-02-01T08:00:00Z,Login,,Open,,,,,,,
+L-3,unknown,2023-02-01T08:00:00Z,Unknown client,,Open,,,,,,,
+L-4,c1,2023-02-01T08:00:00Z,Bad response,,InProgress,,,,,ghost,Hello,2023-02-01T09:00:00Z
+L-5,c1,yesterday,Bad time,,Open,,,,,,,
+L-6,c1,2023-02-01T08:00:00Z,Bad status,,Pending,,,,,,,
+L-7,c1,2023-02-01T08:00:00Z,Conflict,,Open,,,,,,,
+L-7,c2,,,,,,,,,c1,Hello,2023-02-01T09:00:00Z
+L-8,c1,2023-03-01T08:00:00Z,Unordered,,Closed,,,,,a1,Done,2023-03-02T08:00:00Z
+L-8,,,,,,,,,,c1,Help,2023-03-01T09:00:00Z
+`
+
+func TestImporter_ImportCSV(t *testing.T) {
+	t.Parallel()
+	users := Users{
+		Clients: map[string]uuid.UUID{"c1": uuid.New(), "c2": uuid.New()},
+		Agents:  map[string]uuid.UUID{"a1": uuid.New()},
+	}
+	t.Run("It should import the valid tickets with their conversations and report the invalid rows", func(t *testing.T) {
+		t.Parallel()
+		tp := memory.NewTicketPersistence()
+		repo, _ := repository.GetClientTicketRepository(tp)
+		importer, _ := NewImporter(repo, tp, users)
+
+		result, err := importer.ImportCSV(strings.NewReader(legacyCSV), false)
+
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if len(result.Tickets) != 3 || result.Tickets["L-1"] != TicketID("L-1") {
+			t.Errorf("Expected tickets L-1, L-2 and L-8 to be imported, got %v", result.Tickets)
+		}
+		expectedErrors := []struct {
+			row int
+			err error
+		}{{5, ErrUnknownClient}, {6, ErrUnknownUser}, {7, ErrInvalidTime}, {8, ErrUnknownStatus}, {10, ErrConflictingRow}}
+		if len(result.Errors) != len(expectedErrors) {
+			t.Fatalf("Expected %d row errors, got %v", len(expectedErrors), result.Errors)
+		}
+		for i, expected := range expectedErrors {
+			if result.Errors[i].Row != expected.row || !errors.Is(result.Errors[i], expected.err) {
+				t.Errorf("Expected error %v at row %d, got %v", expected.err, expected.row, result.Errors[i])
+			}
+		}
+
+		tck, err := tp.GetTicket(TicketID("L-1"))
+		if err != nil {
+			t.Fatalf("The ticket should be saved, got %v", err)
+		}
+		owner, _ := tp.GetTicketOwner(tck.ID())
+		if owner != users.Clients["c1"] || tck.Assignee() != users.Agents["a1"] || tck.Priority() != ticket.High {
+			t.Errorf("Expected the ticket to keep its owner, assignee and priority, got %v %v %v", owner, tck.Assignee(), tck.Priority())
+		}
+		if !tck.CreatedAt().Equal(time.Date(2023, time.January, 2, 10, 0, 0, 0, time.UTC)) ||
+			!tck.ClosedAt().Equal(time.Date(2023, time.January, 3, 10, 0, 0, 0, time.UTC)) || tck.Status() != ticket.Closed {
+			t.Errorf("Expected the original times, got %v %v", tck.CreatedAt(), tck.ClosedAt())
+		}
+		responses := tck.Responses()
+		if len(responses) != 2 || responses[0].UserId() != users.Clients["c1"] || responses[1].UserId() != users.Agents["a1"] ||
+			responses[1].Content() != "Fixed" {
+			t.Errorf("Expected the conversation to be rebuilt, got %v", responses)
+		}
+		if tags := tck.Tags(); len(tags) != 2 || tags[0] != "billing" {
+			t.Errorf("Expected the tags, got %v", tags)
+		}
+		unordered, _ := tp.GetTicket(TicketID("L-8"))
+		if responses := unordered.Responses(); len(responses) != 2 || responses[0].Content() != "Help" ||
+			!unordered.ClosedAt().Equal(time.Date(2023, time.March, 2, 8, 0, 0, 0, time.UTC)) {
+			t.Errorf("Expected the responses sorted by time and closed at the last one, got %v %v", responses, unordered.ClosedAt())
+		}
+	})
+	t.Run("It should not save anything in a dry run", func(t *testing.T) {
+		t.Parallel()
+		tp := memory.NewTicketPersistence()
+		repo, _ := repository.GetClientTicketRepository(tp)
+		importer, _ := NewImporter(repo, tp, users)
+
+		result, err := importer.ImportCSV(strings.NewReader(legacyCSV), true)
+
+		if err != nil || !result.DryRun || len(result.Tickets) != 3 || len(result.Errors) != 5 {
+			t.Errorf("Expected the dry run to validate the export, got %+v, %v", result, err)
+		}
+		if tickets, _ := tp.GetAllTickets(); len(tickets) != 0 {
+			t.Errorf("Expected no saved ticket, got %d", len(tickets))
+		}
+	})
+	t.Run("It should report the tickets already imported, even in a dry run", func(t *testing.T) {
+		t.Parallel()
+		tp := memory.NewTicketPersistence()
+		repo, _ := repository.GetClientTicketRepository(tp)
+		importer, _ := NewImporter(repo, tp, users)
+		_, _ = importer.ImportCSV(strings.NewReader(legacyCSV), false)
+
+		for _, dryRun := range []bool{true, false} {
+			result, _ := importer.ImportCSV(strings.NewReader(legacyCSV), dryRun)
+
+			if len(result.Tickets) != 0 || !errors.Is(result.Errors[0], ErrAlreadyImported) {
+				t.Errorf("Expected the already imported tickets to be reported, got %+v", result)
+			}
+		}
+	})
+	t.Run("It should report the tickets that cannot be saved", func(t *testing.T) {
+		t.Parallel()
+		importer, _ := NewImporter(&spyClientWriter{err: errors.New("disk full")}, memory.NewTicketPersistence(), users)
+
+		result, _ := importer.ImportCSV(strings.NewReader(legacyCSV), false)
+
+		if len(result.Tickets) != 0 || !errors.Is(result.Errors[0], ErrSavingTicket) {
+			t.Errorf("Expected the tickets to fail, got %+v", result)
+		}
+	})
+	t.Run("It should reject exports without the required columns", func(t *testing.T) {
+		t.Parallel()
+		importer, _ := NewImporter(&spyClientWriter{}, memory.NewTicketPersistence(), users)
+		_, err := importer.ImportCSV(strings.NewReader("id,client,title\nL-1,c1,title\n"), false)
+		assertErrors(t, err, ImportError, ReadExportError, ErrMissingColumn)
+	})
+}
+
+func TestImporter_ImportJSON(t *testing.T) {
+	t.Parallel()
+	client := uuid.New()
+	importer, _ := NewImporter(&spyClientWriter{}, memory.NewTicketPersistence(), Users{Clients: map[string]uuid.UUID{"c1": client}})
+	export := `[
+		{"id": "f47ac10b-58cc-4372-a567-0e02b2c3d479", "client": "c1", "created_at": "2023-01-02T10:00:00Z", "title": "Kept ID",
+		 "responses": [{"user": "c1", "content": "Hi", "created_at": "2023-01-02T11:00:00Z"}]},
+		{"id": "J-2", "client": "c1", "created_at": "2023-01-02T10:00:00Z", "title": "Early response",
+		 "responses": [{"user": "c1", "content": "Hi", "created_at": "2023-01-01T11:00:00Z"}]},
+		{"id": "J-3", "client": "c1", "created_at": "2023-01-02T10:00:00Z", "title": "", "status": "Open"},
+		{"id": "J-3", "client": "c1", "created_at": "2023-01-02T10:00:00Z", "title": "Again"}
+	]`
+
+	result, err := importer.ImportJSON(strings.NewReader(export), false)
+
+	if err != nil {
+		t.Fatalf("Error should be nil, got %v", err)
+	}
+	if result.Tickets["f47ac10b-58cc-4372-a567-0e02b2c3d479"].String() != "f47ac10b-58cc-4372-a567-0e02b2c3d479" {
+		t.Errorf("Expected the legacy UUID to be kept, got %v", result.Tickets)
+	}
+	expected := []error{ErrResponseBeforeTicket, ticket.ErrEmptyTitle, ErrDuplicatedLegacyID}
+	if len(result.Errors) != len(expected) {
+		t.Fatalf("Expected %d row errors, got %v", len(expected), result.Errors)
+	}
+	for i, err := range expected {
+		if result.Errors[i].Row != i+2 || !errors.Is(result.Errors[i], err) {
+			t.Errorf("Expected error %v at row %d, got %v", err, i+2, result.Errors[i])
+		}
+	}
+	_, err = importer.ImportJSON(strings.NewReader("{"), false)
+	assertErrors(t, err, ImportError, ReadExportError)
+}
+
+func TestNewImporter(t *testing.T) {
+	t.Parallel()
+	_, err := NewImporter(nil, memory.NewTicketPersistence(), Users{})
+	assertErrors(t, err, NewImporterError, ErrNilWriter)
+	_, err = NewImporter(&spyClientWriter{}, nil, Users{})
+	assertErrors(t, err, NewImporterError, ErrNilReader)
+	_, err = NewImporter(&spyClientWriter{}, memory.NewTicketPersistence(), nil)
+	assertErrors(t, err, NewImporterError, ErrNilUserMapping)
+}
+
+func TestTicketID(t *testing.T) {
+	t.Parallel()
+	if TicketID("L-1") != TicketID("L-1") || TicketID("L-1") == TicketID("L-2") {
+		t.Error("Expected the same legacy ID to always give the same ticket ID")
+	}
+}
+
+type spyClientWriter struct {
+	created []ticket.Ticket
+	err     error
+}
+
+func (s *spyClientWriter) CreateNewTicketForClient(_ uuid.UUID, tck ticket.Ticket) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.created = append(s.created, tck)
+	return nil
+}
+
+func (s *spyClientWriter) UpdateTicketForClient(uuid.UUID, ticket.Ticket) error {
+	return nil
+}
+
+func assertErrors(t *testing.T, err error, expected ...error) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("Error should not be nil")
+	}
+	for _, e := range expected {
+		if !errors.Is(err, e) {
+			t.Errorf("Error should be %v, but is %s", e, err.Error())
+		}
+	}
+}
@@ -0,0 +1,380 @@
+// Package metrics exposes counters, histograms and gauges in the Prometheus text exposition format, and instruments
+// the ticket repositories and persistence drivers with them.
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the content type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the upper bounds, in seconds, of the histograms of latencies.
+var DefaultBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// CollectionErrorsMetric is the counter, written by every Registry after its other metrics, of the collections that
+// failed, by metric name.
+const CollectionErrorsMetric = "metrics_collection_errors_total"
+
+// NewRegistry creates a Registry whose only metric is CollectionErrorsMetric.
+func NewRegistry() Registry {
+	failures := newCounter(CollectionErrorsMetric, "Metrics that could not be collected, by metric.", []string{"metric"})
+	return &basicRegistry{names: map[string]bool{CollectionErrorsMetric: true}, failures: failures}
+}
+
+// Registry keeps the metrics of the application and writes them, in registration order.
+type Registry interface {
+	// NewCounter registers a counter whose series are identified by the values of the labels. A counter without
+	// labels starts at 0.
+	NewCounter(name, help string, labels ...string) (Counter, error)
+	// NewHistogram registers a histogram with the given bucket upper bounds, sorted in increasing order. The +Inf
+	// bucket is always added.
+	NewHistogram(name, help string, buckets []float64, labels ...string) (Histogram, error)
+	// NewGaugeFunc registers a gauge whose values are collected, by label value, every time the metrics are written.
+	NewGaugeFunc(name, help, label string, collect func() (map[string]float64, error)) error
+	// WriteText writes every metric in the Prometheus text exposition format. The metrics whose collection fails are
+	// left out and counted in CollectionErrorsMetric, and the errors are returned after writing the others.
+	WriteText(w io.Writer) error
+}
+
+// Counter is a value that only goes up.
+type Counter interface {
+	// Add adds a non-negative value to the series of the label values, given in the order of the labels.
+	Add(value float64, labelValues ...string) error
+	Inc(labelValues ...string) error
+}
+
+// Histogram counts observations in buckets.
+type Histogram interface {
+	// Observe records a value in the series of the label values, given in the order of the labels.
+	Observe(value float64, labelValues ...string) error
+}
+
+// Handler returns an HTTP handler that serves the metrics of the registry. The metrics that cannot be collected are
+// left out, so that one failing gauge does not hide the others, and counted in CollectionErrorsMetric.
+func Handler(registry Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body bytes.Buffer
+		_ = registry.WriteText(&body)
+		w.Header().Set("Content-Type", ContentType)
+		_, _ = w.Write(body.Bytes())
+	})
+}
+
+var metricName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+var labelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// family is the part shared by every kind of metric.
+type family struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (f family) familyName() string {
+	return f.name
+}
+
+func (f family) writeHeader(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, escapeHelp(f.help), f.name, f.kind)
+}
+
+// seriesKey identifies the series of the label values, after checking that there is a value per label.
+func (f family) seriesKey(labelValues []string) (string, error) {
+	if len(labelValues) != len(f.labels) {
+		return "", fmt.Errorf("%w: %s expects %d values, got %d", ErrLabelCount, f.name, len(f.labels), len(labelValues))
+	}
+	return strings.Join(labelValues, "\xff"), nil
+}
+
+type collector interface {
+	familyName() string
+	write(w io.Writer) error
+}
+
+type basicRegistry struct {
+	mu         sync.Mutex
+	names      map[string]bool
+	collectors []collector
+	// failures is the CollectionErrorsMetric counter, it is not in the collectors so that it is written last.
+	failures *basicCounter
+}
+
+func (b *basicRegistry) register(f family, c collector) error {
+	if !metricName.MatchString(f.name) {
+		return fmt.Errorf("%w: %q", ErrInvalidMetricName, f.name)
+	}
+	for _, label := range f.labels {
+		if !labelName.MatchString(label) || strings.HasPrefix(label, "__") {
+			return fmt.Errorf("%w: %q", ErrInvalidLabelName, label)
+		}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.names[f.name] {
+		return fmt.Errorf("%w: %s", ErrDuplicatedMetric, f.name)
+	}
+	b.names[f.name] = true
+	b.collectors = append(b.collectors, c)
+	return nil
+}
+
+func (b *basicRegistry) NewCounter(name, help string, labels ...string) (Counter, error) {
+	counter := newCounter(name, help, labels)
+	if err := b.register(counter.family, counter); err != nil {
+		return nil, errors.Join(NewMetricError, err)
+	}
+	return counter, nil
+}
+
+func (b *basicRegistry) NewHistogram(name, help string, buckets []float64, labels ...string) (Histogram, error) {
+	if len(buckets) == 0 || !slices.IsSorted(buckets) || slices.Contains(buckets, math.Inf(1)) ||
+		len(slices.Compact(slices.Clone(buckets))) != len(buckets) {
+		return nil, errors.Join(NewMetricError, ErrInvalidBuckets)
+	}
+	if slices.Contains(labels, "le") {
+		return nil, errors.Join(NewMetricError, fmt.Errorf("%w: %q", ErrInvalidLabelName, "le"))
+	}
+	histogram := &basicHistogram{
+		family:  family{name: name, help: help, kind: "histogram", labels: slices.Clone(labels)},
+		buckets: slices.Clone(buckets),
+		series:  make(map[string]*histogramSeries),
+	}
+	if err := b.register(histogram.family, histogram); err != nil {
+		return nil, errors.Join(NewMetricError, err)
+	}
+	return histogram, nil
+}
+
+func (b *basicRegistry) NewGaugeFunc(name, help, label string, collect func() (map[string]float64, error)) error {
+	if collect == nil {
+		return errors.Join(NewMetricError, ErrNilCollect)
+	}
+	gauge := gaugeFunc{family: family{name: name, help: help, kind: "gauge", labels: []string{label}}, collect: collect}
+	if err := b.register(gauge.family, gauge); err != nil {
+		return errors.Join(NewMetricError, err)
+	}
+	return nil
+}
+
+func (b *basicRegistry) WriteText(w io.Writer) error {
+	b.mu.Lock()
+	collectors := slices.Clone(b.collectors)
+	b.mu.Unlock()
+	var errs []error
+	for _, c := range collectors {
+		var text bytes.Buffer
+		if err := c.write(&text); err != nil {
+			_ = b.failures.Inc(c.familyName())
+			errs = append(errs, err)
+			continue
+		}
+		if _, err := w.Write(text.Bytes()); err != nil {
+			return errors.Join(WriteMetricsError, err)
+		}
+	}
+	var text bytes.Buffer
+	_ = b.failures.write(&text)
+	if _, err := w.Write(text.Bytes()); err != nil {
+		return errors.Join(WriteMetricsError, err)
+	}
+	if len(errs) > 0 {
+		return errors.Join(append([]error{WriteMetricsError}, errs...)...)
+	}
+	return nil
+}
+
+type counterSeries struct {
+	labelValues []string
+	value       float64
+}
+
+type basicCounter struct {
+	family
+	mu     sync.Mutex
+	series map[string]*counterSeries
+}
+
+// newCounter creates a counter, its only series starts at 0 if it has no labels.
+func newCounter(name, help string, labels []string) *basicCounter {
+	counter := &basicCounter{
+		family: family{name: name, help: help, kind: "counter", labels: slices.Clone(labels)},
+		series: make(map[string]*counterSeries),
+	}
+	if len(labels) == 0 {
+		counter.series[""] = &counterSeries{}
+	}
+	return counter
+}
+
+func (c *basicCounter) Add(value float64, labelValues ...string) error {
+	if value < 0 || math.IsNaN(value) {
+		return fmt.Errorf("%w: %v", ErrNegativeIncrement, value)
+	}
+	key, err := c.seriesKey(labelValues)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.series[key] == nil {
+		c.series[key] = &counterSeries{labelValues: slices.Clone(labelValues)}
+	}
+	c.series[key].value += value
+	return nil
+}
+
+func (c *basicCounter) Inc(labelValues ...string) error {
+	return c.Add(1, labelValues...)
+}
+
+func (c *basicCounter) write(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeHeader(w)
+	for _, key := range sortedKeys(c.series) {
+		series := c.series[key]
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, series.labelValues), formatValue(series.value))
+	}
+	return nil
+}
+
+type histogramSeries struct {
+	labelValues []string
+	// counts are the number of observations of each bucket, not cumulative. The last one is the +Inf bucket.
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+type basicHistogram struct {
+	family
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+func (h *basicHistogram) Observe(value float64, labelValues ...string) error {
+	key, err := h.seriesKey(labelValues)
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.series[key] == nil {
+		h.series[key] = &histogramSeries{labelValues: slices.Clone(labelValues), counts: make([]uint64, len(h.buckets)+1)}
+	}
+	series := h.series[key]
+	i, _ := slices.BinarySearch(h.buckets, value)
+	series.counts[i]++
+	series.sum += value
+	series.count++
+	return nil
+}
+
+func (h *basicHistogram) write(w io.Writer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.writeHeader(w)
+	labels := append(slices.Clone(h.labels), "le")
+	for _, key := range sortedKeys(h.series) {
+		series := h.series[key]
+		var cumulative uint64
+		for i, count := range series.counts {
+			cumulative += count
+			bound := "+Inf"
+			if i < len(h.buckets) {
+				bound = formatValue(h.buckets[i])
+			}
+			values := append(slices.Clone(series.labelValues), bound)
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(labels, values), cumulative)
+		}
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, series.labelValues), formatValue(series.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, series.labelValues), series.count)
+	}
+	return nil
+}
+
+type gaugeFunc struct {
+	family
+	collect func() (map[string]float64, error)
+}
+
+func (g gaugeFunc) write(w io.Writer) error {
+	values, err := g.collect()
+	if err != nil {
+		return fmt.Errorf("%w %s: %w", ErrCollectingGauge, g.name, err)
+	}
+	g.writeHeader(w)
+	for _, labelValue := range sortedKeys(values) {
+		fmt.Fprintf(w, "%s%s %s\n", g.name, formatLabels(g.labels, []string{labelValue}), formatValue(values[labelValue]))
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + escapeLabelValue(values[i]) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+var NewMetricError error = errors.New("error registering metric")
+var WriteMetricsError error = errors.New("error writing metrics")
+
+var ErrInvalidMetricName error = errors.New("invalid metric name")
+var ErrInvalidLabelName error = errors.New("invalid label name")
+var ErrDuplicatedMetric error = errors.New("metric already registered")
+var ErrInvalidBuckets error = errors.New("histogram buckets must be finite, distinct and sorted in increasing order")
+var ErrNilCollect error = errors.New("gauge collect function cannot be nil")
+var ErrLabelCount error = errors.New("wrong number of label values")
+var ErrNegativeIncrement error = errors.New("counters cannot decrease")
+var ErrCollectingGauge error = errors.New("error collecting gauge")
@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_WriteText(t *testing.T) {
+	t.Parallel()
+	registry := NewRegistry()
+	counter, _ := registry.NewCounter("requests_total", "Requests\nreceived.", "method")
+	histogram, _ := registry.NewHistogram("latency_seconds", "Latency.", []float64{0.1, 1})
+	_ = registry.NewGaugeFunc("queue_size", "Queued jobs.", "queue", func() (map[string]float64, error) {
+		return map[string]float64{`say "hi"`: 2, "mail": 1}, nil
+	})
+	_ = counter.Inc("POST")
+	_ = counter.Add(2, "GET")
+	_ = histogram.Observe(0.1)
+	_ = histogram.Observe(0.5)
+	_ = histogram.Observe(3)
+
+	var text strings.Builder
+	err := registry.WriteText(&text)
+
+	if err != nil {
+		t.Fatalf("Error should be nil, got %v", err)
+	}
+	expected := `# HELP requests_total Requests\nreceived.
+# TYPE requests_total counter
+requests_total{method="GET"} 2
+requests_total{method="POST"} 1
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 1
+latency_seconds_bucket{le="1"} 2
+latency_seconds_bucket{le="+Inf"} 3
+latency_seconds_sum 3.6
+latency_seconds_count 3
+# HELP queue_size Queued jobs.
+# TYPE queue_size gauge
+queue_size{queue="mail"} 1
+queue_size{queue="say \"hi\""} 2
+# HELP metrics_collection_errors_total Metrics that could not be collected, by metric.
+# TYPE metrics_collection_errors_total counter
+`
+	if text.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, text.String())
+	}
+}
+
+func TestRegistry_Errors(t *testing.T) {
+	t.Parallel()
+	registry := NewRegistry()
+	counter, _ := registry.NewCounter("requests_total", "Requests.", "method")
+	t.Run("It should reject invalid and duplicated metrics", func(t *testing.T) {
+		t.Parallel()
+		_, err := registry.NewCounter("requests_total", "Again.")
+		assertErrors(t, err, NewMetricError, ErrDuplicatedMetric)
+		_, err = registry.NewCounter("requests-total", "Dashes.")
+		assertErrors(t, err, NewMetricError, ErrInvalidMetricName)
+		_, err = registry.NewCounter("valid_total", "Label.", "bad-label")
+		assertErrors(t, err, NewMetricError, ErrInvalidLabelName)
+		_, err = registry.NewHistogram("unsorted_seconds", "Unsorted.", []float64{1, 0.5})
+		assertErrors(t, err, NewMetricError, ErrInvalidBuckets)
+		_, err = registry.NewHistogram("le_seconds", "Reserved label.", DefaultBuckets, "le")
+		assertErrors(t, err, NewMetricError, ErrInvalidLabelName)
+		err = registry.NewGaugeFunc("nil_gauge", "Nil.", "label", nil)
+		assertErrors(t, err, NewMetricError, ErrNilCollect)
+	})
+	t.Run("It should reserve the name of the collection errors", func(t *testing.T) {
+		t.Parallel()
+		_, err := registry.NewCounter(CollectionErrorsMetric, "Again.")
+		assertErrors(t, err, NewMetricError, ErrDuplicatedMetric)
+	})
+	t.Run("It should reject wrong label values and negative increments", func(t *testing.T) {
+		t.Parallel()
+		assertErrors(t, counter.Inc(), ErrLabelCount)
+		assertErrors(t, counter.Add(-1, "GET"), ErrNegativeIncrement)
+	})
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+	t.Run("It should serve the metrics in the text exposition format", func(t *testing.T) {
+		t.Parallel()
+		registry := NewRegistry()
+		counter, _ := registry.NewCounter("events_total", "Events.")
+		_ = counter.Inc()
+		recorder := httptest.NewRecorder()
+
+		Handler(registry).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != ContentType {
+			t.Errorf("Expected a 200 text response, got %d %s", recorder.Code, recorder.Header().Get("Content-Type"))
+		}
+		if !strings.Contains(recorder.Body.String(), "events_total 1\n") {
+			t.Errorf("Expected the counter in the body, got %s", recorder.Body.String())
+		}
+	})
+	t.Run("It should serve the other metrics and count the gauges that cannot be collected", func(t *testing.T) {
+		t.Parallel()
+		registry := NewRegistry()
+		counter, _ := registry.NewCounter("events_total", "Events.")
+		_ = counter.Inc()
+		_ = registry.NewGaugeFunc("broken", "Broken.", "label", func() (map[string]float64, error) {
+			return nil, errors.New("database down")
+		})
+		recorder := httptest.NewRecorder()
+
+		Handler(registry).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		body := recorder.Body.String()
+		if recorder.Code != http.StatusOK || !strings.Contains(body, "events_total 1\n") || strings.Contains(body, "# TYPE broken") {
+			t.Errorf("Expected a 200 response with the counter and without the gauge, got %d %s", recorder.Code, body)
+		}
+		if !strings.Contains(body, CollectionErrorsMetric+`{metric="broken"} 1`) {
+			t.Errorf("Expected the failed collection to be counted, got %s", body)
+		}
+	})
+}
+
+func assertErrors(t *testing.T, err error, expected ...error) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("Error should not be nil")
+	}
+	for _, e := range expected {
+		if !errors.Is(err, e) {
+			t.Errorf("Error should be %v, but is %s", e, err.Error())
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package metrics
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"ticketTao/entities/ticket"
+	"ticketTao/interactors/ticket/events"
+	"ticketTao/interactors/ticket/repository"
+	"time"
+)
+
+// Sentinel names an error, the errors of the instrumented operations are counted by the name of the first sentinel
+// they match with errors.Is.
+type Sentinel struct {
+	Name string
+	Err  error
+}
+
+// OtherError is the name under which the errors that match no sentinel are counted.
+const OtherError = "other"
+
+// DefaultSentinels are the errors of the entities and of the repositories worth telling apart in the dashboards.
+var DefaultSentinels = []Sentinel{
+	{"nil_ticket", ticket.ErrNilTicket},
+	{"empty_title", ticket.ErrEmptyTitle},
+	{"nil_creator", ticket.ErrNilCreatorUserID},
+	{"invalid_page_size", ticket.ErrInvalidPageSize},
+	{"unknown_sort_order", ticket.ErrUnknownSortOrder},
+	{"invalid_time_range", ticket.ErrInvalidTimeRange},
+	{"invalid_cursor", ticket.ErrInvalidCursor},
+	{"nil_ticket_id", repository.ErrNilTicketID},
+	{"nil_client_id", repository.ErrNilClientID},
+	{"ticket_not_accessible", repository.ErrTicketNotAccessible},
+	{"owner_cannot_follow", repository.ErrOwnerCannotFollow},
+	{"too_many_merge_redirects", repository.ErrTooManyMergeRedirects},
+	{"closing_children", repository.ErrClosingChildren},
+}
+
+// The layers of the instrumented operations.
+const (
+	PersistenceLayer = "persistence"
+	RepositoryLayer  = "repository"
+)
+
+// TicketMetrics instruments the ticket repositories and persistence drivers, and counts the ticket lifecycle events.
+type TicketMetrics interface {
+	// InstrumentPersistence decorates a persistence driver to measure the latency and count the errors of its
+	// operations. The owners of the tickets can only be changed if the decorated driver is a
+	// repository.TransferablePersistence.
+	InstrumentPersistence(tp repository.TicketPersistence) (repository.TransferablePersistence, error)
+	// InstrumentAgentRepository works like InstrumentPersistence, for the agent repositories.
+	InstrumentAgentRepository(repo repository.AgentTicketRepository) (repository.AgentTicketRepository, error)
+	// InstrumentClientRepository works like InstrumentPersistence, for the client repositories.
+	InstrumentClientRepository(repo ticket.RepositoryClientAccess) (ticket.RepositoryClientAccess, error)
+	// Handle counts the created tickets, the added responses and the closures. It is meant to be subscribed to an
+	// events.Bus, see Subscribe.
+	Handle(events.Event)
+}
+
+// NewTicketMetrics registers the ticket metrics in the registry. The errors are counted by the sentinels, by the
+// DefaultSentinels if none is given.
+func NewTicketMetrics(registry Registry, sentinels ...Sentinel) (TicketMetrics, error) {
+	if registry == nil {
+		return nil, errors.Join(NewTicketMetricsError, ErrNilRegistry)
+	}
+	if len(sentinels) == 0 {
+		sentinels = DefaultSentinels
+	}
+	m := basicTicketMetrics{sentinels: sentinels}
+	var err error
+	if m.created, err = registry.NewCounter("tickettao_tickets_created_total", "Tickets created."); err != nil {
+		return nil, errors.Join(NewTicketMetricsError, err)
+	}
+	if m.responses, err = registry.NewCounter("tickettao_responses_added_total", "Responses added to tickets."); err != nil {
+		return nil, errors.Join(NewTicketMetricsError, err)
+	}
+	if m.closed, err = registry.NewCounter("tickettao_tickets_closed_total", "Tickets closed, by closing reason.", "reason"); err != nil {
+		return nil, errors.Join(NewTicketMetricsError, err)
+	}
+	m.duration, err = registry.NewHistogram("tickettao_operation_duration_seconds",
+		"Latency of the ticket repository and persistence operations.", DefaultBuckets, "layer", "operation")
+	if err != nil {
+		return nil, errors.Join(NewTicketMetricsError, err)
+	}
+	m.errors, err = registry.NewCounter("tickettao_operation_errors_total",
+		"Errors of the ticket repository and persistence operations, by sentinel error.", "layer", "operation", "error")
+	if err != nil {
+		return nil, errors.Join(NewTicketMetricsError, err)
+	}
+	return m, nil
+}
+
+// Subscribe registers the ticket metrics as a synchronous subscriber, counting an event only takes a lock.
+func Subscribe(subscriber events.Subscriber, metrics TicketMetrics) error {
+	if subscriber == nil {
+		return errors.Join(events.SubscribeError, events.ErrNilSubscriber)
+	}
+	if metrics == nil {
+		return errors.Join(events.SubscribeError, ErrNilTicketMetrics)
+	}
+	return subscriber.Subscribe(metrics.Handle)
+}
+
+// TicketCounter counts the tickets that match a query, like a repository.TicketPersistence.
+type TicketCounter interface {
+	CountTickets(query ticket.Query) (int, error)
+}
+
+// RegisterBacklogGauge registers a gauge with the number of non-closed tickets by status, counted every time the
+// metrics are written.
+func RegisterBacklogGauge(registry Registry, counter TicketCounter) error {
+	if registry == nil {
+		return errors.Join(RegisterBacklogGaugeError, ErrNilRegistry)
+	}
+	if counter == nil {
+		return errors.Join(RegisterBacklogGaugeError, ErrNilRepository)
+	}
+	err := registry.NewGaugeFunc("tickettao_backlog_tickets", "Tickets not closed yet, by status.", "status",
+		func() (map[string]float64, error) {
+			backlog := make(map[string]float64)
+			for _, status := range []ticket.Status{ticket.Open, ticket.InProgress} {
+				count, err := counter.CountTickets(ticket.Query{Statuses: []ticket.Status{status}})
+				if err != nil {
+					return nil, err
+				}
+				backlog[string(status)] = float64(count)
+			}
+			return backlog, nil
+		})
+	if err != nil {
+		return errors.Join(RegisterBacklogGaugeError, err)
+	}
+	return nil
+}
+
+type basicTicketMetrics struct {
+	sentinels []Sentinel
+	created   Counter
+	responses Counter
+	closed    Counter
+	duration  Histogram
+	errors    Counter
+}
+
+// The label counts of the metrics are fixed, so recording them cannot fail.
+
+func (b basicTicketMetrics) Handle(e events.Event) {
+	switch e := e.(type) {
+	case events.TicketCreated:
+		_ = b.created.Inc()
+	case events.ResponseAdded:
+		_ = b.responses.Inc()
+	case events.TicketClosed:
+		_ = b.closed.Inc(string(e.Ticket.ClosingReason()))
+	}
+}
+
+// record measures an operation that started at the given time and ended with the error.
+func (b basicTicketMetrics) record(layer, operation string, start time.Time, err error) {
+	_ = b.duration.Observe(time.Since(start).Seconds(), layer, operation)
+	if err != nil {
+		_ = b.errors.Inc(layer, operation, b.sentinelName(err))
+	}
+}
+
+func (b basicTicketMetrics) sentinelName(err error) string {
+	for _, sentinel := range b.sentinels {
+		if errors.Is(err, sentinel.Err) {
+			return sentinel.Name
+		}
+	}
+	return OtherError
+}
+
+func observe[T any](b basicTicketMetrics, layer, operation string, call func() (T, error)) (T, error) {
+	start := time.Now()
+	result, err := call()
+	b.record(layer, operation, start, err)
+	return result, err
+}
+
+func observeError(b basicTicketMetrics, layer, operation string, call func() error) error {
+	start := time.Now()
+	err := call()
+	b.record(layer, operation, start, err)
+	return err
+}
+
+func (b basicTicketMetrics) InstrumentPersistence(tp repository.TicketPersistence) (repository.TransferablePersistence, error) {
+	if tp == nil {
+		return nil, errors.Join(InstrumentError, repository.NilPersistenceDriverError)
+	}
+	return instrumentedPersistence{tp, b}, nil
+}
+
+func (b basicTicketMetrics) InstrumentAgentRepository(repo repository.AgentTicketRepository) (repository.AgentTicketRepository, error) {
+	if repo == nil {
+		return nil, errors.Join(InstrumentError, ErrNilRepository)
+	}
+	return instrumentedAgentRepository{repo, b}, nil
+}
+
+func (b basicTicketMetrics) InstrumentClientRepository(repo ticket.RepositoryClientAccess) (ticket.RepositoryClientAccess, error) {
+	if repo == nil {
+		return nil, errors.Join(InstrumentError, ErrNilRepository)
+	}
+	return instrumentedClientRepository{repo, b}, nil
+}
+
+type instrumentedPersistence struct {
+	persistence repository.TicketPersistence
+	metrics     basicTicketMetrics
+}
+
+func (i instrumentedPersistence) SaveNewTicketForClient(client uuid.UUID, tck ticket.Ticket) error {
+	return observeError(i.metrics, PersistenceLayer, "SaveNewTicketForClient", func() error {
+		return i.persistence.SaveNewTicketForClient(client, tck)
+	})
+}
+
+func (i instrumentedPersistence) GetTicketOwner(ticketId uuid.UUID) (uuid.UUID, error) {
+	return observe(i.metrics, PersistenceLayer, "GetTicketOwner", func() (uuid.UUID, error) {
+		return i.persistence.GetTicketOwner(ticketId)
+	})
+}
+
+func (i instrumentedPersistence) GetTicket(id uuid.UUID) (ticket.Ticket, error) {
+	return observe(i.metrics, PersistenceLayer, "GetTicket", func() (ticket.Ticket, error) {
+		return i.persistence.GetTicket(id)
+	})
+}
+
+func (i instrumentedPersistence) UpdateTicket(tck ticket.Ticket) error {
+	return observeError(i.metrics, PersistenceLayer, "UpdateTicket", func() error {
+		return i.persistence.UpdateTicket(tck)
+	})
+}
+
+func (i instrumentedPersistence) GetAllTickets() ([]ticket.Ticket, error) {
+	return observe(i.metrics, PersistenceLayer, "GetAllTickets", i.persistence.GetAllTickets)
+}
+
+func (i instrumentedPersistence) QueryTickets(query ticket.Query) (ticket.Page, error) {
+	return observe(i.metrics, PersistenceLayer, "QueryTickets", func() (ticket.Page, error) {
+		return i.persistence.QueryTickets(query)
+	})
+}
+
+func (i instrumentedPersistence) CountTickets(query ticket.Query) (int, error) {
+	return observe(i.metrics, PersistenceLayer, "CountTickets", func() (int, error) {
+		return i.persistence.CountTickets(query)
+	})
+}
+
+func (i instrumentedPersistence) SetTicketOwner(ticketId, client uuid.UUID) error {
+	return observeError(i.metrics, PersistenceLayer, "SetTicketOwner", func() error {
+		transferable, ok := i.persistence.(repository.TransferablePersistence)
+		if !ok {
+			return repository.ErrOwnerChangeNotSupported
+		}
+		return transferable.SetTicketOwner(ticketId, client)
+	})
+}
+
+type instrumentedAgentRepository struct {
+	repository repository.AgentTicketRepository
+	metrics    basicTicketMetrics
+}
+
+func (i instrumentedAgentRepository) GetTicket(ticketId uuid.UUID) (ticket.Ticket, error) {
+	return observe(i.metrics, RepositoryLayer, "GetTicket", func() (ticket.Ticket, error) {
+		return i.repository.GetTicket(ticketId)
+	})
+}
+
+func (i instrumentedAgentRepository) UpdateTicket(tck ticket.Ticket) error {
+	return observeError(i.metrics, RepositoryLayer, "UpdateTicket", func() error {
+		return i.repository.UpdateTicket(tck)
+	})
+}
+
+func (i instrumentedAgentRepository) GetOpenTickets() ([]ticket.Ticket, error) {
+	return observe(i.metrics, RepositoryLayer, "GetOpenTickets", i.repository.GetOpenTickets)
+}
+
+func (i instrumentedAgentRepository) GetTicketOwner(ticketId uuid.UUID) (uuid.UUID, error) {
+	return observe(i.metrics, RepositoryLayer, "GetTicketOwner", func() (uuid.UUID, error) {
+		return i.repository.GetTicketOwner(ticketId)
+	})
+}
+
+func (i instrumentedAgentRepository) QueryTickets(query ticket.Query) (ticket.Page, error) {
+	return observe(i.metrics, RepositoryLayer, "QueryTickets", func() (ticket.Page, error) {
+		return i.repository.QueryTickets(query)
+	})
+}
+
+func (i instrumentedAgentRepository) CountTicketsByTag(query ticket.Query) (map[string]int, error) {
+	return observe(i.metrics, RepositoryLayer, "CountTicketsByTag", func() (map[string]int, error) {
+		return i.repository.CountTicketsByTag(query)
+	})
+}
+
+type instrumentedClientRepository struct {
+	repository ticket.RepositoryClientAccess
+	metrics    basicTicketMetrics
+}
+
+func (i instrumentedClientRepository) GetTicket(client, ticketId uuid.UUID) (ticket.Ticket, error) {
+	return observe(i.metrics, RepositoryLayer, "GetClientTicket", func() (ticket.Ticket, error) {
+		return i.repository.GetTicket(client, ticketId)
+	})
+}
+
+func (i instrumentedClientRepository) GetAllClientTickets(client uuid.UUID) ([]ticket.Ticket, error) {
+	return observe(i.metrics, RepositoryLayer, "GetAllClientTickets", func() ([]ticket.Ticket, error) {
+		return i.repository.GetAllClientTickets(client)
+	})
+}
+
+func (i instrumentedClientRepository) GetClientTicketCount(client uuid.UUID) (int, error) {
+	return observe(i.metrics, RepositoryLayer, "GetClientTicketCount", func() (int, error) {
+		return i.repository.GetClientTicketCount(client)
+	})
+}
+
+func (i instrumentedClientRepository) QueryClientTickets(client uuid.UUID, query ticket.Query) (ticket.Page, error) {
+	return observe(i.metrics, RepositoryLayer, "QueryClientTickets", func() (ticket.Page, error) {
+		return i.repository.QueryClientTickets(client, query)
+	})
+}
+
+func (i instrumentedClientRepository) CountClientTicketsByTag(client uuid.UUID, query ticket.Query) (map[string]int, error) {
+	return observe(i.metrics, RepositoryLayer, "CountClientTicketsByTag", func() (map[string]int, error) {
+		return i.repository.CountClientTicketsByTag(client, query)
+	})
+}
+
+func (i instrumentedClientRepository) FollowTicket(client, ticketId uuid.UUID) error {
+	return observeError(i.metrics, RepositoryLayer, "FollowTicket", func() error {
+		return i.repository.FollowTicket(client, ticketId)
+	})
+}
+
+func (i instrumentedClientRepository) UnfollowTicket(client, ticketId uuid.UUID) error {
+	return observeError(i.metrics, RepositoryLayer, "UnfollowTicket", func() error {
+		return i.repository.UnfollowTicket(client, ticketId)
+	})
+}
+
+func (i instrumentedClientRepository) CreateNewTicketForClient(userId uuid.UUID, tck ticket.Ticket) error {
+	return observeError(i.metrics, RepositoryLayer, "CreateNewTicketForClient", func() error {
+		return i.repository.CreateNewTicketForClient(userId, tck)
+	})
+}
+
+func (i instrumentedClientRepository) UpdateTicketForClient(userId uuid.UUID, tck ticket.Ticket) error {
+	return observeError(i.metrics, RepositoryLayer, "UpdateTicketForClient", func() error {
+		return i.repository.UpdateTicketForClient(userId, tck)
+	})
+}
+
+var NewTicketMetricsError error = errors.New("error creating ticket metrics")
+var RegisterBacklogGaugeError error = errors.New("error registering backlog gauge")
+var InstrumentError error = errors.New("error instrumenting ticket storage")
+
+var ErrNilRegistry error = errors.New("metrics registry cannot be nil")
+var ErrNilRepository error = errors.New("ticket repository cannot be nil")
+var ErrNilTicketMetrics error = errors.New("ticket metrics cannot be nil")
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"github.com/google/uuid"
+	"strings"
+	"testing"
+	"ticketTao/entities/ticket"
+	"ticketTao/interactors/ticket/events"
+	"ticketTao/interactors/ticket/persistence/memory"
+	"ticketTao/interactors/ticket/repository"
+)
+
+func TestTicketMetrics(t *testing.T) {
+	t.Parallel()
+	registry := NewRegistry()
+	metrics, err := NewTicketMetrics(registry)
+	if err != nil {
+		t.Fatalf("Error should be nil, got %v", err)
+	}
+	bus := events.NewBus()
+	_ = Subscribe(bus, metrics)
+	persistence, _ := metrics.InstrumentPersistence(memory.NewTicketPersistence())
+	published, _ := repository.NewPublishingPersistence(persistence, bus)
+	agentRepo, _ := repository.GetAgentTicketRepository(published)
+	clientRepo, _ := repository.GetClientTicketRepository(published)
+	agents, _ := metrics.InstrumentAgentRepository(agentRepo)
+	clients, _ := metrics.InstrumentClientRepository(clientRepo)
+	_ = RegisterBacklogGauge(registry, published)
+
+	client := uuid.New()
+	first, _ := ticket.NewBasicTicket("first", "description")
+	second, _ := ticket.NewBasicTicket("second", "description")
+	_ = clients.CreateNewTicketForClient(client, first)
+	_ = clients.CreateNewTicketForClient(client, second)
+	first.AddResponse(ticket.NewResponse(client, "hello"))
+	_ = clients.UpdateTicketForClient(client, first)
+	second.Close()
+	_ = agents.UpdateTicket(second)
+	_, _ = clients.GetTicket(uuid.New(), first.ID())
+	_, _ = agents.GetTicket(uuid.New())
+
+	var text strings.Builder
+	if err := registry.WriteText(&text); err != nil {
+		t.Fatalf("Error should be nil, got %v", err)
+	}
+	for _, line := range []string{
+		"tickettao_tickets_created_total 2\n",
+		"tickettao_responses_added_total 1\n",
+		`tickettao_tickets_closed_total{reason="Resolved"} 1` + "\n",
+		`tickettao_operation_duration_seconds_count{layer="persistence",operation="SaveNewTicketForClient"} 2` + "\n",
+		`tickettao_operation_duration_seconds_count{layer="repository",operation="UpdateTicketForClient"} 1` + "\n",
+		`tickettao_operation_errors_total{layer="repository",operation="GetClientTicket",error="ticket_not_accessible"} 1` + "\n",
+		`tickettao_operation_errors_total{layer="persistence",operation="GetTicket",error="other"} 1` + "\n",
+		`tickettao_operation_errors_total{layer="repository",operation="GetTicket",error="other"} 1` + "\n",
+		`tickettao_backlog_tickets{status="InProgress"} 1` + "\n",
+		`tickettao_backlog_tickets{status="Open"} 0` + "\n",
+	} {
+		if !strings.Contains(text.String(), line) {
+			t.Errorf("Expected the metrics to contain %q, got\n%s", line, text.String())
+		}
+	}
+}
+
+func TestNewTicketMetrics(t *testing.T) {
+	t.Parallel()
+	_, err := NewTicketMetrics(nil)
+	assertErrors(t, err, NewTicketMetricsError, ErrNilRegistry)
+	registry := NewRegistry()
+	_, _ = NewTicketMetrics(registry)
+	_, err = NewTicketMetrics(registry)
+	assertErrors(t, err, NewTicketMetricsError, ErrDuplicatedMetric)
+	metrics, _ := NewTicketMetrics(NewRegistry())
+	_, err = metrics.InstrumentPersistence(nil)
+	assertErrors(t, err, InstrumentError, repository.NilPersistenceDriverError)
+	persistence, _ := metrics.InstrumentPersistence(nonTransferablePersistence{memory.NewTicketPersistence()})
+	assertErrors(t, persistence.SetTicketOwner(uuid.New(), uuid.New()), repository.ErrOwnerChangeNotSupported)
+	err = RegisterBacklogGauge(registry, nil)
+	assertErrors(t, err, RegisterBacklogGaugeError, ErrNilRepository)
+	assertErrors(t, Subscribe(nil, metrics), events.SubscribeError, events.ErrNilSubscriber)
+}
+
+// nonTransferablePersistence hides the SetTicketOwner method of the persistence driver it wraps.
+type nonTransferablePersistence struct {
+	repository.TicketPersistence
+}
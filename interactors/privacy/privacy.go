@@ -0,0 +1,245 @@
+// Package privacy answers the requests of the clients about their personal data: exporting everything tied to a
+// client and erasing or pseudonymizing it, while keeping the tickets usable for the statistics.
+package privacy
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"slices"
+	"ticketTao/entities"
+	"ticketTao/entities/ticket"
+	"ticketTao/interactors/attachment"
+	"ticketTao/interactors/backup"
+	"ticketTao/interactors/ticket/repository"
+	"time"
+)
+
+// ErasedContent replaces the erased texts, the titles of the tickets cannot be empty.
+const ErasedContent = "[erased]"
+
+// Mode defines what happens to the data of a client.
+type Mode string
+
+const (
+	// Pseudonymize replaces the ID of the client with a pseudonym in the tickets and responses, keeping their
+	// content.
+	Pseudonymize Mode = "pseudonymize"
+	// Erase also replaces the texts written by the client with ErasedContent, and removes their custom fields,
+	// attachments and satisfaction comments. The statuses, times, priorities, tags, categories, assignees, status
+	// history and ratings are kept for the statistics.
+	Erase Mode = "erase"
+)
+
+// SubjectData is everything tied to a client.
+type SubjectData struct {
+	Client     uuid.UUID `json:"client"`
+	ExportedAt time.Time `json:"exported_at"`
+	// Tickets are the tickets owned by the client, with their responses, attachments metadata, satisfaction survey and
+	// status history, which is their audit trail.
+	Tickets []backup.Entry `json:"tickets"`
+	// Responses are the responses written by the client in the tickets of other clients.
+	Responses []AuthoredResponse `json:"responses"`
+	// Followed are the tickets of other clients followed by the client.
+	Followed []uuid.UUID `json:"followed"`
+}
+
+// AuthoredResponse is a response written by a client in a ticket of another client.
+type AuthoredResponse struct {
+	Ticket uuid.UUID `json:"ticket"`
+	backup.Response
+}
+
+// Erasure reports what an erasure changed.
+type Erasure struct {
+	Client uuid.UUID
+	// Pseudonym is the ID that replaced the ID of the client, it is random so the tickets cannot be linked back to the
+	// client without this report.
+	Pseudonym uuid.UUID
+	Mode      Mode
+	// Tickets is the number of changed tickets, owned or not by the client.
+	Tickets   int
+	Responses int
+	// Attachments are the attachments removed in Erase mode.
+	Attachments []uuid.UUID
+}
+
+// Service exports and erases the data of the clients.
+type Service interface {
+	Export(client uuid.UUID) (SubjectData, error)
+	// Erase pseudonymizes or erases the data of the client in every ticket. If it fails midway, calling it again
+	// finishes the job, under a new pseudonym.
+	Erase(client uuid.UUID, mode Mode) (Erasure, error)
+}
+
+// NewService creates a Service over the tickets of the persistence driver. The content of the erased attachments is
+// deleted from the blob store, the blob store can be nil if the attachments are not stored by the application. The
+// driver should be decorated like the one of the repositories, e.g. with repository.NewIndexingPersistence so that the
+// erased texts are also removed from the search index. The decorators of the repository and metrics packages forward
+// the owner changes to the driver they decorate.
+func NewService(tp repository.TransferablePersistence, blobs attachment.BlobStore) (Service, error) {
+	return NewServiceWithClock(tp, blobs, entities.SystemClock())
+}
+
+// NewServiceWithClock works like NewService, but the exports are dated with the clock.
+func NewServiceWithClock(tp repository.TransferablePersistence, blobs attachment.BlobStore, clock entities.Clock) (Service, error) {
+	if tp == nil {
+		return nil, errors.Join(NewServiceError, repository.NilPersistenceDriverError)
+	}
+	if clock == nil {
+		return nil, errors.Join(NewServiceError, entities.ErrNilClock)
+	}
+	return basicService{persistence: tp, blobs: blobs, clock: clock}, nil
+}
+
+type basicService struct {
+	persistence repository.TransferablePersistence
+	blobs       attachment.BlobStore
+	clock       entities.Clock
+}
+
+// ownedTicket is a ticket with its owner.
+type ownedTicket struct {
+	ticket.Ticket
+	owner uuid.UUID
+}
+
+// ticketsOf returns the tickets tied to the client: owned, followed or answered by them.
+func (b basicService) ticketsOf(client uuid.UUID) ([]ownedTicket, error) {
+	var tickets []ownedTicket
+	query := ticket.Query{Limit: ticket.MaxPageSize}
+	for {
+		page, err := b.persistence.QueryTickets(query)
+		if err != nil {
+			return nil, err
+		}
+		for _, tck := range page.Tickets {
+			owner, err := b.persistence.GetTicketOwner(tck.ID())
+			if err != nil {
+				return nil, err
+			}
+			if owner == client || tck.IsFollowedBy(client) || slices.ContainsFunc(tck.Responses(), writtenBy(client)) {
+				tickets = append(tickets, ownedTicket{tck, owner})
+			}
+		}
+		if page.Next == "" {
+			return tickets, nil
+		}
+		query.After = page.Next
+	}
+}
+
+func writtenBy(user uuid.UUID) func(ticket.Response) bool {
+	return func(response ticket.Response) bool {
+		return response.UserId() == user
+	}
+}
+
+func (b basicService) Export(client uuid.UUID) (SubjectData, error) {
+	if client == uuid.Nil {
+		return SubjectData{}, errors.Join(ExportError, repository.ErrNilClientID)
+	}
+	tickets, err := b.ticketsOf(client)
+	if err != nil {
+		return SubjectData{}, errors.Join(ExportError, err)
+	}
+	data := SubjectData{Client: client, ExportedAt: b.clock.Now().UTC()}
+	for _, tck := range tickets {
+		if tck.owner == client {
+			data.Tickets = append(data.Tickets, backup.EntryFrom(tck, client))
+			continue
+		}
+		if tck.IsFollowedBy(client) {
+			data.Followed = append(data.Followed, tck.ID())
+		}
+		for _, response := range tck.Responses() {
+			if response.UserId() == client {
+				data.Responses = append(data.Responses, AuthoredResponse{tck.ID(), backup.ResponseFrom(response)})
+			}
+		}
+	}
+	return data, nil
+}
+
+func (b basicService) Erase(client uuid.UUID, mode Mode) (Erasure, error) {
+	if client == uuid.Nil {
+		return Erasure{}, errors.Join(EraseError, repository.ErrNilClientID)
+	}
+	if mode != Pseudonymize && mode != Erase {
+		return Erasure{}, errors.Join(EraseError, fmt.Errorf("%w: %s", ErrUnknownMode, mode))
+	}
+	tickets, err := b.ticketsOf(client)
+	if err != nil {
+		return Erasure{}, errors.Join(EraseError, err)
+	}
+	erasure := Erasure{Client: client, Pseudonym: uuid.New(), Mode: mode}
+	for _, tck := range tickets {
+		if err := b.erase(tck, &erasure); err != nil {
+			return erasure, errors.Join(EraseError, err)
+		}
+		erasure.Tickets++
+	}
+	return erasure, nil
+}
+
+// erase rewrites the ticket without the client, then gives it to the pseudonym if the client owned it.
+func (b basicService) erase(tck ownedTicket, erasure *Erasure) error {
+	data := ticket.DataFrom(tck)
+	data.Followers = slices.DeleteFunc(data.Followers, func(user uuid.UUID) bool { return user == erasure.Client })
+	for i, response := range data.Responses {
+		if response.UserId() != erasure.Client {
+			continue
+		}
+		content, attachments := response.Content(), response.Attachments()
+		if erasure.Mode == Erase {
+			if err := b.deleteAttachments(attachments, erasure); err != nil {
+				return err
+			}
+			content, attachments = ErasedContent, nil
+		}
+		data.Responses[i] = ticket.RewriteResponse(response, erasure.Pseudonym, content, attachments...)
+		erasure.Responses++
+	}
+	owned := tck.owner == erasure.Client
+	if owned && erasure.Mode == Erase {
+		if err := b.deleteAttachments(data.Attachments, erasure); err != nil {
+			return err
+		}
+		data.Title, data.Description = ErasedContent, ""
+		data.Fields, data.Attachments = nil, nil
+		data.Satisfaction.Comment = ""
+		for i := range data.PastSatisfactions {
+			data.PastSatisfactions[i].Comment = ""
+		}
+	}
+	erased, err := ticket.Rewrite(tck.Ticket, data)
+	if err != nil {
+		return err
+	}
+	if err := b.persistence.UpdateTicket(erased); err != nil {
+		return err
+	}
+	if owned {
+		return b.persistence.SetTicketOwner(tck.ID(), erasure.Pseudonym)
+	}
+	return nil
+}
+
+func (b basicService) deleteAttachments(attachments []ticket.Attachment, erasure *Erasure) error {
+	for _, a := range attachments {
+		if b.blobs != nil {
+			if err := b.blobs.Delete(a.ID); err != nil && !errors.Is(err, attachment.ErrBlobNotFound) {
+				return errors.Join(ErrDeletingAttachment, err)
+			}
+		}
+		erasure.Attachments = append(erasure.Attachments, a.ID)
+	}
+	return nil
+}
+
+var NewServiceError error = errors.New("error creating privacy service")
+var ExportError error = errors.New("error exporting client data")
+var EraseError error = errors.New("error erasing client data")
+
+var ErrUnknownMode error = errors.New("unknown erasure mode")
+var ErrDeletingAttachment error = errors.New("error deleting attachment content")
@@ -0,0 +1,220 @@
+package privacy
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"strings"
+	"testing"
+	"ticketTao/entities"
+	"ticketTao/entities/ticket"
+	"ticketTao/interactors/attachment"
+	"ticketTao/interactors/search"
+	"ticketTao/interactors/ticket/events"
+	"ticketTao/interactors/ticket/persistence/memory"
+	"ticketTao/interactors/ticket/repository"
+	"time"
+)
+
+var created = time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC)
+
+// fixture saves a ticket of the client, answered by an agent and rated, and a ticket of another client followed and
+// answered by the client.
+type fixture struct {
+	persistence repository.TransferablePersistence
+	blobs       attachment.BlobStore
+	client      uuid.UUID
+	agent       uuid.UUID
+	owned       ticket.Ticket
+	other       ticket.Ticket
+	attached    ticket.Attachment
+}
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+	f := fixture{persistence: memory.NewTicketPersistence(), client: uuid.New(), agent: uuid.New()}
+	f.blobs, _ = attachment.NewFileSystemBlobStore(t.TempDir())
+	f.attached = ticket.Attachment{ID: uuid.New(), Name: "invoice.pdf"}
+	_ = f.blobs.Put(f.attached.ID, strings.NewReader("invoice"))
+	f.owned, _ = ticket.MakeBasicTicket(uuid.New(), created, ticket.Data{
+		Title: "My invoice", Description: "It is wrong", Status: ticket.Open, Priority: ticket.High,
+		Fields: map[string]string{"order": "42"},
+	})
+	f.owned.AddResponse(ticket.MakeResponse(f.client, "See attached", created.Add(time.Hour), f.attached))
+	f.owned.AddResponse(ticket.MakeResponse(f.agent, "Fixed", created.Add(2*time.Hour)))
+	f.owned.Close()
+	f.owned.RequestSatisfaction(f.agent, "first token")
+	_ = f.owned.RateSatisfaction("first token", 2, "Still wrong, John")
+	f.owned.SetStatus(ticket.InProgress)
+	f.owned.Close()
+	f.owned.RequestSatisfaction(f.agent, "token")
+	_ = f.owned.RateSatisfaction("token", 4, "Thanks, John")
+	f.other, _ = ticket.MakeBasicTicket(uuid.New(), created, ticket.Data{Title: "Colleague's ticket", Status: ticket.Open})
+	f.other.Follow(f.client)
+	f.other.AddResponse(ticket.MakeResponse(f.client, "Me too", created.Add(time.Hour)))
+	_ = f.persistence.SaveNewTicketForClient(f.client, f.owned)
+	_ = f.persistence.SaveNewTicketForClient(uuid.New(), f.other)
+	unrelated, _ := ticket.NewBasicTicket("unrelated", "description")
+	_ = f.persistence.SaveNewTicketForClient(uuid.New(), unrelated)
+	return f
+}
+
+func TestService_Export(t *testing.T) {
+	t.Parallel()
+	f := newFixture(t)
+	exportedAt := created.Add(24 * time.Hour)
+	service, _ := NewServiceWithClock(f.persistence, f.blobs, entities.ClockFunc(func() time.Time { return exportedAt }))
+
+	data, err := service.Export(f.client)
+
+	if err != nil {
+		t.Fatalf("Error should be nil, got %v", err)
+	}
+	if !data.ExportedAt.Equal(exportedAt) {
+		t.Errorf("Expected the export to be dated %v, got %v", exportedAt, data.ExportedAt)
+	}
+	if len(data.Tickets) != 1 || data.Tickets[0].ID != f.owned.ID() || data.Tickets[0].Owner != f.client {
+		t.Fatalf("Expected the owned ticket, got %+v", data.Tickets)
+	}
+	entry := data.Tickets[0]
+	if len(entry.Responses) != 2 || entry.Responses[0].Attachments[0] != f.attached || len(entry.History) == 0 ||
+		entry.Satisfaction.Comment != "Thanks, John" {
+		t.Errorf("Expected the responses, attachments, history and survey of the ticket, got %+v", entry)
+	}
+	if len(data.Responses) != 1 || data.Responses[0].Ticket != f.other.ID() || data.Responses[0].Content != "Me too" {
+		t.Errorf("Expected the response in the other ticket, got %+v", data.Responses)
+	}
+	if len(data.Followed) != 1 || data.Followed[0] != f.other.ID() {
+		t.Errorf("Expected the followed ticket, got %v", data.Followed)
+	}
+}
+
+func TestService_Erase(t *testing.T) {
+	t.Parallel()
+	t.Run("It should erase the texts of the client and keep the statistics", func(t *testing.T) {
+		t.Parallel()
+		f := newFixture(t)
+		service, _ := NewService(f.persistence, f.blobs)
+
+		erasure, err := service.Erase(f.client, Erase)
+
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if erasure.Tickets != 2 || erasure.Responses != 2 || len(erasure.Attachments) != 1 || erasure.Pseudonym == uuid.Nil {
+			t.Errorf("Unexpected erasure report %+v", erasure)
+		}
+		owned, _ := f.persistence.GetTicket(f.owned.ID())
+		owner, _ := f.persistence.GetTicketOwner(owned.ID())
+		if owner != erasure.Pseudonym || owned.Title() != ErasedContent || owned.Description() != "" || len(owned.Fields()) != 0 {
+			t.Errorf("Expected the ticket to be erased and given to the pseudonym, got %+v", ticket.DataFrom(owned))
+		}
+		responses := owned.Responses()
+		if responses[0].UserId() != erasure.Pseudonym || responses[0].Content() != ErasedContent || len(responses[0].Attachments()) != 0 {
+			t.Errorf("Expected the response of the client to be erased, got %v", responses[0])
+		}
+		if responses[1].UserId() != f.agent || responses[1].Content() != "Fixed" {
+			t.Errorf("Expected the response of the agent to be kept, got %v", responses[1])
+		}
+		past := owned.PastSatisfactions()
+		if owned.Priority() != ticket.High || owned.Satisfaction().Rating != 4 || owned.Satisfaction().Comment != "" ||
+			len(past) != 1 || past[0].Rating != 2 || past[0].Comment != "" || len(owned.History()) != len(f.owned.History()) {
+			t.Errorf("Expected the statistics to be kept, got %+v", ticket.DataFrom(owned))
+		}
+		if _, err := f.blobs.Get(f.attached.ID); !errors.Is(err, attachment.ErrBlobNotFound) {
+			t.Errorf("Expected the attachment content to be deleted, got %v", err)
+		}
+		other, _ := f.persistence.GetTicket(f.other.ID())
+		if other.IsFollowedBy(f.client) || other.Responses()[0].Content() != ErasedContent || other.Title() != "Colleague's ticket" {
+			t.Errorf("Expected the client to be removed from the other ticket, got %+v", ticket.DataFrom(other))
+		}
+		if data, _ := service.Export(f.client); len(data.Tickets)+len(data.Responses)+len(data.Followed) != 0 {
+			t.Errorf("Expected nothing left for the client, got %+v", data)
+		}
+	})
+	t.Run("It should remove the erased texts from the search index", func(t *testing.T) {
+		t.Parallel()
+		f := newFixture(t)
+		index := search.NewMemoryIndex()
+		_ = index.Rebuild(f.persistence)
+		indexed, _ := repository.NewIndexingPersistence(f.persistence, index)
+		service, _ := NewService(indexed, f.blobs)
+		if results := index.Search(search.Query{Text: "invoice"}); len(results) != 1 {
+			t.Fatalf("Expected the ticket to be indexed, got %v", results)
+		}
+
+		erasure, err := service.Erase(f.client, Erase)
+
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		for _, text := range []string{"invoice", "wrong", "attached", "me too"} {
+			if results := index.Search(search.Query{Text: text}); len(results) != 0 {
+				t.Errorf("Expected %q to be erased from the index, got %v", text, results)
+			}
+		}
+		if results := index.Search(search.Query{Text: "fixed", Client: erasure.Pseudonym}); len(results) != 1 {
+			t.Errorf("Expected the ticket to be indexed for the pseudonym, got %v", results)
+		}
+	})
+	t.Run("It should give the tickets to the pseudonym through the decorated drivers", func(t *testing.T) {
+		t.Parallel()
+		f := newFixture(t)
+		cascading, _ := repository.NewCascadingClosePersistence(f.persistence, memory.NewRelationPersistence())
+		publishing, _ := repository.NewPublishingPersistence(cascading, events.NewBus())
+		service, _ := NewService(publishing, f.blobs)
+
+		erasure, err := service.Erase(f.client, Pseudonymize)
+
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if owner, _ := f.persistence.GetTicketOwner(f.owned.ID()); owner != erasure.Pseudonym {
+			t.Errorf("Expected the ticket to be given to the pseudonym, got %s", owner)
+		}
+	})
+	t.Run("It should only replace the client ID when pseudonymizing", func(t *testing.T) {
+		t.Parallel()
+		f := newFixture(t)
+		service, _ := NewService(f.persistence, nil)
+
+		erasure, err := service.Erase(f.client, Pseudonymize)
+
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		owned, _ := f.persistence.GetTicket(f.owned.ID())
+		if owned.Title() != "My invoice" || owned.Responses()[0].UserId() != erasure.Pseudonym ||
+			owned.Responses()[0].Content() != "See attached" || len(erasure.Attachments) != 0 {
+			t.Errorf("Expected the content to be kept, got %+v", ticket.DataFrom(owned))
+		}
+		if owner, _ := f.persistence.GetTicketOwner(owned.ID()); owner != erasure.Pseudonym {
+			t.Errorf("Expected the ticket to be given to the pseudonym, got %s", owner)
+		}
+	})
+	t.Run("It should validate the request", func(t *testing.T) {
+		t.Parallel()
+		service, _ := NewService(memory.NewTicketPersistence(), nil)
+		_, err := service.Erase(uuid.New(), "forget")
+		assertErrors(t, err, EraseError, ErrUnknownMode)
+		_, err = service.Erase(uuid.Nil, Erase)
+		assertErrors(t, err, EraseError, repository.ErrNilClientID)
+		_, err = service.Export(uuid.Nil)
+		assertErrors(t, err, ExportError, repository.ErrNilClientID)
+		_, err = NewService(nil, nil)
+		assertErrors(t, err, NewServiceError, repository.NilPersistenceDriverError)
+		_, err = NewServiceWithClock(memory.NewTicketPersistence(), nil, nil)
+		assertErrors(t, err, NewServiceError, entities.ErrNilClock)
+	})
+}
+
+func assertErrors(t *testing.T, err error, expected ...error) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("Error should not be nil")
+	}
+	for _, e := range expected {
+		if !errors.Is(err, e) {
+			t.Errorf("Error should be %v, but is %s", e, err.Error())
+		}
+	}
+}
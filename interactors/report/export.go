@@ -0,0 +1,103 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Overall is the key of the row of the overall metrics in the exports.
+const Overall = "overall"
+
+// csvHeader names the columns of WriteCSV, the durations are in seconds.
+var csvHeader = []string{
+	"group", "tickets", "median_first_response_seconds", "median_resolution_seconds", "reopen_rate", "backlog",
+	"median_backlog_age_seconds",
+}
+
+// WriteCSV writes a row per group, followed by the overall row.
+func WriteCSV(w io.Writer, report Report) error {
+	writer := csv.NewWriter(w)
+	rows := [][]string{csvHeader}
+	for _, group := range report.Groups {
+		rows = append(rows, csvRow(group.Key, group.Metrics))
+	}
+	rows = append(rows, csvRow(Overall, report.Overall))
+	if err := writer.WriteAll(rows); err != nil {
+		return errors.Join(WriteReportError, err)
+	}
+	return nil
+}
+
+func csvRow(key string, metrics Metrics) []string {
+	return []string{
+		key,
+		strconv.Itoa(metrics.Tickets),
+		formatSeconds(metrics.MedianFirstResponse),
+		formatSeconds(metrics.MedianResolution),
+		strconv.FormatFloat(metrics.ReopenRate, 'f', -1, 64),
+		strconv.Itoa(metrics.Backlog),
+		formatSeconds(metrics.MedianBacklogAge),
+	}
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
+
+// jsonMetrics is the JSON representation of Metrics, with the durations in seconds.
+type jsonMetrics struct {
+	Tickets             int     `json:"tickets"`
+	MedianFirstResponse float64 `json:"median_first_response_seconds"`
+	MedianResolution    float64 `json:"median_resolution_seconds"`
+	ReopenRate          float64 `json:"reopen_rate"`
+	Backlog             int     `json:"backlog"`
+	MedianBacklogAge    float64 `json:"median_backlog_age_seconds"`
+}
+
+type jsonGroup struct {
+	Key string `json:"key"`
+	jsonMetrics
+}
+
+type jsonReport struct {
+	From     time.Time   `json:"from"`
+	To       time.Time   `json:"to"`
+	Grouping Grouping    `json:"grouping"`
+	Overall  jsonMetrics `json:"overall"`
+	Groups   []jsonGroup `json:"groups"`
+}
+
+func toJSONMetrics(metrics Metrics) jsonMetrics {
+	return jsonMetrics{
+		Tickets:             metrics.Tickets,
+		MedianFirstResponse: metrics.MedianFirstResponse.Seconds(),
+		MedianResolution:    metrics.MedianResolution.Seconds(),
+		ReopenRate:          metrics.ReopenRate,
+		Backlog:             metrics.Backlog,
+		MedianBacklogAge:    metrics.MedianBacklogAge.Seconds(),
+	}
+}
+
+// WriteJSON writes the report as a JSON object, with the durations in seconds.
+func WriteJSON(w io.Writer, report Report) error {
+	out := jsonReport{
+		From:     report.From,
+		To:       report.To,
+		Grouping: report.Grouping,
+		Overall:  toJSONMetrics(report.Overall),
+		Groups:   []jsonGroup{},
+	}
+	for _, group := range report.Groups {
+		out.Groups = append(out.Groups, jsonGroup{Key: group.Key, jsonMetrics: toJSONMetrics(group.Metrics)})
+	}
+	if err := json.NewEncoder(w).Encode(out); err != nil {
+		return errors.Join(WriteReportError, err)
+	}
+	return nil
+}
+
+var WriteReportError error = errors.New("error writing report")
@@ -0,0 +1,297 @@
+// Package report computes the ticket metrics asked by the managers (volume, response and resolution times, reopen
+// rate and backlog age) grouped by period, agent, client or priority.
+package report
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"slices"
+	"strings"
+	"ticketTao/entities"
+	"ticketTao/entities/sla"
+	"ticketTao/entities/ticket"
+	"time"
+)
+
+// Grouping is the dimension the tickets of a report are grouped by.
+type Grouping string
+
+const (
+	// ByDay and ByWeek group the tickets by their creation day or week, weeks start on Monday.
+	ByDay  Grouping = "day"
+	ByWeek Grouping = "week"
+	// ByAgent groups the tickets by their assignee.
+	ByAgent    Grouping = "agent"
+	ByClient   Grouping = "client"
+	ByPriority Grouping = "priority"
+)
+
+// Unassigned is the key of the group of the tickets without assignee.
+const Unassigned = "unassigned"
+
+// Metrics are the figures computed for a set of tickets.
+type Metrics struct {
+	Tickets int
+	// MedianFirstResponse is the median time until the first response, not written by the owner of the ticket nor a
+	// note, among the tickets that have one by the end of the range.
+	MedianFirstResponse time.Duration
+	// MedianResolution is the median time until closing, among the tickets closed at the end of the range.
+	MedianResolution time.Duration
+	// ReopenRate is the fraction of the tickets closed by the end of the range that were reopened by then.
+	ReopenRate float64
+	// Backlog is the number of tickets still not closed at the end of the range.
+	Backlog int
+	// MedianBacklogAge is measured at the end of the range, or at the time of the report if the range has not ended.
+	MedianBacklogAge time.Duration
+}
+
+// Group contains the metrics of the tickets that share a key, e.g. the start of a week or an agent ID.
+type Group struct {
+	Key string
+	Metrics
+}
+
+// Report contains the metrics of the tickets created in a time range.
+type Report struct {
+	From     time.Time
+	To       time.Time
+	Grouping Grouping
+	Overall  Metrics
+	// Groups are sorted by key.
+	Groups []Group
+}
+
+// Record is a ticket together with the client that owns it.
+type Record struct {
+	Ticket ticket.Ticket
+	Owner  uuid.UUID
+}
+
+// Compute returns the report of the tickets created from the start of the range, included, to its end, excluded, as
+// of now. The days and weeks are computed in the location.
+func Compute(records []Record, from, to, now time.Time, grouping Grouping, location *time.Location) (Report, error) {
+	if !from.Before(to) {
+		return Report{}, ticket.ErrInvalidTimeRange
+	}
+	if err := grouping.Validate(); err != nil {
+		return Report{}, err
+	}
+	if location == nil {
+		location = time.UTC
+	}
+	var inRange []Record
+	groups := make(map[string][]Record)
+	for _, record := range records {
+		created := record.Ticket.CreatedAt()
+		if created.Before(from) || !created.Before(to) {
+			continue
+		}
+		key := grouping.key(record, location)
+		inRange = append(inRange, record)
+		groups[key] = append(groups[key], record)
+	}
+	end := to
+	if now.Before(end) {
+		end = now
+	}
+	report := Report{From: from, To: to, Grouping: grouping, Overall: measure(inRange, end)}
+	for key, group := range groups {
+		report.Groups = append(report.Groups, Group{Key: key, Metrics: measure(group, end)})
+	}
+	slices.SortFunc(report.Groups, func(a, b Group) int { return strings.Compare(a.Key, b.Key) })
+	return report, nil
+}
+
+// Validate returns an error if the grouping is not one of the known ones.
+func (g Grouping) Validate() error {
+	switch g {
+	case ByDay, ByWeek, ByAgent, ByClient, ByPriority:
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownGrouping, g)
+	}
+}
+
+// key returns the key of the group of the record, days and weeks are formatted as their first date.
+func (g Grouping) key(record Record, location *time.Location) string {
+	day := startOfDay(record.Ticket.CreatedAt().In(location))
+	switch g {
+	case ByWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7).Format(time.DateOnly)
+	case ByAgent:
+		if record.Ticket.Assignee() == uuid.Nil {
+			return Unassigned
+		}
+		return record.Ticket.Assignee().String()
+	case ByClient:
+		return record.Owner.String()
+	case ByPriority:
+		return string(record.Ticket.Priority())
+	default:
+		return day.Format(time.DateOnly)
+	}
+}
+
+// startOfDay returns the midnight of the day of the time, in the location of the time.
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// measure computes the metrics of the tickets as they were at the end, the end of the range or now if it is earlier.
+func measure(records []Record, end time.Time) Metrics {
+	var firstResponses, resolutions, backlogAges []time.Duration
+	closedOnce, reopened := 0, 0
+	for _, record := range records {
+		tck := record.Ticket
+		if responded := sla.FirstAgentResponseTime(tck, record.Owner); !responded.IsZero() && responded.Before(end) {
+			firstResponses = append(firstResponses, responded.Sub(tck.CreatedAt()))
+		}
+		state := stateAt(tck, end)
+		if state.closed {
+			resolutions = append(resolutions, state.closedAt.Sub(tck.CreatedAt()))
+		} else {
+			backlogAges = append(backlogAges, end.Sub(tck.CreatedAt()))
+		}
+		if state.closedOnce {
+			closedOnce++
+			if state.reopened {
+				reopened++
+			}
+		}
+	}
+	metrics := Metrics{
+		Tickets:             len(records),
+		MedianFirstResponse: median(firstResponses),
+		MedianResolution:    median(resolutions),
+		Backlog:             len(backlogAges),
+		MedianBacklogAge:    median(backlogAges),
+	}
+	if closedOnce > 0 {
+		metrics.ReopenRate = float64(reopened) / float64(closedOnce)
+	}
+	return metrics
+}
+
+// state is what the history of a ticket tells about its closing at some time.
+type state struct {
+	closed bool
+	// closedAt is when the ticket was last closed, if it is closed.
+	closedAt   time.Time
+	closedOnce bool
+	reopened   bool
+}
+
+// stateAt returns the state of the ticket at the time, from the changes of its history before it. The tickets made
+// without history only know their current status and closing time.
+func stateAt(tck ticket.Ticket, at time.Time) state {
+	history := tck.History()
+	if len(history) == 0 {
+		closed := tck.Status() == ticket.Closed && tck.ClosedAt().Before(at)
+		return state{closed: closed, closedAt: tck.ClosedAt(), closedOnce: closed}
+	}
+	s := state{closed: history[0].From == ticket.Closed, closedAt: tck.CreatedAt()}
+	s.closedOnce = s.closed
+	for _, change := range history {
+		if !change.At.Before(at) {
+			break
+		}
+		s.closed = change.To == ticket.Closed
+		if s.closed {
+			s.closedAt, s.closedOnce = change.At, true
+		} else if change.From == ticket.Closed {
+			s.reopened = true
+		}
+	}
+	return s
+}
+
+// median returns the median of the durations, 0 if there are none.
+func median(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	slices.Sort(durations)
+	middle := len(durations) / 2
+	if len(durations)%2 == 1 {
+		return durations[middle]
+	}
+	return (durations[middle-1] + durations[middle]) / 2
+}
+
+// Repository gives access to every ticket and to their owners.
+type Repository interface {
+	ticket.RepositoryAgentQuerier
+	GetTicketOwner(ticket uuid.UUID) (uuid.UUID, error)
+}
+
+// Generator computes the reports of the tickets of a repository.
+type Generator interface {
+	// Generate returns the report of the tickets created from the start of the range, included, to its end, excluded.
+	Generate(from, to time.Time, grouping Grouping) (Report, error)
+}
+
+// NewGenerator creates a Generator that reads the tickets of the repository, the days and weeks are computed in the
+// location, UTC if it is nil.
+func NewGenerator(repository Repository, location *time.Location) (Generator, error) {
+	return NewGeneratorWithClock(repository, location, entities.SystemClock())
+}
+
+// NewGeneratorWithClock works like NewGenerator, but the reports are computed as of the time of the clock.
+func NewGeneratorWithClock(repository Repository, location *time.Location, clock entities.Clock) (Generator, error) {
+	if repository == nil {
+		return nil, errors.Join(NewGeneratorError, ErrNilRepository)
+	}
+	if clock == nil {
+		return nil, errors.Join(NewGeneratorError, entities.ErrNilClock)
+	}
+	if location == nil {
+		location = time.UTC
+	}
+	return basicGenerator{repository: repository, location: location, clock: clock}, nil
+}
+
+type basicGenerator struct {
+	repository Repository
+	location   *time.Location
+	clock      entities.Clock
+}
+
+func (b basicGenerator) Generate(from, to time.Time, grouping Grouping) (Report, error) {
+	if !from.Before(to) {
+		return Report{}, errors.Join(GenerateError, ticket.ErrInvalidTimeRange)
+	}
+	if err := grouping.Validate(); err != nil {
+		return Report{}, errors.Join(GenerateError, err)
+	}
+	var records []Record
+	query := ticket.Query{CreatedFrom: from, CreatedTo: to, Limit: ticket.MaxPageSize}
+	for {
+		page, err := b.repository.QueryTickets(query)
+		if err != nil {
+			return Report{}, errors.Join(GenerateError, err)
+		}
+		for _, tck := range page.Tickets {
+			owner, err := b.repository.GetTicketOwner(tck.ID())
+			if err != nil {
+				return Report{}, errors.Join(GenerateError, err)
+			}
+			records = append(records, Record{Ticket: tck, Owner: owner})
+		}
+		if page.Next == "" {
+			break
+		}
+		query.After = page.Next
+	}
+	report, err := Compute(records, from, to, b.clock.Now(), grouping, b.location)
+	if err != nil {
+		return Report{}, errors.Join(GenerateError, err)
+	}
+	return report, nil
+}
+
+var NewGeneratorError error = errors.New("error creating report generator")
+var GenerateError error = errors.New("error generating report")
+
+var ErrNilRepository error = errors.New("ticket repository cannot be nil")
+var ErrUnknownGrouping error = errors.New("unknown report grouping")
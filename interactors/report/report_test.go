@@ -0,0 +1,240 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"strings"
+	"testing"
+	"ticketTao/entities"
+	"ticketTao/entities/ticket"
+	"ticketTao/interactors/ticket/persistence/memory"
+	"ticketTao/interactors/ticket/repository"
+	"time"
+)
+
+var monday = time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC)
+
+func TestGenerator_Generate(t *testing.T) {
+	t.Parallel()
+	client, agent := uuid.New(), uuid.New()
+	tp := memory.NewTicketPersistence()
+	repo, _ := repository.GetAgentTicketRepository(tp)
+	saveTicket(t, tp, client, monday, ticket.Data{
+		Status:    ticket.Closed,
+		Assignee:  agent,
+		Priority:  ticket.High,
+		Responses: responses(client, monday.Add(time.Minute), agent, monday.Add(time.Hour)),
+		ClosedAt:  monday.Add(4 * time.Hour),
+		History: []ticket.StatusChange{
+			{From: ticket.Open, To: ticket.Closed, At: monday.Add(2 * time.Hour)},
+			{From: ticket.Closed, To: ticket.Open, At: monday.Add(3 * time.Hour)},
+			{From: ticket.Open, To: ticket.Closed, At: monday.Add(4 * time.Hour)},
+		},
+	})
+	saveTicket(t, tp, client, monday.Add(24*time.Hour), ticket.Data{
+		Status:    ticket.Closed,
+		Assignee:  agent,
+		Responses: responses(agent, monday.Add(27*time.Hour)),
+		ClosedAt:  monday.Add(32 * time.Hour),
+	})
+	saveTicket(t, tp, uuid.New(), monday.Add(7*24*time.Hour), ticket.Data{Status: ticket.Open})
+	saveTicket(t, tp, client, monday.Add(-time.Hour), ticket.Data{Status: ticket.Open})
+	end := monday.Add(14 * 24 * time.Hour)
+
+	t.Run("It should compute the metrics of the tickets created in the range", func(t *testing.T) {
+		t.Parallel()
+		generator, _ := NewGenerator(repo, nil)
+
+		report, err := generator.Generate(monday, end, ByWeek)
+
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		expected := Metrics{
+			Tickets:             3,
+			MedianFirstResponse: 2 * time.Hour,
+			MedianResolution:    6 * time.Hour,
+			ReopenRate:          0.5,
+			Backlog:             1,
+			MedianBacklogAge:    7 * 24 * time.Hour,
+		}
+		if report.Overall != expected {
+			t.Errorf("Expected overall metrics %+v, got %+v", expected, report.Overall)
+		}
+		if len(report.Groups) != 2 || report.Groups[0].Key != "2024-03-04" || report.Groups[0].Tickets != 2 ||
+			report.Groups[1].Key != "2024-03-11" || report.Groups[1].Backlog != 1 {
+			t.Errorf("Expected two weeks of tickets, got %+v", report.Groups)
+		}
+	})
+	t.Run("It should group the tickets by agent, client and priority", func(t *testing.T) {
+		t.Parallel()
+		generator, _ := NewGenerator(repo, time.UTC)
+		for grouping, keys := range map[Grouping][]string{
+			ByAgent:    {agent.String(), Unassigned},
+			ByPriority: {string(ticket.High), string(ticket.Normal)},
+			ByDay:      {"2024-03-04", "2024-03-05", "2024-03-11"},
+		} {
+			report, err := generator.Generate(monday, end, grouping)
+			if err != nil {
+				t.Fatalf("Error should be nil, got %v", err)
+			}
+			if len(report.Groups) != len(keys) {
+				t.Fatalf("Expected the groups %v, got %+v", keys, report.Groups)
+			}
+			for i, key := range keys {
+				if report.Groups[i].Key != key {
+					t.Errorf("Expected the group %s, got %s", key, report.Groups[i].Key)
+				}
+			}
+		}
+		report, _ := generator.Generate(monday, end, ByClient)
+		if len(report.Groups) != 2 {
+			t.Errorf("Expected a group per client, got %+v", report.Groups)
+		}
+	})
+	t.Run("It should validate the range and the grouping", func(t *testing.T) {
+		t.Parallel()
+		generator, _ := NewGenerator(repo, nil)
+		_, err := generator.Generate(end, monday, ByDay)
+		assertErrors(t, err, GenerateError, ticket.ErrInvalidTimeRange)
+		_, err = generator.Generate(monday, end, "month")
+		assertErrors(t, err, GenerateError, ErrUnknownGrouping)
+		_, err = NewGenerator(nil, nil)
+		assertErrors(t, err, NewGeneratorError, ErrNilRepository)
+		_, err = NewGeneratorWithClock(repo, nil, nil)
+		assertErrors(t, err, NewGeneratorError, entities.ErrNilClock)
+	})
+	t.Run("It should measure the backlog age until now when the range has not ended", func(t *testing.T) {
+		t.Parallel()
+		now := monday.Add(7*24*time.Hour + 12*time.Hour)
+		generator, _ := NewGeneratorWithClock(repo, nil, entities.ClockFunc(func() time.Time { return now }))
+
+		report, err := generator.Generate(monday, end, ByWeek)
+
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if report.Overall.Backlog != 1 || report.Overall.MedianBacklogAge != 12*time.Hour {
+			t.Errorf("Expected a backlog of a ticket created 12 hours ago, got %+v", report.Overall)
+		}
+	})
+}
+
+func TestCompute(t *testing.T) {
+	t.Parallel()
+	t.Run("It should measure the tickets as they were at the end of the range", func(t *testing.T) {
+		t.Parallel()
+		client, agent := uuid.New(), uuid.New()
+		end := monday.Add(24 * time.Hour)
+		reopenedLater, _ := ticket.MakeBasicTicket(uuid.New(), monday, ticket.Data{
+			Title:     "title",
+			Status:    ticket.InProgress,
+			Responses: []ticket.Response{ticket.MakeNote(agent, "Merged", monday.Add(time.Minute)), ticket.MakeResponse(agent, "Fixed", monday.Add(time.Hour))},
+			History: []ticket.StatusChange{
+				{From: ticket.Open, To: ticket.Closed, At: monday.Add(2 * time.Hour)},
+				{From: ticket.Closed, To: ticket.InProgress, At: end.Add(time.Hour)},
+			},
+		})
+		closedLater, _ := ticket.MakeBasicTicket(uuid.New(), monday, ticket.Data{
+			Title:     "title",
+			Status:    ticket.Closed,
+			Responses: responses(agent, end.Add(time.Hour)),
+			ClosedAt:  end.Add(2 * time.Hour),
+			History:   []ticket.StatusChange{{From: ticket.Open, To: ticket.Closed, At: end.Add(2 * time.Hour)}},
+		})
+		records := []Record{{Ticket: reopenedLater, Owner: client}, {Ticket: closedLater, Owner: client}}
+
+		report, err := Compute(records, monday, end, end.Add(24*time.Hour), ByDay, nil)
+
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		expected := Metrics{
+			Tickets:             2,
+			MedianFirstResponse: time.Hour,
+			MedianResolution:    2 * time.Hour,
+			Backlog:             1,
+			MedianBacklogAge:    24 * time.Hour,
+		}
+		if report.Overall != expected {
+			t.Errorf("Expected overall metrics %+v, got %+v", expected, report.Overall)
+		}
+	})
+}
+
+func TestWriteCSV(t *testing.T) {
+	t.Parallel()
+	report := Report{
+		Grouping: ByPriority,
+		Overall:  Metrics{Tickets: 2, MedianFirstResponse: 90 * time.Second, ReopenRate: 0.5},
+		Groups:   []Group{{Key: "High", Metrics: Metrics{Tickets: 2, MedianFirstResponse: 90 * time.Second, ReopenRate: 0.5}}},
+	}
+	var out bytes.Buffer
+
+	err := WriteCSV(&out, report)
+
+	if err != nil {
+		t.Fatalf("Error should be nil, got %v", err)
+	}
+	expected := strings.Join(csvHeader, ",") + "\nHigh,2,90,0,0.5,0,0\noverall,2,90,0,0.5,0,0\n"
+	if out.String() != expected {
+		t.Errorf("Expected CSV\n%s\ngot\n%s", expected, out.String())
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	t.Parallel()
+	report := Report{From: monday, To: monday.Add(time.Hour), Grouping: ByDay, Overall: Metrics{MedianResolution: time.Minute}}
+	var out bytes.Buffer
+
+	err := WriteJSON(&out, report)
+
+	if err != nil {
+		t.Fatalf("Error should be nil, got %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("The report should be valid JSON, got %v", err)
+	}
+	overall := decoded["overall"].(map[string]any)
+	if overall["median_resolution_seconds"] != 60.0 || decoded["grouping"] != "day" {
+		t.Errorf("Unexpected JSON report %s", out.String())
+	}
+	if groups, ok := decoded["groups"].([]any); !ok || len(groups) != 0 {
+		t.Errorf("Expected an empty list of groups, got %v", decoded["groups"])
+	}
+}
+
+func responses(userAndTimes ...any) []ticket.Response {
+	var list []ticket.Response
+	for i := 0; i < len(userAndTimes); i += 2 {
+		list = append(list, ticket.MakeResponse(userAndTimes[i].(uuid.UUID), "content", userAndTimes[i+1].(time.Time)))
+	}
+	return list
+}
+
+func saveTicket(t *testing.T, tp repository.TicketPersistence, owner uuid.UUID, createdAt time.Time, data ticket.Data) {
+	t.Helper()
+	data.Title = "title"
+	tck, err := ticket.MakeBasicTicket(uuid.New(), createdAt, data)
+	if err != nil {
+		t.Fatalf("Error creating ticket: %v", err)
+	}
+	if err := tp.SaveNewTicketForClient(owner, tck); err != nil {
+		t.Fatalf("Error saving ticket: %v", err)
+	}
+}
+
+func assertErrors(t *testing.T, err error, expected ...error) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("Error should not be nil")
+	}
+	for _, e := range expected {
+		if !errors.Is(err, e) {
+			t.Errorf("Error should be %v, but is %s", e, err.Error())
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Package satisfaction aggregates the answers of the satisfaction surveys of the tickets, per agent and per period.
+package satisfaction
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"slices"
+	"ticketTao/entities/ticket"
+	"time"
+)
+
+// Period is the length of the periods the ratings are grouped by.
+type Period string
+
+const (
+	Day   Period = "day"
+	Week  Period = "week"
+	Month Period = "month"
+)
+
+// Start returns the start of the period that contains the time, in the location of the time. Weeks start on Monday.
+func (p Period) Start(t time.Time) (time.Time, error) {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch p {
+	case Day:
+		return day, nil
+	case Week:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7), nil
+	case Month:
+		return day.AddDate(0, 0, 1-day.Day()), nil
+	default:
+		return time.Time{}, fmt.Errorf("%w: %s", ErrUnknownPeriod, p)
+	}
+}
+
+// Score summarizes a set of ratings.
+type Score struct {
+	Ratings int     `json:"ratings"`
+	Average float64 `json:"average"`
+	// Distribution counts the ratings by value, Distribution[0] is the number of ratings of ticket.MinRating.
+	Distribution [ticket.MaxRating - ticket.MinRating + 1]int `json:"distribution"`
+}
+
+func (s *Score) add(rating int) {
+	s.Average = (s.Average*float64(s.Ratings) + float64(rating)) / float64(s.Ratings+1)
+	s.Ratings++
+	s.Distribution[rating-ticket.MinRating]++
+}
+
+// PeriodScore is the score of the ratings given during a period.
+type PeriodScore struct {
+	Start time.Time `json:"start"`
+	Score
+}
+
+// Report contains the scores of the ratings given in a time range.
+type Report struct {
+	Overall Score               `json:"overall"`
+	ByAgent map[uuid.UUID]Score `json:"by_agent"`
+	// ByPeriod only contains the periods with ratings, sorted by start.
+	ByPeriod []PeriodScore `json:"by_period"`
+}
+
+// Summarize returns the report of the ratings of the tickets given from the start of the range, included, to its end,
+// excluded, including the ratings of their past surveys. The periods are computed in the location, UTC if it is nil.
+func Summarize(tickets []ticket.Ticket, from, to time.Time, period Period, location *time.Location) (Report, error) {
+	summary, err := newSummary(from, to, period, location)
+	if err != nil {
+		return Report{}, err
+	}
+	for _, tck := range tickets {
+		summary.add(tck)
+	}
+	return summary.report(), nil
+}
+
+// summary adds up the ratings of the tickets, so that they can be summarized a page at a time.
+type summary struct {
+	from, to time.Time
+	period   Period
+	location *time.Location
+	overall  Score
+	byAgent  map[uuid.UUID]Score
+	byStart  map[time.Time]*PeriodScore
+}
+
+func newSummary(from, to time.Time, period Period, location *time.Location) (*summary, error) {
+	if _, err := period.Start(from); err != nil {
+		return nil, err
+	}
+	if !from.Before(to) {
+		return nil, ErrInvalidTimeRange
+	}
+	if location == nil {
+		location = time.UTC
+	}
+	return &summary{
+		from:     from,
+		to:       to,
+		period:   period,
+		location: location,
+		byAgent:  make(map[uuid.UUID]Score),
+		byStart:  make(map[time.Time]*PeriodScore),
+	}, nil
+}
+
+func (s *summary) add(tck ticket.Ticket) {
+	for _, satisfaction := range append(tck.PastSatisfactions(), tck.Satisfaction()) {
+		if !satisfaction.Rated() || satisfaction.RatedAt.Before(s.from) || !satisfaction.RatedAt.Before(s.to) {
+			continue
+		}
+		s.overall.add(satisfaction.Rating)
+		agentScore := s.byAgent[satisfaction.Agent]
+		agentScore.add(satisfaction.Rating)
+		s.byAgent[satisfaction.Agent] = agentScore
+		start, _ := s.period.Start(satisfaction.RatedAt.In(s.location))
+		if s.byStart[start] == nil {
+			s.byStart[start] = &PeriodScore{Start: start}
+		}
+		s.byStart[start].add(satisfaction.Rating)
+	}
+}
+
+func (s *summary) report() Report {
+	report := Report{Overall: s.overall, ByAgent: s.byAgent}
+	for _, score := range s.byStart {
+		report.ByPeriod = append(report.ByPeriod, *score)
+	}
+	slices.SortFunc(report.ByPeriod, func(a, b PeriodScore) int { return a.Start.Compare(b.Start) })
+	return report
+}
+
+// Aggregator computes the satisfaction reports of the tickets of a repository.
+type Aggregator interface {
+	// Aggregate returns the report of the ratings given from the start of the range, included, to its end, excluded.
+	Aggregate(from, to time.Time, period Period) (Report, error)
+}
+
+// NewAggregator creates an Aggregator that goes through the tickets of the repository a page at a time, the periods are
+// computed in the location, UTC if it is nil.
+func NewAggregator(repository ticket.RepositoryAgentQuerier, location *time.Location) (Aggregator, error) {
+	if repository == nil {
+		return nil, errors.Join(NewAggregatorError, ErrNilRepository)
+	}
+	if location == nil {
+		location = time.UTC
+	}
+	return basicAggregator{repository, location}, nil
+}
+
+type basicAggregator struct {
+	repository ticket.RepositoryAgentQuerier
+	location   *time.Location
+}
+
+func (b basicAggregator) Aggregate(from, to time.Time, period Period) (Report, error) {
+	summary, err := newSummary(from, to, period, b.location)
+	if err != nil {
+		return Report{}, errors.Join(AggregateError, err)
+	}
+	// The tickets created after the range cannot have been rated in it.
+	query := ticket.Query{CreatedTo: to, Limit: ticket.MaxPageSize}
+	for {
+		page, err := b.repository.QueryTickets(query)
+		if err != nil {
+			return Report{}, errors.Join(AggregateError, err)
+		}
+		for _, tck := range page.Tickets {
+			summary.add(tck)
+		}
+		if page.Next == "" {
+			break
+		}
+		query.After = page.Next
+	}
+	return summary.report(), nil
+}
+
+var NewAggregatorError error = errors.New("error creating satisfaction aggregator")
+var AggregateError error = errors.New("error aggregating satisfaction ratings")
+
+var ErrNilRepository error = errors.New("ticket repository cannot be nil")
+var ErrUnknownPeriod error = errors.New("unknown period")
+var ErrInvalidTimeRange error = errors.New("the start of the time range must be before its end")
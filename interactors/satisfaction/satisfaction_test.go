@@ -0,0 +1,145 @@
+package satisfaction
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"testing"
+	"ticketTao/entities/ticket"
+	"ticketTao/interactors/ticket/persistence/memory"
+	"ticketTao/interactors/ticket/repository"
+	"time"
+)
+
+func TestPeriod_Start(t *testing.T) {
+	t.Parallel()
+	wednesday := time.Date(2024, time.March, 6, 15, 30, 0, 0, time.UTC)
+	for period, expected := range map[Period]time.Time{
+		Day:   time.Date(2024, time.March, 6, 0, 0, 0, 0, time.UTC),
+		Week:  time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC),
+		Month: time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		start, err := period.Start(wednesday)
+		if err != nil || !start.Equal(expected) {
+			t.Errorf("Expected the %s to start at %s, got %s, %v", period, expected, start, err)
+		}
+	}
+	sunday := time.Date(2024, time.March, 10, 23, 0, 0, 0, time.UTC)
+	if start, _ := Week.Start(sunday); start.Day() != 4 {
+		t.Errorf("Expected Sunday to belong to the week starting on Monday the 4th, got %s", start)
+	}
+	_, err := Period("year").Start(wednesday)
+	assertErrors(t, err, ErrUnknownPeriod)
+}
+
+func TestAggregator(t *testing.T) {
+	t.Parallel()
+	monday := time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC)
+	alice, bob := uuid.New(), uuid.New()
+	tp := memory.NewTicketPersistence()
+	repo, _ := repository.GetAgentTicketRepository(tp)
+	for _, rating := range []struct {
+		agent  uuid.UUID
+		rating int
+		at     time.Time
+	}{
+		{alice, 5, monday},
+		{alice, 3, monday.Add(24 * time.Hour)},
+		{bob, 1, monday.Add(7 * 24 * time.Hour)},
+		{bob, 4, monday.Add(-time.Hour)},
+		{alice, 0, monday},
+	} {
+		saveRatedTicket(t, tp, rating.agent, rating.rating, rating.at)
+	}
+
+	t.Run("It should aggregate the ratings of the range per agent and per period", func(t *testing.T) {
+		t.Parallel()
+		aggregator, _ := NewAggregator(repo, nil)
+
+		report, err := aggregator.Aggregate(monday, monday.Add(14*24*time.Hour), Week)
+
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if report.Overall.Ratings != 3 || report.Overall.Average != 3 || report.Overall.Distribution != [5]int{1, 0, 1, 0, 1} {
+			t.Errorf("Expected 3 ratings averaging 3, got %+v", report.Overall)
+		}
+		if report.ByAgent[alice].Average != 4 || report.ByAgent[bob].Ratings != 1 {
+			t.Errorf("Expected the scores per agent, got %+v", report.ByAgent)
+		}
+		if len(report.ByPeriod) != 2 || !report.ByPeriod[0].Start.Equal(monday.Truncate(24*time.Hour)) || report.ByPeriod[1].Ratings != 1 {
+			t.Errorf("Expected two weeks of ratings, got %+v", report.ByPeriod)
+		}
+	})
+	t.Run("It should validate the range and the period", func(t *testing.T) {
+		t.Parallel()
+		aggregator, _ := NewAggregator(repo, time.UTC)
+		_, err := aggregator.Aggregate(monday, monday, Day)
+		assertErrors(t, err, AggregateError, ErrInvalidTimeRange)
+		_, err = aggregator.Aggregate(monday, monday.Add(time.Hour), "year")
+		assertErrors(t, err, AggregateError, ErrUnknownPeriod)
+		_, err = NewAggregator(nil, nil)
+		assertErrors(t, err, NewAggregatorError, ErrNilRepository)
+	})
+}
+
+func TestSummarize(t *testing.T) {
+	t.Parallel()
+	monday := time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC)
+	agent := uuid.New()
+	past := ticket.Satisfaction{TokenHash: ticket.HashSurveyToken("first"), Agent: agent, Rating: 2, RatedAt: monday}
+	latest := ticket.Satisfaction{TokenHash: ticket.HashSurveyToken("second"), Agent: agent, Rating: 5, RatedAt: monday.Add(time.Hour)}
+	reopened, _ := ticket.MakeBasicTicket(uuid.New(), monday.Add(-time.Hour), ticket.Data{
+		Title: "title", Status: ticket.Closed, Satisfaction: latest, PastSatisfactions: []ticket.Satisfaction{past},
+	})
+
+	t.Run("It should include the ratings of the past surveys", func(t *testing.T) {
+		t.Parallel()
+		report, err := Summarize([]ticket.Ticket{reopened}, monday, monday.Add(24*time.Hour), Day, time.UTC)
+
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if report.Overall.Ratings != 2 || report.Overall.Average != 3.5 {
+			t.Errorf("Expected the ratings of both surveys of the reopened ticket, got %+v", report.Overall)
+		}
+	})
+	t.Run("It should compute the periods in UTC when the location is nil", func(t *testing.T) {
+		t.Parallel()
+		report, err := Summarize([]ticket.Ticket{reopened}, monday, monday.Add(24*time.Hour), Day, nil)
+
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if len(report.ByPeriod) != 1 || !report.ByPeriod[0].Start.Equal(monday.Truncate(24*time.Hour)) {
+			t.Errorf("Expected a single period starting on Monday at midnight UTC, got %+v", report.ByPeriod)
+		}
+	})
+}
+
+func saveRatedTicket(t *testing.T, tp repository.TicketPersistence, agent uuid.UUID, rating int, at time.Time) {
+	t.Helper()
+	satisfaction := ticket.Satisfaction{TokenHash: ticket.HashSurveyToken("token"), Agent: agent, RequestedAt: at}
+	if rating != 0 {
+		satisfaction.Rating = rating
+		satisfaction.RatedAt = at
+	}
+	tck, err := ticket.MakeBasicTicket(uuid.New(), at.Add(-time.Hour), ticket.Data{Title: "title", Status: ticket.Closed, Satisfaction: satisfaction})
+	if err != nil {
+		t.Fatalf("Error creating ticket: %v", err)
+	}
+	if err := tp.SaveNewTicketForClient(uuid.New(), tck); err != nil {
+		t.Fatalf("Error saving ticket: %v", err)
+	}
+}
+
+func assertErrors(t *testing.T, err error, expected ...error) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("Error should not be nil")
+	}
+	for _, e := range expected {
+		if !errors.Is(err, e) {
+			t.Errorf("Error should be %v, but is %s", e, err.Error())
+		}
+	}
+}
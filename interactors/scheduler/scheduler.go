@@ -0,0 +1,94 @@
+// Package scheduler runs background jobs, like ticket escalation, at a fixed interval. Time is read from an injectable
+// Clock, so the jobs can be tested without waiting.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"ticketTao/entities"
+	"time"
+)
+
+// Clock tells the time and waits for it to pass.
+type Clock interface {
+	entities.Clock
+	// After returns a channel that receives the current time once the duration has elapsed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock returns a Clock that uses the system time.
+func SystemClock() Clock {
+	return systemClock{}
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Job is the work done by the scheduler on every run.
+type Job interface {
+	Run() error
+}
+
+// JobFunc adapts a function to the Job interface.
+type JobFunc func() error
+
+func (f JobFunc) Run() error {
+	return f()
+}
+
+// NewScheduler creates a Scheduler that runs the job every interval. The errors returned by the job are passed to
+// onError, which can be nil to ignore them; a failing run does not stop the scheduler.
+func NewScheduler(clock Clock, interval time.Duration, job Job, onError func(error)) (Scheduler, error) {
+	if clock == nil {
+		return nil, errors.Join(NewSchedulerError, ErrNilClock)
+	}
+	if job == nil {
+		return nil, errors.Join(NewSchedulerError, ErrNilJob)
+	}
+	if interval <= 0 {
+		return nil, errors.Join(NewSchedulerError, ErrInvalidInterval)
+	}
+	if onError == nil {
+		onError = func(error) {}
+	}
+	return basicScheduler{clock: clock, interval: interval, job: job, onError: onError}, nil
+}
+
+// Scheduler runs a job periodically.
+type Scheduler interface {
+	// Run runs the job immediately and then after every interval, until the context is cancelled.
+	Run(ctx context.Context)
+}
+
+type basicScheduler struct {
+	clock    Clock
+	interval time.Duration
+	job      Job
+	onError  func(error)
+}
+
+func (b basicScheduler) Run(ctx context.Context) {
+	for {
+		if err := b.job.Run(); err != nil {
+			b.onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.clock.After(b.interval):
+		}
+	}
+}
+
+var NewSchedulerError error = errors.New("error creating scheduler")
+
+var ErrNilClock error = errors.New("clock cannot be nil")
+var ErrNilJob error = errors.New("job cannot be nil")
+var ErrInvalidInterval error = errors.New("scheduler interval must be positive")
@@ -0,0 +1,97 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewScheduler(t *testing.T) {
+	t.Parallel()
+	job := JobFunc(func() error { return nil })
+	_, err := NewScheduler(nil, time.Minute, job, nil)
+	assertErrors(t, err, NewSchedulerError, ErrNilClock)
+	_, err = NewScheduler(SystemClock(), time.Minute, nil, nil)
+	assertErrors(t, err, NewSchedulerError, ErrNilJob)
+	_, err = NewScheduler(SystemClock(), 0, job, nil)
+	assertErrors(t, err, NewSchedulerError, ErrInvalidInterval)
+}
+
+func TestScheduler_Run(t *testing.T) {
+	t.Parallel()
+	t.Run("The job runs immediately and after every interval until the context is cancelled", func(t *testing.T) {
+		t.Parallel()
+		clock := newManualClock()
+		runs := make(chan struct{}, 10)
+		failure := errors.New("job failed")
+		var reported []error
+		scheduler, err := NewScheduler(clock, time.Minute, JobFunc(func() error {
+			runs <- struct{}{}
+			return failure
+		}), func(err error) { reported = append(reported, err) })
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			scheduler.Run(ctx)
+			close(done)
+		}()
+
+		waitForRun(t, runs)
+		clock.tick()
+		waitForRun(t, runs)
+		clock.tick()
+		waitForRun(t, runs)
+		cancel()
+		<-done
+
+		if len(reported) != 3 || !errors.Is(reported[0], failure) {
+			t.Errorf("Expected the 3 failures to be reported, got %v", reported)
+		}
+	})
+}
+
+func waitForRun(t *testing.T, runs chan struct{}) {
+	t.Helper()
+	select {
+	case <-runs:
+	case <-time.After(time.Second):
+		t.Fatal("The job should have run")
+	}
+}
+
+// manualClock only lets time pass when tick is called.
+type manualClock struct {
+	ticks chan time.Time
+}
+
+func newManualClock() *manualClock {
+	return &manualClock{ticks: make(chan time.Time)}
+}
+
+func (m *manualClock) Now() time.Time {
+	return time.Time{}
+}
+
+func (m *manualClock) After(time.Duration) <-chan time.Time {
+	return m.ticks
+}
+
+func (m *manualClock) tick() {
+	m.ticks <- time.Time{}
+}
+
+func assertErrors(t *testing.T, err error, expected ...error) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("Error should not be nil")
+	}
+	for _, e := range expected {
+		if !errors.Is(err, e) {
+			t.Errorf("Error should be %v, but is %s", e, err.Error())
+		}
+	}
+}
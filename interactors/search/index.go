@@ -0,0 +1,195 @@
+// Package search contains a full-text index over the tickets and their conversations, so agents and clients can find
+// tickets by the words in their title, description and responses.
+package search
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"math"
+	"sort"
+	"sync"
+	"ticketTao/entities/ticket"
+)
+
+// Query is a full-text search over the indexed tickets.
+type Query struct {
+	// Text is tokenized like the tickets, a ticket matches when it contains every term.
+	Text string
+	// Client restricts the search to the tickets of a client, uuid.Nil searches the tickets of every client.
+	Client uuid.UUID
+	// Limit is the maximum number of results, 0 means no limit.
+	Limit int
+}
+
+// Result is a ticket that matched a Query.
+type Result struct {
+	Ticket uuid.UUID
+	Client uuid.UUID
+	Score  float64
+}
+
+// Index keeps the terms of the tickets up to date and searches them.
+type Index interface {
+	// Index adds the ticket to the index, or replaces it if it was already indexed.
+	Index(tck ticket.Ticket, client uuid.UUID)
+	Remove(ticket uuid.UUID)
+	// Search returns the matching tickets, the most relevant first.
+	Search(Query) []Result
+	// Rebuild replaces the indexed tickets with the tickets of the source, e.g. to fill a new index with the tickets
+	// that were already persisted, or after restoring them. The index is not changed if the source fails.
+	Rebuild(Source) error
+}
+
+// Source lists the tickets to index and their owners, a repository.TicketPersistence implements it.
+type Source interface {
+	GetAllTickets() ([]ticket.Ticket, error)
+	GetTicketOwner(ticket uuid.UUID) (uuid.UUID, error)
+}
+
+// Terms found in the title weigh more than those in the description, and those more than the ones in the responses.
+const (
+	titleWeight       = 3
+	descriptionWeight = 2
+	responseWeight    = 1
+)
+
+// NewMemoryIndex creates an empty in-memory inverted index. Results are ranked by TF-IDF, weighting the terms by the
+// field they were found in.
+func NewMemoryIndex() Index {
+	return &memoryIndex{
+		postings:  make(map[string]map[uuid.UUID]float64),
+		documents: make(map[uuid.UUID]document),
+	}
+}
+
+type document struct {
+	client uuid.UUID
+	terms  map[string]float64
+}
+
+type memoryIndex struct {
+	mu sync.RWMutex
+	// postings maps every term to the weighted frequency of the term in each ticket.
+	postings  map[string]map[uuid.UUID]float64
+	documents map[uuid.UUID]document
+}
+
+func (m *memoryIndex) Index(tck ticket.Ticket, client uuid.UUID) {
+	doc := makeDocument(tck, client)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remove(tck.ID())
+	m.add(tck.ID(), doc)
+}
+
+func (m *memoryIndex) Rebuild(source Source) error {
+	tickets, err := source.GetAllTickets()
+	if err != nil {
+		return errors.Join(RebuildError, err)
+	}
+	rebuilt := &memoryIndex{
+		postings:  make(map[string]map[uuid.UUID]float64),
+		documents: make(map[uuid.UUID]document, len(tickets)),
+	}
+	for _, tck := range tickets {
+		client, err := source.GetTicketOwner(tck.ID())
+		if err != nil {
+			return errors.Join(RebuildError, err)
+		}
+		rebuilt.add(tck.ID(), makeDocument(tck, client))
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.postings, m.documents = rebuilt.postings, rebuilt.documents
+	return nil
+}
+
+func makeDocument(tck ticket.Ticket, client uuid.UUID) document {
+	terms := make(map[string]float64)
+	addTerms(terms, tck.Title(), titleWeight)
+	addTerms(terms, tck.Description(), descriptionWeight)
+	for _, response := range tck.Responses() {
+		addTerms(terms, response.Content(), responseWeight)
+	}
+	return document{client: client, terms: terms}
+}
+
+func (m *memoryIndex) add(ticket uuid.UUID, doc document) {
+	m.documents[ticket] = doc
+	for term, frequency := range doc.terms {
+		if m.postings[term] == nil {
+			m.postings[term] = make(map[uuid.UUID]float64)
+		}
+		m.postings[term][ticket] = frequency
+	}
+}
+
+func (m *memoryIndex) Remove(ticket uuid.UUID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remove(ticket)
+}
+
+func (m *memoryIndex) remove(ticket uuid.UUID) {
+	doc, ok := m.documents[ticket]
+	if !ok {
+		return
+	}
+	for term := range doc.terms {
+		delete(m.postings[term], ticket)
+		if len(m.postings[term]) == 0 {
+			delete(m.postings, term)
+		}
+	}
+	delete(m.documents, ticket)
+}
+
+func (m *memoryIndex) Search(query Query) []Result {
+	terms := Tokenize(query.Text)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	scores := make(map[uuid.UUID]float64)
+	for i, term := range terms {
+		postings := m.postings[term]
+		idf := math.Log(1 + float64(len(m.documents))/float64(len(postings)+1))
+		next := make(map[uuid.UUID]float64)
+		for id, frequency := range postings {
+			if query.Client != uuid.Nil && m.documents[id].client != query.Client {
+				continue
+			}
+			score, matched := scores[id]
+			if i > 0 && !matched {
+				continue
+			}
+			next[id] = score + frequency*idf
+		}
+		scores = next
+	}
+
+	results := make([]Result, 0, len(scores))
+	for id, score := range scores {
+		results = append(results, Result{Ticket: id, Client: m.documents[id].client, Score: score})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Ticket.String() < results[j].Ticket.String()
+	})
+	if query.Limit > 0 && len(results) > query.Limit {
+		results = results[:query.Limit]
+	}
+	return results
+}
+
+func addTerms(terms map[string]float64, text string, weight float64) {
+	for _, term := range Tokenize(text) {
+		terms[term] += weight
+	}
+}
+
+var RebuildError error = errors.New("error rebuilding search index")
@@ -0,0 +1,144 @@
+package search
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"slices"
+	"testing"
+	"ticketTao/entities/ticket"
+	"time"
+)
+
+func TestTokenize(t *testing.T) {
+	t.Parallel()
+	got := Tokenize("¿Dónde está la FACTURACIÓN del pedido #1234? The invoice-PDF is missing")
+	want := []string{"donde", "esta", "facturacion", "pedido", "1234", "invoice", "pdf", "missing"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	decomposed := Tokenize("Facturacio\u0301n Pen\u0303a")
+	if !slices.Equal(decomposed, []string{"facturacion", "pena"}) {
+		t.Errorf("Expected the decomposed accents to be removed, got %v", decomposed)
+	}
+}
+
+func TestMemoryIndex_Search(t *testing.T) {
+	t.Parallel()
+	client := uuid.New()
+	other := uuid.New()
+	index := NewMemoryIndex()
+	invoice := makeTicket(t, "The invoice PDF is empty", "I downloaded it twice")
+	index.Index(invoice, client)
+	mention := makeTicket(t, "Login problem", "Cannot log in")
+	mention.AddResponse(ticket.MakeResponse(uuid.New(), "Is this related to the invoice PDF?", time.Now()))
+	index.Index(mention, client)
+	facturacion := makeTicket(t, "Facturación", "La factura en PDF no llega")
+	index.Index(facturacion, other)
+
+	t.Run("Tickets matching every term are ranked by where the terms were found", func(t *testing.T) {
+		t.Parallel()
+		results := index.Search(Query{Text: "invoice pdf"})
+		assertResults(t, results, invoice.ID(), mention.ID())
+	})
+	t.Run("Accents are ignored in the tickets and in the query", func(t *testing.T) {
+		t.Parallel()
+		assertResults(t, index.Search(Query{Text: "FACTURACION"}), facturacion.ID())
+		assertResults(t, index.Search(Query{Text: "fáctura"}), facturacion.ID())
+	})
+	t.Run("A client scoped search only returns the tickets of the client", func(t *testing.T) {
+		t.Parallel()
+		assertResults(t, index.Search(Query{Text: "pdf", Client: other}), facturacion.ID())
+		assertResults(t, index.Search(Query{Text: "pdf", Client: client, Limit: 1}), invoice.ID())
+	})
+	t.Run("Queries without terms match nothing", func(t *testing.T) {
+		t.Parallel()
+		assertResults(t, index.Search(Query{Text: "the, of"}))
+	})
+}
+
+func TestMemoryIndex_Index(t *testing.T) {
+	t.Parallel()
+	client := uuid.New()
+	index := NewMemoryIndex()
+	tck := makeTicket(t, "Shipping", "Where is my order?")
+	index.Index(tck, client)
+
+	t.Run("Reindexing a ticket adds the terms of its new responses", func(t *testing.T) {
+		tck.AddResponse(ticket.MakeResponse(uuid.New(), "It was sent by courier", time.Now()))
+		index.Index(tck, client)
+		assertResults(t, index.Search(Query{Text: "courier"}), tck.ID())
+		assertResults(t, index.Search(Query{Text: "shipping order"}), tck.ID())
+	})
+	t.Run("Removed tickets are not found", func(t *testing.T) {
+		index.Remove(tck.ID())
+		assertResults(t, index.Search(Query{Text: "shipping"}))
+	})
+}
+
+func TestMemoryIndex_Rebuild(t *testing.T) {
+	t.Parallel()
+	client := uuid.New()
+	t.Run("The index is replaced with the tickets of the source", func(t *testing.T) {
+		t.Parallel()
+		index := NewMemoryIndex()
+		stale := makeTicket(t, "Stale", "Deleted from the storage")
+		index.Index(stale, client)
+		stored := makeTicket(t, "Shipping", "Where is my order?")
+		source := fakeSource{tickets: []ticket.Ticket{stored}, owner: client}
+
+		if err := index.Rebuild(source); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+
+		assertResults(t, index.Search(Query{Text: "shipping", Client: client}), stored.ID())
+		assertResults(t, index.Search(Query{Text: "stale"}))
+	})
+	t.Run("The index is kept when the source fails", func(t *testing.T) {
+		t.Parallel()
+		index := NewMemoryIndex()
+		tck := makeTicket(t, "Shipping", "Where is my order?")
+		index.Index(tck, client)
+		failure := errors.New("storage is down")
+
+		err := index.Rebuild(fakeSource{err: failure})
+
+		if !errors.Is(err, RebuildError) || !errors.Is(err, failure) {
+			t.Errorf("Expected a rebuild error, got %v", err)
+		}
+		assertResults(t, index.Search(Query{Text: "shipping"}), tck.ID())
+	})
+}
+
+type fakeSource struct {
+	tickets []ticket.Ticket
+	owner   uuid.UUID
+	err     error
+}
+
+func (f fakeSource) GetAllTickets() ([]ticket.Ticket, error) {
+	return f.tickets, f.err
+}
+
+func (f fakeSource) GetTicketOwner(uuid.UUID) (uuid.UUID, error) {
+	return f.owner, nil
+}
+
+func makeTicket(t *testing.T, title, description string) ticket.Ticket {
+	t.Helper()
+	tck, err := ticket.NewBasicTicket(title, description)
+	if err != nil {
+		t.Fatalf("Error creating ticket: %v", err)
+	}
+	return tck
+}
+
+func assertResults(t *testing.T, results []Result, expected ...uuid.UUID) {
+	t.Helper()
+	got := make([]uuid.UUID, len(results))
+	for i, result := range results {
+		got[i] = result.Ticket
+	}
+	if !slices.Equal(got, expected) {
+		t.Errorf("Expected tickets %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,45 @@
+package search
+
+import (
+	"golang.org/x/text/unicode/norm"
+	"strings"
+	"unicode"
+)
+
+// Tokenize splits the text into the terms used by the index: lower-cased words without accents, so "Facturación" and
+// "facturacion" are the same term, and without the most common Spanish and English stop words.
+func Tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(fold(text)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		if stopWords[word] {
+			continue
+		}
+		terms = append(terms, word)
+	}
+	return terms
+}
+
+// fold removes the accents of the text. The text is decomposed first, so the accents are combining marks whether the
+// text was composed ("é") or decomposed ("e" and "\u0301").
+func fold(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Mn, r) {
+			return -1
+		}
+		return r
+	}, norm.NFD.String(text))
+}
+
+var stopWords = map[string]bool{
+	// English
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true, "for": true,
+	"from": true, "i": true, "in": true, "is": true, "it": true, "my": true, "of": true, "on": true, "or": true,
+	"that": true, "the": true, "this": true, "to": true, "was": true, "with": true,
+	// Spanish
+	"al": true, "como": true, "con": true, "de": true, "del": true, "el": true, "en": true, "es": true, "la": true,
+	"las": true, "lo": true, "los": true, "mi": true, "o": true, "para": true, "por": true, "que": true, "se": true,
+	"su": true, "un": true, "una": true, "y": true,
+}
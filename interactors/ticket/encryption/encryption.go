@@ -0,0 +1,452 @@
+// Package encryption contains a persistence driver decorator that encrypts the titles, descriptions and responses of
+// the tickets at rest, with AES-GCM and a data key per tenant.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"strconv"
+	"strings"
+	"sync"
+	"ticketTao/entities/ticket"
+	"ticketTao/interactors/ticket/repository"
+)
+
+// Prefix starts every encrypted value, followed by the tenant, the version of its key and the base64 encoded nonce
+// and ciphertext, separated by colons. The values without it are read as plain text, unless the driver is strict.
+const Prefix = "enc:v1:"
+
+// The fields of the tickets that are encrypted, they are bound to the ciphertext so that an encrypted value cannot be
+// moved to another field or ticket. The responses are also bound to their position and time, see responseFieldOf.
+const (
+	titleField       = "title"
+	descriptionField = "description"
+	responseField    = "response"
+)
+
+// EncryptingPersistence is a persistence driver that encrypts the tickets before handing them to another driver.
+type EncryptingPersistence interface {
+	repository.TransferablePersistence
+	// ReEncrypt rewrites every ticket not encrypted with the current key of the tenant of its owner, e.g. after a key
+	// rotation or for the tickets written in plain text before the driver was encrypted, even if the driver is strict.
+	// Each ticket is read again and rewritten under the lock of the writes of the driver, so the changes written
+	// meanwhile through the driver are kept. It returns how many tickets were rewritten.
+	ReEncrypt() (int, error)
+}
+
+// NewEncryptingPersistence decorates a persistence driver so that the titles, descriptions and response contents are
+// encrypted with the current key of the tenant of the ticket owner. The driver does not see the plain text, so the
+// text queries are filtered by the decorator, which reads every ticket matching the other criteria; the search index
+// should be used instead when there are many tickets. The owners of the tickets can only be changed if the decorated
+// driver is a repository.TransferablePersistence.
+func NewEncryptingPersistence(tp repository.TicketPersistence, keys KeyRing, tenants TenantResolver) (EncryptingPersistence, error) {
+	return newEncryptingPersistence(tp, keys, tenants, false)
+}
+
+// NewStrictEncryptingPersistence works like NewEncryptingPersistence, but the values without the Prefix are rejected
+// with ErrNotEncrypted instead of being read as plain text. It should be used once ReEncrypt has encrypted the tickets
+// written before the driver was encrypted.
+func NewStrictEncryptingPersistence(tp repository.TicketPersistence, keys KeyRing, tenants TenantResolver) (EncryptingPersistence, error) {
+	return newEncryptingPersistence(tp, keys, tenants, true)
+}
+
+func newEncryptingPersistence(tp repository.TicketPersistence, keys KeyRing, tenants TenantResolver, strict bool) (EncryptingPersistence, error) {
+	if tp == nil {
+		return nil, errors.Join(NewEncryptingPersistenceError, repository.NilPersistenceDriverError)
+	}
+	if keys == nil {
+		return nil, errors.Join(NewEncryptingPersistenceError, ErrNilKeyRing)
+	}
+	if tenants == nil {
+		return nil, errors.Join(NewEncryptingPersistenceError, ErrNilTenantResolver)
+	}
+	return encryptingPersistence{persistence: tp, keys: keys, tenants: tenants, strict: strict, writes: &sync.Mutex{}}, nil
+}
+
+type encryptingPersistence struct {
+	persistence repository.TicketPersistence
+	keys        KeyRing
+	tenants     TenantResolver
+	// strict rejects the values in plain text.
+	strict bool
+	// writes serializes the writes, so ReEncrypt cannot overwrite a ticket with a version read before an update.
+	writes *sync.Mutex
+}
+
+func (e encryptingPersistence) SaveNewTicketForClient(client uuid.UUID, tck ticket.Ticket) error {
+	if tck == nil {
+		return ticket.ErrNilTicket
+	}
+	encrypted, err := e.encryptFor(client, tck)
+	if err != nil {
+		return err
+	}
+	e.writes.Lock()
+	defer e.writes.Unlock()
+	return e.persistence.SaveNewTicketForClient(client, encrypted)
+}
+
+func (e encryptingPersistence) GetTicketOwner(ticketId uuid.UUID) (uuid.UUID, error) {
+	return e.persistence.GetTicketOwner(ticketId)
+}
+
+func (e encryptingPersistence) GetTicket(id uuid.UUID) (ticket.Ticket, error) {
+	tck, err := e.persistence.GetTicket(id)
+	if err != nil {
+		return nil, err
+	}
+	return e.decrypt(tck)
+}
+
+func (e encryptingPersistence) UpdateTicket(tck ticket.Ticket) error {
+	if tck == nil {
+		return ticket.ErrNilTicket
+	}
+	e.writes.Lock()
+	defer e.writes.Unlock()
+	return e.update(tck)
+}
+
+// update encrypts the ticket with the key of its owner and persists it, the writes must be locked.
+func (e encryptingPersistence) update(tck ticket.Ticket) error {
+	owner, err := e.persistence.GetTicketOwner(tck.ID())
+	if err != nil {
+		return err
+	}
+	encrypted, err := e.encryptFor(owner, tck)
+	if err != nil {
+		return err
+	}
+	return e.persistence.UpdateTicket(encrypted)
+}
+
+func (e encryptingPersistence) GetAllTickets() ([]ticket.Ticket, error) {
+	tickets, err := e.persistence.GetAllTickets()
+	if err != nil {
+		return nil, err
+	}
+	return e.decryptAll(tickets)
+}
+
+func (e encryptingPersistence) QueryTickets(query ticket.Query) (ticket.Page, error) {
+	if query.Text == "" {
+		page, err := e.persistence.QueryTickets(query)
+		if err != nil {
+			return ticket.Page{}, err
+		}
+		page.Tickets, err = e.decryptAll(page.Tickets)
+		if err != nil {
+			return ticket.Page{}, err
+		}
+		return page, nil
+	}
+	matching, err := e.matchingText(query)
+	if err != nil {
+		return ticket.Page{}, err
+	}
+	return query.Paginate(matching)
+}
+
+func (e encryptingPersistence) CountTickets(query ticket.Query) (int, error) {
+	if query.Text == "" {
+		return e.persistence.CountTickets(query)
+	}
+	matching, err := e.matchingText(query)
+	if err != nil {
+		return 0, err
+	}
+	return len(matching), nil
+}
+
+// matchingText returns the decrypted tickets matching a query with text, which the driver cannot see.
+func (e encryptingPersistence) matchingText(query ticket.Query) ([]ticket.Ticket, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	var matching []ticket.Ticket
+	err := e.eachEncrypted(ticketsLike(query), func(tck ticket.Ticket) error {
+		owner, err := e.persistence.GetTicketOwner(tck.ID())
+		if err != nil {
+			return err
+		}
+		decrypted, err := e.decrypt(tck)
+		if err != nil {
+			return err
+		}
+		if query.Matches(decrypted, owner) {
+			matching = append(matching, decrypted)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return matching, nil
+}
+
+// ticketsLike returns the query of the tickets matching every criterion of the query but its text, from the first
+// page.
+func ticketsLike(query ticket.Query) ticket.Query {
+	query.Text = ""
+	query.After = ""
+	query.Limit = ticket.MaxPageSize
+	return query
+}
+
+// eachEncrypted calls do with every ticket of the decorated driver matching the query, as stored.
+func (e encryptingPersistence) eachEncrypted(query ticket.Query, do func(ticket.Ticket) error) error {
+	for {
+		page, err := e.persistence.QueryTickets(query)
+		if err != nil {
+			return err
+		}
+		for _, tck := range page.Tickets {
+			if err := do(tck); err != nil {
+				return err
+			}
+		}
+		if page.Next == "" {
+			return nil
+		}
+		query.After = page.Next
+	}
+}
+
+// SetTicketOwner gives the ticket to the client and encrypts it again with the key of the tenant of the client.
+func (e encryptingPersistence) SetTicketOwner(ticketId, client uuid.UUID) error {
+	transferable, ok := e.persistence.(repository.TransferablePersistence)
+	if !ok {
+		return ErrOwnerChangeNotSupported
+	}
+	e.writes.Lock()
+	defer e.writes.Unlock()
+	tck, err := e.GetTicket(ticketId)
+	if err != nil {
+		return err
+	}
+	if err := transferable.SetTicketOwner(ticketId, client); err != nil {
+		return err
+	}
+	return e.update(tck)
+}
+
+func (e encryptingPersistence) ReEncrypt() (int, error) {
+	rewritten := 0
+	err := e.eachEncrypted(ticket.Query{Limit: ticket.MaxPageSize}, func(tck ticket.Ticket) error {
+		done, err := e.reEncrypt(tck.ID())
+		if done {
+			rewritten++
+		}
+		return err
+	})
+	if err != nil {
+		return rewritten, errors.Join(ReEncryptError, err)
+	}
+	return rewritten, nil
+}
+
+// reEncrypt reads the ticket again and rewrites it if it is not encrypted with the current key of the tenant of its
+// owner, under the lock of the writes. It tells whether the ticket was rewritten.
+func (e encryptingPersistence) reEncrypt(id uuid.UUID) (bool, error) {
+	e.writes.Lock()
+	defer e.writes.Unlock()
+	tck, err := e.persistence.GetTicket(id)
+	if err != nil {
+		return false, err
+	}
+	owner, err := e.persistence.GetTicketOwner(id)
+	if err != nil {
+		return false, err
+	}
+	tenant, key, err := e.currentKey(owner)
+	if err != nil {
+		return false, err
+	}
+	if isEncryptedWith(tck, tenant, key) {
+		return false, nil
+	}
+	lenient := e
+	lenient.strict = false
+	decrypted, err := lenient.decrypt(tck)
+	if err != nil {
+		return false, err
+	}
+	encrypted, err := e.encrypt(decrypted, tenant, key)
+	if err != nil {
+		return false, err
+	}
+	if err := e.persistence.UpdateTicket(encrypted); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (e encryptingPersistence) currentKey(owner uuid.UUID) (uuid.UUID, Key, error) {
+	tenant, err := e.tenants.Tenant(owner)
+	if err != nil {
+		return uuid.Nil, Key{}, errors.Join(ErrEncrypting, err)
+	}
+	key, err := e.keys.CurrentKey(tenant)
+	if err != nil {
+		return uuid.Nil, Key{}, errors.Join(ErrEncrypting, err)
+	}
+	return tenant, key, nil
+}
+
+func (e encryptingPersistence) encryptFor(owner uuid.UUID, tck ticket.Ticket) (ticket.Ticket, error) {
+	tenant, key, err := e.currentKey(owner)
+	if err != nil {
+		return nil, err
+	}
+	return e.encrypt(tck, tenant, key)
+}
+
+// encrypt returns a copy of the ticket with its texts encrypted.
+func (e encryptingPersistence) encrypt(tck ticket.Ticket, tenant uuid.UUID, key Key) (ticket.Ticket, error) {
+	return transform(tck, func(field, value string) (string, error) {
+		return seal(tenant, key, tck.ID(), field, value)
+	})
+}
+
+// decrypt returns a copy of the ticket with its texts in plain text.
+func (e encryptingPersistence) decrypt(tck ticket.Ticket) (ticket.Ticket, error) {
+	return transform(tck, func(field, value string) (string, error) {
+		return e.open(tck.ID(), field, value)
+	})
+}
+
+func (e encryptingPersistence) decryptAll(tickets []ticket.Ticket) ([]ticket.Ticket, error) {
+	decrypted := make([]ticket.Ticket, len(tickets))
+	for i, tck := range tickets {
+		var err error
+		if decrypted[i], err = e.decrypt(tck); err != nil {
+			return nil, err
+		}
+	}
+	return decrypted, nil
+}
+
+// transform returns a copy of the ticket with its title, description and response contents converted.
+func transform(tck ticket.Ticket, convert func(field, value string) (string, error)) (ticket.Ticket, error) {
+	data := ticket.DataFrom(tck)
+	var err error
+	if data.Title, err = convert(titleField, data.Title); err != nil {
+		return nil, err
+	}
+	if data.Description, err = convert(descriptionField, data.Description); err != nil {
+		return nil, err
+	}
+	for i, response := range data.Responses {
+		content, err := convert(responseFieldOf(i, response), response.Content())
+		if err != nil {
+			return nil, err
+		}
+		data.Responses[i] = ticket.RewriteResponse(response, response.UserId(), content, response.Attachments()...)
+	}
+	return ticket.Rewrite(tck, data)
+}
+
+// responseFieldOf returns the field of a response, which binds its ciphertext to its position and time in the ticket
+// so that it cannot be swapped with or replayed over another response of the ticket.
+func responseFieldOf(position int, response ticket.Response) string {
+	return fmt.Sprintf("%s:%d:%d", responseField, position, response.TimeStamp().UnixNano())
+}
+
+// isEncryptedWith tells if every text of the ticket is encrypted with the key of the tenant.
+func isEncryptedWith(tck ticket.Ticket, tenant uuid.UUID, key Key) bool {
+	header := fmt.Sprintf("%s%s:%d:", Prefix, tenant, key.Version)
+	values := []string{tck.Title(), tck.Description()}
+	for _, response := range tck.Responses() {
+		values = append(values, response.Content())
+	}
+	for _, value := range values {
+		if !strings.HasPrefix(value, header) {
+			return false
+		}
+	}
+	return true
+}
+
+func newGCM(key Key) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key.Material)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// additionalData binds a ciphertext to the field of the ticket it was written to.
+func additionalData(ticketId uuid.UUID, field string) []byte {
+	return append(ticketId[:], field...)
+}
+
+func seal(tenant uuid.UUID, key Key, ticketId uuid.UUID, field, value string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", errors.Join(ErrEncrypting, err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", errors.Join(ErrEncrypting, err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(value), additionalData(ticketId, field))
+	return fmt.Sprintf("%s%s:%d:%s", Prefix, tenant, key.Version, base64.RawStdEncoding.EncodeToString(sealed)), nil
+}
+
+func (e encryptingPersistence) open(ticketId uuid.UUID, field, value string) (string, error) {
+	if !strings.HasPrefix(value, Prefix) {
+		if e.strict {
+			return "", ErrNotEncrypted
+		}
+		return value, nil
+	}
+	parts := strings.SplitN(strings.TrimPrefix(value, Prefix), ":", 3)
+	if len(parts) != 3 {
+		return "", ErrMalformedCiphertext
+	}
+	tenant, err := uuid.Parse(parts[0])
+	if err != nil {
+		return "", errors.Join(ErrMalformedCiphertext, err)
+	}
+	version, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", errors.Join(ErrMalformedCiphertext, err)
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", errors.Join(ErrMalformedCiphertext, err)
+	}
+	key, err := e.keys.Key(tenant, version)
+	if err != nil {
+		return "", errors.Join(ErrDecrypting, err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", errors.Join(ErrDecrypting, err)
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", ErrMalformedCiphertext
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, additionalData(ticketId, field))
+	if err != nil {
+		return "", errors.Join(ErrDecrypting, err)
+	}
+	return string(plain), nil
+}
+
+var NewEncryptingPersistenceError error = errors.New("error creating encrypting persistence driver")
+var ReEncryptError error = errors.New("error re-encrypting tickets")
+
+var ErrNilKeyRing error = errors.New("key ring cannot be nil")
+var ErrNilTenantResolver error = errors.New("tenant resolver cannot be nil")
+var ErrEncrypting error = errors.New("error encrypting ticket")
+var ErrDecrypting error = errors.New("error decrypting ticket")
+var ErrNotEncrypted error = errors.New("value is not encrypted")
+var ErrMalformedCiphertext error = errors.New("malformed encrypted value")
+var ErrOwnerChangeNotSupported error = repository.ErrOwnerChangeNotSupported
@@ -0,0 +1,294 @@
+package encryption
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"strings"
+	"sync"
+	"testing"
+	"ticketTao/entities/ticket"
+	"ticketTao/interactors/ticket/persistence/memory"
+	"ticketTao/interactors/ticket/repository"
+)
+
+func newEncrypted(t *testing.T) (EncryptingPersistence, repository.TransferablePersistence, KeyRing) {
+	t.Helper()
+	inner := memory.NewTicketPersistence()
+	keys := NewMemoryKeyRing()
+	encrypted, err := NewEncryptingPersistence(inner, keys, ClientTenants())
+	if err != nil {
+		t.Fatalf("Error should be nil, got %v", err)
+	}
+	return encrypted, inner, keys
+}
+
+func newTicket(t *testing.T, author uuid.UUID) ticket.Ticket {
+	t.Helper()
+	tck, _ := ticket.NewBasicTicket("Invoice 42 is wrong", "My card ends with 1234")
+	tck.AddResponse(ticket.NewResponse(author, "Please refund me"))
+	return tck
+}
+
+func TestEncryptingPersistence(t *testing.T) {
+	t.Parallel()
+	t.Run("It should store the texts encrypted and return them in plain text", func(t *testing.T) {
+		t.Parallel()
+		encrypted, inner, _ := newEncrypted(t)
+		client := uuid.New()
+		tck := newTicket(t, client)
+
+		if err := encrypted.SaveNewTicketForClient(client, tck); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+
+		stored, _ := inner.GetTicket(tck.ID())
+		for _, value := range []string{stored.Title(), stored.Description(), stored.Responses()[0].Content()} {
+			if !strings.HasPrefix(value, Prefix+client.String()+":1:") || strings.Contains(value, "1234") {
+				t.Errorf("Expected the value to be encrypted with the key of the client, got %q", value)
+			}
+		}
+		if tck.Title() != "Invoice 42 is wrong" {
+			t.Error("The saved ticket should not be changed")
+		}
+		read, err := encrypted.GetTicket(tck.ID())
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if read.Title() != tck.Title() || read.Description() != tck.Description() ||
+			read.Responses()[0].Content() != "Please refund me" || read.Status() != ticket.InProgress {
+			t.Errorf("Expected the ticket in plain text, got %+v", ticket.DataFrom(read))
+		}
+
+		read.AddResponse(ticket.NewResponse(uuid.New(), "Refunded"))
+		_ = encrypted.UpdateTicket(read)
+		all, _ := encrypted.GetAllTickets()
+		if len(all) != 1 || all[0].Responses()[1].Content() != "Refunded" {
+			t.Errorf("Expected the updated ticket, got %v", all)
+		}
+	})
+	t.Run("It should filter the text queries on the plain text", func(t *testing.T) {
+		t.Parallel()
+		encrypted, _, _ := newEncrypted(t)
+		client := uuid.New()
+		_ = encrypted.SaveNewTicketForClient(client, newTicket(t, client))
+		other, _ := ticket.NewBasicTicket("Login", "Cannot log in")
+		_ = encrypted.SaveNewTicketForClient(client, other)
+
+		page, err := encrypted.QueryTickets(ticket.Query{Text: "refund"})
+
+		if err != nil || len(page.Tickets) != 1 || page.Tickets[0].Title() != "Invoice 42 is wrong" {
+			t.Errorf("Expected the ticket mentioning a refund, got %v, %v", page, err)
+		}
+		page, _ = encrypted.QueryTickets(ticket.Query{Client: client})
+		if len(page.Tickets) != 2 || page.Tickets[1].Title() != "Login" {
+			t.Errorf("Expected every ticket of the client in plain text, got %v", page)
+		}
+		if count, err := encrypted.CountTickets(ticket.Query{Text: "refund"}); err != nil || count != 1 {
+			t.Errorf("Expected the ticket mentioning a refund to be counted, got %d, %v", count, err)
+		}
+		if count, err := encrypted.CountTickets(ticket.Query{Client: client}); err != nil || count != 2 {
+			t.Errorf("Expected every ticket of the client to be counted, got %d, %v", count, err)
+		}
+	})
+	t.Run("It should re-encrypt the tickets after a key rotation", func(t *testing.T) {
+		t.Parallel()
+		encrypted, inner, keys := newEncrypted(t)
+		client := uuid.New()
+		tck := newTicket(t, client)
+		_ = encrypted.SaveNewTicketForClient(client, tck)
+		plain, _ := ticket.NewBasicTicket("Written before encryption", "plain")
+		_ = inner.SaveNewTicketForClient(client, plain)
+		_, _ = keys.Rotate(client)
+
+		rewritten, err := encrypted.ReEncrypt()
+
+		if err != nil || rewritten != 2 {
+			t.Fatalf("Expected both tickets to be rewritten, got %d, %v", rewritten, err)
+		}
+		for _, id := range []uuid.UUID{tck.ID(), plain.ID()} {
+			stored, _ := inner.GetTicket(id)
+			if !strings.HasPrefix(stored.Title(), Prefix+client.String()+":2:") {
+				t.Errorf("Expected the ticket to use the new key, got %q", stored.Title())
+			}
+		}
+		if read, _ := encrypted.GetTicket(plain.ID()); read.Title() != "Written before encryption" {
+			t.Errorf("Expected the ticket in plain text, got %q", read.Title())
+		}
+		if rewritten, _ := encrypted.ReEncrypt(); rewritten != 0 {
+			t.Errorf("Expected nothing left to re-encrypt, got %d", rewritten)
+		}
+	})
+	t.Run("It should encrypt the tickets with the key of their new owner", func(t *testing.T) {
+		t.Parallel()
+		encrypted, inner, _ := newEncrypted(t)
+		client, other := uuid.New(), uuid.New()
+		tck := newTicket(t, client)
+		_ = encrypted.SaveNewTicketForClient(client, tck)
+
+		if err := encrypted.SetTicketOwner(tck.ID(), other); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+
+		stored, _ := inner.GetTicket(tck.ID())
+		if !strings.HasPrefix(stored.Title(), Prefix+other.String()+":") {
+			t.Errorf("Expected the ticket to use the key of the new owner, got %q", stored.Title())
+		}
+	})
+	t.Run("It should reject the values moved to another ticket", func(t *testing.T) {
+		t.Parallel()
+		encrypted, inner, _ := newEncrypted(t)
+		client := uuid.New()
+		first, second := newTicket(t, client), newTicket(t, client)
+		_ = encrypted.SaveNewTicketForClient(client, first)
+		_ = encrypted.SaveNewTicketForClient(client, second)
+		stolen, _ := inner.GetTicket(first.ID())
+		target, _ := inner.GetTicket(second.ID())
+		data := ticket.DataFrom(target)
+		data.Title = stolen.Title()
+		tampered, _ := ticket.MakeBasicTicket(target.ID(), target.CreatedAt(), data)
+		_ = inner.UpdateTicket(tampered)
+
+		_, err := encrypted.GetTicket(second.ID())
+
+		assertErrors(t, err, ErrDecrypting)
+	})
+	t.Run("It should reject the responses moved within the ticket", func(t *testing.T) {
+		t.Parallel()
+		encrypted, inner, _ := newEncrypted(t)
+		client := uuid.New()
+		tck := newTicket(t, client)
+		tck.AddResponse(ticket.NewResponse(client, "Any news?"))
+		_ = encrypted.SaveNewTicketForClient(client, tck)
+		stored, _ := inner.GetTicket(tck.ID())
+		data := ticket.DataFrom(stored)
+		first, second := data.Responses[0], data.Responses[1]
+		data.Responses[0] = ticket.RewriteResponse(first, first.UserId(), second.Content())
+		data.Responses[1] = ticket.RewriteResponse(second, second.UserId(), first.Content())
+		swapped, _ := ticket.Rewrite(stored, data)
+		_ = inner.UpdateTicket(swapped)
+
+		_, err := encrypted.GetTicket(tck.ID())
+
+		assertErrors(t, err, ErrDecrypting)
+	})
+	t.Run("It should keep the updates written while re-encrypting", func(t *testing.T) {
+		t.Parallel()
+		inner := &updatingPersistence{TransferablePersistence: memory.NewTicketPersistence()}
+		keys := NewMemoryKeyRing()
+		encrypted, _ := NewEncryptingPersistence(inner, keys, ClientTenants())
+		client := uuid.New()
+		tck := newTicket(t, client)
+		_ = encrypted.SaveNewTicketForClient(client, tck)
+		_, _ = keys.Rotate(client)
+		inner.afterQuery = func() {
+			read, _ := encrypted.GetTicket(tck.ID())
+			read.AddResponse(ticket.NewResponse(client, "Written meanwhile"))
+			_ = encrypted.UpdateTicket(read)
+		}
+
+		rewritten, err := encrypted.ReEncrypt()
+
+		if err != nil || rewritten != 0 {
+			t.Fatalf("Expected the ticket to be left as updated with the new key, got %d, %v", rewritten, err)
+		}
+		read, _ := encrypted.GetTicket(tck.ID())
+		if responses := read.Responses(); len(responses) != 2 || responses[1].Content() != "Written meanwhile" {
+			t.Errorf("Expected the update to be kept, got %v", responses)
+		}
+	})
+}
+
+func TestStrictEncryptingPersistence(t *testing.T) {
+	t.Parallel()
+	inner := memory.NewTicketPersistence()
+	encrypted, err := NewStrictEncryptingPersistence(inner, NewMemoryKeyRing(), ClientTenants())
+	if err != nil {
+		t.Fatalf("Error should be nil, got %v", err)
+	}
+	plain, _ := ticket.NewBasicTicket("Written before encryption", "plain")
+	_ = inner.SaveNewTicketForClient(uuid.New(), plain)
+
+	_, err = encrypted.GetTicket(plain.ID())
+	assertErrors(t, err, ErrNotEncrypted)
+
+	if rewritten, err := encrypted.ReEncrypt(); err != nil || rewritten != 1 {
+		t.Fatalf("Expected the ticket in plain text to be encrypted, got %d, %v", rewritten, err)
+	}
+	if read, err := encrypted.GetTicket(plain.ID()); err != nil || read.Title() != "Written before encryption" {
+		t.Errorf("Expected the encrypted ticket to be read, got %v", err)
+	}
+}
+
+// updatingPersistence calls afterQuery once, after the first page of tickets is read, to write a change while the
+// tickets are being re-encrypted.
+type updatingPersistence struct {
+	repository.TransferablePersistence
+	afterQuery func()
+	once       sync.Once
+}
+
+func (u *updatingPersistence) QueryTickets(query ticket.Query) (ticket.Page, error) {
+	page, err := u.TransferablePersistence.QueryTickets(query)
+	if u.afterQuery != nil {
+		u.once.Do(u.afterQuery)
+	}
+	return page, err
+}
+
+func TestNewEncryptingPersistence(t *testing.T) {
+	t.Parallel()
+	_, err := NewEncryptingPersistence(nil, NewMemoryKeyRing(), ClientTenants())
+	assertErrors(t, err, NewEncryptingPersistenceError, repository.NilPersistenceDriverError)
+	_, err = NewEncryptingPersistence(memory.NewTicketPersistence(), nil, ClientTenants())
+	assertErrors(t, err, NewEncryptingPersistenceError, ErrNilKeyRing)
+	_, err = NewEncryptingPersistence(memory.NewTicketPersistence(), NewMemoryKeyRing(), nil)
+	assertErrors(t, err, NewEncryptingPersistenceError, ErrNilTenantResolver)
+}
+
+func TestMemoryKeyRing(t *testing.T) {
+	t.Parallel()
+	keys := NewMemoryKeyRing()
+	tenant := uuid.New()
+	first, _ := keys.CurrentKey(tenant)
+	second, _ := keys.Rotate(tenant)
+	current, _ := keys.CurrentKey(tenant)
+	if first.Version != 1 || second.Version != 2 || current.Version != 2 || len(current.Material) != KeySize ||
+		string(first.Material) == string(second.Material) {
+		t.Errorf("Expected a new key version after the rotation, got %d and %d", first.Version, current.Version)
+	}
+	if old, err := keys.Key(tenant, 1); err != nil || string(old.Material) != string(first.Material) {
+		t.Errorf("Expected the old version to be kept, got %v", err)
+	}
+	_, err := keys.Key(tenant, 3)
+	assertErrors(t, err, ErrUnknownKey)
+}
+
+type stubOrganizations map[uuid.UUID]uuid.UUID
+
+func (s stubOrganizations) GetClientOrganization(client uuid.UUID) (uuid.UUID, error) {
+	return s[client], nil
+}
+
+func TestOrganizationTenants(t *testing.T) {
+	t.Parallel()
+	member, loner, organization := uuid.New(), uuid.New(), uuid.New()
+	tenants, _ := OrganizationTenants(stubOrganizations{member: organization})
+	if tenant, _ := tenants.Tenant(member); tenant != organization {
+		t.Errorf("Expected the organization to be the tenant, got %s", tenant)
+	}
+	if tenant, _ := tenants.Tenant(loner); tenant != loner {
+		t.Errorf("Expected the client to be its own tenant, got %s", tenant)
+	}
+}
+
+func assertErrors(t *testing.T, err error, expected ...error) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("Error should not be nil")
+	}
+	for _, e := range expected {
+		if !errors.Is(err, e) {
+			t.Errorf("Error should be %v, but is %s", e, err.Error())
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package encryption
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"sync"
+	"ticketTao/entities/sla"
+)
+
+// KeySize is the size of the data keys, they are AES-256 keys.
+const KeySize = 32
+
+// Key is a version of the data key of a tenant.
+type Key struct {
+	// Version starts at 1 and grows with every rotation.
+	Version  int
+	Material []byte
+}
+
+// KeyRing keeps the data keys of the tenants. The old versions of a key are kept, so the records encrypted before a
+// rotation can still be read until they are re-encrypted.
+type KeyRing interface {
+	// CurrentKey returns the key used to encrypt the records of the tenant, creating it the first time.
+	CurrentKey(tenant uuid.UUID) (Key, error)
+	// Key returns a version of the key of the tenant, it returns ErrUnknownKey if there is no such version.
+	Key(tenant uuid.UUID, version int) (Key, error)
+	// Rotate creates a new current key for the tenant.
+	Rotate(tenant uuid.UUID) (Key, error)
+}
+
+// NewMemoryKeyRing creates an in-memory KeyRing with random keys. The keys are lost with the process, so it is only
+// suitable for tests and demos, production key rings keep the keys wrapped by a key management service.
+func NewMemoryKeyRing() KeyRing {
+	return &memoryKeyRing{keys: make(map[uuid.UUID][]Key)}
+}
+
+type memoryKeyRing struct {
+	mu   sync.Mutex
+	keys map[uuid.UUID][]Key
+}
+
+func (m *memoryKeyRing) CurrentKey(tenant uuid.UUID) (Key, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if versions := m.keys[tenant]; len(versions) > 0 {
+		return versions[len(versions)-1], nil
+	}
+	return m.rotate(tenant)
+}
+
+func (m *memoryKeyRing) Key(tenant uuid.UUID, version int) (Key, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	versions := m.keys[tenant]
+	if version < 1 || version > len(versions) {
+		return Key{}, fmt.Errorf("%w: tenant %s version %d", ErrUnknownKey, tenant, version)
+	}
+	return versions[version-1], nil
+}
+
+func (m *memoryKeyRing) Rotate(tenant uuid.UUID) (Key, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rotate(tenant)
+}
+
+func (m *memoryKeyRing) rotate(tenant uuid.UUID) (Key, error) {
+	material := make([]byte, KeySize)
+	if _, err := rand.Read(material); err != nil {
+		return Key{}, errors.Join(ErrGeneratingKey, err)
+	}
+	key := Key{Version: len(m.keys[tenant]) + 1, Material: material}
+	m.keys[tenant] = append(m.keys[tenant], key)
+	return key, nil
+}
+
+// TenantResolver tells which tenant, and so which data key, the tickets of a client belong to.
+type TenantResolver interface {
+	Tenant(client uuid.UUID) (uuid.UUID, error)
+}
+
+// ClientTenants returns a TenantResolver where every client is its own tenant.
+func ClientTenants() TenantResolver {
+	return clientTenants{}
+}
+
+type clientTenants struct{}
+
+func (clientTenants) Tenant(client uuid.UUID) (uuid.UUID, error) {
+	return client, nil
+}
+
+// OrganizationTenants returns a TenantResolver where the tenant of a client is its organization, or the client itself
+// when it does not belong to one.
+func OrganizationTenants(organizations sla.OrganizationResolver) (TenantResolver, error) {
+	if organizations == nil {
+		return nil, sla.ErrNilOrganizationResolver
+	}
+	return organizationTenants{organizations}, nil
+}
+
+type organizationTenants struct {
+	organizations sla.OrganizationResolver
+}
+
+func (o organizationTenants) Tenant(client uuid.UUID) (uuid.UUID, error) {
+	organization, err := o.organizations.GetClientOrganization(client)
+	if err != nil {
+		return uuid.Nil, errors.Join(sla.ErrResolvingOrganization, err)
+	}
+	if organization == uuid.Nil {
+		return client, nil
+	}
+	return organization, nil
+}
+
+var ErrUnknownKey error = errors.New("unknown data key")
+var ErrGeneratingKey error = errors.New("error generating data key")
@@ -0,0 +1,169 @@
+package events
+
+import (
+	"errors"
+	"slices"
+	"sync"
+)
+
+// NewBus creates a new in-process event bus without subscribers.
+func NewBus() Bus {
+	return &basicBus{}
+}
+
+// Handler is a function that reacts to a published event.
+type Handler func(Event)
+
+// Publisher is the interface used by the persistence layer to announce events.
+type Publisher interface {
+	// Publish delivers the event to every subscriber. Synchronous subscribers are called in subscription order before
+	// Publish returns, asynchronous subscribers receive the event in their own goroutine in publication order.
+	Publish(Event)
+}
+
+// Subscriber is the interface used by integrations to listen for events.
+type Subscriber interface {
+	// Subscribe registers a handler that is called synchronously by Publish.
+	Subscribe(Handler) error
+	// SubscribeAsync registers a handler that is called in its own goroutine, so slow handlers do not delay the
+	// publisher.
+	SubscribeAsync(Handler) error
+}
+
+// Bus is an in-process event bus.
+type Bus interface {
+	Publisher
+	Subscriber
+	// Close stops accepting events and waits until the asynchronous subscribers have handled the pending ones.
+	Close()
+}
+
+type basicBus struct {
+	mu          sync.RWMutex
+	closed      bool
+	synchronous []Handler
+	async       []*asyncSubscriber
+}
+
+func (b *basicBus) Publish(e Event) {
+	if e == nil {
+		return
+	}
+	// Handlers run outside the lock, so they can subscribe or close the bus themselves.
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return
+	}
+	synchronous := slices.Clone(b.synchronous)
+	async := slices.Clone(b.async)
+	b.mu.RUnlock()
+	for _, handler := range synchronous {
+		handler(e)
+	}
+	for _, subscriber := range async {
+		subscriber.push(e)
+	}
+}
+
+func (b *basicBus) Subscribe(handler Handler) error {
+	if handler == nil {
+		return errors.Join(SubscribeError, ErrNilHandler)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return errors.Join(SubscribeError, ErrBusClosed)
+	}
+	b.synchronous = append(b.synchronous, handler)
+	return nil
+}
+
+func (b *basicBus) SubscribeAsync(handler Handler) error {
+	if handler == nil {
+		return errors.Join(SubscribeError, ErrNilHandler)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return errors.Join(SubscribeError, ErrBusClosed)
+	}
+	b.async = append(b.async, newAsyncSubscriber(handler))
+	return nil
+}
+
+func (b *basicBus) Close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	subscribers := b.async
+	b.mu.Unlock()
+	for _, subscriber := range subscribers {
+		subscriber.close()
+	}
+}
+
+// asyncSubscriber keeps an unbounded queue of events, so publishing never blocks on a slow handler.
+type asyncSubscriber struct {
+	handler Handler
+	mu      sync.Mutex
+	ready   *sync.Cond
+	pending []Event
+	closed  bool
+	done    chan struct{}
+}
+
+func newAsyncSubscriber(handler Handler) *asyncSubscriber {
+	s := &asyncSubscriber{
+		handler: handler,
+		done:    make(chan struct{}),
+	}
+	s.ready = sync.NewCond(&s.mu)
+	go s.run()
+	return s
+}
+
+func (s *asyncSubscriber) push(e Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.pending = append(s.pending, e)
+	s.ready.Signal()
+}
+
+func (s *asyncSubscriber) run() {
+	defer close(s.done)
+	for {
+		s.mu.Lock()
+		for len(s.pending) == 0 && !s.closed {
+			s.ready.Wait()
+		}
+		if len(s.pending) == 0 {
+			s.mu.Unlock()
+			return
+		}
+		e := s.pending[0]
+		s.pending = s.pending[1:]
+		s.mu.Unlock()
+		s.handler(e)
+	}
+}
+
+func (s *asyncSubscriber) close() {
+	s.mu.Lock()
+	s.closed = true
+	s.ready.Broadcast()
+	s.mu.Unlock()
+	<-s.done
+}
+
+var SubscribeError error = errors.New("error subscribing to events")
+
+var ErrNilSubscriber error = errors.New("event subscriber cannot be nil")
+var ErrNilHandler error = errors.New("event handler cannot be nil")
+var ErrBusClosed error = errors.New("event bus is closed")
@@ -0,0 +1,151 @@
+package events
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"sync"
+	"testing"
+	"ticketTao/entities/ticket"
+	"time"
+)
+
+func TestBus_Subscribe(t *testing.T) {
+	t.Parallel()
+	t.Run("Synchronous subscribers receive the events before Publish returns, in subscription order", func(t *testing.T) {
+		t.Parallel()
+		bus := NewBus()
+		var received []string
+		_ = bus.Subscribe(func(e Event) { received = append(received, "first:"+string(e.Name())) })
+		_ = bus.Subscribe(func(e Event) { received = append(received, "second:"+string(e.Name())) })
+
+		bus.Publish(makeTicketClosed(t))
+
+		if len(received) != 2 || received[0] != "first:TicketClosed" || received[1] != "second:TicketClosed" {
+			t.Errorf("Unexpected deliveries: %v", received)
+		}
+	})
+	t.Run("A nil handler cannot be subscribed", func(t *testing.T) {
+		t.Parallel()
+		bus := NewBus()
+		assertErrors(t, bus.Subscribe(nil), SubscribeError, ErrNilHandler)
+		assertErrors(t, bus.SubscribeAsync(nil), SubscribeError, ErrNilHandler)
+	})
+	t.Run("Handlers cannot subscribe to a closed bus", func(t *testing.T) {
+		t.Parallel()
+		bus := NewBus()
+		bus.Close()
+		assertErrors(t, bus.Subscribe(func(Event) {}), SubscribeError, ErrBusClosed)
+		assertErrors(t, bus.SubscribeAsync(func(Event) {}), SubscribeError, ErrBusClosed)
+	})
+	t.Run("A synchronous handler can subscribe and close the bus while handling an event", func(t *testing.T) {
+		t.Parallel()
+		bus := NewBus()
+		var subscribeErr error
+		_ = bus.Subscribe(func(Event) {
+			subscribeErr = bus.Subscribe(func(Event) {})
+			bus.Close()
+		})
+
+		done := make(chan struct{})
+		go func() {
+			bus.Publish(makeTicketClosed(t))
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("Publish should not hold the bus lock while calling handlers")
+		}
+		if subscribeErr != nil {
+			t.Errorf("Unexpected error subscribing from a handler: %v", subscribeErr)
+		}
+	})
+}
+
+func TestBus_SubscribeAsync(t *testing.T) {
+	t.Parallel()
+	t.Run("Asynchronous subscribers receive every event in publication order", func(t *testing.T) {
+		t.Parallel()
+		bus := NewBus()
+		var mu sync.Mutex
+		var received []uuid.UUID
+		_ = bus.SubscribeAsync(func(e Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			received = append(received, e.TicketID())
+		})
+		var published []uuid.UUID
+		for i := 0; i < 50; i++ {
+			e := makeTicketClosed(t)
+			published = append(published, e.TicketID())
+			bus.Publish(e)
+		}
+
+		bus.Close()
+
+		if len(received) != len(published) {
+			t.Fatalf("Expected %d events, got %d", len(published), len(received))
+		}
+		for i := range published {
+			if received[i] != published[i] {
+				t.Errorf("Expected event %d to be for ticket %s, got %s", i, published[i], received[i])
+			}
+		}
+	})
+	t.Run("A slow asynchronous subscriber does not block the publisher", func(t *testing.T) {
+		t.Parallel()
+		bus := NewBus()
+		release := make(chan struct{})
+		_ = bus.SubscribeAsync(func(Event) { <-release })
+
+		done := make(chan struct{})
+		go func() {
+			bus.Publish(makeTicketClosed(t))
+			bus.Publish(makeTicketClosed(t))
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Error("Publish should not wait for asynchronous subscribers")
+		}
+		close(release)
+		bus.Close()
+	})
+	t.Run("Events published after closing the bus are dropped", func(t *testing.T) {
+		t.Parallel()
+		bus := NewBus()
+		calls := 0
+		_ = bus.Subscribe(func(Event) { calls++ })
+		bus.Close()
+
+		bus.Publish(makeTicketClosed(t))
+
+		if calls != 0 {
+			t.Errorf("Expected no deliveries, got %d", calls)
+		}
+	})
+}
+
+func makeTicketClosed(t *testing.T) TicketClosed {
+	t.Helper()
+	tck, err := ticket.NewBasicTicket("title", "description")
+	if err != nil {
+		t.Fatalf("Error creating ticket: %v", err)
+	}
+	return TicketClosed{Ticket: tck, At: time.Now()}
+}
+
+func assertErrors(t *testing.T, err error, expected ...error) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("Error should not be nil")
+	}
+	for _, e := range expected {
+		if !errors.Is(err, e) {
+			t.Errorf("Error should be %v, but is %s", e, err.Error())
+		}
+	}
+}
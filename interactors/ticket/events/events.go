@@ -0,0 +1,107 @@
+// Package events contains the ticket lifecycle domain events and an in-process bus to publish them, so that
+// notifications, metrics and integrations can react to changes in tickets without touching the entities.
+package events
+
+import (
+	"github.com/google/uuid"
+	"ticketTao/entities/ticket"
+	"time"
+)
+
+// Event represents something that happened to a ticket after it was successfully persisted.
+type Event interface {
+	Name() Name
+	TicketID() uuid.UUID
+	OccurredAt() time.Time
+}
+
+// Name identifies the kind of event.
+type Name string
+
+// TicketCreatedName is the name of the event published when a new ticket is saved for a client.
+const TicketCreatedName Name = "TicketCreated"
+
+// ResponseAddedName is the name of the event published when a response is added to a ticket.
+const ResponseAddedName Name = "ResponseAdded"
+
+// StatusChangedName is the name of the event published when the status of a ticket changes.
+const StatusChangedName Name = "StatusChanged"
+
+// TicketClosedName is the name of the event published when a ticket is closed.
+const TicketClosedName Name = "TicketClosed"
+
+// TicketCreated is published when a new ticket is saved for a client.
+type TicketCreated struct {
+	Ticket ticket.Ticket
+	Client uuid.UUID
+	At     time.Time
+}
+
+func (e TicketCreated) Name() Name {
+	return TicketCreatedName
+}
+
+func (e TicketCreated) TicketID() uuid.UUID {
+	return e.Ticket.ID()
+}
+
+func (e TicketCreated) OccurredAt() time.Time {
+	return e.At
+}
+
+// ResponseAdded is published for every new response found in a ticket when it is updated.
+type ResponseAdded struct {
+	Ticket   ticket.Ticket
+	Response ticket.Response
+	At       time.Time
+}
+
+func (e ResponseAdded) Name() Name {
+	return ResponseAddedName
+}
+
+func (e ResponseAdded) TicketID() uuid.UUID {
+	return e.Ticket.ID()
+}
+
+func (e ResponseAdded) OccurredAt() time.Time {
+	return e.At
+}
+
+// StatusChanged is published when an updated ticket has a different status than the persisted one.
+type StatusChanged struct {
+	Ticket ticket.Ticket
+	From   ticket.Status
+	To     ticket.Status
+	At     time.Time
+}
+
+func (e StatusChanged) Name() Name {
+	return StatusChangedName
+}
+
+func (e StatusChanged) TicketID() uuid.UUID {
+	return e.Ticket.ID()
+}
+
+func (e StatusChanged) OccurredAt() time.Time {
+	return e.At
+}
+
+// TicketClosed is published after the StatusChanged event of a ticket that was closed.
+type TicketClosed struct {
+	Ticket ticket.Ticket
+	At     time.Time
+}
+
+func (e TicketClosed) Name() Name {
+	return TicketClosedName
+}
+
+func (e TicketClosed) TicketID() uuid.UUID {
+	return e.Ticket.ID()
+}
+
+func (e TicketClosed) OccurredAt() time.Time {
+	return e.At
+}
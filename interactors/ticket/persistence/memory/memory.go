@@ -0,0 +1,176 @@
+// Package memory contains an in-memory ticket persistence driver, useful for tests, demos and single-process
+// deployments.
+package memory
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"slices"
+	"sync"
+	"ticketTao/entities"
+	"ticketTao/entities/ticket"
+	"ticketTao/interactors/ticket/repository"
+	"time"
+)
+
+// NewTicketPersistence creates an empty in-memory repository.TransferablePersistence. It stores and returns copies of
+// the tickets, so changes to a ticket are only visible after updating it.
+func NewTicketPersistence() repository.TransferablePersistence {
+	return newTicketPersistence(ticket.SystemFactory())
+}
+
+// NewTicketPersistenceWithClock works like NewTicketPersistence, but the tickets it returns read the time from the
+// clock, e.g. to close them, and may be created up to the clock's time. It should be the clock of the factory that
+// creates the tickets.
+func NewTicketPersistenceWithClock(clock entities.Clock) (repository.TransferablePersistence, error) {
+	tickets, err := ticket.NewFactory(clock, entities.RandomIDSource())
+	if err != nil {
+		return nil, err
+	}
+	return newTicketPersistence(tickets), nil
+}
+
+func newTicketPersistence(tickets ticket.Factory) *ticketPersistence {
+	return &ticketPersistence{
+		tickets:  make(map[uuid.UUID]ticket.Ticket),
+		owners:   make(map[uuid.UUID]uuid.UUID),
+		instance: tickets,
+	}
+}
+
+type ticketPersistence struct {
+	// instance makes the copies of the tickets.
+	instance ticket.Factory
+	mu       sync.RWMutex
+	tickets  map[uuid.UUID]ticket.Ticket
+	owners   map[uuid.UUID]uuid.UUID
+	// order keeps the ticket IDs sorted by creation time, so GetAllTickets does not need to sort. Tickets created at the
+	// same time keep their insertion order.
+	order []uuid.UUID
+}
+
+func (p *ticketPersistence) SaveNewTicketForClient(client uuid.UUID, tck ticket.Ticket) error {
+	copied, err := p.copyTicket(tck)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.tickets[tck.ID()]; ok {
+		return ErrDuplicatedTicket
+	}
+	p.tickets[tck.ID()] = copied
+	p.owners[tck.ID()] = client
+	at, _ := slices.BinarySearchFunc(p.order, tck.CreatedAt(), func(id uuid.UUID, created time.Time) int {
+		if p.tickets[id].CreatedAt().After(created) {
+			return 1
+		}
+		return -1
+	})
+	p.order = slices.Insert(p.order, at, tck.ID())
+	return nil
+}
+
+func (p *ticketPersistence) GetTicketOwner(tck uuid.UUID) (uuid.UUID, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	owner, ok := p.owners[tck]
+	if !ok {
+		return uuid.Nil, ErrTicketNotFound
+	}
+	return owner, nil
+}
+
+func (p *ticketPersistence) SetTicketOwner(tck, client uuid.UUID) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.owners[tck]; !ok {
+		return ErrTicketNotFound
+	}
+	p.owners[tck] = client
+	return nil
+}
+
+func (p *ticketPersistence) GetTicket(id uuid.UUID) (ticket.Ticket, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	tck, ok := p.tickets[id]
+	if !ok {
+		return nil, ErrTicketNotFound
+	}
+	return p.copyTicket(tck)
+}
+
+func (p *ticketPersistence) UpdateTicket(tck ticket.Ticket) error {
+	if tck == nil {
+		return ticket.ErrNilTicket
+	}
+	copied, err := p.copyTicket(tck)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.tickets[tck.ID()]; !ok {
+		return ErrTicketNotFound
+	}
+	p.tickets[tck.ID()] = copied
+	return nil
+}
+
+func (p *ticketPersistence) GetAllTickets() ([]ticket.Ticket, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	tickets := make([]ticket.Ticket, 0, len(p.order))
+	for _, id := range p.order {
+		copied, err := p.copyTicket(p.tickets[id])
+		if err != nil {
+			return nil, err
+		}
+		tickets = append(tickets, copied)
+	}
+	return tickets, nil
+}
+
+func (p *ticketPersistence) QueryTickets(query ticket.Query) (ticket.Page, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	var matching []ticket.Ticket
+	for _, id := range p.order {
+		if query.Matches(p.tickets[id], p.owners[id]) {
+			matching = append(matching, p.tickets[id])
+		}
+	}
+	page, err := query.Paginate(matching)
+	if err != nil {
+		return ticket.Page{}, err
+	}
+	for i, tck := range page.Tickets {
+		if page.Tickets[i], err = p.copyTicket(tck); err != nil {
+			return ticket.Page{}, err
+		}
+	}
+	return page, nil
+}
+
+func (p *ticketPersistence) CountTickets(query ticket.Query) (int, error) {
+	if err := query.Validate(); err != nil {
+		return 0, err
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	count := 0
+	for _, id := range p.order {
+		if query.Matches(p.tickets[id], p.owners[id]) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (p *ticketPersistence) copyTicket(tck ticket.Ticket) (ticket.Ticket, error) {
+	return p.instance.MakeTicket(tck.ID(), tck.CreatedAt(), ticket.DataFrom(tck))
+}
+
+var ErrTicketNotFound error = errors.New("ticket not found")
+var ErrDuplicatedTicket error = errors.New("ticket already exists")
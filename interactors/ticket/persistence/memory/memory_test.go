@@ -0,0 +1,159 @@
+package memory
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"testing"
+	"ticketTao/entities"
+	"ticketTao/entities/ticket"
+	"ticketTao/interactors/ticket/repository"
+	"time"
+)
+
+func TestTicketPersistence(t *testing.T) {
+	t.Parallel()
+	t.Run("Tickets are stored and returned as copies", func(t *testing.T) {
+		t.Parallel()
+		persistence := NewTicketPersistence()
+		tck := saveTicket(t, persistence, uuid.New(), time.Hour)
+
+		tck.AddResponse(ticket.NewResponse(uuid.New(), "not persisted yet"))
+		stored, err := persistence.GetTicket(tck.ID())
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if stored == tck || len(stored.Responses()) != 0 {
+			t.Fatal("The stored ticket should not change until it is updated")
+		}
+
+		if err := persistence.UpdateTicket(tck); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		stored, _ = persistence.GetTicket(tck.ID())
+		if len(stored.Responses()) != 1 || stored.Status() != ticket.InProgress {
+			t.Errorf("Expected the update to be stored, got %v responses and status %s", len(stored.Responses()), stored.Status())
+		}
+	})
+	t.Run("Unknown and duplicated tickets are rejected", func(t *testing.T) {
+		t.Parallel()
+		persistence := NewTicketPersistence()
+		tck := saveTicket(t, persistence, uuid.New(), time.Hour)
+		if err := persistence.SaveNewTicketForClient(uuid.New(), tck); !errors.Is(err, ErrDuplicatedTicket) {
+			t.Errorf("Error should be ErrDuplicatedTicket, got %v", err)
+		}
+		unknown, _ := ticket.NewBasicTicket("title", "description")
+		if err := persistence.UpdateTicket(unknown); !errors.Is(err, ErrTicketNotFound) {
+			t.Errorf("Error should be ErrTicketNotFound, got %v", err)
+		}
+		if _, err := persistence.GetTicketOwner(unknown.ID()); !errors.Is(err, ErrTicketNotFound) {
+			t.Errorf("Error should be ErrTicketNotFound, got %v", err)
+		}
+		if err := persistence.SetTicketOwner(unknown.ID(), uuid.New()); !errors.Is(err, ErrTicketNotFound) {
+			t.Errorf("Error should be ErrTicketNotFound, got %v", err)
+		}
+	})
+	t.Run("Tickets read the time from the clock of the persistence", func(t *testing.T) {
+		t.Parallel()
+		now := time.Now().Add(24 * time.Hour)
+		clock := entities.ClockFunc(func() time.Time { return now })
+		persistence, err := NewTicketPersistenceWithClock(clock)
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		factory, _ := ticket.NewFactory(clock, entities.RandomIDSource())
+		tck, _ := factory.NewTicket("title", "description")
+		if err := persistence.SaveNewTicketForClient(uuid.New(), tck); err != nil {
+			t.Fatalf("A ticket created by the clock should be saved, got %v", err)
+		}
+
+		stored, _ := persistence.GetTicket(tck.ID())
+		stored.Close()
+
+		if !stored.ClosedAt().Equal(now) {
+			t.Errorf("Expected the ticket to be closed at %v, got %v", now, stored.ClosedAt())
+		}
+		if _, err := NewTicketPersistenceWithClock(nil); !errors.Is(err, entities.ErrNilClock) {
+			t.Errorf("Error should be ErrNilClock, got %v", err)
+		}
+	})
+	t.Run("Tickets are listed by creation time whatever the order they were saved in", func(t *testing.T) {
+		t.Parallel()
+		persistence := NewTicketPersistence()
+		newest := saveTicket(t, persistence, uuid.New(), time.Minute)
+		oldest := saveTicket(t, persistence, uuid.New(), time.Hour)
+		middle := saveTicket(t, persistence, uuid.New(), 30*time.Minute)
+
+		tickets, _ := persistence.GetAllTickets()
+
+		if len(tickets) != 3 || tickets[0].ID() != oldest.ID() || tickets[1].ID() != middle.ID() || tickets[2].ID() != newest.ID() {
+			t.Errorf("Expected the tickets sorted by creation time, got %v", tickets)
+		}
+	})
+	t.Run("Tickets are counted without pagination", func(t *testing.T) {
+		t.Parallel()
+		persistence := NewTicketPersistence()
+		client := uuid.New()
+		for range 3 {
+			saveTicket(t, persistence, client, time.Hour)
+		}
+		saveTicket(t, persistence, uuid.New(), time.Hour)
+
+		count, err := persistence.CountTickets(ticket.Query{Client: client, Limit: 1})
+
+		if err != nil || count != 3 {
+			t.Errorf("Expected 3 tickets, got %d, %v", count, err)
+		}
+		_, err = persistence.CountTickets(ticket.Query{Limit: -1})
+		if err == nil {
+			t.Error("Expected an invalid query to be rejected")
+		}
+	})
+	t.Run("Tickets can be given to another client", func(t *testing.T) {
+		t.Parallel()
+		persistence := NewTicketPersistence()
+		tck := saveTicket(t, persistence, uuid.New(), time.Hour)
+		client := uuid.New()
+
+		if err := persistence.SetTicketOwner(tck.ID(), client); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if owner, _ := persistence.GetTicketOwner(tck.ID()); owner != client {
+			t.Errorf("Expected the owner to be %s, got %s", client, owner)
+		}
+		if page, _ := persistence.QueryTickets(ticket.Query{Client: client}); len(page.Tickets) != 1 {
+			t.Errorf("Expected the ticket to be found for its new owner, got %v", page)
+		}
+	})
+	t.Run("Queries are filtered by client and paginated", func(t *testing.T) {
+		t.Parallel()
+		persistence := NewTicketPersistence()
+		client := uuid.New()
+		first := saveTicket(t, persistence, client, 3*time.Hour)
+		saveTicket(t, persistence, uuid.New(), 2*time.Hour)
+		second := saveTicket(t, persistence, client, time.Hour)
+
+		page, err := persistence.QueryTickets(ticket.Query{Client: client, Limit: 1})
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if len(page.Tickets) != 1 || page.Tickets[0].ID() != first.ID() || page.Next == "" {
+			t.Fatalf("Expected the first ticket and a cursor, got %v", page)
+		}
+		page, _ = persistence.QueryTickets(ticket.Query{Client: client, Limit: 1, After: page.Next})
+		if len(page.Tickets) != 1 || page.Tickets[0].ID() != second.ID() || page.Next != "" {
+			t.Errorf("Expected the second and last ticket, got %v", page)
+		}
+	})
+}
+
+func saveTicket(t *testing.T, persistence repository.TicketPersistence, client uuid.UUID, age time.Duration) ticket.Ticket {
+	t.Helper()
+	tck, err := ticket.MakeBasicTicket(uuid.New(), time.Now().Add(-age), ticket.Data{Title: "title", Status: ticket.Open})
+	if err != nil {
+		t.Fatalf("Error creating ticket: %v", err)
+	}
+	if err := persistence.SaveNewTicketForClient(client, tck); err != nil {
+		t.Fatalf("Error saving ticket: %v", err)
+	}
+	return tck
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"slices"
+	"sync"
+	"ticketTao/entities/ticket"
+	"ticketTao/interactors/ticket/repository"
+)
+
+// NewRelationPersistence creates an empty in-memory repository.RelationPersistence.
+func NewRelationPersistence() repository.RelationPersistence {
+	return &relationPersistence{links: make(map[uuid.UUID][]ticket.Link)}
+}
+
+type relationPersistence struct {
+	mu sync.RWMutex
+	// links indexes every link by both of its tickets, in insertion order.
+	links map[uuid.UUID][]ticket.Link
+}
+
+func (r *relationPersistence) SaveLink(link ticket.Link) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if slices.Contains(r.links[link.From], link) {
+		return ErrDuplicatedLink
+	}
+	r.links[link.From] = append(r.links[link.From], link)
+	r.links[link.To] = append(r.links[link.To], link)
+	return nil
+}
+
+func (r *relationPersistence) DeleteLink(link ticket.Link) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !slices.Contains(r.links[link.From], link) {
+		return ErrLinkNotFound
+	}
+	for _, id := range []uuid.UUID{link.From, link.To} {
+		r.links[id] = slices.DeleteFunc(r.links[id], func(l ticket.Link) bool { return l == link })
+		if len(r.links[id]) == 0 {
+			delete(r.links, id)
+		}
+	}
+	return nil
+}
+
+func (r *relationPersistence) GetLinks(id uuid.UUID) ([]ticket.Link, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.links[id]), nil
+}
+
+var ErrLinkNotFound error = errors.New("link not found")
+var ErrDuplicatedLink error = errors.New("link already exists")
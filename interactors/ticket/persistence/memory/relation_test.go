@@ -0,0 +1,39 @@
+package memory
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"testing"
+	"ticketTao/entities/ticket"
+)
+
+func TestRelationPersistence(t *testing.T) {
+	t.Parallel()
+	t.Run("Links are found from both tickets until they are deleted", func(t *testing.T) {
+		t.Parallel()
+		rp := NewRelationPersistence()
+		link := ticket.Link{From: uuid.New(), Kind: ticket.ParentOf, To: uuid.New()}
+
+		if err := rp.SaveLink(link); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		for _, id := range []uuid.UUID{link.From, link.To} {
+			if links, _ := rp.GetLinks(id); len(links) != 1 || links[0] != link {
+				t.Errorf("Expected the link from ticket %s, got %v", id, links)
+			}
+		}
+		if err := rp.SaveLink(link); !errors.Is(err, ErrDuplicatedLink) {
+			t.Errorf("Error should be ErrDuplicatedLink, got %v", err)
+		}
+
+		if err := rp.DeleteLink(link); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if links, _ := rp.GetLinks(link.To); len(links) != 0 {
+			t.Errorf("Expected no links, got %v", links)
+		}
+		if err := rp.DeleteLink(link); !errors.Is(err, ErrLinkNotFound) {
+			t.Errorf("Error should be ErrLinkNotFound, got %v", err)
+		}
+	})
+}
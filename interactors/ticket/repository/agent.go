@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"ticketTao/entities/ticket"
+)
+
+// AgentTicketRepository is the ticket repository used by agents and by the background jobs working on their behalf.
+type AgentTicketRepository interface {
+	ticket.RepositoryAgentAccess
+	ticket.RepositoryAgentLister
+	ticket.RepositoryAgentQuerier
+}
+
+// GetAgentTicketRepository returns a new instance of AgentTicketRepository
+func GetAgentTicketRepository(tp TicketPersistence) (AgentTicketRepository, error) {
+	if tp == nil {
+		return nil, errors.Join(GetAgentTicketRepositoryError, NilPersistenceDriverError)
+	}
+	return basicAgentTicketRepository{tp}, nil
+}
+
+type basicAgentTicketRepository struct {
+	persistence TicketPersistence
+}
+
+// GetTicket returns any ticket, agents are not restricted by ticket ownership.
+func (b basicAgentTicketRepository) GetTicket(ticketId uuid.UUID) (ticket.Ticket, error) {
+	if ticketId == uuid.Nil {
+		return nil, errors.Join(GetTicketError, ErrNilTicketID)
+	}
+	tck, err := b.persistence.GetTicket(ticketId)
+	if err != nil {
+		return nil, errors.Join(GetTicketError, err)
+	}
+	return tck, nil
+}
+
+// UpdateTicket persists the changes made by an agent to a ticket, it returns an error if the ticket is nil or if the
+// persistence returns an error (for example, when the ticket does not exist).
+func (b basicAgentTicketRepository) UpdateTicket(tck ticket.Ticket) error {
+	if tck == nil {
+		return errors.Join(UpdateTicketError, ticket.ErrNilTicket)
+	}
+	err := b.persistence.UpdateTicket(tck)
+	if err != nil {
+		return errors.Join(UpdateTicketError, err)
+	}
+	return nil
+}
+
+// GetOpenTickets returns every ticket that is not closed, ordered by creation date.
+func (b basicAgentTicketRepository) GetOpenTickets() ([]ticket.Ticket, error) {
+	tickets, err := b.persistence.GetAllTickets()
+	if err != nil {
+		return nil, errors.Join(GetOpenTicketsError, err)
+	}
+	var open []ticket.Ticket
+	for _, tck := range tickets {
+		if tck.Status() != ticket.Closed {
+			open = append(open, tck)
+		}
+	}
+	return open, nil
+}
+
+// QueryTickets returns a page of the tickets matching the query, of every client unless the query sets one.
+func (b basicAgentTicketRepository) QueryTickets(query ticket.Query) (ticket.Page, error) {
+	if err := query.Validate(); err != nil {
+		return ticket.Page{}, errors.Join(QueryTicketsError, err)
+	}
+	page, err := b.persistence.QueryTickets(query)
+	if err != nil {
+		return ticket.Page{}, errors.Join(QueryTicketsError, err)
+	}
+	return page, nil
+}
+
+func (b basicAgentTicketRepository) CountTicketsByTag(query ticket.Query) (map[string]int, error) {
+	counts, err := countByTag(query, b.QueryTickets)
+	if err != nil {
+		return nil, errors.Join(CountTicketsByTagError, err)
+	}
+	return counts, nil
+}
+
+func (b basicAgentTicketRepository) GetTicketOwner(ticketId uuid.UUID) (uuid.UUID, error) {
+	owner, err := b.persistence.GetTicketOwner(ticketId)
+	if err != nil {
+		return uuid.Nil, errors.Join(ValidateTicketOwnershipError, err)
+	}
+	return owner, nil
+}
+
+var GetAgentTicketRepositoryError error = errors.New("error getting agent ticket repository")
+var GetOpenTicketsError error = errors.New("error getting open tickets")
+
+var ErrNilTicketID error = errors.New("ticket id cannot be nil")
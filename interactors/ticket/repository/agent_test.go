@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"testing"
+	"ticketTao/entities/ticket"
+)
+
+func TestGetAgentTicketRepository(t *testing.T) {
+	t.Parallel()
+	t.Run("It should return the ticket repository", func(t *testing.T) {
+		agentRepo, err := GetAgentTicketRepository(&spyTicketPersistence{})
+		if err != nil {
+			t.Errorf("Error should be nil, but is %s", err.Error())
+		}
+		if agentRepo == nil {
+			t.Error("Repository should not be nil")
+		}
+	})
+	t.Run("It should return an error when the persistence driver is nil", func(t *testing.T) {
+		_, err := GetAgentTicketRepository(nil)
+		assertErrors(t, err, GetAgentTicketRepositoryError, NilPersistenceDriverError)
+	})
+}
+
+func TestBasicAgentTicketRepository_GetTicket(t *testing.T) {
+	t.Parallel()
+	spyPersistence := &spyTicketPersistence{}
+	agentRepo, _ := GetAgentTicketRepository(spyPersistence)
+	t.Run("It should return any ticket without checking its owner", func(t *testing.T) {
+		ticketId := uuid.New()
+		tck, err := agentRepo.GetTicket(ticketId)
+		if err != nil {
+			t.Errorf("Error should be nil, but is %s", err.Error())
+		}
+		if tck == nil {
+			t.Error("Ticket should not be nil")
+		}
+		spyPersistence.assertTicketWasRetrieved(t, ticketId)
+		if spyPersistence.calls["GetTicketOwner"] != nil {
+			t.Error("GetTicketOwner should not be called")
+		}
+	})
+	t.Run("It should return an error when the ticket id is nil", func(t *testing.T) {
+		_, err := agentRepo.GetTicket(uuid.Nil)
+		assertErrors(t, err, GetTicketError, ErrNilTicketID)
+	})
+}
+
+func TestBasicAgentTicketRepository_UpdateTicket(t *testing.T) {
+	t.Parallel()
+	spyPersistence := &spyTicketPersistence{}
+	agentRepo, _ := GetAgentTicketRepository(spyPersistence)
+	t.Run("It should update the ticket", func(t *testing.T) {
+		tck, _ := ticket.NewBasicTicket("title", "description")
+		err := agentRepo.UpdateTicket(tck)
+		if err != nil {
+			t.Errorf("Error should be nil, but is %s", err.Error())
+		}
+		spyPersistence.assertTicketWasUpdated(t, tck)
+	})
+	t.Run("It should return an error when the ticket is nil", func(t *testing.T) {
+		err := agentRepo.UpdateTicket(nil)
+		assertErrors(t, err, UpdateTicketError, ticket.ErrNilTicket)
+	})
+}
+
+func TestBasicAgentTicketRepository_GetOpenTickets(t *testing.T) {
+	t.Parallel()
+	persistence := newSnapshotTicketPersistence()
+	agentRepo, _ := GetAgentTicketRepository(persistence)
+	owner := uuid.New()
+	open, _ := ticket.NewBasicTicket("open", "description")
+	closed, _ := ticket.NewBasicTicket("closed", "description")
+	closed.Close()
+	_ = persistence.SaveNewTicketForClient(owner, open)
+	_ = persistence.SaveNewTicketForClient(owner, closed)
+
+	t.Run("It should only return the tickets that are not closed", func(t *testing.T) {
+		tickets, err := agentRepo.GetOpenTickets()
+		if err != nil {
+			t.Fatalf("Error should be nil, but is %s", err.Error())
+		}
+		if len(tickets) != 1 || tickets[0].ID() != open.ID() {
+			t.Errorf("Expected only ticket %s, got %v", open.ID(), tickets)
+		}
+	})
+	t.Run("It should return the owner of a ticket", func(t *testing.T) {
+		got, err := agentRepo.GetTicketOwner(open.ID())
+		if err != nil {
+			t.Fatalf("Error should be nil, but is %s", err.Error())
+		}
+		if got != owner {
+			t.Errorf("Expected owner %s, got %s", owner, got)
+		}
+	})
+}
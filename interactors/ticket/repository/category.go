@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"errors"
+	"ticketTao/entities/category"
+	"ticketTao/entities/ticket"
+)
+
+// NewCategoryUsage returns a category.Usage that looks for the tickets of a category in the persistence.
+func NewCategoryUsage(tp TicketPersistence) (category.Usage, error) {
+	if tp == nil {
+		return nil, errors.Join(NewCategoryUsageError, NilPersistenceDriverError)
+	}
+	return persistedCategoryUsage{persistence: tp}, nil
+}
+
+type persistedCategoryUsage struct {
+	persistence TicketPersistence
+}
+
+func (p persistedCategoryUsage) IsCategoryUsed(path string) (bool, error) {
+	count, err := p.persistence.CountTickets(ticket.Query{Category: path})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+var NewCategoryUsageError error = errors.New("error creating category usage")
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"testing"
+	"ticketTao/entities/category"
+	"ticketTao/entities/ticket"
+)
+
+func TestNewCategoryUsage(t *testing.T) {
+	t.Parallel()
+	_, err := NewCategoryUsage(nil)
+	assertErrors(t, err, NewCategoryUsageError, NilPersistenceDriverError)
+
+	tp := newSnapshotTicketPersistence()
+	refund, _ := ticket.NewBasicTicket("Refund", "Please refund the order")
+	refund.Categorize("billing/refunds")
+	_ = tp.SaveNewTicketForClient(uuid.New(), refund)
+	usage, err := NewCategoryUsage(tp)
+	if err != nil {
+		t.Fatalf("Error should be nil, but is %s", err.Error())
+	}
+	taxonomy, _ := category.NewTaxonomyWithUsage(usage, "billing", "billing/refunds", "billing/invoices")
+
+	t.Run("The categories of persisted tickets cannot be removed", func(t *testing.T) {
+		if err := taxonomy.Remove("billing/refunds"); !errors.Is(err, category.ErrCategoryInUse) {
+			t.Errorf("Error should be %v, but is %v", category.ErrCategoryInUse, err)
+		}
+		if err := taxonomy.Remove("billing/invoices"); err != nil {
+			t.Errorf("Error should be nil, but is %s", err.Error())
+		}
+	})
+}
@@ -3,36 +3,63 @@ package repository
 import (
 	"errors"
 	"github.com/google/uuid"
+	"ticketTao/entities/sla"
 	"ticketTao/entities/ticket"
 )
 
-// GetClientTicketRepository returns a new instance of ticket.RepositoryClientAccess
+// GetClientTicketRepository returns a new instance of ticket.RepositoryClientAccess, its clients cannot follow the
+// tickets of other clients.
 func GetClientTicketRepository(tp TicketPersistence) (ticket.RepositoryClientAccess, error) {
 	if tp == nil {
 		return nil, errors.Join(GetClientTicketRepositoryError, NilPersistenceDriverError)
 	}
-	return basicClientTicketRepository{tp}, nil
+	return basicClientTicketRepository{persistence: tp}, nil
+}
+
+// GetClientTicketRepositoryWithOrganizations returns a new instance of ticket.RepositoryClientAccess whose clients can
+// follow the tickets of the other clients of their organization.
+func GetClientTicketRepositoryWithOrganizations(tp TicketPersistence, organizations sla.OrganizationResolver) (ticket.RepositoryClientAccess, error) {
+	if tp == nil {
+		return nil, errors.Join(GetClientTicketRepositoryError, NilPersistenceDriverError)
+	}
+	if organizations == nil {
+		return nil, errors.Join(GetClientTicketRepositoryError, sla.ErrNilOrganizationResolver)
+	}
+	return basicClientTicketRepository{persistence: tp, organizations: organizations}, nil
 }
 
 type basicClientTicketRepository struct {
 	persistence TicketPersistence
+	// organizations is nil when the clients cannot follow the tickets of other clients.
+	organizations sla.OrganizationResolver
 }
 
-// GetTicket returns a ticket for a client, it returns an error if the client does not own the ticket or if the persistence
-// returns an error.
+// GetTicket returns a ticket for a client, it returns an error if the client neither owns nor follows the ticket or if
+// the persistence returns an error. A ticket merged into another one is redirected to the ticket it was merged into.
 func (b basicClientTicketRepository) GetTicket(clientId, ticketId uuid.UUID) (ticket.Ticket, error) {
-	err := b.validateTicketOwnership(clientId, ticketId)
-	if err != nil {
-		return nil, errors.Join(GetTicketError, err)
-
+	for redirects := 0; redirects <= maxMergeRedirects; redirects++ {
+		owner, err := b.persistence.GetTicketOwner(ticketId)
+		if err != nil {
+			return nil, errors.Join(GetTicketError, ValidateTicketOwnershipError, err)
+		}
+		tck, err := b.persistence.GetTicket(ticketId)
+		if err != nil {
+			return nil, errors.Join(GetTicketError, err)
+		}
+		if owner != clientId && !tck.IsFollowedBy(clientId) {
+			return nil, errors.Join(GetTicketError, ErrTicketNotAccessible)
+		}
+		if tck.MergedInto() == uuid.Nil {
+			return tck, nil
+		}
+		ticketId = tck.MergedInto()
 	}
-	tck, err := b.persistence.GetTicket(ticketId)
-	if err != nil {
-		return nil, errors.Join(GetTicketError, err)
-	}
-	return tck, nil
+	return nil, errors.Join(GetTicketError, ErrTooManyMergeRedirects)
 }
 
+// maxMergeRedirects bounds the chain of merged tickets followed by GetTicket, so a corrupted chain cannot loop forever.
+const maxMergeRedirects = 10
+
 func (b basicClientTicketRepository) validateTicketOwnership(client uuid.UUID, tck uuid.UUID) error {
 	owner, err := b.persistence.GetTicketOwner(tck)
 	if err != nil {
@@ -44,14 +71,61 @@ func (b basicClientTicketRepository) validateTicketOwnership(client uuid.UUID, t
 	return nil
 }
 
+// GetAllClientTickets returns every ticket of the client, oldest first. Clients with many tickets should use
+// QueryClientTickets instead, which does not load them all at once.
 func (b basicClientTicketRepository) GetAllClientTickets(client uuid.UUID) ([]ticket.Ticket, error) {
-	//TODO implement me
-	panic("implement me")
+	var tickets []ticket.Ticket
+	err := b.eachClientPage(client, func(page ticket.Page) {
+		tickets = append(tickets, page.Tickets...)
+	})
+	if err != nil {
+		return nil, errors.Join(GetAllClientTicketsError, err)
+	}
+	return tickets, nil
 }
 
 func (b basicClientTicketRepository) GetClientTicketCount(client uuid.UUID) (int, error) {
-	//TODO implement me
-	panic("implement me")
+	if client == uuid.Nil {
+		return 0, errors.Join(GetClientTicketCountError, ErrNilClientID)
+	}
+	count, err := b.persistence.CountTickets(ticket.Query{Client: client})
+	if err != nil {
+		return 0, errors.Join(GetClientTicketCountError, err)
+	}
+	return count, nil
+}
+
+// QueryClientTickets returns a page of the client's tickets matching the query, the client of the query is replaced
+// by the given one.
+func (b basicClientTicketRepository) QueryClientTickets(client uuid.UUID, query ticket.Query) (ticket.Page, error) {
+	if client == uuid.Nil {
+		return ticket.Page{}, errors.Join(QueryTicketsError, ErrNilClientID)
+	}
+	query.Client = client
+	if err := query.Validate(); err != nil {
+		return ticket.Page{}, errors.Join(QueryTicketsError, err)
+	}
+	page, err := b.persistence.QueryTickets(query)
+	if err != nil {
+		return ticket.Page{}, errors.Join(QueryTicketsError, err)
+	}
+	return page, nil
+}
+
+func (b basicClientTicketRepository) CountClientTicketsByTag(client uuid.UUID, query ticket.Query) (map[string]int, error) {
+	counts, err := countByTag(query, func(query ticket.Query) (ticket.Page, error) {
+		return b.QueryClientTickets(client, query)
+	})
+	if err != nil {
+		return nil, errors.Join(CountTicketsByTagError, err)
+	}
+	return counts, nil
+}
+
+func (b basicClientTicketRepository) eachClientPage(client uuid.UUID, do func(ticket.Page)) error {
+	return eachPage(ticket.Query{}, func(query ticket.Query) (ticket.Page, error) {
+		return b.QueryClientTickets(client, query)
+	}, do)
 }
 
 // CreateNewTicketForClient creates a new ticket for a client, it returns an error if the user id is nil or the ticket
@@ -74,9 +148,84 @@ func (b basicClientTicketRepository) CreateNewTicketForClient(userId uuid.UUID,
 	return nil
 }
 
+// FollowTicket makes the client a follower of a ticket owned by another client of its organization.
+func (b basicClientTicketRepository) FollowTicket(client, ticketId uuid.UUID) error {
+	if client == uuid.Nil {
+		return errors.Join(FollowTicketError, ErrNilClientID)
+	}
+	owner, err := b.persistence.GetTicketOwner(ticketId)
+	if err != nil {
+		return errors.Join(FollowTicketError, ValidateTicketOwnershipError, err)
+	}
+	if owner == client {
+		return errors.Join(FollowTicketError, ErrOwnerCannotFollow)
+	}
+	if err := b.validateSameOrganization(client, owner); err != nil {
+		return errors.Join(FollowTicketError, err)
+	}
+	tck, err := b.persistence.GetTicket(ticketId)
+	if err != nil {
+		return errors.Join(FollowTicketError, err)
+	}
+	if tck.IsFollowedBy(client) {
+		return nil
+	}
+	tck.Follow(client)
+	if err := b.persistence.UpdateTicket(tck); err != nil {
+		return errors.Join(FollowTicketError, err)
+	}
+	return nil
+}
+
+func (b basicClientTicketRepository) validateSameOrganization(client, owner uuid.UUID) error {
+	if b.organizations == nil {
+		return ErrTicketNotAccessible
+	}
+	organization, err := b.organizations.GetClientOrganization(client)
+	if err != nil {
+		return errors.Join(sla.ErrResolvingOrganization, err)
+	}
+	ownerOrganization, err := b.organizations.GetClientOrganization(owner)
+	if err != nil {
+		return errors.Join(sla.ErrResolvingOrganization, err)
+	}
+	if organization == uuid.Nil || organization != ownerOrganization {
+		return ErrTicketNotAccessible
+	}
+	return nil
+}
+
+// UnfollowTicket stops the client from following a ticket, it does nothing if the client does not follow it.
+func (b basicClientTicketRepository) UnfollowTicket(client, ticketId uuid.UUID) error {
+	tck, err := b.persistence.GetTicket(ticketId)
+	if err != nil {
+		return errors.Join(UnfollowTicketError, err)
+	}
+	if !tck.IsFollowedBy(client) {
+		return nil
+	}
+	tck.Unfollow(client)
+	if err := b.persistence.UpdateTicket(tck); err != nil {
+		return errors.Join(UnfollowTicketError, err)
+	}
+	return nil
+}
+
+// UpdateTicketForClient persists the changes made by a client to one of their tickets, it returns an error if the
+// ticket is nil, if the client does not own the ticket or if the persistence returns an error.
 func (b basicClientTicketRepository) UpdateTicketForClient(userId uuid.UUID, tck ticket.Ticket) error {
-	//TODO implement me
-	panic("implement me")
+	if tck == nil {
+		return errors.Join(UpdateTicketError, ticket.ErrNilTicket)
+	}
+	err := b.validateTicketOwnership(userId, tck.ID())
+	if err != nil {
+		return errors.Join(UpdateTicketError, err)
+	}
+	err = b.persistence.UpdateTicket(tck)
+	if err != nil {
+		return errors.Join(UpdateTicketError, err)
+	}
+	return nil
 }
 
 var GetClientTicketRepositoryError error = errors.New("error getting client ticket repository")
@@ -84,5 +233,16 @@ var SaveNewTicketForClientError error = errors.New("error saving new ticket for
 var NilPersistenceDriverError error = errors.New("persistence driver cannot be nil")
 var GetTicketError error = errors.New("error getting ticket")
 var ValidateTicketOwnershipError error = errors.New("error retrieving ticket owner")
+var UpdateTicketError error = errors.New("error updating ticket")
+var GetAllClientTicketsError error = errors.New("error getting all client tickets")
+var GetClientTicketCountError error = errors.New("error counting client tickets")
+var QueryTicketsError error = errors.New("error querying tickets")
+var CountTicketsByTagError error = errors.New("error counting tickets by tag")
+
+var FollowTicketError error = errors.New("error following ticket")
+var UnfollowTicketError error = errors.New("error unfollowing ticket")
 
+var ErrOwnerCannotFollow error = errors.New("the owner of a ticket cannot follow it")
 var ErrTicketNotAccessible error = errors.New("ticket is not accessible by the client")
+var ErrTooManyMergeRedirects error = errors.New("too many merged tickets redirect to each other")
+var ErrNilClientID error = errors.New("client ID cannot be nil")
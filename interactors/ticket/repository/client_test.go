@@ -90,6 +90,78 @@ func TestBasicClientTicketRepository_GetTicket(t *testing.T) {
 		_, err := clientRepo.GetTicket(clientId, ticketId)
 		assertError(t, err, ErrTicketNotAccessible)
 	})
+	t.Run("It should redirect a merged ticket to the ticket it was merged into", func(t *testing.T) {
+		t.Parallel()
+		persistence := newSnapshotTicketPersistence()
+		repo, _ := GetClientTicketRepository(persistence)
+		clientId := uuid.New()
+		source, _ := ticket.NewBasicTicket("duplicate", "description")
+		target, _ := ticket.NewBasicTicket("original", "description")
+		_ = repo.CreateNewTicketForClient(clientId, source)
+		_ = repo.CreateNewTicketForClient(clientId, target)
+		source.CloseAsDuplicateOf(target.ID())
+		_ = persistence.UpdateTicket(source)
+
+		tck, err := repo.GetTicket(clientId, source.ID())
+		if err != nil {
+			t.Fatalf("Error should be nil, but is %s", err.Error())
+		}
+		if tck.ID() != target.ID() {
+			t.Errorf("Expected ticket %s, got %s", target.ID(), tck.ID())
+		}
+		_, err = repo.GetTicket(uuid.New(), source.ID())
+		assertErrors(t, err, GetTicketError, ErrTicketNotAccessible)
+	})
+	t.Run("It should return an error when merged tickets redirect to each other", func(t *testing.T) {
+		t.Parallel()
+		persistence := newSnapshotTicketPersistence()
+		repo, _ := GetClientTicketRepository(persistence)
+		clientId := uuid.New()
+		first, _ := ticket.NewBasicTicket("first", "description")
+		second, _ := ticket.NewBasicTicket("second", "description")
+		_ = repo.CreateNewTicketForClient(clientId, first)
+		_ = repo.CreateNewTicketForClient(clientId, second)
+		first.CloseAsDuplicateOf(second.ID())
+		second.CloseAsDuplicateOf(first.ID())
+		_ = persistence.UpdateTicket(first)
+		_ = persistence.UpdateTicket(second)
+
+		_, err := repo.GetTicket(clientId, first.ID())
+		assertErrors(t, err, GetTicketError, ErrTooManyMergeRedirects)
+	})
+}
+
+func TestBasicClientTicketRepository_UpdateTicketForClient(t *testing.T) {
+	t.Parallel()
+	t.Run("It should update a ticket owned by the client", func(t *testing.T) {
+		spyPersistence := &spyTicketPersistence{}
+		clientRepo, _ := GetClientTicketRepository(spyPersistence)
+		clientId := uuid.New()
+		spyPersistence.setTicketOwnerOverride(clientId)
+		tck, _ := ticket.NewBasicTicket("title", "description")
+		err := clientRepo.UpdateTicketForClient(clientId, tck)
+		if err != nil {
+			t.Errorf("Error should be nil, but is %s", err.Error())
+		}
+		spyPersistence.assertTicketOwnerWasChecked(t, tck.ID())
+		spyPersistence.assertTicketWasUpdated(t, tck)
+	})
+	t.Run("It should return an error when the ticket does not belong to the client", func(t *testing.T) {
+		spyPersistence := &spyTicketPersistence{}
+		clientRepo, _ := GetClientTicketRepository(spyPersistence)
+		spyPersistence.setTicketOwnerOverride(uuid.New())
+		tck, _ := ticket.NewBasicTicket("title", "description")
+		err := clientRepo.UpdateTicketForClient(uuid.New(), tck)
+		assertErrors(t, err, UpdateTicketError, ErrTicketNotAccessible)
+		if spyPersistence.calls["UpdateTicket"] != nil {
+			t.Error("UpdateTicket should not be called")
+		}
+	})
+	t.Run("It should return an error when the ticket is nil", func(t *testing.T) {
+		clientRepo, _ := GetClientTicketRepository(&spyTicketPersistence{})
+		err := clientRepo.UpdateTicketForClient(uuid.New(), nil)
+		assertErrors(t, err, UpdateTicketError, ticket.ErrNilTicket)
+	})
 }
 
 func assertErrors(t *testing.T, err error, expected ...error) {
@@ -150,6 +222,48 @@ func (s *spyTicketPersistence) SaveNewTicketForClient(client uuid.UUID, tck tick
 	return nil
 }
 
+func (s *spyTicketPersistence) UpdateTicket(tck ticket.Ticket) error {
+	if s.calls == nil {
+		s.calls = make(map[method][]argument)
+	}
+	s.calls["UpdateTicket"] = []argument{tck}
+	return nil
+}
+
+func (s *spyTicketPersistence) GetAllTickets() ([]ticket.Ticket, error) {
+	if s.calls == nil {
+		s.calls = make(map[method][]argument)
+	}
+	s.calls["GetAllTickets"] = []argument{}
+	return nil, nil
+}
+
+func (s *spyTicketPersistence) QueryTickets(query ticket.Query) (ticket.Page, error) {
+	if s.calls == nil {
+		s.calls = make(map[method][]argument)
+	}
+	s.calls["QueryTickets"] = []argument{query}
+	return ticket.Page{}, nil
+}
+
+func (s *spyTicketPersistence) CountTickets(query ticket.Query) (int, error) {
+	if s.calls == nil {
+		s.calls = make(map[method][]argument)
+	}
+	s.calls["CountTickets"] = []argument{query}
+	return 0, nil
+}
+
+func (s *spyTicketPersistence) assertTicketWasUpdated(t *testing.T, tck ticket.Ticket) {
+	t.Helper()
+	if s.calls["UpdateTicket"] == nil {
+		t.Fatal("UpdateTicket was not called")
+	}
+	if s.calls["UpdateTicket"][0].(ticket.Ticket) != tck {
+		t.Error("UpdateTicket was called with the wrong ticket")
+	}
+}
+
 func (s *spyTicketPersistence) assertNewTicketWasSaved(t *testing.T, client uuid.UUID, tck ticket.Ticket) {
 	t.Helper()
 	if s.calls["SaveNewTicketForClient"] == nil {
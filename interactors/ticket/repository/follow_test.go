@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"testing"
+	"ticketTao/entities/sla"
+	"ticketTao/entities/ticket"
+)
+
+func TestBasicClientTicketRepository_FollowTicket(t *testing.T) {
+	t.Parallel()
+	organization := uuid.New()
+	owner, colleague, outsider := uuid.New(), uuid.New(), uuid.New()
+	organizations := fakeOrganizations{owner: organization, colleague: organization, outsider: uuid.New()}
+	setup := func(t *testing.T) (ticket.RepositoryClientAccess, ticket.Ticket) {
+		t.Helper()
+		repo, err := GetClientTicketRepositoryWithOrganizations(newSnapshotTicketPersistence(), organizations)
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		tck, _ := ticket.NewBasicTicket("title", "description")
+		_ = repo.CreateNewTicketForClient(owner, tck)
+		return repo, tck
+	}
+
+	t.Run("It should need an organization resolver", func(t *testing.T) {
+		t.Parallel()
+		_, err := GetClientTicketRepositoryWithOrganizations(newSnapshotTicketPersistence(), nil)
+		assertErrors(t, err, GetClientTicketRepositoryError, sla.ErrNilOrganizationResolver)
+	})
+	t.Run("A client of the same organization can follow and read the ticket, but not change it", func(t *testing.T) {
+		t.Parallel()
+		repo, tck := setup(t)
+
+		if err := repo.FollowTicket(colleague, tck.ID()); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+
+		followed, err := repo.GetTicket(colleague, tck.ID())
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if !followed.IsFollowedBy(colleague) {
+			t.Error("Expected the client to follow the ticket")
+		}
+		err = repo.UpdateTicketForClient(colleague, followed)
+		assertErrors(t, err, UpdateTicketError, ErrTicketNotAccessible)
+	})
+	t.Run("A client stops reading the ticket after unfollowing it", func(t *testing.T) {
+		t.Parallel()
+		repo, tck := setup(t)
+		_ = repo.FollowTicket(colleague, tck.ID())
+
+		if err := repo.UnfollowTicket(colleague, tck.ID()); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+
+		_, err := repo.GetTicket(colleague, tck.ID())
+		assertErrors(t, err, GetTicketError, ErrTicketNotAccessible)
+	})
+	t.Run("Only the clients of the owner's organization can follow the ticket", func(t *testing.T) {
+		t.Parallel()
+		repo, tck := setup(t)
+
+		assertErrors(t, repo.FollowTicket(outsider, tck.ID()), FollowTicketError, ErrTicketNotAccessible)
+		assertErrors(t, repo.FollowTicket(owner, tck.ID()), FollowTicketError, ErrOwnerCannotFollow)
+		withoutOrganizations, _ := GetClientTicketRepository(newSnapshotTicketPersistence())
+		_ = withoutOrganizations.CreateNewTicketForClient(owner, tck)
+		assertErrors(t, withoutOrganizations.FollowTicket(colleague, tck.ID()), FollowTicketError, ErrTicketNotAccessible)
+	})
+}
+
+type fakeOrganizations map[uuid.UUID]uuid.UUID
+
+func (f fakeOrganizations) GetClientOrganization(client uuid.UUID) (uuid.UUID, error) {
+	return f[client], nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"sync"
+	"ticketTao/entities/ticket"
+	"ticketTao/interactors/ticket/events"
+	"time"
+)
+
+// NewPublishingPersistence decorates a persistence driver so that ticket lifecycle events are published after every
+// successful write. Both the client and the agent repositories can use the decorated driver. The owners of the
+// tickets can only be changed if the decorated driver is a TransferablePersistence, no event is published for them.
+func NewPublishingPersistence(tp TicketPersistence, publisher events.Publisher) (TransferablePersistence, error) {
+	if tp == nil {
+		return nil, errors.Join(NewPublishingPersistenceError, NilPersistenceDriverError)
+	}
+	if publisher == nil {
+		return nil, errors.Join(NewPublishingPersistenceError, ErrNilPublisher)
+	}
+	return publishingPersistence{TicketPersistence: tp, publisher: publisher, updates: &sync.Mutex{}}, nil
+}
+
+type publishingPersistence struct {
+	TicketPersistence
+	publisher events.Publisher
+	// updates serializes the updates, so two concurrent updates cannot both publish the same changes.
+	updates *sync.Mutex
+}
+
+func (p publishingPersistence) SaveNewTicketForClient(client uuid.UUID, tck ticket.Ticket) error {
+	err := p.TicketPersistence.SaveNewTicketForClient(client, tck)
+	if err != nil {
+		return err
+	}
+	p.publisher.Publish(events.TicketCreated{Ticket: ticket.Snapshot(tck), Client: client, At: time.Now()})
+	return nil
+}
+
+// UpdateTicket compares the ticket with its persisted version to find out which events should be published, so the
+// persistence driver must not return the same instance that is being updated. The events carry a snapshot of the
+// updated ticket and are published once the update is done, outside the lock that serializes the updates.
+func (p publishingPersistence) UpdateTicket(tck ticket.Ticket) error {
+	if tck == nil {
+		return ticket.ErrNilTicket
+	}
+	published, err := p.update(tck)
+	if err != nil {
+		return err
+	}
+	for _, e := range published {
+		p.publisher.Publish(e)
+	}
+	return nil
+}
+
+func (p publishingPersistence) SetTicketOwner(ticketId, client uuid.UUID) error {
+	return setTicketOwner(p.TicketPersistence, ticketId, client)
+}
+
+// update persists the ticket and returns the events of the changes it made.
+func (p publishingPersistence) update(tck ticket.Ticket) ([]events.Event, error) {
+	p.updates.Lock()
+	defer p.updates.Unlock()
+	previous, err := p.TicketPersistence.GetTicket(tck.ID())
+	if err != nil {
+		return nil, err
+	}
+	previousStatus := previous.Status()
+	previousResponses := len(previous.Responses())
+
+	err = p.TicketPersistence.UpdateTicket(tck)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	snapshot := ticket.Snapshot(tck)
+	var published []events.Event
+	responses := snapshot.Responses()
+	for i := previousResponses; i < len(responses); i++ {
+		published = append(published, events.ResponseAdded{Ticket: snapshot, Response: responses[i], At: now})
+	}
+	if snapshot.Status() != previousStatus {
+		published = append(published, events.StatusChanged{Ticket: snapshot, From: previousStatus, To: snapshot.Status(), At: now})
+		if snapshot.Status() == ticket.Closed {
+			published = append(published, events.TicketClosed{Ticket: snapshot, At: now})
+		}
+	}
+	return published, nil
+}
+
+var NewPublishingPersistenceError error = errors.New("error creating publishing persistence driver")
+
+var ErrNilPublisher error = errors.New("event publisher cannot be nil")
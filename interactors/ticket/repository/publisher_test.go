@@ -0,0 +1,275 @@
+package repository
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"sync"
+	"testing"
+	"ticketTao/entities/ticket"
+	"ticketTao/interactors/ticket/events"
+)
+
+func TestNewPublishingPersistence(t *testing.T) {
+	t.Parallel()
+	t.Run("It should return an error when the persistence driver is nil", func(t *testing.T) {
+		_, err := NewPublishingPersistence(nil, &spyPublisher{})
+		assertErrors(t, err, NewPublishingPersistenceError, NilPersistenceDriverError)
+	})
+	t.Run("It should return an error when the publisher is nil", func(t *testing.T) {
+		_, err := NewPublishingPersistence(&spyTicketPersistence{}, nil)
+		assertErrors(t, err, NewPublishingPersistenceError, ErrNilPublisher)
+	})
+	t.Run("It should only change the owners through a driver that supports it", func(t *testing.T) {
+		tp, _ := NewPublishingPersistence(&spyTicketPersistence{}, &spyPublisher{})
+		assertErrors(t, tp.SetTicketOwner(uuid.New(), uuid.New()), ErrOwnerChangeNotSupported)
+	})
+}
+
+func TestPublishingPersistence(t *testing.T) {
+	t.Parallel()
+	t.Run("It should publish a TicketCreated event after saving a new ticket", func(t *testing.T) {
+		publisher := &spyPublisher{}
+		tp, _ := NewPublishingPersistence(newSnapshotTicketPersistence(), publisher)
+		clientRepo, _ := GetClientTicketRepository(tp)
+		clientId := uuid.New()
+		tck, _ := ticket.NewBasicTicket("title", "description")
+
+		err := clientRepo.CreateNewTicketForClient(clientId, tck)
+		if err != nil {
+			t.Fatalf("Error should be nil, but is %s", err.Error())
+		}
+
+		publisher.assertPublished(t, events.TicketCreatedName)
+		created := publisher.published[0].(events.TicketCreated)
+		if created.Client != clientId || created.TicketID() != tck.ID() {
+			t.Error("TicketCreated should reference the client and the ticket")
+		}
+	})
+	t.Run("It should publish ResponseAdded and StatusChanged events after a client comment", func(t *testing.T) {
+		publisher := &spyPublisher{}
+		tp, _ := NewPublishingPersistence(newSnapshotTicketPersistence(), publisher)
+		clientRepo, _ := GetClientTicketRepository(tp)
+		clientId := uuid.New()
+		tck, _ := ticket.NewBasicTicket("title", "description")
+		_ = clientRepo.CreateNewTicketForClient(clientId, tck)
+		publisher.reset()
+
+		response := ticket.NewResponse(clientId, "a comment")
+		tck.AddResponse(response)
+		err := clientRepo.UpdateTicketForClient(clientId, tck)
+		if err != nil {
+			t.Fatalf("Error should be nil, but is %s", err.Error())
+		}
+
+		publisher.assertPublished(t, events.ResponseAddedName, events.StatusChangedName)
+		if publisher.published[0].(events.ResponseAdded).Response != response {
+			t.Error("ResponseAdded should carry the new response")
+		}
+		changed := publisher.published[1].(events.StatusChanged)
+		if changed.From != ticket.Open || changed.To != ticket.InProgress {
+			t.Errorf("StatusChanged should go from Open to InProgress, got %s to %s", changed.From, changed.To)
+		}
+	})
+	t.Run("It should publish StatusChanged and TicketClosed events after an agent closes a ticket", func(t *testing.T) {
+		publisher := &spyPublisher{}
+		tp, _ := NewPublishingPersistence(newSnapshotTicketPersistence(), publisher)
+		clientRepo, _ := GetClientTicketRepository(tp)
+		agentRepo, _ := GetAgentTicketRepository(tp)
+		tck, _ := ticket.NewBasicTicket("title", "description")
+		_ = clientRepo.CreateNewTicketForClient(uuid.New(), tck)
+		publisher.reset()
+
+		tck.Close()
+		err := agentRepo.UpdateTicket(tck)
+		if err != nil {
+			t.Fatalf("Error should be nil, but is %s", err.Error())
+		}
+
+		publisher.assertPublished(t, events.StatusChangedName, events.TicketClosedName)
+	})
+	t.Run("It should not publish events when the persistence fails", func(t *testing.T) {
+		publisher := &spyPublisher{}
+		tp, _ := NewPublishingPersistence(newSnapshotTicketPersistence(), publisher)
+		agentRepo, _ := GetAgentTicketRepository(tp)
+		tck, _ := ticket.NewBasicTicket("title", "description")
+		tck.Close()
+
+		err := agentRepo.UpdateTicket(tck)
+		assertError(t, err, errSnapshotNotFound)
+
+		publisher.assertPublished(t)
+	})
+	t.Run("The events should carry a snapshot of the ticket that later changes do not modify", func(t *testing.T) {
+		publisher := &spyPublisher{}
+		tp, _ := NewPublishingPersistence(newSnapshotTicketPersistence(), publisher)
+		clientRepo, _ := GetClientTicketRepository(tp)
+		clientId := uuid.New()
+		tck, _ := ticket.NewBasicTicket("title", "description")
+		_ = clientRepo.CreateNewTicketForClient(clientId, tck)
+		publisher.reset()
+
+		tck.AddResponse(ticket.NewResponse(clientId, "a comment"))
+		_ = clientRepo.UpdateTicketForClient(clientId, tck)
+		tck.Close()
+
+		added := publisher.published[0].(events.ResponseAdded)
+		if added.Ticket.Status() != ticket.InProgress {
+			t.Errorf("The published ticket should still be %s, got %s", ticket.InProgress, added.Ticket.Status())
+		}
+	})
+	t.Run("Concurrent updates with the same change should publish its events once", func(t *testing.T) {
+		publisher := &spyPublisher{}
+		tp, _ := NewPublishingPersistence(newSnapshotTicketPersistence(), publisher)
+		clientRepo, _ := GetClientTicketRepository(tp)
+		clientId := uuid.New()
+		tck, _ := ticket.NewBasicTicket("title", "description")
+		_ = clientRepo.CreateNewTicketForClient(clientId, tck)
+		publisher.reset()
+		tck.AddResponse(ticket.NewResponse(clientId, "a comment"))
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(tck ticket.Ticket) {
+				defer wg.Done()
+				_ = clientRepo.UpdateTicketForClient(clientId, tck)
+			}(snapshot(tck))
+		}
+		wg.Wait()
+
+		publisher.assertPublished(t, events.ResponseAddedName, events.StatusChangedName)
+	})
+}
+
+type spyPublisher struct {
+	mu        sync.Mutex
+	published []events.Event
+}
+
+func (s *spyPublisher) Publish(e events.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.published = append(s.published, e)
+}
+
+func (s *spyPublisher) reset() {
+	s.published = nil
+}
+
+func (s *spyPublisher) assertPublished(t *testing.T, names ...events.Name) {
+	t.Helper()
+	if len(s.published) != len(names) {
+		t.Fatalf("Expected %d events to be published, got %d", len(names), len(s.published))
+	}
+	for i, name := range names {
+		if s.published[i].Name() != name {
+			t.Errorf("Expected event %d to be %s, got %s", i, name, s.published[i].Name())
+		}
+	}
+}
+
+// snapshotTicketPersistence stores copies of the tickets, like a real persistence driver would, so the persisted
+// version of a ticket does not change when the caller modifies its instance.
+type snapshotTicketPersistence struct {
+	mu      sync.Mutex
+	tickets map[uuid.UUID]ticket.Ticket
+	owners  map[uuid.UUID]uuid.UUID
+	order   []uuid.UUID
+}
+
+func newSnapshotTicketPersistence() *snapshotTicketPersistence {
+	return &snapshotTicketPersistence{
+		tickets: make(map[uuid.UUID]ticket.Ticket),
+		owners:  make(map[uuid.UUID]uuid.UUID),
+	}
+}
+
+func (s *snapshotTicketPersistence) SaveNewTicketForClient(client uuid.UUID, tck ticket.Ticket) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tickets[tck.ID()] = snapshot(tck)
+	s.owners[tck.ID()] = client
+	s.order = append(s.order, tck.ID())
+	return nil
+}
+
+func (s *snapshotTicketPersistence) GetAllTickets() ([]ticket.Ticket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var tickets []ticket.Ticket
+	for _, id := range s.order {
+		tickets = append(tickets, snapshot(s.tickets[id]))
+	}
+	return tickets, nil
+}
+
+func (s *snapshotTicketPersistence) QueryTickets(query ticket.Query) (ticket.Page, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var matching []ticket.Ticket
+	for _, id := range s.order {
+		if query.Matches(s.tickets[id], s.owners[id]) {
+			matching = append(matching, snapshot(s.tickets[id]))
+		}
+	}
+	return query.Paginate(matching)
+}
+
+func (s *snapshotTicketPersistence) CountTickets(query ticket.Query) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := 0
+	for _, id := range s.order {
+		if query.Matches(s.tickets[id], s.owners[id]) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *snapshotTicketPersistence) SetTicketOwner(tck, client uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.owners[tck]; !ok {
+		return errSnapshotNotFound
+	}
+	s.owners[tck] = client
+	return nil
+}
+
+func (s *snapshotTicketPersistence) GetTicketOwner(tck uuid.UUID) (uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	owner, ok := s.owners[tck]
+	if !ok {
+		return uuid.Nil, errSnapshotNotFound
+	}
+	return owner, nil
+}
+
+func (s *snapshotTicketPersistence) GetTicket(id uuid.UUID) (ticket.Ticket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tck, ok := s.tickets[id]
+	if !ok {
+		return nil, errSnapshotNotFound
+	}
+	return snapshot(tck), nil
+}
+
+func (s *snapshotTicketPersistence) UpdateTicket(tck ticket.Ticket) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tickets[tck.ID()]; !ok {
+		return errSnapshotNotFound
+	}
+	s.tickets[tck.ID()] = snapshot(tck)
+	return nil
+}
+
+func snapshot(tck ticket.Ticket) ticket.Ticket {
+	copied, _ := ticket.MakeBasicTicket(tck.ID(), tck.CreatedAt(), ticket.DataFrom(tck))
+	return copied
+}
+
+var errSnapshotNotFound = errors.New("ticket not found")
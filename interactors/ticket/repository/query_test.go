@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"testing"
+	"ticketTao/entities/ticket"
+	"time"
+)
+
+func TestTicketRepositories_Query(t *testing.T) {
+	t.Parallel()
+	persistence := newSnapshotTicketPersistence()
+	clientRepo, _ := GetClientTicketRepository(persistence)
+	agentRepo, _ := GetAgentTicketRepository(persistence)
+	client := uuid.New()
+	start := time.Now().Add(-time.Hour)
+	var clientTickets []ticket.Ticket
+	for i := range 5 {
+		clientTickets = append(clientTickets, saveTicketCreatedAt(t, persistence, client, start.Add(time.Duration(i)*time.Minute)))
+	}
+	saveTicketCreatedAt(t, persistence, uuid.New(), start)
+
+	t.Run("Clients only query their own tickets, whatever the query says", func(t *testing.T) {
+		page, err := clientRepo.QueryClientTickets(client, ticket.Query{Client: uuid.New(), Sort: ticket.NewestFirst, Limit: 2})
+		if err != nil {
+			t.Fatalf("Error should be nil, but is %s", err.Error())
+		}
+		if len(page.Tickets) != 2 || page.Tickets[0].ID() != clientTickets[4].ID() || page.Next == "" {
+			t.Errorf("Expected the 2 newest tickets of the client and a cursor, got %v", page)
+		}
+		_, err = clientRepo.QueryClientTickets(uuid.Nil, ticket.Query{})
+		assertErrors(t, err, QueryTicketsError, ErrNilClientID)
+	})
+	t.Run("Clients get all their tickets and their count through every page", func(t *testing.T) {
+		tickets, err := clientRepo.GetAllClientTickets(client)
+		if err != nil || len(tickets) != len(clientTickets) {
+			t.Errorf("Expected %d tickets, got %d, %v", len(clientTickets), len(tickets), err)
+		}
+		count, err := clientRepo.GetClientTicketCount(client)
+		if err != nil || count != len(clientTickets) {
+			t.Errorf("Expected %d tickets, got %d, %v", len(clientTickets), count, err)
+		}
+		_, err = clientRepo.GetClientTicketCount(uuid.Nil)
+		assertErrors(t, err, GetClientTicketCountError, ErrNilClientID)
+	})
+	t.Run("Agents query the tickets of every client", func(t *testing.T) {
+		page, err := agentRepo.QueryTickets(ticket.Query{CreatedTo: start.Add(time.Second)})
+		if err != nil {
+			t.Fatalf("Error should be nil, but is %s", err.Error())
+		}
+		if len(page.Tickets) != 2 {
+			t.Errorf("Expected the oldest ticket of both clients, got %v", page.Tickets)
+		}
+	})
+	t.Run("Tickets are filtered and counted by tag", func(t *testing.T) {
+		tagged := clientTickets[0]
+		tagged.AddTag("billing")
+		tagged.AddTag("vip")
+		_ = agentRepo.UpdateTicket(tagged)
+		clientTickets[1].AddTag("billing")
+		_ = agentRepo.UpdateTicket(clientTickets[1])
+
+		page, err := clientRepo.QueryClientTickets(client, ticket.Query{Tags: []string{"billing", "vip"}})
+		if err != nil || len(page.Tickets) != 1 || page.Tickets[0].ID() != tagged.ID() {
+			t.Errorf("Expected only the ticket with both tags, got %v, %v", page.Tickets, err)
+		}
+		counts, err := agentRepo.CountTicketsByTag(ticket.Query{Limit: 1})
+		if err != nil {
+			t.Fatalf("Error should be nil, but is %s", err.Error())
+		}
+		if len(counts) != 2 || counts["billing"] != 2 || counts["vip"] != 1 {
+			t.Errorf("Expected 2 billing and 1 vip tickets, got %v", counts)
+		}
+		counts, _ = clientRepo.CountClientTicketsByTag(client, ticket.Query{Tags: []string{"vip"}})
+		if len(counts) != 2 || counts["billing"] != 1 {
+			t.Errorf("Expected only the vip ticket to be counted, got %v", counts)
+		}
+	})
+	t.Run("Invalid queries are rejected", func(t *testing.T) {
+		_, err := agentRepo.QueryTickets(ticket.Query{Limit: -1})
+		assertErrors(t, err, QueryTicketsError, ticket.ErrInvalidPageSize)
+	})
+}
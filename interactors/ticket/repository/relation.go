@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"sync"
+	"ticketTao/entities/ticket"
+)
+
+// RelationPersistence is an interface that defines the methods that a ticket relation persistence driver should
+// implement. The links it receives are canonical (see ticket.Link.Canonical).
+type RelationPersistence interface {
+	// SaveLink stores a link, it should return an error if the link already exists.
+	SaveLink(link ticket.Link) error
+	// DeleteLink removes a link, it should return an error if the link does not exist.
+	DeleteLink(link ticket.Link) error
+	// GetLinks returns the links from or to the ticket.
+	GetLinks(ticket uuid.UUID) ([]ticket.Link, error)
+}
+
+// GetRelationRepository returns a new instance of ticket.RepositoryRelations, the ticket persistence is used to check
+// that the linked tickets exist. New links are checked against the existing ones and saved under a lock, so all the
+// links of a relation persistence should be made through the same repository.
+func GetRelationRepository(tp TicketPersistence, rp RelationPersistence) (ticket.RepositoryRelations, error) {
+	if tp == nil {
+		return nil, errors.Join(GetRelationRepositoryError, NilPersistenceDriverError)
+	}
+	if rp == nil {
+		return nil, errors.Join(GetRelationRepositoryError, ErrNilRelationPersistence)
+	}
+	return basicRelationRepository{tickets: tp, relations: rp, links: &sync.Mutex{}}, nil
+}
+
+type basicRelationRepository struct {
+	tickets   TicketPersistence
+	relations RelationPersistence
+	// links serializes the new links, so two concurrent links cannot both pass the checks against the existing ones.
+	links *sync.Mutex
+}
+
+func (b basicRelationRepository) LinkTickets(link ticket.Link) error {
+	if err := b.validateLink(link); err != nil {
+		return errors.Join(LinkTicketsError, err)
+	}
+	link = link.Canonical()
+	b.links.Lock()
+	defer b.links.Unlock()
+	if err := b.validateNewLink(link); err != nil {
+		return errors.Join(LinkTicketsError, err)
+	}
+	if err := b.relations.SaveLink(link); err != nil {
+		return errors.Join(LinkTicketsError, err)
+	}
+	return nil
+}
+
+func (b basicRelationRepository) validateLink(link ticket.Link) error {
+	if err := link.Kind.Validate(); err != nil {
+		return err
+	}
+	if link.From == uuid.Nil || link.To == uuid.Nil {
+		return ErrNilTicketID
+	}
+	if link.From == link.To {
+		return ErrSelfLink
+	}
+	for _, id := range []uuid.UUID{link.From, link.To} {
+		if _, err := b.tickets.GetTicket(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateNewLink checks the canonical link against the existing ones.
+func (b basicRelationRepository) validateNewLink(link ticket.Link) error {
+	fromLinks, err := b.relations.GetLinks(link.From)
+	if err != nil {
+		return err
+	}
+	for _, existing := range fromLinks {
+		if existing == link {
+			return ErrLinkExists
+		}
+		if link.Kind == ticket.DuplicateOf && existing.Kind == ticket.DuplicateOf && existing.From == link.From {
+			return ErrAlreadyDuplicate
+		}
+	}
+	if link.Kind == ticket.ParentOf {
+		toLinks, err := b.relations.GetLinks(link.To)
+		if err != nil {
+			return err
+		}
+		for _, existing := range toLinks {
+			if existing.Kind == ticket.ParentOf && existing.To == link.To {
+				return ErrAlreadyHasParent
+			}
+		}
+	}
+	if link.Kind.Acyclic() {
+		reaches, err := b.reaches(link.To, link.From, link.Kind)
+		if err != nil {
+			return err
+		}
+		if reaches {
+			return ErrRelationCycle
+		}
+	}
+	return nil
+}
+
+// reaches tells if there is a path of links of the kind from the ticket to the target.
+func (b basicRelationRepository) reaches(from, target uuid.UUID, kind ticket.RelationKind) (bool, error) {
+	visited := map[uuid.UUID]bool{from: true}
+	pending := []uuid.UUID{from}
+	for len(pending) > 0 {
+		current := pending[0]
+		pending = pending[1:]
+		if current == target {
+			return true, nil
+		}
+		links, err := b.relations.GetLinks(current)
+		if err != nil {
+			return false, err
+		}
+		for _, link := range links {
+			if link.Kind == kind && link.From == current && !visited[link.To] {
+				visited[link.To] = true
+				pending = append(pending, link.To)
+			}
+		}
+	}
+	return false, nil
+}
+
+func (b basicRelationRepository) UnlinkTickets(link ticket.Link) error {
+	if err := link.Kind.Validate(); err != nil {
+		return errors.Join(UnlinkTicketsError, err)
+	}
+	if err := b.relations.DeleteLink(link.Canonical()); err != nil {
+		return errors.Join(UnlinkTicketsError, err)
+	}
+	return nil
+}
+
+func (b basicRelationRepository) GetRelations(id uuid.UUID) ([]ticket.Relation, error) {
+	links, err := b.relations.GetLinks(id)
+	if err != nil {
+		return nil, errors.Join(GetRelationsError, err)
+	}
+	relations := make([]ticket.Relation, 0, len(links))
+	for _, link := range links {
+		relations = append(relations, link.RelationFrom(id))
+	}
+	return relations, nil
+}
+
+func (b basicRelationRepository) GetRelationGraph(id uuid.UUID, depth int) (ticket.RelationGraph, error) {
+	if depth < 0 {
+		return ticket.RelationGraph{}, errors.Join(GetRelationsError, fmt.Errorf("%w: %d", ErrInvalidDepth, depth))
+	}
+	graph := ticket.RelationGraph{Root: id, Tickets: []uuid.UUID{id}}
+	distances := map[uuid.UUID]int{id: 0}
+	seenLinks := make(map[ticket.Link]bool)
+	for i := 0; i < len(graph.Tickets); i++ {
+		current := graph.Tickets[i]
+		if depth > 0 && distances[current] == depth {
+			continue
+		}
+		links, err := b.relations.GetLinks(current)
+		if err != nil {
+			return ticket.RelationGraph{}, errors.Join(GetRelationsError, err)
+		}
+		for _, link := range links {
+			if !seenLinks[link] {
+				seenLinks[link] = true
+				graph.Links = append(graph.Links, link)
+			}
+			other := link.RelationFrom(current).Ticket
+			if _, seen := distances[other]; !seen {
+				distances[other] = distances[current] + 1
+				graph.Tickets = append(graph.Tickets, other)
+			}
+		}
+	}
+	return graph, nil
+}
+
+// NewCascadingClosePersistence decorates a persistence driver so that closing a ticket also closes its open
+// children, and their children, as Resolved. The children are closed before the ticket is updated, so an error means
+// that the ticket was not updated, and updating it again closes the children left open. The owner changes are passed on
+// to the decorated driver.
+func NewCascadingClosePersistence(tp TicketPersistence, rp RelationPersistence) (TransferablePersistence, error) {
+	if tp == nil {
+		return nil, errors.Join(NewCascadingClosePersistenceError, NilPersistenceDriverError)
+	}
+	if rp == nil {
+		return nil, errors.Join(NewCascadingClosePersistenceError, ErrNilRelationPersistence)
+	}
+	return cascadingClosePersistence{tp, rp}, nil
+}
+
+type cascadingClosePersistence struct {
+	TicketPersistence
+	relations RelationPersistence
+}
+
+func (c cascadingClosePersistence) UpdateTicket(tck ticket.Ticket) error {
+	if tck == nil || tck.Status() != ticket.Closed {
+		return c.TicketPersistence.UpdateTicket(tck)
+	}
+	previous, err := c.TicketPersistence.GetTicket(tck.ID())
+	if err != nil {
+		return err
+	}
+	if previous.Status() != ticket.Closed {
+		if err := c.closeChildren(tck.ID()); err != nil {
+			return err
+		}
+	}
+	return c.TicketPersistence.UpdateTicket(tck)
+}
+
+func (c cascadingClosePersistence) SetTicketOwner(ticketId, client uuid.UUID) error {
+	return setTicketOwner(c.TicketPersistence, ticketId, client)
+}
+
+func (c cascadingClosePersistence) closeChildren(parent uuid.UUID) error {
+	links, err := c.relations.GetLinks(parent)
+	if err != nil {
+		return errors.Join(ErrClosingChildren, err)
+	}
+	var failures []error
+	for _, link := range links {
+		if link.Kind != ticket.ParentOf || link.From != parent {
+			continue
+		}
+		child, err := c.TicketPersistence.GetTicket(link.To)
+		if err == nil && child.Status() != ticket.Closed {
+			child.Close()
+			err = c.UpdateTicket(child)
+		}
+		if err != nil {
+			failures = append(failures, fmt.Errorf("ticket %s: %w", link.To, err))
+		}
+	}
+	if len(failures) > 0 {
+		return errors.Join(ErrClosingChildren, errors.Join(failures...))
+	}
+	return nil
+}
+
+var GetRelationRepositoryError error = errors.New("error getting relation repository")
+var NewCascadingClosePersistenceError error = errors.New("error creating cascading close persistence driver")
+var LinkTicketsError error = errors.New("error linking tickets")
+var UnlinkTicketsError error = errors.New("error unlinking tickets")
+var GetRelationsError error = errors.New("error getting ticket relations")
+
+var ErrNilRelationPersistence error = errors.New("relation persistence driver cannot be nil")
+var ErrSelfLink error = errors.New("a ticket cannot be linked to itself")
+var ErrLinkExists error = errors.New("the tickets are already linked")
+var ErrAlreadyHasParent error = errors.New("the ticket already has a parent")
+var ErrAlreadyDuplicate error = errors.New("the ticket is already a duplicate of another ticket")
+var ErrRelationCycle error = errors.New("the link would make a cycle")
+var ErrInvalidDepth error = errors.New("relation graph depth cannot be negative")
+var ErrClosingChildren error = errors.New("error closing the children of the ticket")
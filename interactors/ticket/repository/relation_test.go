@@ -0,0 +1,303 @@
+package repository
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"slices"
+	"sync"
+	"testing"
+	"ticketTao/entities/ticket"
+)
+
+func TestGetRelationRepository(t *testing.T) {
+	t.Parallel()
+	t.Run("It should return an error when a persistence driver is nil", func(t *testing.T) {
+		_, err := GetRelationRepository(nil, newFakeRelationPersistence())
+		assertErrors(t, err, GetRelationRepositoryError, NilPersistenceDriverError)
+		_, err = GetRelationRepository(newSnapshotTicketPersistence(), nil)
+		assertErrors(t, err, GetRelationRepositoryError, ErrNilRelationPersistence)
+	})
+}
+
+func TestRelationRepository_LinkTickets(t *testing.T) {
+	t.Parallel()
+	t.Run("Links are stored canonically and seen from both tickets", func(t *testing.T) {
+		t.Parallel()
+		repo, tickets := newRelationRepository(t, 2)
+		outage, report := tickets[0], tickets[1]
+
+		if err := repo.LinkTickets(ticket.Link{From: report, Kind: ticket.ChildOf, To: outage}); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+
+		assertRelations(t, repo, outage, ticket.Relation{Kind: ticket.ParentOf, Ticket: report})
+		assertRelations(t, repo, report, ticket.Relation{Kind: ticket.ChildOf, Ticket: outage})
+		err := repo.LinkTickets(ticket.Link{From: outage, Kind: ticket.ParentOf, To: report})
+		assertErrors(t, err, LinkTicketsError, ErrLinkExists)
+	})
+	t.Run("Invalid links are rejected", func(t *testing.T) {
+		t.Parallel()
+		repo, tickets := newRelationRepository(t, 3)
+
+		err := repo.LinkTickets(ticket.Link{From: tickets[0], Kind: "caused_by", To: tickets[1]})
+		assertErrors(t, err, LinkTicketsError, ticket.ErrUnknownRelationKind)
+		err = repo.LinkTickets(ticket.Link{From: tickets[0], Kind: ticket.RelatedTo, To: tickets[0]})
+		assertErrors(t, err, LinkTicketsError, ErrSelfLink)
+		err = repo.LinkTickets(ticket.Link{From: tickets[0], Kind: ticket.RelatedTo, To: uuid.New()})
+		assertErrors(t, err, LinkTicketsError, errSnapshotNotFound)
+		_ = repo.LinkTickets(ticket.Link{From: tickets[0], Kind: ticket.RelatedTo, To: tickets[1]})
+		err = repo.LinkTickets(ticket.Link{From: tickets[1], Kind: ticket.RelatedTo, To: tickets[0]})
+		assertErrors(t, err, LinkTicketsError, ErrLinkExists)
+	})
+	t.Run("A ticket has only one parent and duplicates only one ticket", func(t *testing.T) {
+		t.Parallel()
+		repo, tickets := newRelationRepository(t, 3)
+
+		_ = repo.LinkTickets(ticket.Link{From: tickets[0], Kind: ticket.ParentOf, To: tickets[2]})
+		err := repo.LinkTickets(ticket.Link{From: tickets[1], Kind: ticket.ParentOf, To: tickets[2]})
+		assertErrors(t, err, LinkTicketsError, ErrAlreadyHasParent)
+		_ = repo.LinkTickets(ticket.Link{From: tickets[2], Kind: ticket.DuplicateOf, To: tickets[0]})
+		err = repo.LinkTickets(ticket.Link{From: tickets[2], Kind: ticket.DuplicateOf, To: tickets[1]})
+		assertErrors(t, err, LinkTicketsError, ErrAlreadyDuplicate)
+	})
+	t.Run("Links that would make a cycle are rejected", func(t *testing.T) {
+		t.Parallel()
+		repo, tickets := newRelationRepository(t, 3)
+
+		_ = repo.LinkTickets(ticket.Link{From: tickets[0], Kind: ticket.Blocks, To: tickets[1]})
+		_ = repo.LinkTickets(ticket.Link{From: tickets[1], Kind: ticket.Blocks, To: tickets[2]})
+		err := repo.LinkTickets(ticket.Link{From: tickets[0], Kind: ticket.BlockedBy, To: tickets[2]})
+		assertErrors(t, err, LinkTicketsError, ErrRelationCycle)
+		if err := repo.LinkTickets(ticket.Link{From: tickets[2], Kind: ticket.RelatedTo, To: tickets[0]}); err != nil {
+			t.Errorf("Related links can make cycles, got %v", err)
+		}
+	})
+}
+
+func TestRelationRepository_UnlinkTickets(t *testing.T) {
+	t.Parallel()
+	t.Run("A link can be removed through its inverse", func(t *testing.T) {
+		t.Parallel()
+		repo, tickets := newRelationRepository(t, 2)
+		_ = repo.LinkTickets(ticket.Link{From: tickets[0], Kind: ticket.Blocks, To: tickets[1]})
+
+		if err := repo.UnlinkTickets(ticket.Link{From: tickets[1], Kind: ticket.BlockedBy, To: tickets[0]}); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+
+		assertRelations(t, repo, tickets[0])
+		err := repo.UnlinkTickets(ticket.Link{From: tickets[0], Kind: ticket.Blocks, To: tickets[1]})
+		assertErrors(t, err, UnlinkTicketsError, errLinkNotFound)
+	})
+}
+
+func TestRelationRepository_GetRelationGraph(t *testing.T) {
+	t.Parallel()
+	repo, tickets := newRelationRepository(t, 5)
+	_ = repo.LinkTickets(ticket.Link{From: tickets[0], Kind: ticket.ParentOf, To: tickets[1]})
+	_ = repo.LinkTickets(ticket.Link{From: tickets[0], Kind: ticket.ParentOf, To: tickets[2]})
+	_ = repo.LinkTickets(ticket.Link{From: tickets[2], Kind: ticket.RelatedTo, To: tickets[3]})
+	t.Run("The graph contains every ticket reachable from the root", func(t *testing.T) {
+		t.Parallel()
+		graph, err := repo.GetRelationGraph(tickets[1], 0)
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if graph.Root != tickets[1] || len(graph.Tickets) != 4 || len(graph.Links) != 3 {
+			t.Errorf("Expected 4 tickets and 3 links, got %v", graph)
+		}
+		if slices.Contains(graph.Tickets, tickets[4]) {
+			t.Error("Unlinked tickets should not be in the graph")
+		}
+	})
+	t.Run("The graph can be limited to a depth", func(t *testing.T) {
+		t.Parallel()
+		graph, _ := repo.GetRelationGraph(tickets[1], 1)
+		if !slices.Equal(graph.Tickets, []uuid.UUID{tickets[1], tickets[0]}) || len(graph.Links) != 1 {
+			t.Errorf("Expected the ticket and its parent, got %v", graph)
+		}
+		_, err := repo.GetRelationGraph(tickets[1], -1)
+		assertErrors(t, err, GetRelationsError, ErrInvalidDepth)
+	})
+}
+
+func TestCascadingClosePersistence(t *testing.T) {
+	t.Parallel()
+	t.Run("It should return an error when a persistence driver is nil", func(t *testing.T) {
+		_, err := NewCascadingClosePersistence(nil, newFakeRelationPersistence())
+		assertErrors(t, err, NewCascadingClosePersistenceError, NilPersistenceDriverError)
+		_, err = NewCascadingClosePersistence(newSnapshotTicketPersistence(), nil)
+		assertErrors(t, err, NewCascadingClosePersistenceError, ErrNilRelationPersistence)
+	})
+	t.Run("It should only change the owners through a driver that supports it", func(t *testing.T) {
+		tp, _ := NewCascadingClosePersistence(&spyTicketPersistence{}, newFakeRelationPersistence())
+		assertErrors(t, tp.SetTicketOwner(uuid.New(), uuid.New()), ErrOwnerChangeNotSupported)
+	})
+	t.Run("Closing a parent closes its open descendants", func(t *testing.T) {
+		t.Parallel()
+		persistence := newSnapshotTicketPersistence()
+		relations := newFakeRelationPersistence()
+		repo, _ := GetRelationRepository(persistence, relations)
+		tickets := saveTickets(t, persistence, 4)
+		_ = repo.LinkTickets(ticket.Link{From: tickets[0], Kind: ticket.ParentOf, To: tickets[1]})
+		_ = repo.LinkTickets(ticket.Link{From: tickets[1], Kind: ticket.ParentOf, To: tickets[2]})
+		_ = repo.LinkTickets(ticket.Link{From: tickets[0], Kind: ticket.Blocks, To: tickets[3]})
+		tp, _ := NewCascadingClosePersistence(persistence, relations)
+		agentRepo, _ := GetAgentTicketRepository(tp)
+
+		parent, _ := agentRepo.GetTicket(tickets[0])
+		parent.Close()
+		if err := agentRepo.UpdateTicket(parent); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+
+		for _, id := range tickets[1:3] {
+			if tck, _ := persistence.GetTicket(id); tck.Status() != ticket.Closed {
+				t.Errorf("Expected descendant %s to be closed", id)
+			}
+		}
+		if tck, _ := persistence.GetTicket(tickets[3]); tck.Status() == ticket.Closed {
+			t.Error("Blocked tickets should not be closed")
+		}
+	})
+	t.Run("A parent whose children cannot be closed is not updated", func(t *testing.T) {
+		t.Parallel()
+		persistence := &failingUpdatePersistence{snapshotTicketPersistence: newSnapshotTicketPersistence()}
+		relations := newFakeRelationPersistence()
+		repo, _ := GetRelationRepository(persistence, relations)
+		tickets := saveTickets(t, persistence, 3)
+		_ = repo.LinkTickets(ticket.Link{From: tickets[0], Kind: ticket.ParentOf, To: tickets[1]})
+		_ = repo.LinkTickets(ticket.Link{From: tickets[0], Kind: ticket.ParentOf, To: tickets[2]})
+		tp, _ := NewCascadingClosePersistence(persistence, relations)
+		persistence.failing = tickets[2]
+
+		parent, _ := tp.GetTicket(tickets[0])
+		parent.Close()
+		assertErrors(t, tp.UpdateTicket(parent), ErrClosingChildren, errUpdateFailed)
+		if tck, _ := persistence.GetTicket(tickets[0]); tck.Status() == ticket.Closed {
+			t.Error("The parent should not be closed")
+		}
+
+		persistence.failing = uuid.Nil
+		if err := tp.UpdateTicket(parent); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		for _, id := range tickets {
+			if tck, _ := persistence.GetTicket(id); tck.Status() != ticket.Closed {
+				t.Errorf("Expected ticket %s to be closed", id)
+			}
+		}
+	})
+}
+
+func TestBasicRelationRepository_LinkTicketsConcurrently(t *testing.T) {
+	t.Parallel()
+	repo, tickets := newRelationRepository(t, 5)
+	child := tickets[0]
+	var wg sync.WaitGroup
+	linked := make(chan uuid.UUID, len(tickets))
+	for _, parent := range tickets[1:] {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if repo.LinkTickets(ticket.Link{From: parent, Kind: ticket.ParentOf, To: child}) == nil {
+				linked <- parent
+			}
+		}()
+	}
+	wg.Wait()
+	close(linked)
+	if len(linked) != 1 {
+		t.Errorf("Expected the child to get only one parent, got %d", len(linked))
+	}
+}
+
+func newRelationRepository(t *testing.T, n int) (ticket.RepositoryRelations, []uuid.UUID) {
+	t.Helper()
+	persistence := newSnapshotTicketPersistence()
+	repo, err := GetRelationRepository(persistence, newFakeRelationPersistence())
+	if err != nil {
+		t.Fatalf("Error creating repository: %v", err)
+	}
+	return repo, saveTickets(t, persistence, n)
+}
+
+func saveTickets(t *testing.T, persistence TicketPersistence, n int) []uuid.UUID {
+	t.Helper()
+	var ids []uuid.UUID
+	for range n {
+		tck, _ := ticket.NewBasicTicket("title", "description")
+		if err := persistence.SaveNewTicketForClient(uuid.New(), tck); err != nil {
+			t.Fatalf("Error saving ticket: %v", err)
+		}
+		ids = append(ids, tck.ID())
+	}
+	return ids
+}
+
+func assertRelations(t *testing.T, repo ticket.RepositoryRelations, id uuid.UUID, expected ...ticket.Relation) {
+	t.Helper()
+	relations, err := repo.GetRelations(id)
+	if err != nil {
+		t.Fatalf("Error should be nil, got %v", err)
+	}
+	if !slices.Equal(relations, expected) {
+		t.Errorf("Expected relations %v, got %v", expected, relations)
+	}
+}
+
+type fakeRelationPersistence struct {
+	mu    sync.Mutex
+	links []ticket.Link
+}
+
+func newFakeRelationPersistence() *fakeRelationPersistence {
+	return &fakeRelationPersistence{}
+}
+
+func (f *fakeRelationPersistence) SaveLink(link ticket.Link) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.links = append(f.links, link)
+	return nil
+}
+
+func (f *fakeRelationPersistence) DeleteLink(link ticket.Link) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := slices.Index(f.links, link)
+	if i < 0 {
+		return errLinkNotFound
+	}
+	f.links = slices.Delete(f.links, i, i+1)
+	return nil
+}
+
+func (f *fakeRelationPersistence) GetLinks(id uuid.UUID) ([]ticket.Link, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var links []ticket.Link
+	for _, link := range f.links {
+		if link.From == id || link.To == id {
+			links = append(links, link)
+		}
+	}
+	return links, nil
+}
+
+var errLinkNotFound = errors.New("link not found")
+
+// failingUpdatePersistence fails the updates of a ticket.
+type failingUpdatePersistence struct {
+	*snapshotTicketPersistence
+	failing uuid.UUID
+}
+
+func (f *failingUpdatePersistence) UpdateTicket(tck ticket.Ticket) error {
+	if tck.ID() == f.failing {
+		return errUpdateFailed
+	}
+	return f.snapshotTicketPersistence.UpdateTicket(tck)
+}
+
+var errUpdateFailed = errors.New("connection lost")
@@ -11,4 +11,64 @@ type TicketPersistence interface {
 	SaveNewTicketForClient(client uuid.UUID, tck ticket.Ticket) error
 	GetTicketOwner(ticket uuid.UUID) (client uuid.UUID, err error)
 	GetTicket(id uuid.UUID) (ticket.Ticket, error)
+	// UpdateTicket replaces a persisted ticket, it should return an error if the ticket does not exist.
+	UpdateTicket(tck ticket.Ticket) error
+	// GetAllTickets returns every ticket ordered by creation date, the oldest first.
+	GetAllTickets() ([]ticket.Ticket, error)
+	// QueryTickets returns a page of the tickets matching the query, it should return an error if the query is not
+	// valid. Drivers that cannot run the query natively can use ticket.Query.Matches and ticket.Query.Paginate.
+	QueryTickets(query ticket.Query) (ticket.Page, error)
+	// CountTickets returns how many tickets match the query, ignoring its pagination. It should return an error if the
+	// query is not valid.
+	CountTickets(query ticket.Query) (int, error)
+}
+
+// TransferablePersistence is a persistence driver that can also change the owner of the persisted tickets.
+type TransferablePersistence interface {
+	TicketPersistence
+	// SetTicketOwner gives a persisted ticket to another client, it should return an error if the ticket does not
+	// exist.
+	SetTicketOwner(ticket, client uuid.UUID) error
+}
+
+// setTicketOwner gives the ticket to the client through the persistence driver, the decorators use it to forward the
+// owner changes to the driver they decorate.
+func setTicketOwner(tp TicketPersistence, ticketId, client uuid.UUID) error {
+	transferable, ok := tp.(TransferablePersistence)
+	if !ok {
+		return ErrOwnerChangeNotSupported
+	}
+	return transferable.SetTicketOwner(ticketId, client)
+}
+
+// eachPage calls do with every page of the query, from its first one, following the cursors.
+func eachPage(query ticket.Query, fetch func(ticket.Query) (ticket.Page, error), do func(ticket.Page)) error {
+	query.Limit = ticket.MaxPageSize
+	query.After = ""
+	for {
+		page, err := fetch(query)
+		if err != nil {
+			return err
+		}
+		do(page)
+		if page.Next == "" {
+			return nil
+		}
+		query.After = page.Next
+	}
+}
+
+func countByTag(query ticket.Query, fetch func(ticket.Query) (ticket.Page, error)) (map[string]int, error) {
+	counts := make(map[string]int)
+	err := eachPage(query, fetch, func(page ticket.Page) {
+		for _, tck := range page.Tickets {
+			for _, tag := range tck.Tags() {
+				counts[tag]++
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
 }
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"ticketTao/entities/ticket"
+	"ticketTao/interactors/search"
+)
+
+// NewIndexingPersistence decorates a persistence driver so that the search index is updated after every successful
+// write. Both the client and the agent repositories can use the decorated driver. The owners of the tickets can only
+// be changed if the decorated driver is a TransferablePersistence.
+func NewIndexingPersistence(tp TicketPersistence, index search.Index) (TransferablePersistence, error) {
+	if tp == nil {
+		return nil, errors.Join(NewIndexingPersistenceError, NilPersistenceDriverError)
+	}
+	if index == nil {
+		return nil, errors.Join(NewIndexingPersistenceError, ErrNilSearchIndex)
+	}
+	return indexingPersistence{tp, index}, nil
+}
+
+type indexingPersistence struct {
+	TicketPersistence
+	index search.Index
+}
+
+func (i indexingPersistence) SaveNewTicketForClient(client uuid.UUID, tck ticket.Ticket) error {
+	err := i.TicketPersistence.SaveNewTicketForClient(client, tck)
+	if err != nil {
+		return err
+	}
+	i.index.Index(tck, client)
+	return nil
+}
+
+func (i indexingPersistence) UpdateTicket(tck ticket.Ticket) error {
+	err := i.TicketPersistence.UpdateTicket(tck)
+	if err != nil {
+		return err
+	}
+	owner, err := i.TicketPersistence.GetTicketOwner(tck.ID())
+	if err != nil {
+		return err
+	}
+	i.index.Index(tck, owner)
+	return nil
+}
+
+// SetTicketOwner gives the ticket to the client and indexes it again, so that it is found in the searches of the
+// client.
+func (i indexingPersistence) SetTicketOwner(ticketId, client uuid.UUID) error {
+	if err := setTicketOwner(i.TicketPersistence, ticketId, client); err != nil {
+		return err
+	}
+	tck, err := i.TicketPersistence.GetTicket(ticketId)
+	if err != nil {
+		return err
+	}
+	i.index.Index(tck, client)
+	return nil
+}
+
+// SearchAgentTicketRepository is an agent ticket repository that can also search the tickets by their text.
+type SearchAgentTicketRepository interface {
+	AgentTicketRepository
+	ticket.RepositoryAgentSearcher
+}
+
+// SearchClientTicketRepository is a client ticket repository that can also search the client's tickets by their text.
+type SearchClientTicketRepository interface {
+	ticket.RepositoryClientAccess
+	ticket.RepositoryClientSearcher
+}
+
+// GetSearchAgentTicketRepository returns a new instance of SearchAgentTicketRepository that searches the index. The
+// index should be kept up to date by a persistence driver decorated with NewIndexingPersistence.
+func GetSearchAgentTicketRepository(tp TicketPersistence, index search.Index) (SearchAgentTicketRepository, error) {
+	if tp == nil {
+		return nil, errors.Join(GetAgentTicketRepositoryError, NilPersistenceDriverError)
+	}
+	if index == nil {
+		return nil, errors.Join(GetAgentTicketRepositoryError, ErrNilSearchIndex)
+	}
+	return searchAgentTicketRepository{basicAgentTicketRepository{tp}, index}, nil
+}
+
+// GetSearchClientTicketRepository returns a new instance of SearchClientTicketRepository that searches the index. The
+// index should be kept up to date by a persistence driver decorated with NewIndexingPersistence.
+func GetSearchClientTicketRepository(tp TicketPersistence, index search.Index) (SearchClientTicketRepository, error) {
+	if tp == nil {
+		return nil, errors.Join(GetClientTicketRepositoryError, NilPersistenceDriverError)
+	}
+	if index == nil {
+		return nil, errors.Join(GetClientTicketRepositoryError, ErrNilSearchIndex)
+	}
+	return searchClientTicketRepository{basicClientTicketRepository{persistence: tp}, index}, nil
+}
+
+type searchAgentTicketRepository struct {
+	basicAgentTicketRepository
+	index search.Index
+}
+
+func (s searchAgentTicketRepository) SearchTickets(query string, limit int) ([]ticket.Ticket, error) {
+	return searchTickets(s.persistence, s.index, search.Query{Text: query, Limit: limit})
+}
+
+type searchClientTicketRepository struct {
+	basicClientTicketRepository
+	index search.Index
+}
+
+func (s searchClientTicketRepository) SearchClientTickets(client uuid.UUID, query string, limit int) ([]ticket.Ticket, error) {
+	if client == uuid.Nil {
+		return nil, errors.Join(SearchTicketsError, ErrNilClientID)
+	}
+	return searchTickets(s.persistence, s.index, search.Query{Text: query, Client: client, Limit: limit})
+}
+
+func searchTickets(tp TicketPersistence, index search.Index, query search.Query) ([]ticket.Ticket, error) {
+	results := index.Search(query)
+	tickets := make([]ticket.Ticket, 0, len(results))
+	for _, result := range results {
+		tck, err := tp.GetTicket(result.Ticket)
+		if err != nil {
+			return nil, errors.Join(SearchTicketsError, err)
+		}
+		tickets = append(tickets, tck)
+	}
+	return tickets, nil
+}
+
+var NewIndexingPersistenceError error = errors.New("error creating indexing persistence driver")
+var SearchTicketsError error = errors.New("error searching tickets")
+
+var ErrNilSearchIndex error = errors.New("search index cannot be nil")
+var ErrOwnerChangeNotSupported error = errors.New("the persistence driver cannot change the owner of the tickets")
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"testing"
+	"ticketTao/entities/ticket"
+	"ticketTao/interactors/search"
+)
+
+func TestNewIndexingPersistence(t *testing.T) {
+	t.Parallel()
+	_, err := NewIndexingPersistence(nil, search.NewMemoryIndex())
+	assertErrors(t, err, NewIndexingPersistenceError, NilPersistenceDriverError)
+	_, err = NewIndexingPersistence(&spyTicketPersistence{}, nil)
+	assertErrors(t, err, NewIndexingPersistenceError, ErrNilSearchIndex)
+	tp, _ := NewIndexingPersistence(&spyTicketPersistence{}, search.NewMemoryIndex())
+	if err := tp.SetTicketOwner(uuid.New(), uuid.New()); err != ErrOwnerChangeNotSupported {
+		t.Errorf("Expected %v, got %v", ErrOwnerChangeNotSupported, err)
+	}
+	_, err = GetSearchAgentTicketRepository(&spyTicketPersistence{}, nil)
+	assertErrors(t, err, GetAgentTicketRepositoryError, ErrNilSearchIndex)
+	_, err = GetSearchClientTicketRepository(&spyTicketPersistence{}, nil)
+	assertErrors(t, err, GetClientTicketRepositoryError, ErrNilSearchIndex)
+}
+
+func TestSearchTicketRepositories(t *testing.T) {
+	t.Parallel()
+	index := search.NewMemoryIndex()
+	tp, _ := NewIndexingPersistence(newSnapshotTicketPersistence(), index)
+	agentRepo, err := GetSearchAgentTicketRepository(tp, index)
+	if err != nil {
+		t.Fatalf("Error should be nil, but is %s", err.Error())
+	}
+	clientRepo, err := GetSearchClientTicketRepository(tp, index)
+	if err != nil {
+		t.Fatalf("Error should be nil, but is %s", err.Error())
+	}
+	client := uuid.New()
+	other := uuid.New()
+	invoice, _ := ticket.NewBasicTicket("Invoice", "The PDF is empty")
+	_ = clientRepo.CreateNewTicketForClient(client, invoice)
+	otherInvoice, _ := ticket.NewBasicTicket("Invoice", "Wrong address")
+	_ = clientRepo.CreateNewTicketForClient(other, otherInvoice)
+
+	t.Run("New tickets and their new responses are searchable by the agents", func(t *testing.T) {
+		tickets, err := agentRepo.SearchTickets("invoice", 0)
+		assertSearchResults(t, tickets, err, invoice.ID(), otherInvoice.ID())
+
+		otherInvoice.AddResponse(ticket.NewResponse(uuid.New(), "Please resend the PDF"))
+		err = agentRepo.UpdateTicket(otherInvoice)
+		if err != nil {
+			t.Fatalf("Error should be nil, but is %s", err.Error())
+		}
+		tickets, err = agentRepo.SearchTickets("resend pdf", 0)
+		assertSearchResults(t, tickets, err, otherInvoice.ID())
+	})
+	t.Run("Clients only find their own tickets", func(t *testing.T) {
+		tickets, err := clientRepo.SearchClientTickets(client, "invoice", 0)
+		assertSearchResults(t, tickets, err, invoice.ID())
+		_, err = clientRepo.SearchClientTickets(uuid.Nil, "invoice", 0)
+		assertErrors(t, err, SearchTicketsError, ErrNilClientID)
+	})
+	t.Run("Transferred tickets are found by their new owner only", func(t *testing.T) {
+		if err := tp.SetTicketOwner(invoice.ID(), other); err != nil {
+			t.Fatalf("Error should be nil, but is %s", err.Error())
+		}
+		tickets, err := clientRepo.SearchClientTickets(other, "invoice", 0)
+		assertSearchResults(t, tickets, err, invoice.ID(), otherInvoice.ID())
+		tickets, err = clientRepo.SearchClientTickets(client, "invoice", 0)
+		assertSearchResults(t, tickets, err)
+	})
+}
+
+func assertSearchResults(t *testing.T, tickets []ticket.Ticket, err error, expected ...uuid.UUID) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("Error should be nil, but is %s", err.Error())
+	}
+	if len(tickets) != len(expected) {
+		t.Fatalf("Expected %d tickets, got %d", len(expected), len(tickets))
+	}
+	found := make(map[uuid.UUID]bool)
+	for _, tck := range tickets {
+		found[tck.ID()] = true
+	}
+	for _, id := range expected {
+		if !found[id] {
+			t.Errorf("Expected ticket %s to be found", id)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"ticketTao/entities/sla"
+	"ticketTao/entities/ticket"
+)
+
+// SLAAgentTicketRepository is an agent ticket repository that can also answer SLA queries.
+type SLAAgentTicketRepository interface {
+	AgentTicketRepository
+	sla.RepositoryAgentReader
+}
+
+// GetSLAAgentTicketRepository returns a new instance of SLAAgentTicketRepository that uses the tracker to evaluate the
+// tickets.
+func GetSLAAgentTicketRepository(tp TicketPersistence, tracker sla.Tracker) (SLAAgentTicketRepository, error) {
+	if tp == nil {
+		return nil, errors.Join(GetAgentTicketRepositoryError, NilPersistenceDriverError)
+	}
+	if tracker == nil {
+		return nil, errors.Join(GetAgentTicketRepositoryError, ErrNilSLATracker)
+	}
+	return slaAgentTicketRepository{basicAgentTicketRepository{tp}, tracker}, nil
+}
+
+type slaAgentTicketRepository struct {
+	basicAgentTicketRepository
+	tracker sla.Tracker
+}
+
+func (s slaAgentTicketRepository) GetTicketSLA(ticketId uuid.UUID) (sla.Status, error) {
+	tck, err := s.GetTicket(ticketId)
+	if err != nil {
+		return sla.Status{}, errors.Join(GetTicketSLAError, err)
+	}
+	status, err := s.evaluate(tck)
+	if err != nil {
+		return sla.Status{}, errors.Join(GetTicketSLAError, err)
+	}
+	return status, nil
+}
+
+func (s slaAgentTicketRepository) GetAtRiskTickets() ([]sla.Status, error) {
+	return s.filterOpenTickets(func(status sla.Status) bool {
+		return status.AtRisk
+	})
+}
+
+func (s slaAgentTicketRepository) GetBreachedTickets() ([]sla.Status, error) {
+	return s.filterOpenTickets(sla.Status.Breached)
+}
+
+func (s slaAgentTicketRepository) filterOpenTickets(keep func(sla.Status) bool) ([]sla.Status, error) {
+	tickets, err := s.GetOpenTickets()
+	if err != nil {
+		return nil, errors.Join(GetTicketSLAError, err)
+	}
+	var statuses []sla.Status
+	for _, tck := range tickets {
+		status, err := s.evaluate(tck)
+		if err != nil {
+			return nil, errors.Join(GetTicketSLAError, err)
+		}
+		if keep(status) {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses, nil
+}
+
+func (s slaAgentTicketRepository) evaluate(tck ticket.Ticket) (sla.Status, error) {
+	owner, err := s.GetTicketOwner(tck.ID())
+	if err != nil {
+		return sla.Status{}, err
+	}
+	return s.tracker.Evaluate(tck, owner)
+}
+
+var GetTicketSLAError error = errors.New("error getting ticket SLA")
+
+var ErrNilSLATracker error = errors.New("SLA tracker cannot be nil")
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"testing"
+	"ticketTao/entities"
+	"ticketTao/entities/sla"
+	"ticketTao/entities/ticket"
+	"time"
+)
+
+func TestGetSLAAgentTicketRepository(t *testing.T) {
+	t.Parallel()
+	tracker, _ := sla.NewTracker(sla.Policies{}, nil, nil)
+	_, err := GetSLAAgentTicketRepository(nil, tracker)
+	assertErrors(t, err, GetAgentTicketRepositoryError, NilPersistenceDriverError)
+	_, err = GetSLAAgentTicketRepository(&spyTicketPersistence{}, nil)
+	assertErrors(t, err, GetAgentTicketRepositoryError, ErrNilSLATracker)
+}
+
+func TestSLAAgentTicketRepository(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	policy := sla.Policy{Name: "standard", FirstResponse: 4 * time.Hour, AtRiskWithin: time.Hour}
+	tracker, _ := sla.NewTracker(sla.Policies{Default: policy}, nil, entities.ClockFunc(func() time.Time { return now }))
+	persistence := newSnapshotTicketPersistence()
+	repo, err := GetSLAAgentTicketRepository(persistence, tracker)
+	if err != nil {
+		t.Fatalf("Error should be nil, but is %s", err.Error())
+	}
+	owner := uuid.New()
+	onTime := saveTicketCreatedAt(t, persistence, owner, now.Add(-time.Hour))
+	atRisk := saveTicketCreatedAt(t, persistence, owner, now.Add(-3*time.Hour-30*time.Minute))
+	breached := saveTicketCreatedAt(t, persistence, owner, now.Add(-5*time.Hour))
+	closedBreached := saveTicketCreatedAt(t, persistence, owner, now.Add(-6*time.Hour))
+	closedBreached.Close()
+	_ = persistence.UpdateTicket(closedBreached)
+
+	t.Run("It should evaluate a single ticket", func(t *testing.T) {
+		status, err := repo.GetTicketSLA(onTime.ID())
+		if err != nil {
+			t.Fatalf("Error should be nil, but is %s", err.Error())
+		}
+		if status.Ticket != onTime.ID() || status.Breached() || status.AtRisk {
+			t.Errorf("Unexpected status %+v", status)
+		}
+	})
+	t.Run("It should return the open tickets at risk", func(t *testing.T) {
+		statuses, err := repo.GetAtRiskTickets()
+		if err != nil {
+			t.Fatalf("Error should be nil, but is %s", err.Error())
+		}
+		if len(statuses) != 1 || statuses[0].Ticket != atRisk.ID() {
+			t.Errorf("Expected only ticket %s to be at risk, got %+v", atRisk.ID(), statuses)
+		}
+	})
+	t.Run("It should return the open breached tickets", func(t *testing.T) {
+		statuses, err := repo.GetBreachedTickets()
+		if err != nil {
+			t.Fatalf("Error should be nil, but is %s", err.Error())
+		}
+		if len(statuses) != 1 || statuses[0].Ticket != breached.ID() {
+			t.Errorf("Expected only ticket %s to be breached, got %+v", breached.ID(), statuses)
+		}
+	})
+}
+
+func saveTicketCreatedAt(t *testing.T, persistence TicketPersistence, owner uuid.UUID, created time.Time) ticket.Ticket {
+	t.Helper()
+	tck, err := ticket.MakeBasicTicket(uuid.New(), created, ticket.Data{Title: "title", Status: ticket.Open})
+	if err != nil {
+		t.Fatalf("Error creating ticket: %v", err)
+	}
+	err = persistence.SaveNewTicketForClient(owner, tck)
+	if err != nil {
+		t.Fatalf("Error saving ticket: %v", err)
+	}
+	return tck
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"ticketTao/entities/ticket"
+	"ticketTao/entities/tickettype"
+)
+
+// NewValidatingPersistence decorates a persistence driver so that the custom fields of the tickets are validated
+// against their ticket type before every write. Both the client and the agent repositories can use the decorated
+// driver. The owner changes need no validation and are passed on to the decorated driver.
+func NewValidatingPersistence(tp TicketPersistence, types tickettype.Registry) (TransferablePersistence, error) {
+	if tp == nil {
+		return nil, errors.Join(NewValidatingPersistenceError, NilPersistenceDriverError)
+	}
+	if types == nil {
+		return nil, errors.Join(NewValidatingPersistenceError, ErrNilTypeRegistry)
+	}
+	return validatingPersistence{tp, types}, nil
+}
+
+type validatingPersistence struct {
+	TicketPersistence
+	types tickettype.Registry
+}
+
+func (v validatingPersistence) SaveNewTicketForClient(client uuid.UUID, tck ticket.Ticket) error {
+	if tck == nil {
+		return ticket.ErrNilTicket
+	}
+	if err := v.types.Validate(tck); err != nil {
+		return err
+	}
+	return v.TicketPersistence.SaveNewTicketForClient(client, tck)
+}
+
+func (v validatingPersistence) UpdateTicket(tck ticket.Ticket) error {
+	if tck == nil {
+		return ticket.ErrNilTicket
+	}
+	if err := v.types.Validate(tck); err != nil {
+		return err
+	}
+	return v.TicketPersistence.UpdateTicket(tck)
+}
+
+func (v validatingPersistence) SetTicketOwner(ticketId, client uuid.UUID) error {
+	return setTicketOwner(v.TicketPersistence, ticketId, client)
+}
+
+var NewValidatingPersistenceError error = errors.New("error creating validating persistence driver")
+
+var ErrNilTypeRegistry error = errors.New("ticket type registry cannot be nil")
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"testing"
+	"ticketTao/entities/ticket"
+	"ticketTao/entities/tickettype"
+)
+
+func TestNewValidatingPersistence(t *testing.T) {
+	t.Parallel()
+	types, _ := tickettype.NewRegistry()
+	_, err := NewValidatingPersistence(nil, types)
+	assertErrors(t, err, NewValidatingPersistenceError, NilPersistenceDriverError)
+	_, err = NewValidatingPersistence(&spyTicketPersistence{}, nil)
+	assertErrors(t, err, NewValidatingPersistenceError, ErrNilTypeRegistry)
+	tp, _ := NewValidatingPersistence(&spyTicketPersistence{}, types)
+	assertErrors(t, tp.SaveNewTicketForClient(uuid.New(), nil), ticket.ErrNilTicket)
+	assertErrors(t, tp.UpdateTicket(nil), ticket.ErrNilTicket)
+	assertErrors(t, tp.SetTicketOwner(uuid.New(), uuid.New()), ErrOwnerChangeNotSupported)
+}
+
+func TestValidatingPersistence(t *testing.T) {
+	t.Parallel()
+	types, _ := tickettype.NewRegistry(tickettype.Type{Name: "inventory", Fields: []tickettype.Field{
+		{Name: "sku", Kind: tickettype.String, Required: true},
+		{Name: "quantity", Kind: tickettype.Number},
+	}})
+	tp, _ := NewValidatingPersistence(newSnapshotTicketPersistence(), types)
+	clientRepo, _ := GetClientTicketRepository(tp)
+	agentRepo, _ := GetAgentTicketRepository(tp)
+	client := uuid.New()
+
+	t.Run("Tickets with invalid custom fields are not created", func(t *testing.T) {
+		tck, _ := ticket.NewTypedTicket("inventory", "Missing stock", "", map[string]string{"quantity": "3"})
+		err := clientRepo.CreateNewTicketForClient(client, tck)
+		assertErrors(t, err, SaveNewTicketForClientError, tickettype.ValidateError, tickettype.ErrMissingField)
+	})
+	t.Run("Tickets can be created, updated and queried by their custom fields", func(t *testing.T) {
+		tck, _ := ticket.NewTypedTicket("inventory", "Missing stock", "", map[string]string{"sku": "A-1"})
+		if err := clientRepo.CreateNewTicketForClient(client, tck); err != nil {
+			t.Fatalf("Error should be nil, but is %s", err.Error())
+		}
+		tck.SetField("quantity", "many")
+		err := agentRepo.UpdateTicket(tck)
+		assertErrors(t, err, UpdateTicketError, tickettype.ErrInvalidValue)
+		tck.SetField("quantity", "12")
+		if err := agentRepo.UpdateTicket(tck); err != nil {
+			t.Fatalf("Error should be nil, but is %s", err.Error())
+		}
+
+		page, err := clientRepo.QueryClientTickets(client, ticket.Query{Type: "inventory", Fields: map[string]string{"quantity": "12"}})
+		if err != nil || len(page.Tickets) != 1 || page.Tickets[0].ID() != tck.ID() {
+			t.Errorf("Expected the ticket to be found by its custom fields, got %v, %v", page.Tickets, err)
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package webhook
+
+import (
+	"github.com/google/uuid"
+	"slices"
+	"sync"
+	"ticketTao/interactors/ticket/events"
+	"time"
+)
+
+// Attempt records a single try to deliver an event to an endpoint.
+type Attempt struct {
+	Delivery uuid.UUID
+	Endpoint uuid.UUID
+	Event    events.Name
+	TicketID uuid.UUID
+	// Number starts at 1 for the first try of a delivery.
+	Number int
+	At     time.Time
+	// StatusCode is zero when the request could not be sent.
+	StatusCode int
+	// Error describes why the attempt failed, it is empty for successful attempts.
+	Error     string
+	Succeeded bool
+}
+
+// DeliveryLog records the delivery attempts, so failing endpoints can be diagnosed.
+type DeliveryLog interface {
+	Record(Attempt)
+	// Attempts returns the attempts made to an endpoint in the order they were recorded.
+	Attempts(endpoint uuid.UUID) []Attempt
+}
+
+// NewMemoryDeliveryLog creates an empty in-memory DeliveryLog.
+func NewMemoryDeliveryLog() DeliveryLog {
+	return &memoryDeliveryLog{attempts: make(map[uuid.UUID][]Attempt)}
+}
+
+type memoryDeliveryLog struct {
+	mu       sync.RWMutex
+	attempts map[uuid.UUID][]Attempt
+}
+
+func (m *memoryDeliveryLog) Record(attempt Attempt) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attempts[attempt.Endpoint] = append(m.attempts[attempt.Endpoint], attempt)
+}
+
+func (m *memoryDeliveryLog) Attempts(endpoint uuid.UUID) []Attempt {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Clone(m.attempts[endpoint])
+}
@@ -0,0 +1,203 @@
+// Package webhook contains a dispatcher that posts the ticket lifecycle events to registered URLs, signing the
+// payloads with HMAC-SHA256 and retrying failed deliveries with exponential backoff.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"net/http"
+	"sync"
+	"ticketTao/interactors/ticket/events"
+	"time"
+)
+
+// SignatureHeader contains the HMAC-SHA256 signature of the request body, formatted as "sha256=<hex digest>".
+const SignatureHeader = "X-TicketTao-Signature"
+
+// EventHeader contains the name of the delivered event.
+const EventHeader = "X-TicketTao-Event"
+
+// DeliveryHeader contains the delivery ID, which is the same for every retry of a delivery.
+const DeliveryHeader = "X-TicketTao-Delivery"
+
+// NewDispatcher creates a Dispatcher that posts events to the endpoints of the registry and records every attempt in
+// the delivery log.
+func NewDispatcher(registry Registry, log DeliveryLog, client *http.Client, policy RetryPolicy) (Dispatcher, error) {
+	if registry == nil {
+		return nil, errors.Join(NewDispatcherError, ErrNilRegistry)
+	}
+	if log == nil {
+		return nil, errors.Join(NewDispatcherError, ErrNilDeliveryLog)
+	}
+	if client == nil {
+		return nil, errors.Join(NewDispatcherError, ErrNilHTTPClient)
+	}
+	if policy.MaxAttempts < 1 {
+		return nil, errors.Join(NewDispatcherError, ErrInvalidRetryPolicy)
+	}
+	return basicDispatcher{
+		registry: registry,
+		log:      log,
+		client:   client,
+		policy:   policy,
+		sleep:    time.Sleep,
+	}, nil
+}
+
+// Dispatcher delivers ticket lifecycle events to the registered endpoints.
+type Dispatcher interface {
+	// Handle delivers the event to every endpoint interested in it, it returns once every delivery has succeeded or
+	// run out of attempts. It is meant to be subscribed asynchronously to an events.Bus.
+	Handle(events.Event)
+}
+
+// Subscribe registers the dispatcher as an asynchronous subscriber, so slow endpoints do not delay ticket updates.
+func Subscribe(subscriber events.Subscriber, dispatcher Dispatcher) error {
+	if subscriber == nil {
+		return errors.Join(events.SubscribeError, events.ErrNilSubscriber)
+	}
+	if dispatcher == nil {
+		return errors.Join(events.SubscribeError, ErrNilDispatcher)
+	}
+	return subscriber.SubscribeAsync(dispatcher.Handle)
+}
+
+// RetryPolicy defines how many times a delivery is attempted and how long to wait between attempts. The wait doubles
+// after every failed attempt, starting at InitialBackoff and never exceeding MaxBackoff.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy tries a delivery 5 times, waiting 1s, 2s, 4s and 8s between attempts.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    5,
+	InitialBackoff: time.Second,
+	MaxBackoff:     time.Minute,
+}
+
+// backoff returns the wait before the next attempt, after the given failed attempt number.
+func (r RetryPolicy) backoff(attempt int) time.Duration {
+	wait := r.InitialBackoff
+	for i := 1; i < attempt; i++ {
+		wait *= 2
+		if r.MaxBackoff > 0 && wait >= r.MaxBackoff {
+			return r.MaxBackoff
+		}
+	}
+	return wait
+}
+
+// Sign returns the value of the SignatureHeader for a body signed with the given secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks, in constant time, that a signature was produced by Sign with the given secret and body.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+type basicDispatcher struct {
+	registry Registry
+	log      DeliveryLog
+	client   *http.Client
+	policy   RetryPolicy
+	sleep    func(time.Duration)
+}
+
+func (b basicDispatcher) Handle(e events.Event) {
+	var wg sync.WaitGroup
+	for _, endpoint := range b.registry.Endpoints() {
+		if !endpoint.accepts(e.Name()) {
+			continue
+		}
+		wg.Add(1)
+		go func(endpoint Endpoint) {
+			defer wg.Done()
+			b.deliver(endpoint, e)
+		}(endpoint)
+	}
+	wg.Wait()
+}
+
+func (b basicDispatcher) deliver(endpoint Endpoint, e events.Event) {
+	delivery := uuid.New()
+	body, err := json.Marshal(makePayload(delivery, e))
+	if err != nil {
+		b.log.Record(Attempt{
+			Delivery: delivery, Endpoint: endpoint.ID, Event: e.Name(), TicketID: e.TicketID(),
+			Number: 1, At: time.Now(), Error: fmt.Errorf("%w: %w", ErrEncodingPayload, err).Error(),
+		})
+		return
+	}
+	for number := 1; number <= b.policy.MaxAttempts; number++ {
+		attempt := Attempt{
+			Delivery: delivery, Endpoint: endpoint.ID, Event: e.Name(), TicketID: e.TicketID(),
+			Number: number, At: time.Now(),
+		}
+		retry := b.post(endpoint, delivery, e.Name(), body, &attempt)
+		b.log.Record(attempt)
+		if attempt.Succeeded || !retry || number == b.policy.MaxAttempts {
+			return
+		}
+		b.sleep(b.policy.backoff(number))
+	}
+}
+
+// post sends the payload and fills the result of the attempt, it returns whether the failure is worth retrying.
+func (b basicDispatcher) post(endpoint Endpoint, delivery uuid.UUID, name events.Name, body []byte, attempt *Attempt) bool {
+	request, err := http.NewRequest(http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return false
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(SignatureHeader, Sign(endpoint.Secret, body))
+	request.Header.Set(EventHeader, string(name))
+	request.Header.Set(DeliveryHeader, delivery.String())
+
+	response, err := b.client.Do(request)
+	if err != nil {
+		attempt.Error = err.Error()
+		return true
+	}
+	// The body is drained so the connection can be reused by the next delivery.
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, maxDrainedBody))
+	_ = response.Body.Close()
+	attempt.StatusCode = response.StatusCode
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		attempt.Succeeded = true
+		return false
+	}
+	attempt.Error = fmt.Sprintf("%s: %s", ErrUnexpectedStatus, response.Status)
+	return isRetryableStatus(response.StatusCode)
+}
+
+// maxDrainedBody limits how much of an answer is read, an endpoint answering with a larger body loses the connection.
+const maxDrainedBody = 64 << 10
+
+// isRetryableStatus tells apart temporary failures from requests the receiver will never accept.
+func isRetryableStatus(code int) bool {
+	return code >= 500 || code == http.StatusRequestTimeout || code == http.StatusTooManyRequests
+}
+
+var NewDispatcherError error = errors.New("error creating webhook dispatcher")
+
+var ErrNilRegistry error = errors.New("webhook registry cannot be nil")
+var ErrNilDeliveryLog error = errors.New("webhook delivery log cannot be nil")
+var ErrNilHTTPClient error = errors.New("http client cannot be nil")
+var ErrNilDispatcher error = errors.New("webhook dispatcher cannot be nil")
+var ErrInvalidRetryPolicy error = errors.New("retry policy must allow at least one attempt")
+var ErrEncodingPayload error = errors.New("error encoding webhook payload")
+var ErrUnexpectedStatus error = errors.New("webhook endpoint answered with an unexpected status")
@@ -0,0 +1,293 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"ticketTao/entities/ticket"
+	"ticketTao/interactors/ticket/events"
+	"time"
+)
+
+func TestNewDispatcher(t *testing.T) {
+	t.Parallel()
+	t.Run("It should return an error when a dependency is missing", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewDispatcher(nil, NewMemoryDeliveryLog(), http.DefaultClient, DefaultRetryPolicy)
+		assertErrors(t, err, NewDispatcherError, ErrNilRegistry)
+		_, err = NewDispatcher(NewMemoryRegistry(), nil, http.DefaultClient, DefaultRetryPolicy)
+		assertErrors(t, err, NewDispatcherError, ErrNilDeliveryLog)
+		_, err = NewDispatcher(NewMemoryRegistry(), NewMemoryDeliveryLog(), nil, DefaultRetryPolicy)
+		assertErrors(t, err, NewDispatcherError, ErrNilHTTPClient)
+	})
+	t.Run("It should return an error when the retry policy does not allow any attempt", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewDispatcher(NewMemoryRegistry(), NewMemoryDeliveryLog(), http.DefaultClient, RetryPolicy{})
+		assertErrors(t, err, NewDispatcherError, ErrInvalidRetryPolicy)
+	})
+}
+
+func TestDispatcher_Handle(t *testing.T) {
+	t.Parallel()
+	t.Run("It should post a signed JSON payload to the registered endpoint", func(t *testing.T) {
+		t.Parallel()
+		receiver := newFakeReceiver(http.StatusOK)
+		defer receiver.Close()
+		dispatcher, registry, log := setupDispatcher(t)
+		endpoint, _ := registry.Register(Endpoint{URL: receiver.URL, Secret: "s3cr3t"})
+		e := makeResponseAdded(t)
+
+		dispatcher.Handle(e)
+
+		requests := receiver.received()
+		if len(requests) != 1 {
+			t.Fatalf("Expected 1 request, got %d", len(requests))
+		}
+		if !Verify("s3cr3t", requests[0].body, requests[0].header.Get(SignatureHeader)) {
+			t.Error("The payload signature should be valid")
+		}
+		if requests[0].header.Get(EventHeader) != string(events.ResponseAddedName) {
+			t.Errorf("Expected event header to be %s, got %s", events.ResponseAddedName, requests[0].header.Get(EventHeader))
+		}
+		var payload Payload
+		if err := json.Unmarshal(requests[0].body, &payload); err != nil {
+			t.Fatalf("Payload should be valid JSON: %v", err)
+		}
+		if payload.Ticket.ID != e.TicketID() || payload.Response == nil || payload.Response.Content != "an answer" {
+			t.Errorf("Unexpected payload: %+v", payload)
+		}
+		attempts := log.Attempts(endpoint)
+		if len(attempts) != 1 || !attempts[0].Succeeded || attempts[0].StatusCode != http.StatusOK {
+			t.Errorf("Expected one successful attempt, got %+v", attempts)
+		}
+	})
+	t.Run("It should retry failed deliveries with exponential backoff", func(t *testing.T) {
+		t.Parallel()
+		receiver := newFakeReceiver(http.StatusInternalServerError, http.StatusBadGateway, http.StatusNoContent)
+		defer receiver.Close()
+		dispatcher, registry, log := setupDispatcher(t)
+		var waits []time.Duration
+		dispatcher.sleep = func(d time.Duration) { waits = append(waits, d) }
+		endpoint, _ := registry.Register(Endpoint{URL: receiver.URL, Secret: "s3cr3t"})
+
+		dispatcher.Handle(makeResponseAdded(t))
+
+		attempts := log.Attempts(endpoint)
+		if len(attempts) != 3 {
+			t.Fatalf("Expected 3 attempts, got %d", len(attempts))
+		}
+		if attempts[0].Succeeded || attempts[1].Succeeded || !attempts[2].Succeeded {
+			t.Errorf("Only the last attempt should succeed: %+v", attempts)
+		}
+		if attempts[0].Delivery != attempts[2].Delivery {
+			t.Error("Retries should keep the same delivery ID")
+		}
+		if len(waits) != 2 || waits[0] != 10*time.Millisecond || waits[1] != 20*time.Millisecond {
+			t.Errorf("Expected waits of 10ms and 20ms, got %v", waits)
+		}
+	})
+	t.Run("It should stop after the maximum number of attempts", func(t *testing.T) {
+		t.Parallel()
+		receiver := newFakeReceiver(http.StatusServiceUnavailable)
+		defer receiver.Close()
+		dispatcher, registry, log := setupDispatcher(t)
+		dispatcher.sleep = func(time.Duration) {}
+		endpoint, _ := registry.Register(Endpoint{URL: receiver.URL, Secret: "s3cr3t"})
+
+		dispatcher.Handle(makeResponseAdded(t))
+
+		if len(log.Attempts(endpoint)) != dispatcher.policy.MaxAttempts {
+			t.Errorf("Expected %d attempts, got %d", dispatcher.policy.MaxAttempts, len(log.Attempts(endpoint)))
+		}
+	})
+	t.Run("It should not retry requests rejected by the receiver", func(t *testing.T) {
+		t.Parallel()
+		receiver := newFakeReceiver(http.StatusBadRequest)
+		defer receiver.Close()
+		dispatcher, registry, log := setupDispatcher(t)
+		endpoint, _ := registry.Register(Endpoint{URL: receiver.URL, Secret: "s3cr3t"})
+
+		dispatcher.Handle(makeResponseAdded(t))
+
+		attempts := log.Attempts(endpoint)
+		if len(attempts) != 1 || attempts[0].StatusCode != http.StatusBadRequest || attempts[0].Error == "" {
+			t.Errorf("Expected a single failed attempt, got %+v", attempts)
+		}
+	})
+	t.Run("It should only deliver the events an endpoint is interested in", func(t *testing.T) {
+		t.Parallel()
+		receiver := newFakeReceiver(http.StatusOK)
+		defer receiver.Close()
+		dispatcher, registry, _ := setupDispatcher(t)
+		_, _ = registry.Register(Endpoint{URL: receiver.URL, Secret: "s3cr3t", Events: []events.Name{events.TicketClosedName}})
+
+		dispatcher.Handle(makeResponseAdded(t))
+
+		if len(receiver.received()) != 0 {
+			t.Error("The endpoint should not receive ResponseAdded events")
+		}
+	})
+}
+
+func TestDispatcher_Connections(t *testing.T) {
+	t.Parallel()
+	t.Run("It should reuse the connection when the endpoint answers with a body", func(t *testing.T) {
+		t.Parallel()
+		var mu sync.Mutex
+		connections := 0
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.ReadAll(r.Body)
+			_, _ = w.Write(bytes.Repeat([]byte("received "), 4096))
+		}))
+		server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+			if state == http.StateNew {
+				mu.Lock()
+				connections++
+				mu.Unlock()
+			}
+		}
+		server.Start()
+		defer server.Close()
+		registry := NewMemoryRegistry()
+		_, _ = registry.Register(Endpoint{URL: server.URL, Secret: "s3cr3t"})
+		dispatcher, _ := NewDispatcher(registry, NewMemoryDeliveryLog(), server.Client(), DefaultRetryPolicy)
+
+		dispatcher.Handle(makeResponseAdded(t))
+		dispatcher.Handle(makeResponseAdded(t))
+
+		mu.Lock()
+		defer mu.Unlock()
+		if connections != 1 {
+			t.Errorf("Expected the deliveries to share 1 connection, got %d", connections)
+
+		}
+	})
+}
+
+func TestSubscribe(t *testing.T) {
+	t.Parallel()
+	t.Run("The dispatcher delivers the events published in the bus", func(t *testing.T) {
+		t.Parallel()
+		receiver := newFakeReceiver(http.StatusOK)
+		defer receiver.Close()
+		dispatcher, registry, _ := setupDispatcher(t)
+		_, _ = registry.Register(Endpoint{URL: receiver.URL, Secret: "s3cr3t"})
+		bus := events.NewBus()
+		if err := Subscribe(bus, dispatcher); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+
+		bus.Publish(makeResponseAdded(t))
+		bus.Close()
+
+		if len(receiver.received()) != 1 {
+			t.Errorf("Expected 1 request, got %d", len(receiver.received()))
+		}
+	})
+	t.Run("It should return an error when the subscriber or the dispatcher is nil", func(t *testing.T) {
+		t.Parallel()
+		dispatcher, _, _ := setupDispatcher(t)
+		assertErrors(t, Subscribe(nil, dispatcher), events.SubscribeError, events.ErrNilSubscriber)
+		assertErrors(t, Subscribe(events.NewBus(), nil), events.SubscribeError, ErrNilDispatcher)
+	})
+}
+
+func TestRegistry_Register(t *testing.T) {
+	t.Parallel()
+	t.Run("It should reject invalid endpoints", func(t *testing.T) {
+		t.Parallel()
+		registry := NewMemoryRegistry()
+		_, err := registry.Register(Endpoint{URL: "ftp://example.com", Secret: "s3cr3t"})
+		assertErrors(t, err, RegisterEndpointError, ErrInvalidEndpointURL)
+		_, err = registry.Register(Endpoint{URL: "https://example.com/hook"})
+		assertErrors(t, err, RegisterEndpointError, ErrEmptySecret)
+	})
+	t.Run("It should unregister endpoints", func(t *testing.T) {
+		t.Parallel()
+		registry := NewMemoryRegistry()
+		id, _ := registry.Register(Endpoint{URL: "https://example.com/hook", Secret: "s3cr3t"})
+		if err := registry.Unregister(id); err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if len(registry.Endpoints()) != 0 {
+			t.Error("The endpoint should be removed")
+		}
+		assertErrors(t, registry.Unregister(id), UnregisterEndpointError, ErrEndpointNotFound)
+	})
+}
+
+func setupDispatcher(t *testing.T) (basicDispatcher, Registry, DeliveryLog) {
+	t.Helper()
+	registry := NewMemoryRegistry()
+	log := NewMemoryDeliveryLog()
+	policy := RetryPolicy{MaxAttempts: 4, InitialBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+	dispatcher, err := NewDispatcher(registry, log, http.DefaultClient, policy)
+	if err != nil {
+		t.Fatalf("Error creating dispatcher: %v", err)
+	}
+	return dispatcher.(basicDispatcher), registry, log
+}
+
+func makeResponseAdded(t *testing.T) events.ResponseAdded {
+	t.Helper()
+	tck, err := ticket.NewBasicTicket("title", "description")
+	if err != nil {
+		t.Fatalf("Error creating ticket: %v", err)
+	}
+	response := ticket.NewResponse(tck.ID(), "an answer")
+	tck.AddResponse(response)
+	return events.ResponseAdded{Ticket: tck, Response: response, At: time.Now()}
+}
+
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// fakeReceiver answers with the given status codes in order, repeating the last one.
+type fakeReceiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	requests []receivedRequest
+}
+
+func newFakeReceiver(statuses ...int) *fakeReceiver {
+	receiver := &fakeReceiver{statuses: statuses}
+	receiver.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		receiver.mu.Lock()
+		receiver.requests = append(receiver.requests, receivedRequest{header: r.Header, body: body})
+		status := receiver.statuses[0]
+		if len(receiver.statuses) > 1 {
+			receiver.statuses = receiver.statuses[1:]
+		}
+		receiver.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	return receiver
+}
+
+func (f *fakeReceiver) received() []receivedRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests
+}
+
+func assertErrors(t *testing.T, err error, expected ...error) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("Error should not be nil")
+	}
+	for _, e := range expected {
+		if !errors.Is(err, e) {
+			t.Errorf("Error should be %v, but is %s", e, err.Error())
+		}
+	}
+}
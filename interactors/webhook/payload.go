@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"github.com/google/uuid"
+	"ticketTao/entities/ticket"
+	"ticketTao/interactors/ticket/events"
+	"time"
+)
+
+// Payload is the JSON document posted to the registered endpoints.
+type Payload struct {
+	Delivery   uuid.UUID     `json:"delivery"`
+	Event      events.Name   `json:"event"`
+	OccurredAt time.Time     `json:"occurred_at"`
+	Ticket     TicketPayload `json:"ticket"`
+	// Client is only set for TicketCreated events.
+	Client *uuid.UUID `json:"client,omitempty"`
+	// Response is only set for ResponseAdded events.
+	Response *ResponsePayload `json:"response,omitempty"`
+	// From and To are only set for StatusChanged events.
+	From ticket.Status `json:"from,omitempty"`
+	To   ticket.Status `json:"to,omitempty"`
+}
+
+// TicketPayload is the representation of a ticket inside a Payload.
+type TicketPayload struct {
+	ID          uuid.UUID     `json:"id"`
+	Title       string        `json:"title"`
+	Description string        `json:"description"`
+	Status      ticket.Status `json:"status"`
+	CreatedAt   time.Time     `json:"created_at"`
+	// ClosingReason is only set for closed tickets.
+	ClosingReason ticket.ClosingReason `json:"closing_reason,omitempty"`
+}
+
+// ResponsePayload is the representation of a ticket.Response inside a Payload.
+type ResponsePayload struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Content   string    `json:"content"`
+	TimeStamp time.Time `json:"timestamp"`
+}
+
+func makePayload(delivery uuid.UUID, e events.Event) Payload {
+	payload := Payload{
+		Delivery:   delivery,
+		Event:      e.Name(),
+		OccurredAt: e.OccurredAt(),
+	}
+	switch typed := e.(type) {
+	case events.TicketCreated:
+		payload.Ticket = makeTicketPayload(typed.Ticket)
+		payload.Client = &typed.Client
+	case events.ResponseAdded:
+		payload.Ticket = makeTicketPayload(typed.Ticket)
+		payload.Response = &ResponsePayload{
+			UserID:    typed.Response.UserId(),
+			Content:   typed.Response.Content(),
+			TimeStamp: typed.Response.TimeStamp(),
+		}
+	case events.StatusChanged:
+		payload.Ticket = makeTicketPayload(typed.Ticket)
+		payload.From = typed.From
+		payload.To = typed.To
+	case events.TicketClosed:
+		payload.Ticket = makeTicketPayload(typed.Ticket)
+	default:
+		payload.Ticket = TicketPayload{ID: e.TicketID()}
+	}
+	return payload
+}
+
+func makeTicketPayload(tck ticket.Ticket) TicketPayload {
+	return TicketPayload{
+		ID:            tck.ID(),
+		Title:         tck.Title(),
+		Description:   tck.Description(),
+		Status:        tck.Status(),
+		CreatedAt:     tck.CreatedAt(),
+		ClosingReason: tck.ClosingReason(),
+	}
+}
@@ -0,0 +1,98 @@
+package webhook
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"net/url"
+	"slices"
+	"sync"
+	"ticketTao/interactors/ticket/events"
+)
+
+// Endpoint is a URL registered to receive the ticket lifecycle events.
+type Endpoint struct {
+	ID  uuid.UUID
+	URL string
+	// Secret is used to sign the payloads, so the receiver can verify that they come from TicketTao.
+	Secret string
+	// Events is the list of events the endpoint is interested in, an empty list means all events.
+	Events []events.Name
+}
+
+func (e Endpoint) accepts(name events.Name) bool {
+	return len(e.Events) == 0 || slices.Contains(e.Events, name)
+}
+
+// Registry keeps the endpoints that receive webhooks.
+type Registry interface {
+	// Register validates and saves an endpoint, a new ID is assigned when the endpoint does not have one.
+	Register(Endpoint) (uuid.UUID, error)
+	Unregister(endpoint uuid.UUID) error
+	Endpoints() []Endpoint
+}
+
+// NewMemoryRegistry creates an empty in-memory Registry.
+func NewMemoryRegistry() Registry {
+	return &memoryRegistry{}
+}
+
+type memoryRegistry struct {
+	mu        sync.RWMutex
+	endpoints []Endpoint
+}
+
+func (m *memoryRegistry) Register(endpoint Endpoint) (uuid.UUID, error) {
+	err := validateEndpoint(endpoint)
+	if err != nil {
+		return uuid.Nil, errors.Join(RegisterEndpointError, err)
+	}
+	if endpoint.ID == uuid.Nil {
+		endpoint.ID = uuid.New()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, registered := range m.endpoints {
+		if registered.ID == endpoint.ID {
+			return uuid.Nil, errors.Join(RegisterEndpointError, ErrDuplicatedEndpoint)
+		}
+	}
+	m.endpoints = append(m.endpoints, endpoint)
+	return endpoint.ID, nil
+}
+
+func (m *memoryRegistry) Unregister(id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, registered := range m.endpoints {
+		if registered.ID == id {
+			m.endpoints = slices.Delete(m.endpoints, i, i+1)
+			return nil
+		}
+	}
+	return errors.Join(UnregisterEndpointError, ErrEndpointNotFound)
+}
+
+func (m *memoryRegistry) Endpoints() []Endpoint {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Clone(m.endpoints)
+}
+
+func validateEndpoint(endpoint Endpoint) error {
+	parsed, err := url.Parse(endpoint.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return ErrInvalidEndpointURL
+	}
+	if endpoint.Secret == "" {
+		return ErrEmptySecret
+	}
+	return nil
+}
+
+var RegisterEndpointError error = errors.New("error registering webhook endpoint")
+var UnregisterEndpointError error = errors.New("error unregistering webhook endpoint")
+
+var ErrInvalidEndpointURL error = errors.New("webhook endpoint url must be an absolute http or https url")
+var ErrEmptySecret error = errors.New("webhook endpoint secret cannot be empty")
+var ErrDuplicatedEndpoint error = errors.New("webhook endpoint is already registered")
+var ErrEndpointNotFound error = errors.New("webhook endpoint not found")